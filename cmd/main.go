@@ -1,6 +1,6 @@
 // @title           toge API
 // @version         1.0
-// @description     This is a toge server API documentation.
+// @description     This is a toge server API documentation. Response messages are localized by the user's locale preference or the Accept-Language header (en-US, zh-CN), en-US by default.
 // @termsOfService  http://swagger.io/terms/
 
 // @contact.name   API Support
@@ -20,6 +20,7 @@ package main
 
 import (
 	"log"
+	"log/slog"
 	"os"
 
 	"github.com/chenyl99x/toge-api/internal/app"
@@ -40,47 +41,43 @@ func main() {
 	}
 
 	// 加载配置文件
-	if err := config.LoadConfig(env); err != nil {
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
 
 	// 验证日志配置
-	if err := logger.ValidateLogConfig(); err != nil {
+	if err := logger.ValidateLogConfig(cfg.Log); err != nil {
 		log.Fatal("Invalid log config:", err)
 	}
 
 	// 设置 Gin 模式
-	gin.SetMode(config.GlobalConfig.App.Mode)
-
-	// 初始化日志
-	app.InitializeLogger()
+	gin.SetMode(cfg.App.Mode)
 
-	// 使用 Wire 初始化应用
-	appInstance, err := wire.InitializeApp()
+	// 使用 Wire 初始化应用，数据库和 Redis 连接在启动阶段检查
+	appInstance, err := wire.InitializeApp(cfg)
 	if err != nil {
 		log.Fatal("Failed to initialize app:", err)
 	}
 
-	// 初始化数据库
-	if err := app.InitializeDatabase(); err != nil {
-		log.Fatal("Failed to initialize database:", err)
-	}
-
-	// 初始化 Redis
-	if err := app.InitializeRedis(); err != nil {
-		log.Fatal("Failed to initialize Redis:", err)
-	}
+	// 第三方库和未注入日志的组件使用默认日志
+	slog.SetDefault(appInstance.Logger)
 
 	// 初始化时区
-	if err := app.InitializeTimezone(); err != nil {
+	if err := app.InitializeTimezone(cfg, appInstance.Logger); err != nil {
 		log.Fatal("Failed to initialize timezone:", err)
 	}
 
+	// 注册校验错误的翻译
+	if err := app.InitializeValidator(); err != nil {
+		log.Fatal("Failed to initialize validator:", err)
+	}
+
 	// 设置路由
 	appInstance.SetupRoutes()
 
-	// 启动服务器
+	// 按依赖顺序启动数据库、Redis、后台组件和 HTTP 服务，直到收到退出信号
 	if err := appInstance.Run(); err != nil {
-		log.Fatal("Failed to start server:", err)
+		log.Fatal("Server exited with error:", err)
 	}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -21,27 +22,35 @@ func main() {
 	flag.Parse()
 
 	// 加载配置
-	if err := config.LoadConfig(*env); err != nil {
+	cfg, err := config.LoadConfig(*env)
+	if err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
 
 	// 验证日志配置
-	if err := logger.ValidateLogConfig(); err != nil {
+	if err := logger.ValidateLogConfig(cfg.Log); err != nil {
 		log.Fatal("Invalid log config:", err)
 	}
 
 	// 初始化日志
-	logger.InitLogger()
+	appLogger := logger.New(cfg.Log, logger.NewLevel(cfg.Log))
 
 	// 初始化数据库连接
-	if err := database.InitDB(); err != nil {
+	db, err := database.New(cfg.Database, cfg.Log, appLogger)
+	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
+	if err := database.Ping(context.Background(), db); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.Close(db)
+
+	migrator := migrate.New(db, appLogger)
 
 	// 执行迁移操作
 	switch *action {
 	case "up":
-		if err := migrate.RunMigrations(); err != nil {
+		if err := migrator.RunMigrations(); err != nil {
 			log.Fatal("Failed to run migrations:", err)
 		}
 		fmt.Println("Migrations completed successfully")
@@ -50,13 +59,13 @@ func main() {
 		if *version == "" {
 			log.Fatal("Version is required for down action")
 		}
-		if err := migrate.RollbackMigration(*version); err != nil {
+		if err := migrator.RollbackMigration(*version); err != nil {
 			log.Fatal("Failed to rollback migration:", err)
 		}
 		fmt.Printf("Migration %s rolled back successfully\n", *version)
 
 	case "status":
-		migrations, err := migrate.GetMigrationStatus()
+		migrations, err := migrator.GetMigrationStatus()
 		if err != nil {
 			log.Fatal("Failed to get migration status:", err)
 		}
@@ -84,7 +93,7 @@ func main() {
 			os.Exit(0)
 		}
 
-		if err := migrate.ResetDatabase(); err != nil {
+		if err := migrator.ResetDatabase(); err != nil {
 			log.Fatal("Failed to reset database:", err)
 		}
 		fmt.Println("Database reset successfully")
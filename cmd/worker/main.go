@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/chenyl99x/toge-api/internal/app"
+	"github.com/chenyl99x/toge-api/internal/wire"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/logger"
+)
+
+func main() {
+	// 获取环境变量，默认为 dev
+	env := os.Getenv("ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	// 加载配置文件
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	// 验证日志配置
+	if err := logger.ValidateLogConfig(cfg.Log); err != nil {
+		log.Fatal("Invalid log config:", err)
+	}
+
+	// 使用 Wire 初始化 worker，与 API 共用同一套依赖
+	worker, err := wire.InitializeWorker(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize worker:", err)
+	}
+
+	// 第三方库和未注入日志的组件使用默认日志
+	slog.SetDefault(worker.Logger)
+
+	// 初始化时区
+	if err := app.InitializeTimezone(cfg, worker.Logger); err != nil {
+		log.Fatal("Failed to initialize timezone:", err)
+	}
+
+	// 导入时按创建用户接口的规则校验，需要注册自定义校验规则
+	if err := app.InitializeValidator(); err != nil {
+		log.Fatal("Failed to initialize validator:", err)
+	}
+
+	// 启动数据库、Redis 和任务消费，直到收到退出信号
+	if err := worker.Run(); err != nil {
+		log.Fatal("Worker exited with error:", err)
+	}
+}
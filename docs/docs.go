@@ -1,4 +1,4 @@
-// Code generated by swaggo/swag. DO NOT EDIT
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
 package docs
 
 import "github.com/swaggo/swag"
@@ -24,9 +24,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "用户登录并返回 JWT token",
+        "/admin/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按状态、类型和聚合筛选发件箱中的事件（支持分页）",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,18 +39,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "认证与校验"
+                    "管理"
                 ],
-                "summary": "用户登录",
+                "summary": "获取领域事件列表",
                 "parameters": [
                     {
-                        "description": "登录信息",
-                        "name": "login",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_handler.LoginRequest"
-                        }
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "页码，默认为1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "每页大小，默认为10，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：occurred_at, type, status, attempts, published_at",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序方向：asc, desc，默认为desc",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "投递状态：pending, published, failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "事件类型，例如 user.registered",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "聚合类型，例如 user",
+                        "name": "aggregate_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "聚合ID",
+                        "name": "aggregate_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -60,7 +107,22 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/internal_handler.LoginResponse"
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_pagination.PageResponse"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "data": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_event.Event"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
                                         }
                                     }
                                 }
@@ -78,18 +140,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
                     }
                 }
             }
         },
-        "/auth/logout": {
-            "post": {
+        "/admin/events/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "用户登出，将 token 加入黑名单",
+                "description": "获取事件载荷、投递状态和最近一次错误",
                 "consumes": [
                     "application/json"
                 ],
@@ -97,9 +165,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "认证与校验"
+                    "管理"
+                ],
+                "summary": "获取领域事件详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "事件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "用户登出",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -112,25 +189,42 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "additionalProperties": true
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_event.Event"
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
                     }
                 }
             }
         },
-        "/auth/profile": {
-            "get": {
+        "/admin/events/{id}/replay": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取当前登录用户的信息",
+                "description": "将事件重置为待投递状态，由 Relay 重新投递给所有订阅者",
                 "consumes": [
                     "application/json"
                 ],
@@ -138,9 +232,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "认证与校验"
+                    "管理"
+                ],
+                "summary": "重放领域事件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "事件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "获取用户信息",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -153,7 +256,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_event.Event"
                                         }
                                     }
                                 }
@@ -165,13 +268,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
                     }
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "新用户注册",
+        "/admin/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取所有已注册的定时任务及其下次运行时间、最近一次运行结果",
                 "consumes": [
                     "application/json"
                 ],
@@ -179,23 +299,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "认证与校验"
-                ],
-                "summary": "用户注册",
-                "parameters": [
-                    {
-                        "description": "注册信息",
-                        "name": "register",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_handler.RegisterRequest"
-                        }
-                    }
+                    "管理"
                 ],
+                "summary": "获取定时任务列表",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -205,27 +314,24 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_scheduler.JobInfo"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
-                        }
-                    },
-                    "409": {
-                        "description": "Conflict",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
@@ -233,9 +339,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/admin/jobs/{name}/runs": {
             "get": {
-                "description": "获取应用健康状态和系统信息",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按时间倒序获取指定任务最近的运行记录",
                 "consumes": [
                     "application/json"
                 ],
@@ -243,9 +354,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "健康"
+                    "管理"
+                ],
+                "summary": "获取任务运行记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "任务名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回条数，默认20",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "健康检查",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -258,20 +384,45 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "additionalProperties": true
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_scheduler.Run"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
                     }
                 }
             }
         },
-        "/space": {
+        "/admin/jobs/{name}/trigger": {
             "post": {
-                "description": "创建岛屿",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "立即在后台运行指定任务，返回本次运行的 trace_id",
                 "consumes": [
                     "application/json"
                 ],
@@ -279,23 +430,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "岛屿"
+                    "管理"
                 ],
-                "summary": "创建岛屿",
+                "summary": "手动触发定时任务",
                 "parameters": [
                     {
-                        "description": "创建岛屿请求",
-                        "name": "space",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CreateSpaceRequest"
-                        }
+                        "type": "string",
+                        "description": "任务名称",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "创建成功",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -305,53 +454,83 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                            "type": "object",
+                                            "additionalProperties": true
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "参数错误",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "500": {
-                        "description": "服务器错误",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
-                    }
-                }
-            }
-        },
-        "/space/{id}": {
-            "get": {
-                "description": "获取岛屿详情",
-                "consumes": [
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/queues/{queue}/dead": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定队列中重试耗尽或无法处理的任务，按失败时间倒序",
+                "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "岛屿"
+                    "管理"
                 ],
-                "summary": "获取岛屿详情",
+                "summary": "获取死信任务",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "岛屿ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "队列名称",
+                        "name": "queue",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回条数，默认20",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -361,35 +540,39 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_queue.Task"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "参数错误",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
-                        }
-                    },
-                    "404": {
-                        "description": "空间不存在",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "500": {
-                        "description": "服务器错误",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "修改岛屿信息，支持修改 name、owner_user_id、type、description 字段",
+            }
+        },
+        "/admin/queues/{queue}/dead/{id}/requeue": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "将死信任务放回就绪队列并清零重试次数",
                 "consumes": [
                     "application/json"
                 ],
@@ -397,30 +580,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "岛屿"
+                    "管理"
                 ],
-                "summary": "修改岛屿信息",
+                "summary": "重新投递死信任务",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "岛屿ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "队列名称",
+                        "name": "queue",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "修改岛屿请求",
-                        "name": "space",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UpdateSpaceRequest"
-                        }
+                        "type": "string",
+                        "description": "任务ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "修改成功",
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -430,27 +611,27 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_queue.Task"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "参数错误",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "404": {
-                        "description": "岛屿不存在",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "500": {
-                        "description": "服务器错误",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
@@ -458,9 +639,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/timezone/available": {
-            "get": {
-                "description": "获取系统支持的所有时区列表",
+        "/auth/login": {
+            "post": {
+                "description": "用户登录并返回 JWT token",
                 "consumes": [
                     "application/json"
                 ],
@@ -468,9 +649,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "时区"
+                    "认证与校验"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "登录信息",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_handler.LoginRequest"
+                        }
+                    }
                 ],
-                "summary": "获取可用时区列表",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -483,22 +675,36 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "type": "string"
-                                            }
+                                            "$ref": "#/definitions/internal_handler.LoginResponse"
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
                     }
                 }
             }
         },
-        "/timezone/convert": {
-            "get": {
-                "description": "将时间从一个时区转换到另一个时区",
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "用户登出，将 token 加入黑名单",
                 "consumes": [
                     "application/json"
                 ],
@@ -506,35 +712,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "时区"
-                ],
-                "summary": "转换时区",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "example": "2023-01-01 12:00:00",
-                        "description": "时间字符串",
-                        "name": "time",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "Asia/Shanghai",
-                        "description": "源时区",
-                        "name": "from_timezone",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "America/New_York",
-                        "description": "目标时区",
-                        "name": "to_timezone",
-                        "in": "query",
-                        "required": true
-                    }
+                    "认证与校验"
                 ],
+                "summary": "用户登出",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -554,19 +734,18 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
-                        }
                     }
                 }
             }
         },
-        "/timezone/current": {
+        "/auth/profile": {
             "get": {
-                "description": "获取系统当前设置的时区",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前登录用户的信息",
                 "consumes": [
                     "application/json"
                 ],
@@ -574,9 +753,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "时区"
+                    "认证与校验"
                 ],
-                "summary": "获取当前时区",
+                "summary": "获取用户信息",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -589,20 +768,25 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "additionalProperties": true
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
                     }
                 }
             }
         },
-        "/timezone/format": {
-            "get": {
-                "description": "将时间戳格式化为指定时区的时间字符串",
+        "/auth/register": {
+            "post": {
+                "description": "新用户注册，保留的用户名（例如 admin）不能注册，返回 409",
                 "consumes": [
                     "application/json"
                 ],
@@ -610,37 +794,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "时区"
+                    "认证与校验"
                 ],
-                "summary": "格式化时间",
+                "summary": "用户注册",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "example": 1672531200,
-                        "description": "时间戳",
-                        "name": "timestamp",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "Asia/Shanghai",
-                        "description": "时区名称",
-                        "name": "timezone",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "2006-01-02 15:04:05",
-                        "description": "时间格式",
-                        "name": "format",
-                        "in": "query"
+                        "description": "注册信息",
+                        "name": "register",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_handler.RegisterRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "allOf": [
                                 {
@@ -650,8 +820,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "additionalProperties": true
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
                                         }
                                     }
                                 }
@@ -663,63 +832,15 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
-                    }
-                }
-            }
-        },
-        "/timezone/parse": {
-            "get": {
-                "description": "在指定时区解析时间字符串",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "时区"
-                ],
-                "summary": "解析时间字符串",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "example": "2023-01-01 12:00:00",
-                        "description": "时间字符串",
-                        "name": "time",
-                        "in": "query",
-                        "required": true
                     },
-                    {
-                        "type": "string",
-                        "example": "Asia/Shanghai",
-                        "description": "时区名称",
-                        "name": "timezone",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "object",
-                                            "additionalProperties": true
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
@@ -727,9 +848,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/timezone/time": {
+        "/health": {
             "get": {
-                "description": "获取指定时区的当前时间",
+                "description": "获取应用健康状态、依赖检查结果和系统信息，启动完成前、优雅停机期间或关键检查失败时返回 503",
                 "consumes": [
                     "application/json"
                 ],
@@ -737,19 +858,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "时区"
-                ],
-                "summary": "获取指定时区的时间",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "example": "Asia/Shanghai",
-                        "description": "时区名称",
-                        "name": "timezone",
-                        "in": "query",
-                        "required": true
-                    }
+                    "健康"
                 ],
+                "summary": "健康检查",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -770,8 +881,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
@@ -779,9 +890,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
-            "post": {
-                "description": "创建新用户",
+        "/livez": {
+            "get": {
+                "description": "只要进程能处理请求就返回 200，不检查外部依赖，避免依赖故障导致实例被反复重启",
                 "consumes": [
                     "application/json"
                 ],
@@ -789,23 +900,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
-                ],
-                "summary": "创建用户",
-                "parameters": [
-                    {
-                        "description": "用户信息",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CreateUserRequest"
-                        }
-                    }
+                    "健康"
                 ],
+                "summary": "存活探针",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -815,31 +915,25 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                            "type": "object",
+                                            "additionalProperties": true
                                         }
                                     }
                                 }
                             ]
                         }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
-                        }
                     }
                 }
             }
         },
-        "/users/": {
+        "/notifications/": {
             "get": {
-                "description": "获取所有用户列表（支持分页、排序和搜索）",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户的通知收件箱（支持分页和只看未读）",
                 "consumes": [
                     "application/json"
                 ],
@@ -847,9 +941,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
+                    "通知"
                 ],
-                "summary": "获取所有用户",
+                "summary": "获取通知列表",
                 "parameters": [
                     {
                         "minimum": 1,
@@ -868,7 +962,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "排序字段：id, username, email, nickname, status, created_at, updated_at",
+                        "description": "排序字段：id, category, created_at, read_at",
                         "name": "sort_by",
                         "in": "query"
                     },
@@ -879,15 +973,9 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "搜索关键词",
-                        "name": "keyword",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "搜索字段：username, email, nickname，不指定则在所有字段中搜索",
-                        "name": "search_by",
+                        "type": "boolean",
+                        "description": "只返回未读通知",
+                        "name": "unread_only",
                         "in": "query"
                     }
                 ],
@@ -913,7 +1001,7 @@ const docTemplate = `{
                                                         "data": {
                                                             "type": "array",
                                                             "items": {
-                                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Notification"
                                                             }
                                                         }
                                                     }
@@ -925,8 +1013,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
@@ -940,9 +1028,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/notifications/preferences": {
             "get": {
-                "description": "根据ID获取用户详细信息",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户各类别的渠道开关和免打扰时段",
                 "consumes": [
                     "application/json"
                 ],
@@ -950,18 +1043,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
-                ],
-                "summary": "获取用户详情",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "用户ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "通知"
                 ],
+                "summary": "获取通知偏好",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -974,21 +1058,21 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.NotificationPreferencesResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
@@ -996,7 +1080,12 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "根据ID更新用户信息",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "更新当前用户各类别的渠道开关和免打扰时段，未提交的类别保持不变",
                 "consumes": [
                     "application/json"
                 ],
@@ -1004,24 +1093,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
+                    "通知"
                 ],
-                "summary": "更新用户",
+                "summary": "更新通知偏好",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "用户ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "用户更新信息",
-                        "name": "user",
+                        "description": "通知偏好",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UpdateUserRequest"
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UpdateNotificationPreferencesRequest"
                         }
                     }
                 ],
@@ -1037,7 +1119,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.NotificationPreferencesResponse"
                                         }
                                     }
                                 }
@@ -1050,8 +1132,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
@@ -1063,9 +1145,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "根据ID删除用户",
+            }
+        },
+        "/notifications/read": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "将指定的通知标记为已读",
                 "consumes": [
                     "application/json"
                 ],
@@ -1073,16 +1162,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
+                    "通知"
                 ],
-                "summary": "删除用户",
+                "summary": "批量标记已读",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "用户ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "通知ID列表",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.MarkNotificationsReadRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -1111,6 +1202,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1119,59 +1216,2968 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_chenyl99x_toge-api_internal_domain.CreateSpaceRequest": {
-            "type": "object",
-            "required": [
-                "description",
-                "name",
-                "owner_user_id",
-                "type"
-            ],
-            "properties": {
-                "description": {
-                    "type": "string",
-                    "example": "这是一个美好的空间"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "我的空间"
-                },
-                "owner_user_id": {
-                    "type": "integer",
-                    "example": 1
-                },
-                "type": {
-                    "type": "string",
-                    "example": "情侣空间"
-                }
-            }
         },
-        "github_com_chenyl99x_toge-api_internal_domain.CreateUserRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "password",
-                "username"
-            ],
-            "properties": {
-                "avatar": {
-                    "type": "string",
-                    "example": "https://example.com/avatar.jpg"
-                },
-                "email": {
-                    "type": "string",
-                    "example": "john@example.com"
-                },
-                "nickname": {
-                    "type": "string",
-                    "example": "John Doe"
-                },
-                "password": {
-                    "type": "string",
-                    "minLength": 6,
+        "/notifications/read-all": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "将当前用户的所有未读通知标记为已读",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "全部标记已读",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/unread-count": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户的未读通知总数及按类别统计",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "获取未读数",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UnreadCountResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除当前用户的一条通知",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "删除通知",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "通知ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "检查数据库、Redis、日志目录磁盘空间和数据库迁移，结果会短暂缓存\n关键检查失败或启动未完成、优雅停机期间返回 503；非关键检查失败时状态为 degraded，仍返回 200",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "健康"
+                ],
+                "summary": "就绪探针",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_health.Report"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_health.Report"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "在用户和当前用户所属的空间中搜索，中文按二元切分，所有词都需要命中，结果按相关度排序\nhighlights 中命中的词用 \u003cmark\u003e 包裹，其余内容已转义；facets 为各内容类型的命中数，不受 type 过滤影响",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "搜索"
+                ],
+                "summary": "全文搜索",
+                "parameters": [
+                    {
+                        "maxLength": 100,
+                        "type": "string",
+                        "description": "搜索文本",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "内容类型：user, space，多个用逗号分隔，为空时搜索所有类型",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "页码，默认为1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "每页大小，默认为10，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_search.Result"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/space": {
+            "post": {
+                "description": "创建岛屿",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "岛屿"
+                ],
+                "summary": "创建岛屿",
+                "parameters": [
+                    {
+                        "description": "创建岛屿请求",
+                        "name": "space",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CreateSpaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/space/{id}": {
+            "get": {
+                "description": "获取岛屿详情",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "岛屿"
+                ],
+                "summary": "获取岛屿详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "岛屿ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "返回的字段，逗号分隔，例如 ID,name；展开的关联的字段用点号，例如 owner.nickname,members.avatar",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "展开的关联：owner（拥有者）、members（成员，不含拥有者，只有拥有者和成员可以展开），嵌套用点号，例如 owner.spaces，最多 2 层",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "岛屿的版本，修改时通过 If-Match 传回"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "不是岛屿的成员，不能展开成员",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "空间不存在",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "修改岛屿信息，支持修改 name、owner_user_id、type、description 字段，需要通过 If-Match 传入获取岛屿时的 ETag\n版本不一致时返回 412，写入时被其他请求抢先修改返回 409，两者都在 data 中返回岛屿的当前数据，ETag 为当前版本",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "岛屿"
+                ],
+                "summary": "修改岛屿信息",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "岛屿ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "获取岛屿时的 ETag，* 表示不检查版本",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "修改岛屿请求",
+                        "name": "space",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UpdateSpaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "修改后的版本"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "岛屿不存在",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "岛屿已被其他请求修改",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "版本不一致",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "428": {
+                        "description": "缺少 If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Content-Type 为 application/merge-patch+json（或 application/json）时按 RFC 7396 合并补丁处理，为 application/json-patch+json 时按 RFC 6902 JSON Patch 处理\n可写字段：name、owner_user_id、type、description，description 可以用 null 或 remove 清空\n需要通过 If-Match 传入获取岛屿时的 ETag，版本不一致返回 412，写入时被抢先修改或 test 操作不成立返回 409，两者都在 data 中返回岛屿的当前数据",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "岛屿"
+                ],
+                "summary": "部分修改岛屿信息",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "岛屿ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "获取岛屿时的 ETag，* 表示不检查版本",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "合并补丁，或 JSON Patch 操作数组，例如 [{\\",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.SpacePatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "修改后的版本"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "岛屿不存在",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "岛屿已被其他请求修改或 test 操作不成立",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "版本不一致",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "415": {
+                        "description": "不支持的补丁格式",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "428": {
+                        "description": "缺少 If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/space/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取加入岛屿的成员，按加入时间排序，不包含拥有者，只有拥有者和成员可以查看",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "岛屿"
+                ],
+                "summary": "获取岛屿成员",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "岛屿ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.SpaceMember"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "不是岛屿的成员",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "岛屿不存在",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "岛屿拥有者把用户加入岛屿，并发布 space.member_joined 事件，其他用户返回 403，拥有者和已加入的用户返回 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "岛屿"
+                ],
+                "summary": "添加岛屿成员",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "岛屿ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "添加成员请求",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.AddSpaceMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "添加成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.SpaceMember"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "不是岛屿的拥有者",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "岛屿不存在",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "已经是岛屿的成员",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/timezone/available": {
+            "get": {
+                "description": "获取系统支持的所有时区列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "时区"
+                ],
+                "summary": "获取可用时区列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/timezone/convert": {
+            "get": {
+                "description": "将时间从一个时区转换到另一个时区",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "时区"
+                ],
+                "summary": "转换时区",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2023-01-01 12:00:00",
+                        "description": "时间字符串",
+                        "name": "time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Asia/Shanghai",
+                        "description": "源时区",
+                        "name": "from_timezone",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "America/New_York",
+                        "description": "目标时区",
+                        "name": "to_timezone",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/timezone/current": {
+            "get": {
+                "description": "获取系统当前设置的时区",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "时区"
+                ],
+                "summary": "获取当前时区",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/timezone/format": {
+            "get": {
+                "description": "将时间戳格式化为指定时区的时间字符串",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "时区"
+                ],
+                "summary": "格式化时间",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "example": 1672531200,
+                        "description": "时间戳",
+                        "name": "timestamp",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Asia/Shanghai",
+                        "description": "时区名称",
+                        "name": "timezone",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2006-01-02 15:04:05",
+                        "description": "时间格式",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/timezone/parse": {
+            "get": {
+                "description": "在指定时区解析时间字符串",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "时区"
+                ],
+                "summary": "解析时间字符串",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2023-01-01 12:00:00",
+                        "description": "时间字符串",
+                        "name": "time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Asia/Shanghai",
+                        "description": "时区名称",
+                        "name": "timezone",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/timezone/time": {
+            "get": {
+                "description": "获取指定时区的当前时间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "时区"
+                ],
+                "summary": "获取指定时区的时间",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "Asia/Shanghai",
+                        "description": "时区名称",
+                        "name": "timezone",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "description": "创建新用户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "创建用户",
+                "parameters": [
+                    {
+                        "description": "用户信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "用户名或邮箱已存在，error_code 为 USERNAME_EXISTS 或 EMAIL_EXISTS",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/": {
+            "get": {
+                "description": "获取所有用户列表（支持分页、排序、搜索和过滤）\n传 cursor 或 limit 时使用游标分页，不统计总数，响应为 data、limit、next_cursor、prev_cursor、has_next、has_prev；翻页时只需传上次响应中的 next_cursor 或 prev_cursor，排序沿用游标中的设置，搜索和过滤条件需要重复传递\n过滤字段见 Swagger UI 中的 filter[字段] 参数，由 domain.UserFilters 白名单生成\n过滤：filter[字段]=值 表示等于，filter[字段][操作符]=值 指定操作符，in 和 nin 的值以逗号分隔，例如 filter[status][in]=0,1、filter[created_at][gte]=2025-01-01\n组合：filter[or][0][字段]=值\u0026filter[or][1][字段]=值 满足其一，同一序号内的条件同时满足，filter[not][字段]=值 取反",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "获取所有用户",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "页码，默认为1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "每页大小，默认为10，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：id, username, email, nickname, status, created_at, updated_at",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序方向：asc, desc，默认为desc",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "搜索关键词",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "搜索字段：username, email, nickname，不指定则在所有字段中搜索",
+                        "name": "search_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，为空表示第一页",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "游标分页每页大小，默认为10，最大100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "返回的字段，逗号分隔，例如 id,nickname,avatar；展开的关联的字段用点号，例如 spaces.name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "展开的关联：spaces（拥有的岛屿），嵌套用点号，例如 spaces.owner，最多 2 层；列表不能展开 spaces.members",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_pagination.PageResponse"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "data": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按与用户列表相同的搜索和过滤条件导出用户，结果按 ID 升序流式写出，忽略分页和排序参数\n过滤参数与用户列表相同，见 GET /users/ 的说明\n表头与导入字段同名，补充 password 列后可以直接导入；CSV 为带 BOM 的 UTF-8，以 = + - @ 开头的值前加单引号",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "导出用户",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件格式：csv, xlsx，默认为csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "搜索关键词",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "搜索字段：username, email, nickname，不指定则在所有字段中搜索",
+                        "name": "search_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "上传 CSV 或 XLSX 文件批量创建用户，第一行为表头，空行跳过；必须包含 username、email、password 列，可选 nickname、avatar、status\nmapping 为表头到字段的 JSON 对象，例如 {\"用户名\":\"username\",\"邮箱\":\"email\",\"密码\":\"password\",\"备注\":\"\"}，值为空字符串时忽略该列，未出现的表头与字段同名时自动映射\ndry_run 为 true 时只校验，逐行报告格式错误、文件内重复以及已被占用的用户名和邮箱\n行数不超过阈值时直接执行并返回 200 和逐行结果；超过时返回 202，通过 GET /users/import/{id} 轮询进度，完成后返回逐行结果",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "导入用户",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV 或 XLSX 文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件格式：csv, xlsx，为空时按扩展名判断",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "列映射，JSON 对象，键为表头，值为字段",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只校验不写入",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UserImportJobResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UserImportJobResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/import/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "查询导入任务的状态和进度，任务完成后包含逐行结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "查询导入任务",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任务ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UserImportJobResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "根据ID获取用户详细信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "获取用户详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "返回的字段，逗号分隔，例如 id,nickname,avatar；展开的关联的字段用点号，例如 spaces.name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "展开的关联：spaces（拥有的岛屿），嵌套用点号，例如 spaces.owner、spaces.members，最多 2 层；spaces.members 只能在获取自己时展开",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "用户的版本，修改时通过 If-Match 传回"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "根据ID更新用户信息，需要通过 If-Match 传入获取用户时的 ETag\n版本不一致时返回 412，写入时被其他请求抢先修改返回 409，两者都在 data 中返回用户的当前数据，ETag 为当前版本",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "更新用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "获取用户时的 ETag，* 表示不检查版本",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "用户更新信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "修改后的版本"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "根据ID删除用户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "删除用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": true
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Content-Type 为 application/merge-patch+json（或 application/json）时按 RFC 7396 合并补丁处理，为 application/json-patch+json 时按 RFC 6902 JSON Patch 处理\n可写字段：username、email、password、nickname、avatar、status、locale，nickname 和 avatar 可以用 null 或 remove 清空，locale 清空后按 Accept-Language 选择语言；password 只写，补丁中视为 null\n需要通过 If-Match 传入获取用户时的 ETag，版本不一致返回 412，写入时被抢先修改或 test 操作不成立返回 409，两者都在 data 中返回用户的当前数据",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户"
+                ],
+                "summary": "部分更新用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "获取用户时的 ETag，* 表示不检查版本",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "合并补丁，或 JSON Patch 操作数组，例如 [{\\",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UserPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "修改后的版本"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "默认返回当前用户的 webhook，指定 owner_type=space 和 owner_id 时返回空间的 webhook",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "获取 Webhook 列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "归属类型：user, space，默认为user",
+                        "name": "owner_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "空间ID",
+                        "name": "owner_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Webhook"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "为当前用户或自己拥有的空间注册 webhook，签名密钥只在创建时返回\n投递请求头：X-Toge-Event、X-Toge-Event-Id、X-Toge-Delivery、X-Toge-Timestamp、X-Toge-Signature（sha256=HMAC-SHA256(secret, \"\u003ctimestamp\u003e.\u003cbody\u003e\")）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "创建 Webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook 信息",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CreateWebhookResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取 webhook 配置和连续失败次数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "获取 Webhook 详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "更新地址、订阅的事件或启用状态，重新启用时清零连续失败次数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "更新 Webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "需要更新的字段",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除 webhook，已入队的投递会被跳过",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "删除 Webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按时间倒序获取每次投递的请求和响应快照",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "获取投递日志",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "页码，默认为1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "每页大小，默认为10，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：id, event_type, status, created_at",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序方向：asc, desc，默认为desc",
+                        "name": "sort_order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_pagination.PageResponse"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "data": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.WebhookDelivery"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "使用投递日志中的原始请求体重新投递一次，结果会记录为新的投递日志",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "重新投递",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "投递记录ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/rotate-secret": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "生成新的签名密钥并立即生效，新密钥只在此时返回",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "轮换签名密钥",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CreateWebhookResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_response.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_chenyl99x_toge-api_internal_domain.AddSpaceMemberRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.CategoryPreference": {
+            "type": "object",
+            "required": [
+                "category"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "comment"
+                },
+                "email": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "in_app": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "push": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.CreateSpaceRequest": {
+            "type": "object",
+            "required": [
+                "description",
+                "name",
+                "owner_user_id",
+                "type"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "这是一个美好的空间"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "我的空间"
+                },
+                "owner_user_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "type": {
+                    "type": "string",
+                    "example": "情侣空间"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "avatar": {
+                    "type": "string",
+                    "example": "https://example.com/avatar.jpg"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                },
+                "locale": {
+                    "description": "语言偏好：en-US、zh-CN",
+                    "type": "string",
+                    "example": "zh-CN"
+                },
+                "nickname": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "123456"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "type": "string",
+                    "example": "john_doe"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "owner_type",
+                "url"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "同步到合作方"
+                },
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "space.created",
+                        "space.updated"
+                    ]
+                },
+                "owner_id": {
+                    "description": "owner_type 为 space 时必填，为 user 时忽略并使用当前用户",
+                    "type": "integer",
+                    "example": 1
+                },
+                "owner_type": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "space"
+                    ],
+                    "example": "space"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "https://example.com/hooks/toge"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.CreateWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_3f9a1c2b7d4e5f60a1b2c3d4e5f60718"
+                },
+                "webhook": {
+                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Webhook"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.MarkNotificationsReadRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.NotificationPreferencesResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CategoryPreference"
+                    }
+                },
+                "quiet_hours": {
+                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.QuietHours"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.QuietHours": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "end": {
+                    "type": "string",
+                    "example": "07:00"
+                },
+                "start": {
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.SpacePatch": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "这是一个美好的空间"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "我的空间"
+                },
+                "owner_user_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "type": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1,
+                    "example": "情侣空间"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.UnreadCountResponse": {
+            "type": "object",
+            "properties": {
+                "by_category": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.UpdateNotificationPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.CategoryPreference"
+                    }
+                },
+                "quiet_hours": {
+                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.QuietHours"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.UpdateSpaceRequest": {
+            "type": "object",
+            "required": [
+                "description",
+                "name",
+                "owner_user_id",
+                "type"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "这是一个美好的空间"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "我的空间"
+                },
+                "owner_user_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "type": {
+                    "type": "string",
+                    "example": "情侣空间"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "type": "string",
+                    "example": "https://example.com/avatar.jpg"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                },
+                "locale": {
+                    "description": "语言偏好：en-US、zh-CN，空字符串表示按 Accept-Language 选择",
+                    "type": "string",
+                    "example": "zh-CN"
+                },
+                "nickname": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
                     "example": "123456"
                 },
                 "status": {
@@ -1184,136 +4190,723 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_chenyl99x_toge-api_internal_domain.UpdateSpaceRequest": {
+        "github_com_chenyl99x_toge-api_internal_domain.UpdateWebhookRequest": {
             "type": "object",
-            "required": [
-                "description",
-                "name",
-                "owner_user_id",
-                "type"
-            ],
             "properties": {
                 "description": {
                     "type": "string",
-                    "example": "这是一个美好的空间"
+                    "maxLength": 255,
+                    "example": "同步到合作方"
+                },
+                "enabled": {
+                    "description": "重新启用时清零连续失败次数",
+                    "type": "boolean",
+                    "example": true
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "space.created"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "https://example.com/hooks/toge"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.UserImportJobResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "发起用户ID",
+                    "type": "integer",
+                    "example": 1
+                },
+                "dry_run": {
+                    "description": "只校验不写入",
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "description": "任务失败原因",
+                    "type": "string"
+                },
+                "failed": {
+                    "description": "失败行数",
+                    "type": "integer",
+                    "example": 2
+                },
+                "file_name": {
+                    "description": "文件名",
+                    "type": "string",
+                    "example": "users.xlsx"
+                },
+                "finished_at": {
+                    "description": "结束时间",
+                    "type": "string"
+                },
+                "format": {
+                    "description": "文件格式:csv、xlsx",
+                    "type": "string",
+                    "example": "xlsx"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "processed": {
+                    "description": "已处理行数",
+                    "type": "integer",
+                    "example": 400
+                },
+                "results": {
+                    "description": "逐行结果，任务结束后返回",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_domain.UserImportRowResult"
+                    }
+                },
+                "started_at": {
+                    "description": "开始时间",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "任务状态:pending、running、completed、failed",
+                    "type": "string",
+                    "example": "running"
+                },
+                "succeeded": {
+                    "description": "成功行数，只校验时为校验通过的行数",
+                    "type": "integer",
+                    "example": 398
+                },
+                "total": {
+                    "description": "总行数",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.UserImportRowResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "description": "校验或创建失败的原因",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "email is invalid"
+                    ]
+                },
+                "row": {
+                    "description": "文件中的行号，表头为第 1 行",
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "description": "结果:valid、created、invalid、failed",
+                    "type": "string",
+                    "example": "invalid"
+                },
+                "user_id": {
+                    "description": "创建的用户ID",
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "description": "用户名",
+                    "type": "string",
+                    "example": "john_doe"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_domain.UserPatch": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://example.com/avatar.jpg"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "john@example.com"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "zh-CN"
+                },
+                "nickname": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "John Doe"
+                },
+                "password": {
+                    "description": "明文密码，由调用方校验强度并加密",
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "123456"
+                },
+                "status": {
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ],
+                    "example": 1
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1,
+                    "example": "john_doe"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_model.Notification": {
+            "description": "站内通知",
+            "type": "object",
+            "properties": {
+                "category": {
+                    "description": "通知类别",
+                    "type": "string",
+                    "example": "comment"
+                },
+                "content": {
+                    "description": "内容",
+                    "type": "string",
+                    "example": "小明评论了你的动态"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "link": {
+                    "description": "跳转链接",
+                    "type": "string",
+                    "example": "/space/1"
+                },
+                "read_at": {
+                    "description": "阅读时间，为空表示未读",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "title": {
+                    "description": "标题",
+                    "type": "string",
+                    "example": "你收到一条新评论"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "接收用户ID",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_model.Space": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "description": "描述",
+                    "type": "string",
+                    "example": "这是一个美好的岛屿"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "members": {
+                    "description": "成员，不含拥有者，只在 expand=members 时加载",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                    }
                 },
                 "name": {
+                    "description": "岛屿名称",
                     "type": "string",
-                    "example": "我的空间"
+                    "example": "我的岛屿"
+                },
+                "owner": {
+                    "description": "拥有者，只在 expand=owner 时加载",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.User"
+                        }
+                    ]
                 },
                 "owner_user_id": {
+                    "description": "岛屿拥有者ID",
                     "type": "integer",
                     "example": 1
                 },
                 "type": {
+                    "description": "岛屿类型:情侣空间、家庭空间",
                     "type": "string",
                     "example": "情侣空间"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "版本号，每次修改加一，用于 ETag 和 If-Match",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "github_com_chenyl99x_toge-api_internal_domain.UpdateUserRequest": {
+        "github_com_chenyl99x_toge-api_internal_model.SpaceMember": {
+            "description": "岛屿成员",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "加入时间",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "space_id": {
+                    "description": "岛屿ID",
+                    "type": "integer",
+                    "example": 1
+                },
+                "user_id": {
+                    "description": "用户ID",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_model.User": {
+            "description": "用户信息",
             "type": "object",
             "properties": {
                 "avatar": {
                     "type": "string",
                     "example": "https://example.com/avatar.jpg"
                 },
-                "email": {
-                    "type": "string",
-                    "example": "john@example.com"
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "locale": {
+                    "description": "语言偏好，为空时按 Accept-Language 选择",
+                    "type": "string",
+                    "example": "zh-CN"
+                },
+                "nickname": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "spaces": {
+                    "description": "拥有的岛屿，只在 expand=spaces 时加载",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_internal_model.Space"
+                    }
+                },
+                "status": {
+                    "description": "1: 正常, 0: 禁用",
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "john_doe"
+                },
+                "version": {
+                    "description": "版本号，每次修改加一，用于 ETag 和 If-Match",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_internal_model.Webhook": {
+            "description": "Webhook",
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "description": "连续失败次数",
+                    "type": "integer",
+                    "example": 0
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "创建用户ID",
+                    "type": "integer",
+                    "example": 1
                 },
-                "nickname": {
-                    "type": "string",
-                    "example": "John Doe"
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
                 },
-                "password": {
+                "description": {
+                    "description": "描述",
                     "type": "string",
-                    "minLength": 6,
-                    "example": "123456"
+                    "example": "同步到合作方"
                 },
-                "status": {
+                "disabled_at": {
+                    "description": "自动停用时间",
+                    "type": "string"
+                },
+                "disabled_reason": {
+                    "description": "停用原因",
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "是否启用",
+                    "type": "boolean",
+                    "example": true
+                },
+                "events": {
+                    "description": "订阅的事件类型，* 表示全部",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "space.created",
+                        "space.updated"
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "owner_id": {
+                    "description": "归属ID",
                     "type": "integer",
                     "example": 1
                 },
-                "username": {
+                "owner_type": {
+                    "description": "归属类型:user、space",
                     "type": "string",
-                    "example": "john_doe"
+                    "example": "space"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "投递地址",
+                    "type": "string",
+                    "example": "https://example.com/hooks/toge"
                 }
             }
         },
-        "github_com_chenyl99x_toge-api_internal_model.Space": {
+        "github_com_chenyl99x_toge-api_internal_model.WebhookDelivery": {
+            "description": "Webhook 投递记录",
             "type": "object",
             "properties": {
+                "attempt": {
+                    "description": "第几次尝试",
+                    "type": "integer",
+                    "example": 1
+                },
                 "createdAt": {
                     "type": "string"
                 },
                 "deletedAt": {
                     "$ref": "#/definitions/gorm.DeletedAt"
                 },
-                "description": {
-                    "description": "描述",
+                "duration_ms": {
+                    "description": "耗时",
+                    "type": "integer",
+                    "example": 120
+                },
+                "error": {
+                    "description": "错误信息",
+                    "type": "string"
+                },
+                "event_id": {
+                    "description": "事件ID",
                     "type": "string",
-                    "example": "这是一个美好的岛屿"
+                    "example": "9b1c2d3e4f5a6b7c"
+                },
+                "event_type": {
+                    "description": "事件类型",
+                    "type": "string",
+                    "example": "space.created"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
-                    "description": "岛屿名称",
+                "request_body": {
+                    "description": "请求体",
+                    "type": "string"
+                },
+                "request_headers": {
+                    "description": "请求头",
+                    "type": "string"
+                },
+                "request_url": {
+                    "description": "请求地址",
                     "type": "string",
-                    "example": "我的岛屿"
+                    "example": "https://example.com/hooks/toge"
                 },
-                "owner_user_id": {
-                    "description": "岛屿拥有者ID",
+                "response_body": {
+                    "description": "响应体",
+                    "type": "string"
+                },
+                "response_headers": {
+                    "description": "响应头",
+                    "type": "string"
+                },
+                "response_status": {
+                    "description": "响应状态码",
                     "type": "integer",
-                    "example": 1
+                    "example": 200
                 },
-                "type": {
-                    "description": "岛屿类型:情侣空间、家庭空间",
+                "status": {
+                    "description": "投递结果:success、failed",
                     "type": "string",
-                    "example": "情侣空间"
+                    "example": "success"
+                },
+                "trigger": {
+                    "description": "触发方式:event、manual",
+                    "type": "string",
+                    "example": "event"
                 },
                 "updatedAt": {
                     "type": "string"
+                },
+                "webhook_id": {
+                    "description": "WebhookID",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "github_com_chenyl99x_toge-api_internal_model.User": {
-            "description": "用户信息",
+        "github_com_chenyl99x_toge-api_pkg_apperror.Code": {
+            "type": "string",
+            "enum": [
+                "INVALID_ARGUMENT",
+                "VALIDATION_FAILED",
+                "UNAUTHENTICATED",
+                "PERMISSION_DENIED",
+                "NOT_FOUND",
+                "METHOD_NOT_ALLOWED",
+                "CONFLICT",
+                "USERNAME_EXISTS",
+                "EMAIL_EXISTS",
+                "VERSION_CONFLICT",
+                "PRECONDITION_FAILED",
+                "UNSUPPORTED_MEDIA_TYPE",
+                "INVALID_IMPORT",
+                "PRECONDITION_REQUIRED",
+                "RATE_LIMITED",
+                "INTERNAL",
+                "UNAVAILABLE"
+            ],
+            "x-enum-comments": {
+                "CodeConflict": "资源已存在或状态冲突，HTTP 409",
+                "CodeEmailExists": "邮箱已存在，HTTP 409",
+                "CodeInternal": "服务器内部错误，HTTP 500",
+                "CodeInvalidArgument": "请求参数错误，HTTP 400",
+                "CodeInvalidImport": "导入文件或列映射无效，HTTP 400",
+                "CodeMethodNotAllowed": "请求方法不支持，HTTP 405",
+                "CodeNotFound": "资源不存在，HTTP 404",
+                "CodePermissionDenied": "没有权限，HTTP 403",
+                "CodePreconditionFailed": "If-Match 与资源的当前版本不一致，HTTP 412",
+                "CodePreconditionRequired": "缺少 If-Match，HTTP 428",
+                "CodeRateLimited": "请求过于频繁，HTTP 429",
+                "CodeUnauthenticated": "未登录或令牌无效，HTTP 401",
+                "CodeUnavailable": "服务暂不可用，HTTP 503",
+                "CodeUnsupportedMediaType": "不支持的请求体格式，HTTP 415",
+                "CodeUsernameExists": "用户名已存在，HTTP 409",
+                "CodeValidationFailed": "请求参数校验失败，HTTP 400",
+                "CodeVersionConflict": "资源已被其他请求修改，HTTP 409"
+            },
+            "x-enum-descriptions": [
+                "请求参数错误，HTTP 400",
+                "请求参数校验失败，HTTP 400",
+                "未登录或令牌无效，HTTP 401",
+                "没有权限，HTTP 403",
+                "资源不存在，HTTP 404",
+                "请求方法不支持，HTTP 405",
+                "资源已存在或状态冲突，HTTP 409",
+                "用户名已存在，HTTP 409",
+                "邮箱已存在，HTTP 409",
+                "资源已被其他请求修改，HTTP 409",
+                "If-Match 与资源的当前版本不一致，HTTP 412",
+                "不支持的请求体格式，HTTP 415",
+                "导入文件或列映射无效，HTTP 400",
+                "缺少 If-Match，HTTP 428",
+                "请求过于频繁，HTTP 429",
+                "服务器内部错误，HTTP 500",
+                "服务暂不可用，HTTP 503"
+            ],
+            "x-enum-varnames": [
+                "CodeInvalidArgument",
+                "CodeValidationFailed",
+                "CodeUnauthenticated",
+                "CodePermissionDenied",
+                "CodeNotFound",
+                "CodeMethodNotAllowed",
+                "CodeConflict",
+                "CodeUsernameExists",
+                "CodeEmailExists",
+                "CodeVersionConflict",
+                "CodePreconditionFailed",
+                "CodeUnsupportedMediaType",
+                "CodeInvalidImport",
+                "CodePreconditionRequired",
+                "CodeRateLimited",
+                "CodeInternal",
+                "CodeUnavailable"
+            ]
+        },
+        "github_com_chenyl99x_toge-api_pkg_event.Event": {
             "type": "object",
             "properties": {
-                "avatar": {
+                "aggregate_id": {
                     "type": "string",
-                    "example": "https://example.com/avatar.jpg"
+                    "example": "1"
                 },
-                "created_at": {
+                "aggregate_type": {
                     "type": "string",
-                    "example": "2023-01-01T00:00:00Z"
+                    "example": "user"
                 },
-                "email": {
-                    "type": "string",
-                    "example": "john@example.com"
+                "attempts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "available_at": {
+                    "type": "string"
                 },
                 "id": {
-                    "type": "integer",
-                    "example": 1
+                    "type": "string",
+                    "example": "9b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"
                 },
-                "nickname": {
+                "last_error": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string",
-                    "example": "John Doe"
+                    "example": "pending"
+                },
+                "trace_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f60718"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "user.registered"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_pkg_health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_health.Result"
+                    }
                 },
                 "status": {
-                    "description": "1: 正常, 0: 禁用",
-                    "type": "integer",
-                    "example": 1
+                    "type": "string",
+                    "example": "healthy"
                 },
-                "updated_at": {
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_pkg_health.Result": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "checked_at": {
+                    "type": "string"
+                },
+                "critical": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "error": {
                     "type": "string",
-                    "example": "2023-01-01T00:00:00Z"
+                    "example": "dial tcp 127.0.0.1:6379: connect: connection refused"
                 },
-                "username": {
+                "latency_ms": {
+                    "type": "number",
+                    "example": 1.25
+                },
+                "status": {
                     "type": "string",
-                    "example": "john_doe"
+                    "example": "healthy"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_pkg_i18n.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "字段的 JSON 路径，嵌套字段用点号",
+                    "type": "string",
+                    "example": "email"
+                },
+                "message": {
+                    "description": "本地化的提示",
+                    "type": "string",
+                    "example": "email must be a valid email address"
+                },
+                "rule": {
+                    "description": "校验规则",
+                    "type": "string",
+                    "example": "email"
                 }
             }
         },
@@ -1349,6 +4942,57 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_chenyl99x_toge-api_pkg_queue.Task": {
+            "type": "object",
+            "properties": {
+                "enqueued_at": {
+                    "type": "string"
+                },
+                "failed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f9a1c2b7d4e5f60"
+                },
+                "keep_unique": {
+                    "description": "任务结束后保留唯一键，直到过期",
+                    "type": "boolean"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "max_retries": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "queue": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "retried": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "timeout": {
+                    "type": "integer"
+                },
+                "trace_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f60718"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "notification:send"
+                },
+                "unique_key": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_chenyl99x_toge-api_pkg_response.Response": {
             "type": "object",
             "properties": {
@@ -1359,13 +5003,156 @@ const docTemplate = `{
                 "data": {
                     "description": "响应数据"
                 },
+                "details": {
+                    "description": "校验失败的字段，只在校验错误响应中返回",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_i18n.FieldError"
+                    }
+                },
                 "error": {
-                    "description": "错误信息",
+                    "description": "错误信息，英文原文，不随语言变化",
                     "type": "string"
                 },
+                "error_code": {
+                    "description": "业务错误码，只在错误响应中返回",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_apperror.Code"
+                        }
+                    ]
+                },
                 "message": {
-                    "description": "响应消息",
+                    "description": "响应消息，按请求的语言本地化",
+                    "type": "string"
+                },
+                "trace_id": {
+                    "description": "链路 ID，与响应头 X-Trace-ID 相同，只在错误响应中返回",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_pkg_scheduler.JobInfo": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "清理已读通知"
+                },
+                "jitter": {
+                    "type": "string",
+                    "example": "30s"
+                },
+                "last_run": {
+                    "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_scheduler.Run"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "notification_purge"
+                },
+                "next_run": {
+                    "type": "string"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "spec": {
+                    "type": "string",
+                    "example": "0 3 * * *"
+                },
+                "timeout": {
+                    "type": "string",
+                    "example": "10m0s"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_pkg_scheduler.Run": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "type": "string",
+                    "example": "1.2s"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
                     "type": "string"
+                },
+                "job": {
+                    "type": "string",
+                    "example": "notification_purge"
+                },
+                "scheduled_at": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                },
+                "trace_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4e5f60718"
+                },
+                "trigger": {
+                    "type": "string",
+                    "example": "schedule"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_pkg_search.Hit": {
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "description": "命中片段，键为 title 或 body，命中词用 \u003cmark\u003e 包裹，其余内容已转义",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "score": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "title": {
+                    "type": "string",
+                    "example": "我的岛屿"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "space"
+                }
+            }
+        },
+        "github_com_chenyl99x_toge-api_pkg_search.Result": {
+            "type": "object",
+            "properties": {
+                "facets": {
+                    "description": "各内容类型的命中数，不受类型过滤影响",
+                    "type": "object"
+                },
+                "hits": {
+                    "description": "按相关度排序的当前页",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_chenyl99x_toge-api_pkg_search.Hit"
+                    }
+                },
+                "total": {
+                    "description": "按类型过滤后的命中总数",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
@@ -1460,9 +5247,11 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "toge API",
-	Description:      "This is a toge server API documentation.",
+	Description:      "This is a toge server API documentation. Response messages are localized by the user's locale preference or the Accept-Language header (en-US, zh-CN), en-US by default.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
 }
 
 func init() {
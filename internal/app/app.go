@@ -1,118 +1,259 @@
 package app
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"time"
 
+	"github.com/chenyl99x/toge-api/internal/domain"
 	"github.com/chenyl99x/toge-api/internal/handler"
 	"github.com/chenyl99x/toge-api/internal/middleware"
 	"github.com/chenyl99x/toge-api/pkg/config"
 	"github.com/chenyl99x/toge-api/pkg/database"
-	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/filter"
+	"github.com/chenyl99x/toge-api/pkg/i18n"
+	"github.com/chenyl99x/toge-api/pkg/jwt"
+	"github.com/chenyl99x/toge-api/pkg/lifecycle"
 	"github.com/chenyl99x/toge-api/pkg/redis"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+	"github.com/chenyl99x/toge-api/pkg/search"
 	"github.com/chenyl99x/toge-api/pkg/timezone"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag"
+	"gorm.io/gorm"
 )
 
 // App 应用结构体
 type App struct {
-	Engine          *gin.Engine
-	AuthHandler     *handler.AuthHandler
-	HealthHandler   *handler.HealthHandler
-	UserHandler     *handler.UserHandler
-	SpaceHandler    *handler.SpaceHandler
-	TimezoneHandler *handler.TimezoneHandler
+	Config              *config.Config
+	DB                  *gorm.DB
+	Replicas            *database.Replicas
+	Redis               redis.Client
+	Logger              *slog.Logger
+	LogLevel            *slog.LevelVar
+	JWT                 *jwt.Manager
+	Locales             *middleware.UserLocales
+	Engine              *gin.Engine
+	AuthHandler         *handler.AuthHandler
+	HealthHandler       *handler.HealthHandler
+	UserHandler         *handler.UserHandler
+	UserTransferHandler *handler.UserTransferHandler
+	SpaceHandler        *handler.SpaceHandler
+	TimezoneHandler     *handler.TimezoneHandler
+	NotificationHandler *handler.NotificationHandler
+	JobHandler          *handler.JobHandler
+	QueueHandler        *handler.QueueHandler
+	EventHandler        *handler.EventHandler
+	WebhookHandler      *handler.WebhookHandler
+	SearchHandler       *handler.SearchHandler
+	Scheduler           *scheduler.Scheduler
+	EventRelay          *event.Relay
+	SearchSyncer        *search.Syncer
+	Lifecycle           *lifecycle.Manager
+	ConfigReloader      *config.Reloader
+	RateLimiter         *middleware.RateLimiter
 }
 
 // NewApp 创建应用实例
 func NewApp(
+	cfg *config.Config,
+	db *gorm.DB,
+	replicas *database.Replicas,
+	redisClient redis.Client,
+	log *slog.Logger,
+	level *slog.LevelVar,
+	jwtManager *jwt.Manager,
+	locales *middleware.UserLocales,
 	engine *gin.Engine,
 	authHandler *handler.AuthHandler,
 	healthHandler *handler.HealthHandler,
 	userHandler *handler.UserHandler,
+	userTransferHandler *handler.UserTransferHandler,
 	spaceHandler *handler.SpaceHandler,
 	timezoneHandler *handler.TimezoneHandler,
+	notificationHandler *handler.NotificationHandler,
+	jobHandler *handler.JobHandler,
+	queueHandler *handler.QueueHandler,
+	eventHandler *handler.EventHandler,
+	webhookHandler *handler.WebhookHandler,
+	searchHandler *handler.SearchHandler,
+	jobScheduler *scheduler.Scheduler,
+	eventRelay *event.Relay,
+	searchSyncer *search.Syncer,
+	lc *lifecycle.Manager,
+	reloader *config.Reloader,
+	rateLimiter *middleware.RateLimiter,
 ) *App {
 	return &App{
-		Engine:          engine,
-		AuthHandler:     authHandler,
-		HealthHandler:   healthHandler,
-		UserHandler:     userHandler,
-		SpaceHandler:    spaceHandler,
-		TimezoneHandler: timezoneHandler,
+		Config:              cfg,
+		DB:                  db,
+		Replicas:            replicas,
+		Redis:               redisClient,
+		Logger:              log,
+		LogLevel:            level,
+		JWT:                 jwtManager,
+		Locales:             locales,
+		Engine:              engine,
+		AuthHandler:         authHandler,
+		HealthHandler:       healthHandler,
+		UserHandler:         userHandler,
+		UserTransferHandler: userTransferHandler,
+		SpaceHandler:        spaceHandler,
+		TimezoneHandler:     timezoneHandler,
+		NotificationHandler: notificationHandler,
+		JobHandler:          jobHandler,
+		QueueHandler:        queueHandler,
+		EventHandler:        eventHandler,
+		WebhookHandler:      webhookHandler,
+		SearchHandler:       searchHandler,
+		Scheduler:           jobScheduler,
+		EventRelay:          eventRelay,
+		SearchSyncer:        searchSyncer,
+		Lifecycle:           lc,
+		ConfigReloader:      reloader,
+		RateLimiter:         rateLimiter,
 	}
 }
 
-// InitializeDatabase 初始化数据库
-func InitializeDatabase() error {
-	// 初始化数据库连接
-	if err := database.InitDB(); err != nil {
-		return err
+// InitializeTimezone 初始化时区
+func InitializeTimezone(cfg *config.Config, log *slog.Logger) error {
+	if cfg.Timezone.Timezone == "" {
+		// 如果没有配置时区，使用默认时区
+		cfg.Timezone.Timezone = "UTC"
 	}
 
-	// 注意：不再自动执行迁移
-	// 迁移应该通过独立的 migrate 命令手动执行
-	// 这样可以避免每次启动都执行迁移，提高启动速度
-	// 使用命令: go run cmd/migrate/main.go -action=up
+	if err := timezone.SetTimezone(cfg.Timezone.Timezone); err != nil {
+		return fmt.Errorf("failed to set timezone: %v", err)
+	}
 
+	log.Info("Timezone initialized", "timezone", cfg.Timezone.Timezone)
 	return nil
 }
 
-// InitializeLogger 初始化日志
-func InitializeLogger() {
-	logger.InitLogger()
-}
-
-// InitializeRedis 初始化 Redis
-func InitializeRedis() error {
-	err := redis.InitRedis()
-	if err != nil {
-		// 如果 Redis 连接失败，记录警告但不阻止服务启动
-		logger.Warn("Failed to connect to Redis", "error", err)
-		logger.Info("Service will continue without Redis functionality")
-		return nil
+// InitializeValidator 为 gin 的 validator 注册各语言的校验错误翻译和自定义校验规则
+func InitializeValidator() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unexpected validator engine %T", binding.Validator.Engine())
+	}
+	if err := i18n.RegisterValidator(v); err != nil {
+		return fmt.Errorf("failed to register validator translations: %w", err)
 	}
 	return nil
 }
 
-// InitializeTimezone 初始化时区
-func InitializeTimezone() error {
-	if config.GlobalConfig.Timezone.Timezone == "" {
-		// 如果没有配置时区，使用默认时区
-		config.GlobalConfig.Timezone.Timezone = "UTC"
+// registerHooks 注册 API 进程的组件，HTTP 服务最后启动、最先停止
+func (app *App) registerHooks(server *http.Server, serveErr chan<- error) error {
+	cfg := app.Config
+	hooks := []lifecycle.Hook{
+		tracingHook(cfg, cfg.App.Name),
+		databaseHook(app.DB),
+		replicasHook(app.Replicas),
+		redisHook(app.Redis, false, app.Logger),
 	}
 
-	if err := timezone.SetTimezone(config.GlobalConfig.Timezone.Timezone); err != nil {
-		return fmt.Errorf("failed to set timezone: %v", err)
+	// CORS 每次请求读取当前配置，限流器需要在配置变化时更新
+	app.ConfigReloader.Subscribe("rate_limiter", func(cfg *config.Config) {
+		app.RateLimiter.Update(cfg.RateLimit)
+	})
+	hooks = append(hooks, configReloadHook(app.ConfigReloader, app.LogLevel))
+
+	if cfg.Scheduler.Enabled {
+		hooks = append(hooks, lifecycle.Hook{
+			Name:      ComponentScheduler,
+			DependsOn: []string{ComponentDatabase, ComponentReplicas, ComponentRedis},
+			OnStart: func(context.Context) error {
+				app.Scheduler.Start()
+				return nil
+			},
+			OnStop: lifecycle.Wait(app.Scheduler.Stop),
+		})
+	} else {
+		app.Logger.Info("Scheduler disabled by config")
+	}
+
+	if cfg.Event.RelayEnabled {
+		hooks = append(hooks, lifecycle.Hook{
+			Name:      ComponentEventRelay,
+			DependsOn: []string{ComponentDatabase, ComponentReplicas, ComponentRedis},
+			OnStart: func(context.Context) error {
+				app.EventRelay.Start()
+				return nil
+			},
+			OnStop: lifecycle.Wait(app.EventRelay.Stop),
+		})
+	} else {
+		app.Logger.Info("Event relay disabled by config")
 	}
 
-	logger.Info("Timezone initialized", "timezone", config.GlobalConfig.Timezone.Timezone)
+	if cfg.Search.Enabled {
+		hooks = append(hooks, lifecycle.Hook{
+			Name:      ComponentSearch,
+			DependsOn: []string{ComponentDatabase, ComponentReplicas},
+			OnStart: func(context.Context) error {
+				app.SearchSyncer.Start()
+				return nil
+			},
+			OnStop: lifecycle.Wait(app.SearchSyncer.Stop),
+		})
+	} else {
+		app.Logger.Info("Search disabled by config")
+	}
+
+	if cfg.Metrics.Enabled {
+		hooks = append(hooks, metricsHook(cfg.Metrics, cfg.Metrics.Port, serveErr, app.Logger))
+	}
+
+	hooks = append(hooks, httpServerHook(ComponentHTTP, server, serveErr, app.Logger, ComponentDatabase, ComponentReplicas, ComponentRedis))
+
+	for _, hook := range hooks {
+		if err := app.Lifecycle.Append(hook); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // SetupRoutes 设置路由
 func (app *App) SetupRoutes() {
+	// 添加指标中间件，放在恢复中间件之前，panic 的请求也会记录为 500
+	app.Engine.Use(middleware.MetricsMiddleware())
+
 	// 添加恢复中间件（处理 panic）
-	app.Engine.Use(middleware.Recovery())
+	app.Engine.Use(middleware.Recovery(app.Logger))
 
 	// 添加 CORS 中间件（必须在最前面）
-	app.Engine.Use(middleware.CORSMiddleware())
+	app.Engine.Use(middleware.CORSMiddleware(app.ConfigReloader))
 
 	// 添加 traceId 中间件（必须在最前面）
-	app.Engine.Use(middleware.TraceMiddleware())
+	app.Engine.Use(middleware.TraceMiddleware(app.Logger))
+
+	// 添加语言中间件，之后的响应消息按请求的语言本地化
+	app.Engine.Use(middleware.LocaleMiddleware())
 
 	// 添加 SQL 日志中间件
 	app.Engine.Use(middleware.SQLLoggerMiddleware())
 
 	// 添加日志中间件
 	app.Engine.Use(middleware.LoggerMiddleware())
-	app.Engine.Use(middleware.ErrorLoggerMiddleware())
+	app.Engine.Use(middleware.ErrorLoggerMiddleware(app.Logger))
+
+	// 添加限流中间件，放在日志之后，被限流的请求也会记录
+	app.Engine.Use(app.RateLimiter.Middleware())
 
 	// 添加响应中间件
-	app.Engine.Use(middleware.ResponseMiddleware())
+	app.Engine.Use(middleware.ResponseMiddleware(app.Logger))
+
+	auth := middleware.AuthMiddleware(app.JWT, app.Locales)
+	adminOnly := middleware.AdminMiddleware(app.Config.Admin)
 
 	// 定义路由
 	app.Engine.GET("/", func(c *gin.Context) {
@@ -123,37 +264,53 @@ func (app *App) SetupRoutes() {
 
 	// 健康检查路由
 	app.Engine.GET("/health", app.HealthHandler.Health)
+	app.Engine.GET("/livez", app.HealthHandler.Livez)
+	app.Engine.GET("/readyz", app.HealthHandler.Readyz)
 
-	// Swagger 文档路由
-	app.Engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Swagger 文档路由，列表接口的过滤参数按白名单加入文档
+	const swaggerInstance = "api"
+	filter.RegisterSwagger(swaggerInstance, swag.Name,
+		filter.Route{Method: http.MethodGet, Path: "/users/", Schema: domain.UserFilters},
+		filter.Route{Method: http.MethodGet, Path: "/users/export", Schema: domain.UserFilters},
+	)
+	app.Engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName(swaggerInstance)))
 
 	// 认证路由
-	auth := app.Engine.Group("/auth")
+	authGroup := app.Engine.Group("/auth")
 	{
-		auth.POST("/register", app.AuthHandler.Register)
-		auth.POST("/login", app.AuthHandler.Login)
-		auth.POST("/logout", middleware.AuthMiddleware(), app.AuthHandler.Logout)
-		auth.GET("/profile", middleware.AuthMiddleware(), app.AuthHandler.Profile)
+		authGroup.POST("/register", app.AuthHandler.Register)
+		authGroup.POST("/login", app.AuthHandler.Login)
+		authGroup.POST("/logout", auth, app.AuthHandler.Logout)
+		authGroup.GET("/profile", auth, app.AuthHandler.Profile)
 	}
 
 	// User 路由（需要认证）
 	users := app.Engine.Group("/users")
-	users.Use(middleware.AuthMiddleware())
+	users.Use(auth)
 	{
 		users.POST("/", app.UserHandler.Create)
 		users.GET("/", app.UserHandler.GetAll)
 		users.GET("/:id", app.UserHandler.GetByID)
 		users.PUT("/:id", app.UserHandler.Update)
+		users.PATCH("/:id", app.UserHandler.Patch)
 		users.DELETE("/:id", app.UserHandler.Delete)
+
+		// 批量导入导出（需要管理员权限）
+		users.GET("/export", adminOnly, app.UserTransferHandler.Export)
+		users.POST("/import", adminOnly, app.UserTransferHandler.Import)
+		users.GET("/import/:id", adminOnly, app.UserTransferHandler.GetImportJob)
 	}
 
 	// 空间相关路由（需要认证）
 	spaces := app.Engine.Group("/space")
-	spaces.Use(middleware.AuthMiddleware())
+	spaces.Use(auth)
 	{
 		spaces.POST("/", app.SpaceHandler.Create)
 		spaces.GET("/:id", app.SpaceHandler.GetByID)
 		spaces.PUT("/:id", app.SpaceHandler.Update)
+		spaces.PATCH("/:id", app.SpaceHandler.Patch)
+		spaces.POST("/:id/members", app.SpaceHandler.AddMember)
+		spaces.GET("/:id/members", app.SpaceHandler.GetMembers)
 	}
 
 	// 时区相关路由（不需要认证）
@@ -167,11 +324,69 @@ func (app *App) SetupRoutes() {
 		timezones.GET("/convert", app.TimezoneHandler.ConvertTime)
 	}
 
+	// 通知相关路由（需要认证）
+	notifications := app.Engine.Group("/notifications")
+	notifications.Use(auth)
+	{
+		notifications.GET("/", app.NotificationHandler.GetAll)
+		notifications.GET("/unread-count", app.NotificationHandler.GetUnreadCount)
+		notifications.PUT("/read", app.NotificationHandler.MarkAsRead)
+		notifications.PUT("/read-all", app.NotificationHandler.MarkAllAsRead)
+		notifications.GET("/preferences", app.NotificationHandler.GetPreferences)
+		notifications.PUT("/preferences", app.NotificationHandler.UpdatePreferences)
+		notifications.DELETE("/:id", app.NotificationHandler.Delete)
+	}
+
+	// Webhook 路由（需要认证）
+	webhooks := app.Engine.Group("/webhooks")
+	webhooks.Use(auth)
+	{
+		webhooks.POST("/", app.WebhookHandler.Create)
+		webhooks.GET("/", app.WebhookHandler.GetAll)
+		webhooks.GET("/:id", app.WebhookHandler.GetByID)
+		webhooks.PUT("/:id", app.WebhookHandler.Update)
+		webhooks.DELETE("/:id", app.WebhookHandler.Delete)
+		webhooks.POST("/:id/rotate-secret", app.WebhookHandler.RotateSecret)
+		webhooks.GET("/:id/deliveries", app.WebhookHandler.GetDeliveries)
+		webhooks.POST("/:id/deliveries/:delivery_id/redeliver", app.WebhookHandler.Redeliver)
+	}
+
+	// 搜索路由（需要认证），索引在后台构建，关闭时不注册
+	if app.Config.Search.Enabled {
+		app.Engine.GET("/search", auth, app.SearchHandler.Search)
+	}
+
+	// 管理路由（需要管理员权限）
+	admin := app.Engine.Group("/admin")
+	admin.Use(auth, adminOnly)
+	{
+		admin.GET("/jobs", app.JobHandler.List)
+		admin.POST("/jobs/:name/trigger", app.JobHandler.Trigger)
+		admin.GET("/jobs/:name/runs", app.JobHandler.History)
+		admin.GET("/queues/:queue/dead", app.QueueHandler.Dead)
+		admin.POST("/queues/:queue/dead/:id/requeue", app.QueueHandler.Requeue)
+		admin.GET("/events", app.EventHandler.GetAll)
+		admin.GET("/events/:id", app.EventHandler.GetByID)
+		admin.POST("/events/:id/replay", app.EventHandler.Replay)
+	}
+
 }
 
-// Run 启动应用
+// Run 启动所有组件，收到 SIGINT/SIGTERM 后优雅停机
 func (app *App) Run() error {
-	addr := fmt.Sprintf(":%d", config.GlobalConfig.App.Port)
-	log.Printf("Server starting on port %d in %s mode", config.GlobalConfig.App.Port, config.GlobalConfig.App.Mode)
-	return app.Engine.Run(addr)
+	appConfig := app.Config.App
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", appConfig.Port),
+		Handler:           app.Engine,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 2)
+	if err := app.registerHooks(server, serveErr); err != nil {
+		return err
+	}
+
+	app.Logger.Info("Server starting", "port", appConfig.Port, "mode", appConfig.Mode)
+	drainDelay := time.Duration(appConfig.DrainDelay) * time.Second
+	return runUntilSignal(app.Lifecycle, serveErr, drainDelay, appConfig.ShutdownTimeout, app.Logger)
 }
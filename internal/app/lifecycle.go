@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/lifecycle"
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+	"github.com/chenyl99x/toge-api/pkg/tracing"
+
+	"gorm.io/gorm"
+)
+
+// 组件名称，用于声明依赖顺序
+const (
+	ComponentTracing    = "tracing"
+	ComponentDatabase   = "database"
+	ComponentReplicas   = "database_replicas"
+	ComponentRedis      = "redis"
+	ComponentScheduler  = "scheduler"
+	ComponentEventRelay = "event_relay"
+	ComponentSearch     = "search_syncer"
+	ComponentHTTP       = "http"
+	ComponentMetrics    = "metrics_http"
+	ComponentWorker     = "queue_worker"
+	ComponentReload     = "config_reload"
+)
+
+// defaultShutdownTimeout 未配置时的优雅停机等待时间
+const defaultShutdownTimeout = 15 * time.Second
+
+// tracingHook 链路追踪，最先启动、最后停止，停止时刷新未导出的 span
+func tracingHook(cfg *config.Config, serviceName string) lifecycle.Hook {
+	var shutdown func(context.Context) error
+	return lifecycle.Hook{
+		Name: ComponentTracing,
+		OnStart: func(ctx context.Context) error {
+			tracingConfig := cfg.Tracing
+			var err error
+			shutdown, err = tracing.Init(ctx, tracing.Options{
+				ServiceName:    serviceName,
+				ServiceVersion: cfg.App.Version,
+				Environment:    cfg.Env,
+				Exporter:       tracingConfig.Exporter,
+				Endpoint:       tracingConfig.Endpoint,
+				Insecure:       tracingConfig.Insecure,
+				Headers:        tracingConfig.Headers,
+				SampleRatio:    tracingConfig.SampleRatio,
+			})
+			return err
+		},
+		OnStop: func(ctx context.Context) error {
+			return shutdown(ctx)
+		},
+	}
+}
+
+// configReloadHook 配置热更新，收到 SIGHUP 或配置文件变化时更新日志级别等可热更新的设置
+func configReloadHook(reloader *config.Reloader, level *slog.LevelVar) lifecycle.Hook {
+	reloader.Subscribe("logger", func(cfg *config.Config) {
+		level.Set(logger.ParseLevel(cfg.Log.Level))
+	})
+	return lifecycle.Hook{
+		Name: ComponentReload,
+		OnStart: func(context.Context) error {
+			reloader.Start()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			reloader.Stop()
+			return nil
+		},
+	}
+}
+
+// databaseHook 数据库连接池，启动时检查连接，停止时关闭连接池
+// 不会自动执行迁移，迁移通过独立的 migrate 命令执行: go run cmd/migrate/main.go -action=up
+func databaseHook(db *gorm.DB) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: ComponentDatabase,
+		OnStart: func(ctx context.Context) error {
+			return database.Ping(ctx, db)
+		},
+		OnStop: func(context.Context) error {
+			return database.Close(db)
+		},
+	}
+}
+
+// replicasHook 只读从库，启动时检查一次并开始定期检查，从库不可用时读主库，不影响启动
+func replicasHook(replicas *database.Replicas) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name:      ComponentReplicas,
+		DependsOn: []string{ComponentDatabase},
+		OnStart: func(ctx context.Context) error {
+			replicas.Start(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return replicas.Close()
+		},
+	}
+}
+
+// redisHook Redis 连接池，required 为 false 时连接失败只记录警告
+func redisHook(client redis.Client, required bool, log *slog.Logger) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: ComponentRedis,
+		OnStart: func(ctx context.Context) error {
+			err := redis.Ping(ctx, client)
+			if err != nil && !required {
+				// 如果 Redis 连接失败，记录警告但不阻止服务启动
+				log.Warn("Failed to connect to Redis", "error", err)
+				log.Info("Service will continue without Redis functionality")
+				return nil
+			}
+			return err
+		},
+		OnStop: func(context.Context) error {
+			return client.Close()
+		},
+	}
+}
+
+// httpServerHook HTTP 服务，启动时同步监听端口，停止时等待处理中的请求完成
+func httpServerHook(name string, server *http.Server, serveErr chan<- error, log *slog.Logger, dependsOn ...string) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name:      name,
+		DependsOn: dependsOn,
+		OnStart: func(context.Context) error {
+			// 同步监听端口，端口被占用时启动失败
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					serveErr <- fmt.Errorf("%s: %w", name, err)
+				}
+			}()
+			log.Info("Server started", "component", name, "addr", server.Addr)
+			return nil
+		},
+		// Shutdown 停止接收新连接，等待处理中的请求完成
+		OnStop: server.Shutdown,
+	}
+}
+
+// metricsHook 管理端口上的 Prometheus 指标服务，与业务端口分开，避免对外暴露
+func metricsHook(metricsConfig config.MetricsConfig, port int, serveErr chan<- error, log *slog.Logger) lifecycle.Hook {
+	path := metricsConfig.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, metrics.Handler())
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return httpServerHook(ComponentMetrics, server, serveErr, log)
+}
+
+// runUntilSignal 启动所有组件，直到收到 SIGINT/SIGTERM 或 fatal 报错后按相反顺序停止
+func runUntilSignal(lc *lifecycle.Manager, fatal <-chan error, drainDelay time.Duration, shutdownTimeout int, log *slog.Logger) error {
+	if err := lc.Start(context.Background()); err != nil {
+		return err
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	var runErr error
+	select {
+	case sig := <-quit:
+		log.Info("Shutting down", "signal", sig.String())
+	case err := <-fatal:
+		log.Error("Component failed, shutting down", "error", err)
+		runErr = err
+	}
+
+	// 先将就绪状态置为 false，等待负载均衡摘除实例后再停止接收请求
+	lc.SetReady(false)
+	if drainDelay > 0 && runErr == nil {
+		log.Info("Waiting for load balancer to drain", "delay", drainDelay.String())
+		time.Sleep(drainDelay)
+	}
+
+	timeout := time.Duration(shutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := lc.Stop(ctx); err != nil {
+		return errors.Join(runErr, err)
+	}
+	log.Info("Shutdown complete")
+	return runErr
+}
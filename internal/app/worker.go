@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/lifecycle"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+
+	"gorm.io/gorm"
+)
+
+// Worker 后台任务进程
+type Worker struct {
+	Config    *config.Config
+	DB        *gorm.DB
+	Replicas  *database.Replicas
+	Redis     redis.Client
+	Logger    *slog.Logger
+	LogLevel  *slog.LevelVar
+	Queue     *queue.Worker
+	Lifecycle *lifecycle.Manager
+	Reloader  *config.Reloader
+}
+
+// NewWorker 创建后台任务进程
+func NewWorker(
+	cfg *config.Config,
+	db *gorm.DB,
+	replicas *database.Replicas,
+	redisClient redis.Client,
+	log *slog.Logger,
+	level *slog.LevelVar,
+	queueWorker *queue.Worker,
+	lc *lifecycle.Manager,
+	reloader *config.Reloader,
+) *Worker {
+	return &Worker{
+		Config:    cfg,
+		DB:        db,
+		Replicas:  replicas,
+		Redis:     redisClient,
+		Logger:    log,
+		LogLevel:  level,
+		Queue:     queueWorker,
+		Lifecycle: lc,
+		Reloader:  reloader,
+	}
+}
+
+// Run 启动任务消费，收到 SIGINT/SIGTERM 后等待执行中的任务结束再退出
+func (w *Worker) Run() error {
+	// 任务队列依赖 Redis，与 API 不同，连接失败时直接退出
+	cfg := w.Config
+	hooks := []lifecycle.Hook{
+		tracingHook(cfg, cfg.App.Name+"-worker"),
+		databaseHook(w.DB),
+		replicasHook(w.Replicas),
+		redisHook(w.Redis, true, w.Logger),
+		configReloadHook(w.Reloader, w.LogLevel),
+		{
+			Name:      ComponentWorker,
+			DependsOn: []string{ComponentDatabase, ComponentReplicas, ComponentRedis},
+			OnStart: func(context.Context) error {
+				w.Logger.Info("Worker starting", "task_types", w.Queue.Types())
+				w.Queue.Start()
+				return nil
+			},
+			OnStop: lifecycle.Wait(w.Queue.Stop),
+		},
+	}
+	// 与 API 同机部署时使用不同的指标端口
+	serveErr := make(chan error, 2)
+	if cfg.Metrics.Enabled {
+		hooks = append(hooks, metricsHook(cfg.Metrics, cfg.Metrics.WorkerPort, serveErr, w.Logger))
+	}
+
+	for _, hook := range hooks {
+		if err := w.Lifecycle.Append(hook); err != nil {
+			return err
+		}
+	}
+
+	return runUntilSignal(w.Lifecycle, serveErr, 0, cfg.App.ShutdownTimeout, w.Logger)
+}
@@ -21,3 +21,32 @@ const (
 	ArtifactTypeGoblet  ArtifactType = "杯"
 	ArtifactTypeCirclet ArtifactType = "头"
 )
+
+type NotificationCategory string
+
+const (
+	NotificationCategoryReminder   NotificationCategory = "reminder"   // 纪念日、日程提醒
+	NotificationCategoryInvitation NotificationCategory = "invitation" // 空间邀请
+	NotificationCategoryComment    NotificationCategory = "comment"    // 评论
+	NotificationCategoryMention    NotificationCategory = "mention"    // 聊天中被 @
+	NotificationCategorySystem     NotificationCategory = "system"     // 系统通知
+)
+
+// NotificationCategories 所有通知类别
+var NotificationCategories = []NotificationCategory{
+	NotificationCategoryReminder,
+	NotificationCategoryInvitation,
+	NotificationCategoryComment,
+	NotificationCategoryMention,
+	NotificationCategorySystem,
+}
+
+// IsValid 检查通知类别是否有效
+func (c NotificationCategory) IsValid() bool {
+	for _, category := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+)
+
+// 领域事件类型
+const (
+	EventUserRegistered = "user.registered"
+	EventUserUpdated    = "user.updated"
+	EventUserDeleted    = "user.deleted"
+	EventSpaceCreated   = "space.created"
+	EventSpaceUpdated   = "space.updated"
+	EventSpaceDeleted   = "space.deleted"
+
+	EventSpaceMemberJoined = "space.member_joined"
+)
+
+// 聚合类型
+const (
+	AggregateUser  = "user"
+	AggregateSpace = "space"
+)
+
+// UserEventPayload 用户事件载荷
+type UserEventPayload struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// SpaceEventPayload 空间事件载荷
+type SpaceEventPayload struct {
+	SpaceID     uint   `json:"space_id"`
+	OwnerUserID uint   `json:"owner_user_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// SpaceMemberEventPayload 岛屿成员事件载荷
+type SpaceMemberEventPayload struct {
+	SpaceID uint `json:"space_id"`
+	UserID  uint `json:"user_id"`
+}
+
+// EventRepository 事件发件箱仓储
+type EventRepository interface {
+	event.Store
+	GetAllWithPagination(ctx context.Context, filter *EventFilter, page *pagination.PageRequest) ([]*event.Event, int64, error)
+	GetByID(ctx context.Context, id string) (*event.Event, error)
+	// Replay 将事件重置为待投递状态并清零尝试次数
+	Replay(ctx context.Context, id string) error
+	// ListAfter 按发生时间和 ID 升序读取 (after, afterID) 之后的事件，不区分投递状态
+	ListAfter(ctx context.Context, after time.Time, afterID string, aggregateTypes []string, limit int) ([]*event.Event, error)
+	// PurgePublished 删除早于 before 发布的事件，返回删除数量
+	PurgePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventService 事件管理服务
+type EventService interface {
+	GetAllWithPagination(ctx context.Context, filter *EventFilter, page *pagination.PageRequest) (*pagination.PageResponse, error)
+	GetByID(ctx context.Context, id string) (*event.Event, error)
+	Replay(ctx context.Context, id string) (*event.Event, error)
+}
+
+// EventFilter 事件查询条件
+type EventFilter struct {
+	Status        string `form:"status" binding:"omitempty,oneof=pending published failed" example:"failed"`
+	Type          string `form:"type" example:"user.registered"`
+	AggregateType string `form:"aggregate_type" example:"user"`
+	AggregateID   string `form:"aggregate_id" example:"1"`
+}
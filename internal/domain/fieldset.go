@@ -0,0 +1,20 @@
+package domain
+
+import "github.com/chenyl99x/toge-api/pkg/fieldset"
+
+// UserFieldset、SpaceFieldset 读取接口 fields 和 expand 参数的白名单，字段名与响应中的 JSON 字段名一致
+// 用户和岛屿互相关联，先创建白名单再声明关联
+var UserFieldset, SpaceFieldset = newFieldsets()
+
+func newFieldsets() (*fieldset.Schema, *fieldset.Schema) {
+	user := fieldset.NewSchema("id", "username", "email", "nickname", "avatar", "status", "locale", "version", "created_at", "updated_at")
+	space := fieldset.NewSchema("ID", "name", "owner_user_id", "type", "description", "version", "CreatedAt", "UpdatedAt")
+
+	// 拥有者和成员都按用户白名单输出，密码等字段不会随岛屿返回
+	user.Relate(fieldset.Relation{Name: "spaces", Preload: "Spaces", Schema: space})
+	space.Relate(
+		fieldset.Relation{Name: "owner", Preload: "Owner", Schema: user},
+		fieldset.Relation{Name: "members", Preload: "Members", Schema: user},
+	)
+	return user, space
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/consts"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/notifier"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	GetAllWithPagination(ctx context.Context, userID uint, unreadOnly bool, page *pagination.PageRequest) ([]model.Notification, int64, error)
+	CountUnreadByCategory(ctx context.Context, userID uint) (map[string]int64, error)
+	MarkAsRead(ctx context.Context, userID uint, ids []uint) (int64, error)
+	MarkAllAsRead(ctx context.Context, userID uint) (int64, error)
+	Delete(ctx context.Context, userID uint, id uint) error
+	PurgeRead(ctx context.Context, before time.Time) (int64, error)
+
+	GetPreferences(ctx context.Context, userID uint) ([]model.NotificationPreference, error)
+	SavePreference(ctx context.Context, preference *model.NotificationPreference) error
+	GetSetting(ctx context.Context, userID uint) (*model.NotificationSetting, error)
+	SaveSetting(ctx context.Context, setting *model.NotificationSetting) error
+}
+
+type NotificationService interface {
+	// Notify 向用户发送通知，按用户偏好投递到各渠道
+	Notify(ctx context.Context, req *NotifyRequest) error
+	GetAllWithPagination(ctx context.Context, userID uint, unreadOnly bool, page *pagination.PageRequest) (*pagination.PageResponse, error)
+	GetUnreadCount(ctx context.Context, userID uint) (*UnreadCountResponse, error)
+	MarkAsRead(ctx context.Context, userID uint, ids []uint) (int64, error)
+	MarkAllAsRead(ctx context.Context, userID uint) (int64, error)
+	Delete(ctx context.Context, userID uint, id uint) error
+	GetPreferences(ctx context.Context, userID uint) (*NotificationPreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, userID uint, req *UpdateNotificationPreferencesRequest) (*NotificationPreferencesResponse, error)
+}
+
+// NotifyRequest 发送通知请求，供其他业务模块调用
+type NotifyRequest struct {
+	UserID   uint
+	Category consts.NotificationCategory
+	Title    string
+	Content  string
+	Link     string
+	DedupKey string // 去重键，任务或事件重试时站内信只写入一次
+}
+
+type UnreadCountResponse struct {
+	Total      int64            `json:"total" example:"3"`
+	ByCategory map[string]int64 `json:"by_category"`
+}
+
+type MarkNotificationsReadRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1" example:"1,2,3"`
+}
+
+type CategoryPreference struct {
+	Category string `json:"category" binding:"required" example:"comment"`
+	InApp    bool   `json:"in_app" example:"true"`
+	Email    bool   `json:"email" example:"false"`
+	Push     bool   `json:"push" example:"true"`
+}
+
+type QuietHours struct {
+	Enabled  bool   `json:"enabled" example:"true"`
+	Start    string `json:"start" example:"22:00"`
+	End      string `json:"end" example:"07:00"`
+	Timezone string `json:"timezone" example:"Asia/Shanghai"`
+}
+
+type NotificationPreferencesResponse struct {
+	Categories []CategoryPreference `json:"categories"`
+	QuietHours QuietHours           `json:"quiet_hours"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Categories []CategoryPreference `json:"categories" binding:"dive"`
+	QuietHours *QuietHours          `json:"quiet_hours"`
+}
+
+// Validate 验证通知偏好更新请求
+func (r *UpdateNotificationPreferencesRequest) Validate() error {
+	for _, p := range r.Categories {
+		if !consts.NotificationCategory(p.Category).IsValid() {
+			return fmt.Errorf("invalid notification category: %s", p.Category)
+		}
+	}
+	if r.QuietHours != nil && r.QuietHours.Enabled {
+		quiet := notifier.QuietHours{Start: r.QuietHours.Start, End: r.QuietHours.End, Timezone: r.QuietHours.Timezone}
+		if err := quiet.Validate(); err != nil {
+			return fmt.Errorf("invalid quiet hours: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/search"
+)
+
+// 搜索内容类型
+const (
+	SearchTypeUser  = "user"
+	SearchTypeSpace = "space"
+)
+
+// SearchTypes 可搜索的内容类型
+var SearchTypes = []string{SearchTypeUser, SearchTypeSpace}
+
+// SearchService 全文搜索服务
+type SearchService interface {
+	// Search 在调用方可见的内容中搜索，types 为空时搜索所有类型
+	Search(ctx context.Context, userID uint, text string, types []string, page *pagination.PageRequest) (*search.Result, error)
+}
+
+// SearchRequest 搜索参数
+type SearchRequest struct {
+	Q    string `form:"q" binding:"required,max=100" example:"岛屿"` // 搜索文本
+	Type string `form:"type" example:"user,space"`                 // 内容类型，多个用逗号分隔，为空时搜索所有类型
+}
@@ -4,16 +4,36 @@ import (
 	"context"
 
 	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/jsonpatch"
 	"github.com/chenyl99x/toge-api/pkg/pagination"
 )
 
+var (
+	// ErrAlreadySpaceMember 用户已是岛屿的成员或拥有者
+	ErrAlreadySpaceMember = apperror.New(apperror.CodeConflict, "already a member of the space")
+	// ErrNotSpaceOwner 只有岛屿拥有者可以添加成员
+	ErrNotSpaceOwner = apperror.New(apperror.CodePermissionDenied, "only the space owner can add members")
+	// ErrNotSpaceMember 只有岛屿拥有者和成员可以查看成员
+	ErrNotSpaceMember = apperror.New(apperror.CodePermissionDenied, "not a member of the space")
+)
+
 type SpaceRepository interface {
 	Create(ctx context.Context, space *model.Space) error
 	GetByID(ctx context.Context, id uint) (*model.Space, error)
 	GetAll(ctx context.Context) ([]model.Space, error)
 	GetAllWithPagination(ctx context.Context, page *pagination.PageRequest) ([]model.Space, int64, error)
+	// GetIDsByUser 用户拥有或加入的空间 ID
+	GetIDsByUser(ctx context.Context, userID uint) ([]uint, error)
+	// Update 按 space.Version 条件更新并把版本号加一，记录已被修改时返回 ErrVersionConflict
 	Update(ctx context.Context, space *model.Space) error
 	Delete(ctx context.Context, id uint) error
+	// AddMember 添加成员，已是成员时返回 gorm.ErrDuplicatedKey
+	AddMember(ctx context.Context, member *model.SpaceMember) error
+	// GetMembers 按加入时间排序的成员
+	GetMembers(ctx context.Context, spaceID uint) ([]model.SpaceMember, error)
+	// IsMember 用户是否是岛屿的成员，不含拥有者
+	IsMember(ctx context.Context, spaceID, userID uint) (bool, error)
 }
 
 type SpaceService interface {
@@ -23,6 +43,12 @@ type SpaceService interface {
 	GetAllWithPagination(ctx context.Context, page *pagination.PageRequest) (*pagination.PageResponse, error)
 	Update(ctx context.Context, space *model.Space) error
 	Delete(ctx context.Context, id uint) error
+	// AddMember 拥有者把用户加入岛屿，和 space.member_joined 事件在同一事务中写入，非拥有者返回 ErrNotSpaceOwner
+	AddMember(ctx context.Context, spaceID, ownerID, userID uint) (*model.SpaceMember, error)
+	// GetMembers 拥有者和成员查看岛屿成员，其他用户返回 ErrNotSpaceMember
+	GetMembers(ctx context.Context, spaceID, userID uint) ([]model.SpaceMember, error)
+	// CheckMember 用户是岛屿的拥有者或成员时返回 nil，否则返回 ErrNotSpaceMember
+	CheckMember(ctx context.Context, space *model.Space, userID uint) error
 }
 
 type CreateSpaceRequest struct {
@@ -32,9 +58,45 @@ type CreateSpaceRequest struct {
 	OwnerUserID uint   `json:"owner_user_id" binding:"required" example:"1"`
 }
 
+type AddSpaceMemberRequest struct {
+	UserID uint `json:"user_id" binding:"required" example:"2"`
+}
+
 type UpdateSpaceRequest struct {
 	Name        string `json:"name" binding:"required" example:"我的空间"`
 	Description string `json:"description" binding:"required" example:"这是一个美好的空间"`
 	Type        string `json:"type" binding:"required" example:"情侣空间"`
 	OwnerUserID uint   `json:"owner_user_id" binding:"required" example:"1"`
 }
+
+// SpaceWritableFields PATCH 岛屿时允许修改的字段，描述可以用 null 清空
+var SpaceWritableFields = jsonpatch.NewSchema(
+	jsonpatch.Field{Name: "name"},
+	jsonpatch.Field{Name: "owner_user_id"},
+	jsonpatch.Field{Name: "type"},
+	jsonpatch.Field{Name: "description", Nullable: true},
+)
+
+// SpacePatch 补丁应用后发生变化的字段，nil 表示未修改，清空的字段为空字符串
+type SpacePatch struct {
+	Name        *string `json:"name" binding:"omitnil,min=1,max=100" example:"我的空间"`
+	OwnerUserID *uint   `json:"owner_user_id" binding:"omitnil,min=1" example:"1"`
+	Type        *string `json:"type" binding:"omitnil,min=1,max=50" example:"情侣空间"`
+	Description *string `json:"description" example:"这是一个美好的空间"`
+}
+
+// Apply 把变化的字段写入岛屿
+func (p *SpacePatch) Apply(space *model.Space) {
+	if p.Name != nil {
+		space.Name = *p.Name
+	}
+	if p.OwnerUserID != nil {
+		space.OwnerUserID = *p.OwnerUserID
+	}
+	if p.Type != nil {
+		space.Type = *p.Type
+	}
+	if p.Description != nil {
+		space.Description = *p.Description
+	}
+}
@@ -4,9 +4,21 @@ import (
 	"context"
 
 	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/filter"
+	"github.com/chenyl99x/toge-api/pkg/jsonpatch"
 	"github.com/chenyl99x/toge-api/pkg/pagination"
 )
 
+var (
+	ErrUsernameExists = apperror.New(apperror.CodeUsernameExists, "username already exists")
+	ErrEmailExists    = apperror.New(apperror.CodeEmailExists, "email already exists")
+	// ErrUsernameReserved 公开注册时使用了保留的用户名
+	ErrUsernameReserved = apperror.New(apperror.CodeUsernameExists, "username is reserved")
+	// ErrVersionConflict 按版本号更新时记录已被其他请求修改
+	ErrVersionConflict = apperror.New(apperror.CodeVersionConflict, "version conflict")
+)
+
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uint) (*model.User, error)
@@ -14,6 +26,10 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetAll(ctx context.Context) ([]model.User, error)
 	GetAllWithPagination(ctx context.Context, page *pagination.PageRequest) ([]model.User, int64, error)
+	GetAllWithCursor(ctx context.Context, page *pagination.PageRequest) ([]model.User, *pagination.CursorPage, error)
+	// FindInBatches 按列表的搜索和过滤条件分批读取，按 ID 升序，忽略分页和排序参数
+	FindInBatches(ctx context.Context, page *pagination.PageRequest, batchSize int, fn func(users []model.User) error) error
+	// Update 按 user.Version 条件更新并把版本号加一，记录已被修改时返回 ErrVersionConflict
 	Update(ctx context.Context, user *model.User) error
 	Delete(ctx context.Context, id uint) error
 }
@@ -25,6 +41,7 @@ type UserService interface {
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetAll(ctx context.Context) ([]model.User, error)
 	GetAllWithPagination(ctx context.Context, page *pagination.PageRequest) (*pagination.PageResponse, error)
+	GetAllWithCursor(ctx context.Context, page *pagination.PageRequest) (*pagination.CursorResponse, error)
 	Update(ctx context.Context, user *model.User) error
 	Delete(ctx context.Context, id uint) error
 }
@@ -36,13 +53,69 @@ type CreateUserRequest struct {
 	Nickname string `json:"nickname" example:"John Doe"`
 	Avatar   string `json:"avatar" example:"https://example.com/avatar.jpg"`
 	Status   int    `json:"status" example:"1"`
+	Locale   string `json:"locale" binding:"omitempty,locale" example:"zh-CN"` // 语言偏好：en-US、zh-CN
 }
 
 type UpdateUserRequest struct {
-	Username string `json:"username" example:"john_doe"`
-	Email    string `json:"email" binding:"omitempty,email" example:"john@example.com"`
-	Password string `json:"password" binding:"omitempty,min=6" example:"123456"`
-	Nickname string `json:"nickname" example:"John Doe"`
-	Avatar   string `json:"avatar" example:"https://example.com/avatar.jpg"`
-	Status   *int   `json:"status" example:"1"`
+	Username string  `json:"username" example:"john_doe"`
+	Email    string  `json:"email" binding:"omitempty,email" example:"john@example.com"`
+	Password string  `json:"password" binding:"omitempty,min=6" example:"123456"`
+	Nickname string  `json:"nickname" example:"John Doe"`
+	Avatar   string  `json:"avatar" example:"https://example.com/avatar.jpg"`
+	Status   *int    `json:"status" example:"1"`
+	Locale   *string `json:"locale" binding:"omitnil,locale" example:"zh-CN"` // 语言偏好：en-US、zh-CN，空字符串表示按 Accept-Language 选择
+}
+
+// UserWritableFields PATCH 用户时允许修改的字段，昵称、头像和语言偏好可以用 null 清空，密码只写不读
+var UserWritableFields = jsonpatch.NewSchema(
+	jsonpatch.Field{Name: "username"},
+	jsonpatch.Field{Name: "email"},
+	jsonpatch.Field{Name: "password"},
+	jsonpatch.Field{Name: "nickname", Nullable: true},
+	jsonpatch.Field{Name: "avatar", Nullable: true},
+	jsonpatch.Field{Name: "status"},
+	jsonpatch.Field{Name: "locale", Nullable: true},
+)
+
+// UserPatch 补丁应用后发生变化的字段，nil 表示未修改，清空的字段为空字符串
+type UserPatch struct {
+	Username *string `json:"username" binding:"omitnil,min=1,max=50" example:"john_doe"`
+	Email    *string `json:"email" binding:"omitnil,email,max=100" example:"john@example.com"`
+	Password *string `json:"password" binding:"omitnil,min=6" example:"123456"` // 明文密码，由调用方校验强度并加密
+	Nickname *string `json:"nickname" binding:"omitnil,max=50" example:"John Doe"`
+	Avatar   *string `json:"avatar" binding:"omitnil,max=255" example:"https://example.com/avatar.jpg"`
+	Status   *int    `json:"status" binding:"omitnil,oneof=0 1" example:"1"`
+	Locale   *string `json:"locale" binding:"omitnil,locale" example:"zh-CN"`
+}
+
+// Apply 把变化的字段写入用户，不包括需要加密的密码
+func (p *UserPatch) Apply(user *model.User) {
+	if p.Username != nil {
+		user.Username = *p.Username
+	}
+	if p.Email != nil {
+		user.Email = *p.Email
+	}
+	if p.Nickname != nil {
+		user.Nickname = *p.Nickname
+	}
+	if p.Avatar != nil {
+		user.Avatar = *p.Avatar
+	}
+	if p.Status != nil {
+		user.Status = *p.Status
+	}
+	if p.Locale != nil {
+		user.Locale = *p.Locale
+	}
 }
+
+// UserFilters 用户列表允许的过滤字段
+var UserFilters = filter.NewSchema(
+	filter.Field{Name: "status", Type: filter.TypeInt, Ops: []filter.Op{filter.OpEq, filter.OpNe, filter.OpIn}, Description: "状态"},
+	filter.Field{Name: "username", Type: filter.TypeString, Description: "用户名"},
+	filter.Field{Name: "email", Type: filter.TypeString, Description: "邮箱"},
+	filter.Field{Name: "nickname", Type: filter.TypeString, Description: "昵称"},
+	filter.Field{Name: "created_at", Type: filter.TypeTime, Description: "创建时间，例如 2025-01-01"},
+	filter.Field{Name: "updated_at", Type: filter.TypeTime, Description: "更新时间，例如 2025-01-01"},
+)
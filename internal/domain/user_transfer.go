@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"io"
+
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+)
+
+// ErrInvalidImport 导入文件或列映射无效
+var ErrInvalidImport = apperror.New(apperror.CodeInvalidImport, "invalid import")
+
+// 导入任务状态
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// 导入行的结果
+const (
+	ImportRowValid   = "valid"   // 只校验时校验通过
+	ImportRowCreated = "created" // 已创建用户
+	ImportRowInvalid = "invalid" // 校验失败
+	ImportRowFailed  = "failed"  // 校验通过但创建失败，例如用户名已被占用
+)
+
+// UserExportColumns 导出的列，表头与导入字段同名，导出的文件补充密码列后可以直接导入
+var UserExportColumns = []string{"id", "username", "email", "nickname", "avatar", "status", "created_at", "updated_at"}
+
+// UserImportFields 可导入的字段
+var UserImportFields = []string{"username", "email", "password", "nickname", "avatar", "status"}
+
+// UserImportRequiredFields 必须映射的字段
+var UserImportRequiredFields = []string{"username", "email", "password"}
+
+// UserImportRepository 用户导入任务仓储
+type UserImportRepository interface {
+	Create(ctx context.Context, job *model.UserImportJob) error
+	GetByID(ctx context.Context, id uint) (*model.UserImportJob, error)
+	Update(ctx context.Context, job *model.UserImportJob) error
+}
+
+// UserTransferService 用户批量导入导出服务
+type UserTransferService interface {
+	// Export 按列表的过滤和搜索条件流式导出用户，写入第一行之前出错时不会写入 w
+	Export(ctx context.Context, page *pagination.PageRequest, format string, w io.Writer) error
+	// Import 解析文件并创建导入任务，行数不超过阈值时直接执行，否则放入后台队列，async 表示任务是否在后台执行
+	Import(ctx context.Context, userID uint, req *UserImportRequest, fileName string, file io.Reader) (job *UserImportJobResponse, async bool, err error)
+	// GetImportJob 查询导入任务的进度，完成后包含逐行结果
+	GetImportJob(ctx context.Context, id uint) (*UserImportJobResponse, error)
+	// RunImportJob 执行导入任务，中断后重新执行时从上次保存的进度继续
+	RunImportJob(ctx context.Context, id uint) error
+}
+
+// UserImportRequest 导入参数，文件通过 multipart 的 file 字段上传
+type UserImportRequest struct {
+	Format  string `form:"format" binding:"omitempty,oneof=csv xlsx" example:"xlsx"`                      // 文件格式，为空时按扩展名判断
+	Mapping string `form:"mapping" example:"{\"用户名\":\"username\",\"邮箱\":\"email\",\"密码\":\"password\"}"` // 列映射，JSON 对象，键为表头，值为字段
+	DryRun  bool   `form:"dry_run" example:"true"`                                                        // 只校验不写入
+}
+
+// UserImportRow 待导入的行，保存到任务之前已完成校验，密码加密保存
+type UserImportRow struct {
+	Row               int               `json:"row"`                          // 文件中的行号
+	Values            map[string]string `json:"values"`                       // 字段 -> 值，不包含密码
+	EncryptedPassword string            `json:"encrypted_password,omitempty"` // 加密后的密码，执行时解密并计算哈希，只校验或校验失败时为空
+	Errors            []string          `json:"errors,omitempty"`             // 字段校验错误
+}
+
+// UserImportRowResult 单行的导入结果
+type UserImportRowResult struct {
+	Row      int      `json:"row" example:"2"`                             // 文件中的行号，表头为第 1 行
+	Status   string   `json:"status" example:"invalid"`                    // 结果:valid、created、invalid、failed
+	Username string   `json:"username,omitempty" example:"john_doe"`       // 用户名
+	UserID   uint     `json:"user_id,omitempty" example:"1"`               // 创建的用户ID
+	Errors   []string `json:"errors,omitempty" example:"email is invalid"` // 校验或创建失败的原因
+}
+
+// UserImportJobResponse 导入任务及结果
+type UserImportJobResponse struct {
+	*model.UserImportJob
+	Results []UserImportRowResult `json:"results,omitempty"` // 逐行结果，任务结束后返回
+}
+
+// UserImportPayload 后台导入任务载荷
+type UserImportPayload struct {
+	JobID uint `json:"job_id"`
+}
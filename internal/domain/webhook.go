@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/webhook"
+)
+
+// Webhook 归属类型
+const (
+	WebhookOwnerUser  = "user"
+	WebhookOwnerSpace = "space"
+)
+
+// Webhook 投递结果和触发方式
+const (
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+
+	WebhookTriggerEvent  = "event"
+	WebhookTriggerManual = "manual"
+)
+
+var (
+	ErrWebhookForbidden = apperror.New(apperror.CodePermissionDenied, "no permission to manage this webhook")
+	ErrWebhookDisabled  = apperror.New(apperror.CodeConflict, "webhook is disabled")
+)
+
+// WebhookEventTypes 可订阅的事件类型
+var WebhookEventTypes = []string{
+	EventUserUpdated,
+	EventUserDeleted,
+	EventSpaceCreated,
+	EventSpaceUpdated,
+	EventSpaceDeleted,
+	EventSpaceMemberJoined,
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, hook *model.Webhook) error
+	GetByID(ctx context.Context, id uint) (*model.Webhook, error)
+	GetByOwner(ctx context.Context, ownerType string, ownerID uint) ([]model.Webhook, error)
+	// GetEnabledByOwner 获取归属对象下所有启用的 webhook
+	GetEnabledByOwner(ctx context.Context, ownerType string, ownerID uint) ([]model.Webhook, error)
+	Update(ctx context.Context, hook *model.Webhook) error
+	Delete(ctx context.Context, id uint) error
+	// RecordResult 更新连续失败次数，达到 disableAfter 时自动停用，返回更新后的 webhook
+	RecordResult(ctx context.Context, id uint, success bool, disableAfter int) (*model.Webhook, error)
+
+	CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	GetDelivery(ctx context.Context, webhookID uint, id uint) (*model.WebhookDelivery, error)
+	GetDeliveriesWithPagination(ctx context.Context, webhookID uint, page *pagination.PageRequest) ([]model.WebhookDelivery, int64, error)
+	CountAttempts(ctx context.Context, webhookID uint, eventID string) (int64, error)
+}
+
+type WebhookService interface {
+	Create(ctx context.Context, userID uint, req *CreateWebhookRequest) (*CreateWebhookResponse, error)
+	GetAll(ctx context.Context, userID uint, ownerType string, ownerID uint) ([]model.Webhook, error)
+	GetByID(ctx context.Context, userID uint, id uint) (*model.Webhook, error)
+	Update(ctx context.Context, userID uint, id uint, req *UpdateWebhookRequest) (*model.Webhook, error)
+	Delete(ctx context.Context, userID uint, id uint) error
+	RotateSecret(ctx context.Context, userID uint, id uint) (*CreateWebhookResponse, error)
+	GetDeliveries(ctx context.Context, userID uint, id uint, page *pagination.PageRequest) (*pagination.PageResponse, error)
+	Redeliver(ctx context.Context, userID uint, id uint, deliveryID uint) error
+
+	// Fanout 为订阅了事件的 webhook 创建投递任务，由事件 Relay 调用
+	Fanout(ctx context.Context, e *event.Event) error
+	// Deliver 执行一次投递并记录结果，失败时返回错误以触发队列重试
+	Deliver(ctx context.Context, payload *WebhookDeliveryPayload) error
+}
+
+// WebhookDeliveryPayload webhook 投递任务载荷
+type WebhookDeliveryPayload struct {
+	WebhookID uint   `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	Body      string `json:"body"`
+	Trigger   string `json:"trigger"`
+}
+
+// WebhookBody 投递给接收方的请求体
+type WebhookBody struct {
+	EventID       string      `json:"event_id" example:"9b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"`
+	Type          string      `json:"type" example:"space.created"`
+	AggregateType string      `json:"aggregate_type" example:"space"`
+	AggregateID   string      `json:"aggregate_id" example:"1"`
+	OccurredAt    string      `json:"occurred_at" example:"2025-01-01T00:00:00Z"`
+	Data          interface{} `json:"data" swaggertype:"object"`
+}
+
+type CreateWebhookRequest struct {
+	OwnerType   string   `json:"owner_type" binding:"required,oneof=user space" example:"space"`
+	OwnerID     uint     `json:"owner_id" example:"1"` // owner_type 为 space 时必填，为 user 时忽略并使用当前用户
+	URL         string   `json:"url" binding:"required,max=500" example:"https://example.com/hooks/toge"`
+	Events      []string `json:"events" binding:"required,min=1" example:"space.created,space.updated"`
+	Description string   `json:"description" binding:"max=255" example:"同步到合作方"`
+}
+
+// Validate 校验地址和事件类型
+func (r *CreateWebhookRequest) Validate() error {
+	if r.OwnerType == WebhookOwnerSpace && r.OwnerID == 0 {
+		return fmt.Errorf("owner_id is required for space webhooks")
+	}
+	if err := webhook.ValidateURL(r.URL); err != nil {
+		return err
+	}
+	return validateWebhookEvents(r.Events)
+}
+
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url" binding:"omitempty,max=500" example:"https://example.com/hooks/toge"`
+	Events      []string `json:"events" example:"space.created"`
+	Description *string  `json:"description" binding:"omitempty,max=255" example:"同步到合作方"`
+	Enabled     *bool    `json:"enabled" example:"true"` // 重新启用时清零连续失败次数
+}
+
+// Validate 校验地址和事件类型
+func (r *UpdateWebhookRequest) Validate() error {
+	if r.URL != nil {
+		if err := webhook.ValidateURL(*r.URL); err != nil {
+			return err
+		}
+	}
+	if r.Events != nil {
+		return validateWebhookEvents(r.Events)
+	}
+	return nil
+}
+
+// CreateWebhookResponse 创建或轮换密钥的响应，密钥只在此时返回
+type CreateWebhookResponse struct {
+	Webhook *model.Webhook `json:"webhook"`
+	Secret  string         `json:"secret" example:"whsec_3f9a1c2b7d4e5f60a1b2c3d4e5f60718"`
+}
+
+func validateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("at least one event type is required")
+	}
+	for _, e := range events {
+		if e == event.Wildcard {
+			continue
+		}
+		valid := false
+		for _, t := range WebhookEventTypes {
+			if e == t {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid webhook event type: %s", e)
+		}
+	}
+	return nil
+}
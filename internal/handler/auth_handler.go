@@ -1,14 +1,15 @@
 package handler
 
 import (
-	"net/http"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/chenyl99x/toge-api/internal/domain"
 	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
 	"github.com/chenyl99x/toge-api/pkg/jwt"
-	"github.com/chenyl99x/toge-api/pkg/logger"
 	"github.com/chenyl99x/toge-api/pkg/password"
 	"github.com/chenyl99x/toge-api/pkg/redis"
 	"github.com/chenyl99x/toge-api/pkg/response"
@@ -18,15 +19,19 @@ import (
 
 type AuthHandler struct {
 	userService domain.UserService
+	jwtManager  *jwt.Manager
+	redis       redis.Client
+	admin       config.AdminConfig
+	log         *slog.Logger
 }
 
-func NewAuthHandler(userService domain.UserService) *AuthHandler {
-	return &AuthHandler{userService: userService}
+func NewAuthHandler(userService domain.UserService, jwtManager *jwt.Manager, redisClient redis.Client, adminConfig config.AdminConfig, log *slog.Logger) *AuthHandler {
+	return &AuthHandler{userService: userService, jwtManager: jwtManager, redis: redisClient, admin: adminConfig, log: log}
 }
 
 // Register godoc
 // @Summary      用户注册
-// @Description  新用户注册
+// @Description  新用户注册，保留的用户名（例如 admin）不能注册，返回 409
 // @Tags         认证与校验
 // @Accept       json
 // @Produce      json
@@ -40,28 +45,21 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	ctx := c.Request.Context()
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.ErrorWithTrace(ctx, "Register validation failed", "error", err.Error())
-		response.ValidationError(c, err.Error())
+		h.log.ErrorContext(ctx, "Register validation failed", "error", err.Error())
+		response.BindError(c, err)
 		return
 	}
 
-	// 检查用户名是否已存在
-	if _, err := h.userService.GetByUsername(ctx, req.Username); err == nil {
-		logger.WarnWithTrace(ctx, "Username already exists", "username", req.Username)
-		response.Error(c, http.StatusConflict, "Username already exists")
-		return
-	}
-
-	// 检查邮箱是否已存在
-	if _, err := h.userService.GetByEmail(ctx, req.Email); err == nil {
-		logger.WarnWithTrace(ctx, "Email already exists", "email", req.Email)
-		response.Error(c, http.StatusConflict, "Email already exists")
+	// 保留的用户名只能由管理员创建
+	if h.admin.IsReservedUsername(req.Username) {
+		h.log.WarnContext(ctx, "Reserved username rejected", "username", req.Username)
+		response.AppError(c, domain.ErrUsernameReserved)
 		return
 	}
 
 	// 验证密码强度
 	if err := password.ValidatePassword(req.Password); err != nil {
-		logger.WarnWithTrace(ctx, "Password validation failed", "error", err.Error(), "username", req.Username)
+		h.log.WarnContext(ctx, "Password validation failed", "error", err.Error(), "username", req.Username)
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -69,7 +67,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// 加密密码
 	hashedPassword, err := password.HashPassword(req.Password)
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to hash password", "error", err.Error(), "username", req.Username)
+		h.log.ErrorContext(ctx, "Failed to hash password", "error", err.Error(), "username", req.Username)
 		response.InternalServerError(c, "Failed to process password")
 		return
 	}
@@ -84,16 +82,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Status:   1, // 默认启用状态
 	}
 
+	// 用户名和邮箱的唯一性由 userService.Create 检查
 	if err := h.userService.Create(ctx, user); err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to create user", "error", err.Error(), "username", req.Username)
-		response.DatabaseError(c, "Failed to create user")
+		response.AppError(c, err)
 		return
 	}
 
 	// 不返回密码
 	user.Password = ""
 
-	logger.InfoWithTrace(ctx, "User registered successfully", "user_id", user.ID, "username", user.Username)
+	h.log.InfoContext(ctx, "User registered successfully", "user_id", user.ID, "username", user.Username)
 	response.Created(c, user)
 }
 
@@ -131,12 +129,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	ctx := c.Request.Context()
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, err.Error())
+		response.BindError(c, err)
 		return
 	}
 
-	// 验证用户名和密码
-	user, err := h.userService.GetByUsername(ctx, req.Username)
+	// 验证用户名和密码，缓存中没有密码哈希，从主库读取
+	user, err := h.userService.GetByUsername(database.ForcePrimary(ctx), req.Username)
 	if err != nil {
 		response.Unauthorized(c, "Invalid credentials")
 		return
@@ -144,13 +142,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// 使用 bcrypt 验证密码
 	if !password.CheckPassword(req.Password, user.Password) {
-		logger.WarnWithTrace(ctx, "Invalid password", "username", req.Username)
+		h.log.WarnContext(ctx, "Invalid password", "username", req.Username)
 		response.Unauthorized(c, "Invalid credentials")
 		return
 	}
 
 	// 生成 JWT token
-	token, err := jwt.GenerateToken(user.ID, user.Username)
+	token, err := h.jwtManager.GenerateToken(user.ID, user.Username)
 	if err != nil {
 		response.InternalServerError(c, "Failed to generate token")
 		return
@@ -158,10 +156,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// 将 token 存储到 Redis（可选，用于 token 黑名单功能）
 	tokenKey := "token:" + token
-	expiration := time.Duration(24) * time.Hour // 24小时
-	if err := redis.Set(tokenKey, "valid", expiration); err != nil {
+	expiration := h.jwtManager.Expiration()
+	if err := h.redis.Set(ctx, tokenKey, "valid", expiration).Err(); err != nil {
 		// 这里只是记录错误，不影响登录流程
-		// log.Printf("Failed to store token in Redis: %v", err)
+		h.log.WarnContext(ctx, "Failed to store token in Redis", "error", err.Error())
 	}
 
 	// 不返回密码
@@ -203,7 +201,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 	// 将 token 加入黑名单（设置较短的过期时间）
 	tokenKey := "token:" + token
-	if err := redis.Set(tokenKey, "blacklisted", 24*time.Hour); err != nil {
+	if err := h.redis.Set(c.Request.Context(), tokenKey, "blacklisted", 24*time.Hour).Err(); err != nil {
 		response.InternalServerError(c, "Failed to logout")
 		return
 	}
@@ -231,7 +229,7 @@ func (h *AuthHandler) Profile(c *gin.Context) {
 
 	user, err := h.userService.GetByID(ctx, userID.(uint))
 	if err != nil {
-		response.NotFound(c, "User not found")
+		response.AppError(c, err)
 		return
 	}
 
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/etag"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag 按资源的版本号设置 ETag 响应头
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", etag.Format(version))
+}
+
+// requireIfMatch 读取修改请求的 If-Match 条件，缺少时返回 428
+func requireIfMatch(c *gin.Context) (etag.Condition, bool) {
+	cond, ok := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		response.Error(c, http.StatusPreconditionRequired, "If-Match header is required")
+	}
+	return cond, ok
+}
+
+// checkIfMatch 资源的当前版本不满足 If-Match 时返回 412 和当前数据
+func checkIfMatch(c *gin.Context, cond etag.Condition, version uint, current any) bool {
+	if cond.Matches(version) {
+		return true
+	}
+	setETag(c, version)
+	response.ErrorWithData(c, http.StatusPreconditionFailed, "Resource has been modified", current)
+	return false
+}
+
+// versionConflict 读取后写入前资源被其他请求修改时返回 409 和当前数据
+func versionConflict(c *gin.Context, version uint, current any) {
+	setETag(c, version)
+	response.AppErrorWithData(c, apperror.New(apperror.CodeVersionConflict, "Resource has been modified concurrently"), current)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	_ "github.com/chenyl99x/toge-api/pkg/event" // swagger 文档引用 event.Event
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// EventHandler 领域事件管理处理器
+type EventHandler struct {
+	eventService domain.EventService
+	log          *slog.Logger
+}
+
+// NewEventHandler 创建领域事件管理处理器
+func NewEventHandler(eventService domain.EventService, log *slog.Logger) *EventHandler {
+	return &EventHandler{eventService: eventService, log: log}
+}
+
+// GetAll ListEvents godoc
+// @Summary      获取领域事件列表
+// @Description  按状态、类型和聚合筛选发件箱中的事件（支持分页）
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page            query     int     false  "页码，默认为1"  minimum(1)
+// @Param        page_size       query     int     false  "每页大小，默认为10，最大100"  minimum(1) maximum(100)
+// @Param        sort_by         query     string  false  "排序字段：occurred_at, type, status, attempts, published_at"
+// @Param        sort_order      query     string  false  "排序方向：asc, desc，默认为desc"
+// @Param        status          query     string  false  "投递状态：pending, published, failed"
+// @Param        type            query     string  false  "事件类型，例如 user.registered"
+// @Param        aggregate_type  query     string  false  "聚合类型，例如 user"
+// @Param        aggregate_id    query     string  false  "聚合ID"
+// @Success      200  {object}  response.Response{data=pagination.PageResponse{data=[]event.Event}}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Router       /admin/events [get]
+func (h *EventHandler) GetAll(c *gin.Context) {
+	var filter domain.EventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	pageResponse, err := h.eventService.GetAllWithPagination(c.Request.Context(), &filter, pagination.ParsePageRequest(c))
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, pageResponse)
+}
+
+// GetByID GetEvent godoc
+// @Summary      获取领域事件详情
+// @Description  获取事件载荷、投递状态和最近一次错误
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "事件ID"
+// @Success      200  {object}  response.Response{data=event.Event}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /admin/events/{id} [get]
+func (h *EventHandler) GetByID(c *gin.Context) {
+	e, err := h.eventService.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Event not found")
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, e)
+}
+
+// Replay ReplayEvent godoc
+// @Summary      重放领域事件
+// @Description  将事件重置为待投递状态，由 Relay 重新投递给所有订阅者
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "事件ID"
+// @Success      200  {object}  response.Response{data=event.Event}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /admin/events/{id}/replay [post]
+func (h *EventHandler) Replay(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	e, err := h.eventService.Replay(ctx, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Event not found")
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+
+	h.log.InfoContext(ctx, "Event replay requested", "event_id", e.ID, "type", e.Type, "username", c.GetString("username"))
+	response.Success(c, e)
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"context"
+	"slices"
+
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/fieldset"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// selectFields 解析 fields 和 expand 参数，展开的关联通过 context 交给仓储预加载，成功响应按选择裁剪，参数无效时返回 400
+func selectFields(c *gin.Context, schema *fieldset.Schema) (context.Context, bool) {
+	sel, err := schema.Parse(c.Request.URL.Query())
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return nil, false
+	}
+	response.Select(c, sel)
+	return database.WithPreload(c.Request.Context(), sel.Preloads()...), true
+}
+
+// expands 是否要求展开关联，association 为 gorm 的关联路径，例如 Spaces.Members
+func expands(ctx context.Context, association string) bool {
+	return slices.Contains(database.Preloads(ctx), association)
+}
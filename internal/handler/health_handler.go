@@ -1,36 +1,97 @@
 package handler
 
 import (
+	"net/http"
 	"runtime"
 	"time"
 
 	"github.com/chenyl99x/toge-api/internal/middleware"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/health"
+	"github.com/chenyl99x/toge-api/pkg/lifecycle"
 	"github.com/chenyl99x/toge-api/pkg/logger"
 	"github.com/chenyl99x/toge-api/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	lifecycle *lifecycle.Manager
+	registry  *health.Registry
+	db        *gorm.DB
+	replicas  *database.Replicas
+	reloader  *config.Reloader
+}
+
+func NewHealthHandler(lc *lifecycle.Manager, registry *health.Registry, db *gorm.DB, replicas *database.Replicas, reloader *config.Reloader) *HealthHandler {
+	return &HealthHandler{lifecycle: lc, registry: registry, db: db, replicas: replicas, reloader: reloader}
+}
+
+// Livez godoc
+// @Summary      存活探针
+// @Description  只要进程能处理请求就返回 200，不检查外部依赖，避免依赖故障导致实例被反复重启
+// @Tags         健康
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  response.Response{data=map[string]any}
+// @Router       /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	response.Success(c, map[string]any{
+		"status": "alive",
+		"uptime": time.Since(startTime).String(),
+	})
+}
+
+// Readyz godoc
+// @Summary      就绪探针
+// @Description  检查数据库、Redis、日志目录磁盘空间和数据库迁移，结果会短暂缓存
+// @Description  关键检查失败或启动未完成、优雅停机期间返回 503；非关键检查失败时状态为 degraded，仍返回 200
+// @Tags         健康
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  response.Response{data=health.Report}
+// @Failure      503  {object}  response.Response{data=health.Report}
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if !h.lifecycle.Ready() {
+		response.ServiceUnavailable(c, "Service is not ready", nil)
+		return
+	}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+	report := h.registry.Run(c.Request.Context())
+	if !report.Healthy() {
+		response.ServiceUnavailable(c, "Service is unhealthy", report)
+		return
+	}
+	response.Success(c, report)
 }
 
 // Health godoc
 // @Summary      健康检查
-// @Description  获取应用健康状态和系统信息
+// @Description  获取应用健康状态、依赖检查结果和系统信息，启动完成前、优雅停机期间或关键检查失败时返回 503
 // @Tags         健康
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  response.Response{data=map[string]any}
+// @Failure      503  {object}  response.Response
 // @Router       /health [get]
 func (h *HealthHandler) Health(c *gin.Context) {
+	// 优雅停机期间返回 503，让负载均衡尽快摘除实例
+	if !h.lifecycle.Ready() {
+		response.Error(c, http.StatusServiceUnavailable, "Service is shutting down")
+		return
+	}
+
+	report := h.registry.Run(c.Request.Context())
+
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
 	healthInfo := map[string]any{
-		"status":    "healthy",
+		"status":    report.Status,
+		"checks":    report.Checks,
 		"timestamp": time.Now().Format(time.RFC3339),
 		"uptime":    time.Since(startTime).String(),
 		"system": map[string]any{
@@ -46,10 +107,17 @@ func (h *HealthHandler) Health(c *gin.Context) {
 			"sys":         m.Sys,
 			"num_gc":      m.NumGC,
 		},
-		"database": middleware.GetDBStats(),
-		"logging":  logger.GetLogStatus(),
+		"database": middleware.GetDBStats(h.db),
+		"logging":  logger.GetLogStatus(h.reloader.Current().Log),
+	}
+	if h.replicas.Len() > 0 {
+		healthInfo["database_replicas"] = h.replicas.Status()
 	}
 
+	if !report.Healthy() {
+		response.ServiceUnavailable(c, "Service is unhealthy", healthInfo)
+		return
+	}
 	response.Success(c, healthInfo)
 }
 
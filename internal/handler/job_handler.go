@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/chenyl99x/toge-api/pkg/response"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler 定时任务管理处理器
+type JobHandler struct {
+	scheduler *scheduler.Scheduler
+	log       *slog.Logger
+}
+
+// NewJobHandler 创建定时任务管理处理器
+func NewJobHandler(s *scheduler.Scheduler, log *slog.Logger) *JobHandler {
+	return &JobHandler{scheduler: s, log: log}
+}
+
+// List ListJobs godoc
+// @Summary      获取定时任务列表
+// @Description  获取所有已注册的定时任务及其下次运行时间、最近一次运行结果
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response{data=[]scheduler.JobInfo}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Router       /admin/jobs [get]
+func (h *JobHandler) List(c *gin.Context) {
+	response.Success(c, h.scheduler.Jobs())
+}
+
+// Trigger TriggerJob godoc
+// @Summary      手动触发定时任务
+// @Description  立即在后台运行指定任务，返回本次运行的 trace_id
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name path      string  true  "任务名称"
+// @Success      200  {object}  response.Response{data=map[string]interface{}}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      503  {object}  response.Response
+// @Router       /admin/jobs/{name}/trigger [post]
+func (h *JobHandler) Trigger(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param("name")
+
+	traceID, err := h.scheduler.Trigger(name)
+	if err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrJobNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, scheduler.ErrJobRunning):
+			response.Error(c, http.StatusConflict, err.Error())
+		case errors.Is(err, scheduler.ErrStopped):
+			response.Error(c, http.StatusServiceUnavailable, err.Error())
+		default:
+			response.AppError(c, err)
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx, "Job triggered manually", "job", name, "job_trace_id", traceID, "username", c.GetString("username"))
+	response.Success(c, gin.H{"job": name, "trace_id": traceID})
+}
+
+// History JobHistory godoc
+// @Summary      获取任务运行记录
+// @Description  按时间倒序获取指定任务最近的运行记录
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name  path      string  true   "任务名称"
+// @Param        limit query     int     false  "返回条数，默认20"
+// @Success      200  {object}  response.Response{data=[]scheduler.Run}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /admin/jobs/{name}/runs [get]
+func (h *JobHandler) History(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	runs, err := h.scheduler.History(c.Param("name"), limit)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrJobNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, runs)
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	_ "github.com/chenyl99x/toge-api/internal/model" // swagger 文档引用 model.Notification
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService domain.NotificationService
+}
+
+func NewNotificationHandler(notificationService domain.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetAll GetNotifications godoc
+// @Summary      获取通知列表
+// @Description  获取当前用户的通知收件箱（支持分页和只看未读）
+// @Tags         通知
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page         query     int    false  "页码，默认为1"  minimum(1)
+// @Param        page_size    query     int    false  "每页大小，默认为10，最大100"  minimum(1) maximum(100)
+// @Param        sort_by      query     string false  "排序字段：id, category, created_at, read_at"
+// @Param        sort_order   query     string false  "排序方向：asc, desc，默认为desc"
+// @Param        unread_only  query     bool   false  "只返回未读通知"
+// @Success      200  {object}  response.Response{data=pagination.PageResponse{data=[]model.Notification}}
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /notifications/ [get]
+func (h *NotificationHandler) GetAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	pageReq := pagination.ParsePageRequest(c)
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread_only", "false"))
+
+	pageResponse, err := h.notificationService.GetAllWithPagination(ctx, userID, unreadOnly, pageReq)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, pageResponse)
+}
+
+// GetUnreadCount godoc
+// @Summary      获取未读数
+// @Description  获取当前用户的未读通知总数及按类别统计
+// @Tags         通知
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response{data=domain.UnreadCountResponse}
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	count, err := h.notificationService.GetUnreadCount(ctx, userID)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, count)
+}
+
+// MarkAsRead godoc
+// @Summary      批量标记已读
+// @Description  将指定的通知标记为已读
+// @Tags         通知
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body domain.MarkNotificationsReadRequest true "通知ID列表"
+// @Success      200  {object}  response.Response{data=map[string]interface{}}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /notifications/read [put]
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req domain.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	updated, err := h.notificationService.MarkAsRead(ctx, userID, req.IDs)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"updated": updated})
+}
+
+// MarkAllAsRead godoc
+// @Summary      全部标记已读
+// @Description  将当前用户的所有未读通知标记为已读
+// @Tags         通知
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response{data=map[string]interface{}}
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /notifications/read-all [put]
+func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	updated, err := h.notificationService.MarkAllAsRead(ctx, userID)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"updated": updated})
+}
+
+// Delete DeleteNotification godoc
+// @Summary      删除通知
+// @Description  删除当前用户的一条通知
+// @Tags         通知
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "通知ID"
+// @Success      200  {object}  response.Response{data=map[string]interface{}}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /notifications/{id} [delete]
+func (h *NotificationHandler) Delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid ID")
+		return
+	}
+
+	if err := h.notificationService.Delete(ctx, userID, uint(id)); err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Notification deleted successfully"})
+}
+
+// GetPreferences godoc
+// @Summary      获取通知偏好
+// @Description  获取当前用户各类别的渠道开关和免打扰时段
+// @Tags         通知
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response{data=domain.NotificationPreferencesResponse}
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(ctx, userID)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary      更新通知偏好
+// @Description  更新当前用户各类别的渠道开关和免打扰时段，未提交的类别保持不变
+// @Tags         通知
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body domain.UpdateNotificationPreferencesRequest true "通知偏好"
+// @Success      200  {object}  response.Response{data=domain.NotificationPreferencesResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req domain.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ValidationError(c, err.Error())
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(ctx, userID, &req)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, preferences)
+}
+
+// currentUserID 获取认证中间件写入的用户ID
+func currentUserID(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+	id, ok := userID.(uint)
+	return id, ok
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/chenyl99x/toge-api/pkg/jsonpatch"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// applyPatch 把请求体中的合并补丁或 JSON Patch 应用到资源的可写字段上，变化的字段解码到 v 并校验，出错时写入响应
+// resource 为资源对外展示的数据，test 操作不成立时作为当前数据随 409 返回
+func applyPatch(c *gin.Context, schema *jsonpatch.Schema, resource any, version uint, v any) bool {
+	body, err := c.GetRawData()
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return false
+	}
+
+	err = schema.Apply(c.GetHeader("Content-Type"), body, resource, v)
+	switch {
+	case err == nil:
+	case errors.Is(err, jsonpatch.ErrUnsupportedMediaType):
+		response.Error(c, http.StatusUnsupportedMediaType, err.Error())
+		return false
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		setETag(c, version)
+		response.ErrorWithData(c, http.StatusConflict, err.Error(), resource)
+		return false
+	default:
+		response.BadRequest(c, err.Error())
+		return false
+	}
+
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		response.BindError(c, err)
+		return false
+	}
+	return true
+}
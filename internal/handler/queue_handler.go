@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueHandler 后台任务队列管理处理器
+type QueueHandler struct {
+	client *queue.Client
+	log    *slog.Logger
+}
+
+// NewQueueHandler 创建后台任务队列管理处理器
+func NewQueueHandler(client *queue.Client, log *slog.Logger) *QueueHandler {
+	return &QueueHandler{client: client, log: log}
+}
+
+// Dead ListDeadTasks godoc
+// @Summary      获取死信任务
+// @Description  获取指定队列中重试耗尽或无法处理的任务，按失败时间倒序
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue path      string  true   "队列名称"
+// @Param        limit query     int     false  "返回条数，默认20"
+// @Success      200  {object}  response.Response{data=[]queue.Task}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Router       /admin/queues/{queue}/dead [get]
+func (h *QueueHandler) Dead(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	tasks, err := h.client.Dead(c.Request.Context(), c.Param("queue"), limit)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, tasks)
+}
+
+// Requeue RequeueDeadTask godoc
+// @Summary      重新投递死信任务
+// @Description  将死信任务放回就绪队列并清零重试次数
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        queue path      string  true  "队列名称"
+// @Param        id    path      string  true  "任务ID"
+// @Success      200  {object}  response.Response{data=queue.Task}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /admin/queues/{queue}/dead/{id}/requeue [post]
+func (h *QueueHandler) Requeue(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	task, err := h.client.Requeue(ctx, c.Param("queue"), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, queue.ErrTaskNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+
+	h.log.InfoContext(ctx, "Dead task requeued", "task_id", task.ID, "type", task.Type, "queue", task.Queue, "username", c.GetString("username"))
+	response.Success(c, task)
+}
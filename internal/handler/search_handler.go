@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/response"
+	"github.com/chenyl99x/toge-api/pkg/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService domain.SearchService
+}
+
+func NewSearchHandler(searchService domain.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// Search godoc
+// @Summary      全文搜索
+// @Description  在用户和当前用户所属的空间中搜索，中文按二元切分，所有词都需要命中，结果按相关度排序
+// @Description  highlights 中命中的词用 <mark> 包裹，其余内容已转义；facets 为各内容类型的命中数，不受 type 过滤影响
+// @Tags         搜索
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q          query     string  true   "搜索文本"  maxlength(100)
+// @Param        type       query     string  false  "内容类型：user, space，多个用逗号分隔，为空时搜索所有类型"
+// @Param        page       query     int     false  "页码，默认为1"  minimum(1)
+// @Param        page_size  query     int     false  "每页大小，默认为10，最大100"  minimum(1) maximum(100)
+// @Success      200  {object}  response.Response{data=search.Result}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req domain.SearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	var types []string
+	for _, t := range strings.Split(req.Type, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !slices.Contains(domain.SearchTypes, t) {
+			response.BadRequest(c, "invalid search type: "+t)
+			return
+		}
+		types = append(types, t)
+	}
+
+	result, err := h.searchService.Search(ctx, userID, req.Q, types, pagination.ParsePageRequest(c))
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/chenyl99x/toge-api/internal/domain"
 	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/database"
 	"github.com/chenyl99x/toge-api/pkg/response"
 	"github.com/gin-gonic/gin"
 )
@@ -34,7 +36,7 @@ func (h *SpaceHandler) Create(c *gin.Context) {
 	ctx := c.Request.Context()
 	var req domain.CreateSpaceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, err.Error())
+		response.BindError(c, err)
 		return
 	}
 	space := &model.Space{
@@ -44,10 +46,11 @@ func (h *SpaceHandler) Create(c *gin.Context) {
 		Type:        req.Type,
 	}
 	if err := h.spaceService.Create(ctx, space); err != nil {
-		response.InternalServerError(c, err.Error())
+		response.AppError(c, err)
 		return
 	}
 
+	setETag(c, space.Version)
 	response.Created(c, space)
 }
 
@@ -58,41 +61,64 @@ func (h *SpaceHandler) Create(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path uint true "岛屿ID"
+// @Param fields query string false "返回的字段，逗号分隔，例如 ID,name；展开的关联的字段用点号，例如 owner.nickname,members.avatar"
+// @Param expand query string false "展开的关联：owner（拥有者）、members（成员，不含拥有者，只有拥有者和成员可以展开），嵌套用点号，例如 owner.spaces，最多 2 层"
 // @Success 200 {object} response.Response{data=model.Space} "获取成功"
+// @Header 200 {string} ETag "岛屿的版本，修改时通过 If-Match 传回"
 // @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "不是岛屿的成员，不能展开成员"
 // @Failure 404 {object} response.Response "空间不存在"
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /space/{id} [get]
 func (h *SpaceHandler) GetByID(c *gin.Context) {
-	ctx := c.Request.Context()
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
+	ctx, ok := selectFields(c, domain.SpaceFieldset)
+	if !ok {
+		return
+	}
 	space, err := h.spaceService.GetByID(ctx, uint(id))
 	if err != nil {
-		response.NotFound(c, err.Error())
+		response.AppError(c, err)
 		return
 	}
+	// 成员列表只对拥有者和成员可见
+	if expands(ctx, "Members") {
+		userID, _ := currentUserID(c)
+		if err := h.spaceService.CheckMember(ctx, space, userID); err != nil {
+			response.AppError(c, err)
+			return
+		}
+	}
+	setETag(c, space.Version)
 	response.Success(c, space)
 }
 
 // Update UpdateSpace godoc
 // @Summary 修改岛屿信息
-// @Description 修改岛屿信息，支持修改 name、owner_user_id、type、description 字段
+// @Description 修改岛屿信息，支持修改 name、owner_user_id、type、description 字段，需要通过 If-Match 传入获取岛屿时的 ETag
+// @Description 版本不一致时返回 412，写入时被其他请求抢先修改返回 409，两者都在 data 中返回岛屿的当前数据，ETag 为当前版本
 // @Tags 岛屿
 // @Accept json
 // @Produce json
 // @Param id path uint true "岛屿ID"
+// @Param If-Match header string true "获取岛屿时的 ETag，* 表示不检查版本"
 // @Param space body domain.UpdateSpaceRequest true "修改岛屿请求"
 // @Success 200 {object} response.Response{data=model.Space} "修改成功"
+// @Header 200 {string} ETag "修改后的版本"
 // @Failure 400 {object} response.Response "参数错误"
 // @Failure 404 {object} response.Response "岛屿不存在"
+// @Failure 409 {object} response.Response{data=model.Space} "岛屿已被其他请求修改"
+// @Failure 412 {object} response.Response{data=model.Space} "版本不一致"
+// @Failure 428 {object} response.Response "缺少 If-Match"
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /space/{id} [put]
 func (h *SpaceHandler) Update(c *gin.Context) {
-	ctx := c.Request.Context()
+	// 读取后修改再写回，从主库读取，避免用从库的旧数据覆盖刚写入的修改
+	ctx := database.ForcePrimary(c.Request.Context())
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		response.BadRequest(c, err.Error())
@@ -101,14 +127,22 @@ func (h *SpaceHandler) Update(c *gin.Context) {
 
 	var req domain.UpdateSpaceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.BadRequest(c, err.Error())
+		response.BindError(c, err)
+		return
+	}
+
+	cond, ok := requireIfMatch(c)
+	if !ok {
 		return
 	}
 
 	// 先获取现有的空间信息
 	space, err := h.spaceService.GetByID(ctx, uint(id))
 	if err != nil {
-		response.NotFound(c, err.Error())
+		response.AppError(c, err)
+		return
+	}
+	if !checkIfMatch(c, cond, space.Version, space) {
 		return
 	}
 
@@ -119,9 +153,168 @@ func (h *SpaceHandler) Update(c *gin.Context) {
 	space.Description = req.Description
 
 	if err := h.spaceService.Update(ctx, space); err != nil {
-		response.InternalServerError(c, err.Error())
+		if errors.Is(err, domain.ErrVersionConflict) {
+			h.conflict(c, uint(id))
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+
+	setETag(c, space.Version)
+	response.Success(c, space)
+}
+
+// Patch PatchSpace godoc
+// @Summary 部分修改岛屿信息
+// @Description Content-Type 为 application/merge-patch+json（或 application/json）时按 RFC 7396 合并补丁处理，为 application/json-patch+json 时按 RFC 6902 JSON Patch 处理
+// @Description 可写字段：name、owner_user_id、type、description，description 可以用 null 或 remove 清空
+// @Description 需要通过 If-Match 传入获取岛屿时的 ETag，版本不一致返回 412，写入时被抢先修改或 test 操作不成立返回 409，两者都在 data 中返回岛屿的当前数据
+// @Tags 岛屿
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path uint true "岛屿ID"
+// @Param If-Match header string true "获取岛屿时的 ETag，* 表示不检查版本"
+// @Param patch body domain.SpacePatch true "合并补丁，或 JSON Patch 操作数组，例如 [{\"op\":\"replace\",\"path\":\"/name\",\"value\":\"新名字\"}]"
+// @Success 200 {object} response.Response{data=model.Space} "修改成功"
+// @Header 200 {string} ETag "修改后的版本"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 404 {object} response.Response "岛屿不存在"
+// @Failure 409 {object} response.Response{data=model.Space} "岛屿已被其他请求修改或 test 操作不成立"
+// @Failure 412 {object} response.Response{data=model.Space} "版本不一致"
+// @Failure 415 {object} response.Response "不支持的补丁格式"
+// @Failure 428 {object} response.Response "缺少 If-Match"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /space/{id} [patch]
+func (h *SpaceHandler) Patch(c *gin.Context) {
+	// 读取后修改再写回，从主库读取，避免用从库的旧数据覆盖刚写入的修改
+	ctx := database.ForcePrimary(c.Request.Context())
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	cond, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	space, err := h.spaceService.GetByID(ctx, uint(id))
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+	if !checkIfMatch(c, cond, space.Version, space) {
+		return
+	}
+
+	var patch domain.SpacePatch
+	if !applyPatch(c, domain.SpaceWritableFields, space, space.Version, &patch) {
+		return
+	}
+	patch.Apply(space)
+
+	if err := h.spaceService.Update(ctx, space); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			h.conflict(c, uint(id))
+			return
+		}
+		response.AppError(c, err)
 		return
 	}
 
+	setETag(c, space.Version)
 	response.Success(c, space)
 }
+
+// AddMember AddSpaceMember godoc
+// @Summary 添加岛屿成员
+// @Description 岛屿拥有者把用户加入岛屿，并发布 space.member_joined 事件，其他用户返回 403，拥有者和已加入的用户返回 409
+// @Tags 岛屿
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path uint true "岛屿ID"
+// @Param member body domain.AddSpaceMemberRequest true "添加成员请求"
+// @Success 201 {object} response.Response{data=model.SpaceMember} "添加成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未登录"
+// @Failure 403 {object} response.Response "不是岛屿的拥有者"
+// @Failure 404 {object} response.Response "岛屿不存在"
+// @Failure 409 {object} response.Response "已经是岛屿的成员"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /space/{id}/members [post]
+func (h *SpaceHandler) AddMember(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req domain.AddSpaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	member, err := h.spaceService.AddMember(c.Request.Context(), uint(id), userID, req.UserID)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+	response.Created(c, member)
+}
+
+// GetMembers GetSpaceMembers godoc
+// @Summary 获取岛屿成员
+// @Description 获取加入岛屿的成员，按加入时间排序，不包含拥有者，只有拥有者和成员可以查看
+// @Tags 岛屿
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path uint true "岛屿ID"
+// @Success 200 {object} response.Response{data=[]model.SpaceMember} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未登录"
+// @Failure 403 {object} response.Response "不是岛屿的成员"
+// @Failure 404 {object} response.Response "岛屿不存在"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /space/{id}/members [get]
+func (h *SpaceHandler) GetMembers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	members, err := h.spaceService.GetMembers(c.Request.Context(), uint(id), userID)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+	response.Success(c, members)
+}
+
+// conflict 返回 409 和岛屿的当前数据
+func (h *SpaceHandler) conflict(c *gin.Context, id uint) {
+	current, err := h.spaceService.GetByID(database.ForcePrimary(c.Request.Context()), id)
+	if err != nil {
+		// 岛屿已被删除
+		response.AppError(c, err)
+		return
+	}
+	versionConflict(c, current.Version, current)
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/middleware"
 	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/filter"
 	"github.com/chenyl99x/toge-api/pkg/pagination"
 	"github.com/chenyl99x/toge-api/pkg/password"
 	"github.com/chenyl99x/toge-api/pkg/response"
@@ -14,10 +18,11 @@ import (
 
 type UserHandler struct {
 	userService domain.UserService
+	locales     *middleware.UserLocales
 }
 
-func NewUserHandler(userService domain.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService domain.UserService, locales *middleware.UserLocales) *UserHandler {
+	return &UserHandler{userService: userService, locales: locales}
 }
 
 // Create CreateUser godoc
@@ -29,13 +34,14 @@ func NewUserHandler(userService domain.UserService) *UserHandler {
 // @Param        user body domain.CreateUserRequest true "用户信息"
 // @Success      201  {object}  response.Response{data=model.User}
 // @Failure      400  {object}  response.Response
+// @Failure      409  {object}  response.Response  "用户名或邮箱已存在，error_code 为 USERNAME_EXISTS 或 EMAIL_EXISTS"
 // @Failure      500  {object}  response.Response
 // @Router       /users [post]
 func (h *UserHandler) Create(c *gin.Context) {
 	ctx := c.Request.Context()
 	var req domain.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, err.Error())
+		response.BindError(c, err)
 		return
 	}
 
@@ -59,15 +65,17 @@ func (h *UserHandler) Create(c *gin.Context) {
 		Nickname: req.Nickname,
 		Avatar:   req.Avatar,
 		Status:   req.Status,
+		Locale:   req.Locale,
 	}
 
 	if err := h.userService.Create(ctx, user); err != nil {
-		response.DatabaseError(c, err.Error())
+		response.AppError(c, err)
 		return
 	}
 
 	// 不返回密码
 	user.Password = ""
+	setETag(c, user.Version)
 	response.Created(c, user)
 }
 
@@ -77,33 +85,51 @@ func (h *UserHandler) Create(c *gin.Context) {
 // @Tags         用户
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "用户ID"
+// @Param        id      path      int     true   "用户ID"
+// @Param        fields  query     string  false  "返回的字段，逗号分隔，例如 id,nickname,avatar；展开的关联的字段用点号，例如 spaces.name"
+// @Param        expand  query     string  false  "展开的关联：spaces（拥有的岛屿），嵌套用点号，例如 spaces.owner、spaces.members，最多 2 层；spaces.members 只能在获取自己时展开"
 // @Success      200  {object}  response.Response{data=model.User}
+// @Header       200  {string}  ETag  "用户的版本，修改时通过 If-Match 传回"
 // @Failure      400  {object}  response.Response
+// @Failure      403  {object}  response.Response
 // @Failure      404  {object}  response.Response
 // @Router       /users/{id} [get]
 func (h *UserHandler) GetByID(c *gin.Context) {
-	ctx := c.Request.Context()
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		response.BadRequest(c, "Invalid ID")
 		return
 	}
 
+	ctx, ok := selectFields(c, domain.UserFieldset)
+	if !ok {
+		return
+	}
+	// 岛屿成员只对拥有者可见
+	if userID, _ := currentUserID(c); expands(ctx, "Spaces.Members") && userID != uint(id) {
+		response.AppError(c, domain.ErrNotSpaceMember)
+		return
+	}
+
 	user, err := h.userService.GetByID(ctx, uint(id))
 	if err != nil {
-		response.NotFound(c, "User not found")
+		response.AppError(c, err)
 		return
 	}
 
 	// 不返回密码
 	user.Password = ""
+	setETag(c, user.Version)
 	response.Success(c, user)
 }
 
 // GetAll GetAllUsers godoc
 // @Summary      获取所有用户
-// @Description  获取所有用户列表（支持分页、排序和搜索）
+// @Description  获取所有用户列表（支持分页、排序、搜索和过滤）
+// @Description  传 cursor 或 limit 时使用游标分页，不统计总数，响应为 data、limit、next_cursor、prev_cursor、has_next、has_prev；翻页时只需传上次响应中的 next_cursor 或 prev_cursor，排序沿用游标中的设置，搜索和过滤条件需要重复传递
+// @Description  过滤字段见 Swagger UI 中的 filter[字段] 参数，由 domain.UserFilters 白名单生成
+// @Description  过滤：filter[字段]=值 表示等于，filter[字段][操作符]=值 指定操作符，in 和 nin 的值以逗号分隔，例如 filter[status][in]=0,1、filter[created_at][gte]=2025-01-01
+// @Description  组合：filter[or][0][字段]=值&filter[or][1][字段]=值 满足其一，同一序号内的条件同时满足，filter[not][字段]=值 取反
 // @Tags         用户
 // @Accept       json
 // @Produce      json
@@ -113,20 +139,51 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 // @Param        sort_order query     string false  "排序方向：asc, desc，默认为desc"
 // @Param        keyword    query     string false  "搜索关键词"
 // @Param        search_by  query     string false  "搜索字段：username, email, nickname，不指定则在所有字段中搜索"
+// @Param        cursor     query     string false  "游标，为空表示第一页"
+// @Param        limit      query     int    false  "游标分页每页大小，默认为10，最大100"  minimum(1) maximum(100)
+// @Param        fields     query     string false  "返回的字段，逗号分隔，例如 id,nickname,avatar；展开的关联的字段用点号，例如 spaces.name"
+// @Param        expand     query     string false  "展开的关联：spaces（拥有的岛屿），嵌套用点号，例如 spaces.owner，最多 2 层；列表不能展开 spaces.members"
 // @Success      200  {object}  response.Response{data=pagination.PageResponse{data=[]model.User}}
 // @Failure      400  {object}  response.Response
+// @Failure      403  {object}  response.Response
 // @Failure      500  {object}  response.Response
 // @Router       /users/ [get]
 func (h *UserHandler) GetAll(c *gin.Context) {
-	ctx := c.Request.Context()
+	ctx, ok := selectFields(c, domain.UserFieldset)
+	if !ok {
+		return
+	}
+	// 列表中的岛屿大多不属于当前用户，不展开成员
+	if expands(ctx, "Spaces.Members") {
+		response.AppError(c, domain.ErrNotSpaceMember)
+		return
+	}
 
 	// 解析分页参数
 	pageReq := pagination.ParsePageRequest(c)
 
+	if pageReq.IsCursor() {
+		cursorResponse, err := h.userService.GetAllWithCursor(ctx, pageReq)
+		if errors.Is(err, pagination.ErrInvalidCursor) || errors.Is(err, filter.ErrInvalidFilter) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		if err != nil {
+			response.AppError(c, err)
+			return
+		}
+		response.Success(c, cursorResponse)
+		return
+	}
+
 	// 使用分页获取用户列表
 	pageResponse, err := h.userService.GetAllWithPagination(ctx, pageReq)
+	if errors.Is(err, filter.ErrInvalidFilter) {
+		response.BadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
-		response.DatabaseError(c, err.Error())
+		response.AppError(c, err)
 		return
 	}
 
@@ -135,19 +192,26 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 
 // Update UpdateUser godoc
 // @Summary      更新用户
-// @Description  根据ID更新用户信息
+// @Description  根据ID更新用户信息，需要通过 If-Match 传入获取用户时的 ETag
+// @Description  版本不一致时返回 412，写入时被其他请求抢先修改返回 409，两者都在 data 中返回用户的当前数据，ETag 为当前版本
 // @Tags         用户
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "用户ID"
+// @Param        id        path      int     true  "用户ID"
+// @Param        If-Match  header    string  true  "获取用户时的 ETag，* 表示不检查版本"
 // @Param        user body domain.UpdateUserRequest true "用户更新信息"
 // @Success      200  {object}  response.Response{data=model.User}
+// @Header       200  {string}  ETag  "修改后的版本"
 // @Failure      400  {object}  response.Response
 // @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response{data=model.User}
+// @Failure      412  {object}  response.Response{data=model.User}
+// @Failure      428  {object}  response.Response
 // @Failure      500  {object}  response.Response
 // @Router       /users/{id} [put]
 func (h *UserHandler) Update(c *gin.Context) {
-	ctx := c.Request.Context()
+	// 读取后修改再写回，从主库读取，避免用从库的旧数据覆盖刚写入的修改
+	ctx := database.ForcePrimary(c.Request.Context())
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		response.BadRequest(c, "Invalid ID")
@@ -156,13 +220,21 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 	var req domain.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, err.Error())
+		response.BindError(c, err)
+		return
+	}
+
+	cond, ok := requireIfMatch(c)
+	if !ok {
 		return
 	}
 
 	user, err := h.userService.GetByID(ctx, uint(id))
 	if err != nil {
-		response.NotFound(c, "User not found")
+		response.AppError(c, err)
+		return
+	}
+	if !checkIfMatch(c, cond, user.Version, withoutPassword(user)) {
 		return
 	}
 
@@ -196,14 +268,107 @@ func (h *UserHandler) Update(c *gin.Context) {
 	if req.Status != nil {
 		user.Status = *req.Status
 	}
+	if req.Locale != nil {
+		user.Locale = *req.Locale
+	}
 
 	if err := h.userService.Update(ctx, user); err != nil {
-		response.DatabaseError(c, err.Error())
+		if errors.Is(err, domain.ErrVersionConflict) {
+			h.conflict(c, uint(id))
+			return
+		}
+		response.AppError(c, err)
 		return
 	}
+	// 语言偏好可能已修改
+	h.locales.Invalidate(user.ID)
 
 	// 不返回密码
 	user.Password = ""
+	setETag(c, user.Version)
+	response.Success(c, user)
+}
+
+// Patch PatchUser godoc
+// @Summary      部分更新用户
+// @Description  Content-Type 为 application/merge-patch+json（或 application/json）时按 RFC 7396 合并补丁处理，为 application/json-patch+json 时按 RFC 6902 JSON Patch 处理
+// @Description  可写字段：username、email、password、nickname、avatar、status、locale，nickname 和 avatar 可以用 null 或 remove 清空，locale 清空后按 Accept-Language 选择语言；password 只写，补丁中视为 null
+// @Description  需要通过 If-Match 传入获取用户时的 ETag，版本不一致返回 412，写入时被抢先修改或 test 操作不成立返回 409，两者都在 data 中返回用户的当前数据
+// @Tags         用户
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id        path      int     true  "用户ID"
+// @Param        If-Match  header    string  true  "获取用户时的 ETag，* 表示不检查版本"
+// @Param        patch     body      domain.UserPatch  true  "合并补丁，或 JSON Patch 操作数组，例如 [{\"op\":\"remove\",\"path\":\"/nickname\"}]"
+// @Success      200  {object}  response.Response{data=model.User}
+// @Header       200  {string}  ETag  "修改后的版本"
+// @Failure      400  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response{data=model.User}
+// @Failure      412  {object}  response.Response{data=model.User}
+// @Failure      415  {object}  response.Response
+// @Failure      428  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/{id} [patch]
+func (h *UserHandler) Patch(c *gin.Context) {
+	// 读取后修改再写回，从主库读取，避免用从库的旧数据覆盖刚写入的修改
+	ctx := database.ForcePrimary(c.Request.Context())
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid ID")
+		return
+	}
+
+	cond, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetByID(ctx, uint(id))
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+	// 补丁的目标文档不包含密码哈希
+	current := withoutPassword(user)
+	if !checkIfMatch(c, cond, user.Version, current) {
+		return
+	}
+
+	var patch domain.UserPatch
+	if !applyPatch(c, domain.UserWritableFields, current, user.Version, &patch) {
+		return
+	}
+	if patch.Password != nil {
+		if err := password.ValidatePassword(*patch.Password); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		hashedPassword, err := password.HashPassword(*patch.Password)
+		if err != nil {
+			response.InternalServerError(c, "Failed to process password")
+			return
+		}
+		user.Password = hashedPassword
+	}
+	patch.Apply(user)
+
+	if err := h.userService.Update(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			h.conflict(c, uint(id))
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+	// 语言偏好可能已修改
+	h.locales.Invalidate(user.ID)
+
+	// 不返回密码
+	user.Password = ""
+	setETag(c, user.Version)
 	response.Success(c, user)
 }
 
@@ -227,9 +392,28 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.userService.Delete(ctx, uint(id)); err != nil {
-		response.DatabaseError(c, err.Error())
+		response.AppError(c, err)
 		return
 	}
+	h.locales.Invalidate(uint(id))
 
 	response.Success(c, gin.H{"message": "User deleted successfully"})
 }
+
+// conflict 返回 409 和用户的当前数据
+func (h *UserHandler) conflict(c *gin.Context, id uint) {
+	current, err := h.userService.GetByID(database.ForcePrimary(c.Request.Context()), id)
+	if err != nil {
+		// 用户已被删除
+		response.AppError(c, err)
+		return
+	}
+	versionConflict(c, current.Version, withoutPassword(current))
+}
+
+// withoutPassword 去掉密码的用户副本
+func withoutPassword(user *model.User) *model.User {
+	u := *user
+	u.Password = ""
+	return &u
+}
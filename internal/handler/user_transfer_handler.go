@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/filter"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/response"
+	"github.com/chenyl99x/toge-api/pkg/tabular"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UserTransferHandler struct {
+	transferService domain.UserTransferService
+}
+
+func NewUserTransferHandler(transferService domain.UserTransferService) *UserTransferHandler {
+	return &UserTransferHandler{transferService: transferService}
+}
+
+// Export ExportUsers godoc
+// @Summary      导出用户
+// @Description  按与用户列表相同的搜索和过滤条件导出用户，结果按 ID 升序流式写出，忽略分页和排序参数
+// @Description  过滤参数与用户列表相同，见 GET /users/ 的说明
+// @Description  表头与导入字段同名，补充 password 列后可以直接导入；CSV 为带 BOM 的 UTF-8，以 = + - @ 开头的值前加单引号
+// @Tags         用户
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security     BearerAuth
+// @Param        format     query     string false  "文件格式：csv, xlsx，默认为csv"
+// @Param        keyword    query     string false  "搜索关键词"
+// @Param        search_by  query     string false  "搜索字段：username, email, nickname，不指定则在所有字段中搜索"
+// @Success      200  {file}    file
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/export [get]
+func (h *UserTransferHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", tabular.FormatCSV)
+	if !slices.Contains(tabular.Formats, format) {
+		response.BadRequest(c, "Invalid format, use csv or xlsx")
+		return
+	}
+
+	w := &attachmentWriter{
+		c:           c,
+		filename:    fmt.Sprintf("users-%s.%s", time.Now().Format("20060102-150405"), format),
+		contentType: tabular.ContentType(format),
+	}
+	err := h.transferService.Export(c.Request.Context(), pagination.ParsePageRequest(c), format, w)
+	if err == nil || w.started {
+		// 开始写入后出错只能中断下载，错误已记录日志
+		return
+	}
+	if errors.Is(err, filter.ErrInvalidFilter) || errors.Is(err, tabular.ErrUnsupportedFormat) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.AppError(c, err)
+}
+
+// attachmentWriter 第一次写入时才设置下载响应头，写入前出错仍可以返回 JSON 错误响应
+type attachmentWriter struct {
+	c           *gin.Context
+	filename    string
+	contentType string
+	started     bool
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": w.filename}))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// Import ImportUsers godoc
+// @Summary      导入用户
+// @Description  上传 CSV 或 XLSX 文件批量创建用户，第一行为表头，空行跳过；必须包含 username、email、password 列，可选 nickname、avatar、status
+// @Description  mapping 为表头到字段的 JSON 对象，例如 {"用户名":"username","邮箱":"email","密码":"password","备注":""}，值为空字符串时忽略该列，未出现的表头与字段同名时自动映射
+// @Description  dry_run 为 true 时只校验，逐行报告格式错误、文件内重复以及已被占用的用户名和邮箱
+// @Description  行数不超过阈值时直接执行并返回 200 和逐行结果；超过时返回 202，通过 GET /users/import/{id} 轮询进度，完成后返回逐行结果
+// @Tags         用户
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file     formData  file    true   "CSV 或 XLSX 文件"
+// @Param        format   formData  string  false  "文件格式：csv, xlsx，为空时按扩展名判断"
+// @Param        mapping  formData  string  false  "列映射，JSON 对象，键为表头，值为字段"
+// @Param        dry_run  formData  bool    false  "只校验不写入"
+// @Success      200  {object}  response.Response{data=domain.UserImportJobResponse}
+// @Success      202  {object}  response.Response{data=domain.UserImportJobResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/import [post]
+func (h *UserTransferHandler) Import(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req domain.UserImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "File is required")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	defer file.Close()
+
+	job, async, err := h.transferService.Import(ctx, userID, &req, fileHeader.Filename, file)
+	if err != nil {
+		response.AppError(c, err)
+		return
+	}
+
+	if async {
+		response.Accepted(c, job)
+		return
+	}
+	response.Success(c, job)
+}
+
+// GetImportJob GetUserImportJob godoc
+// @Summary      查询导入任务
+// @Description  查询导入任务的状态和进度，任务完成后包含逐行结果
+// @Tags         用户
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "任务ID"
+// @Success      200  {object}  response.Response{data=domain.UserImportJobResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /users/import/{id} [get]
+func (h *UserTransferHandler) GetImportJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid ID")
+		return
+	}
+
+	job, err := h.transferService.GetImportJob(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Import job not found")
+			return
+		}
+		response.AppError(c, err)
+		return
+	}
+
+	response.Success(c, job)
+}
@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	_ "github.com/chenyl99x/toge-api/internal/model" // swagger 文档引用 model.Webhook
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler struct {
+	webhookService domain.WebhookService
+}
+
+func NewWebhookHandler(webhookService domain.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// Create CreateWebhook godoc
+// @Summary      创建 Webhook
+// @Description  为当前用户或自己拥有的空间注册 webhook，签名密钥只在创建时返回
+// @Description  投递请求头：X-Toge-Event、X-Toge-Event-Id、X-Toge-Delivery、X-Toge-Timestamp、X-Toge-Signature（sha256=HMAC-SHA256(secret, "<timestamp>.<body>")）
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        webhook body      domain.CreateWebhookRequest  true  "Webhook 信息"
+// @Success      201  {object}  response.Response{data=domain.CreateWebhookResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /webhooks/ [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req domain.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ValidationError(c, err.Error())
+		return
+	}
+
+	result, err := h.webhookService.Create(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, result)
+}
+
+// GetAll GetWebhooks godoc
+// @Summary      获取 Webhook 列表
+// @Description  默认返回当前用户的 webhook，指定 owner_type=space 和 owner_id 时返回空间的 webhook
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        owner_type  query     string  false  "归属类型：user, space，默认为user"
+// @Param        owner_id    query     int     false  "空间ID"
+// @Success      200  {object}  response.Response{data=[]model.Webhook}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Router       /webhooks/ [get]
+func (h *WebhookHandler) GetAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	ownerID, _ := strconv.ParseUint(c.Query("owner_id"), 10, 32)
+
+	hooks, err := h.webhookService.GetAll(c.Request.Context(), userID, c.Query("owner_type"), uint(ownerID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, hooks)
+}
+
+// GetByID GetWebhook godoc
+// @Summary      获取 Webhook 详情
+// @Description  获取 webhook 配置和连续失败次数
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  response.Response{data=model.Webhook}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	hook, err := h.webhookService.GetByID(c.Request.Context(), userID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, hook)
+}
+
+// Update UpdateWebhook godoc
+// @Summary      更新 Webhook
+// @Description  更新地址、订阅的事件或启用状态，重新启用时清零连续失败次数
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int                          true  "Webhook ID"
+// @Param        webhook body      domain.UpdateWebhookRequest  true  "需要更新的字段"
+// @Success      200  {object}  response.Response{data=model.Webhook}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.ValidationError(c, err.Error())
+		return
+	}
+
+	hook, err := h.webhookService.Update(c.Request.Context(), userID, id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, hook)
+}
+
+// Delete DeleteWebhook godoc
+// @Summary      删除 Webhook
+// @Description  删除 webhook，已入队的投递会被跳过
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(c.Request.Context(), userID, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// RotateSecret RotateWebhookSecret godoc
+// @Summary      轮换签名密钥
+// @Description  生成新的签名密钥并立即生效，新密钥只在此时返回
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  response.Response{data=domain.CreateWebhookResponse}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.webhookService.RotateSecret(c.Request.Context(), userID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetDeliveries GetWebhookDeliveries godoc
+// @Summary      获取投递日志
+// @Description  按时间倒序获取每次投递的请求和响应快照
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int     true   "Webhook ID"
+// @Param        page       query     int     false  "页码，默认为1"  minimum(1)
+// @Param        page_size  query     int     false  "每页大小，默认为10，最大100"  minimum(1) maximum(100)
+// @Param        sort_by    query     string  false  "排序字段：id, event_type, status, created_at"
+// @Param        sort_order query     string  false  "排序方向：asc, desc，默认为desc"
+// @Success      200  {object}  response.Response{data=pagination.PageResponse{data=[]model.WebhookDelivery}}
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	pageResponse, err := h.webhookService.GetDeliveries(c.Request.Context(), userID, id, pagination.ParsePageRequest(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, pageResponse)
+}
+
+// Redeliver RedeliverWebhook godoc
+// @Summary      重新投递
+// @Description  使用投递日志中的原始请求体重新投递一次，结果会记录为新的投递日志
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id           path      int  true  "Webhook ID"
+// @Param        delivery_id  path      int  true  "投递记录ID"
+// @Success      200  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Router       /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, id, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid delivery ID")
+		return
+	}
+
+	if err := h.webhookService.Redeliver(c.Request.Context(), userID, id, uint(deliveryID)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// parseRequest 获取当前用户和路径中的 webhook ID
+func (h *WebhookHandler) parseRequest(c *gin.Context) (uint, uint, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid webhook ID")
+		return 0, 0, false
+	}
+	return userID, uint(id), true
+}
+
+// handleError 将服务层错误映射为响应
+func (h *WebhookHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.NotFound(c, "Webhook not found")
+	default:
+		response.AppError(c, err)
+	}
+}
@@ -0,0 +1,31 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+)
+
+// EventPurgeJobName 已发布事件清理任务名称
+const EventPurgeJobName = "event_purge"
+
+// NewEventPurgeJob 创建已发布事件清理任务，删除发布时间超过保留天数的事件
+// 待投递和失败的事件不会被清理
+func NewEventPurgeJob(repo domain.EventRepository, retentionDays int, log *slog.Logger) scheduler.JobFunc {
+	if retentionDays <= 0 {
+		retentionDays = 30
+	}
+
+	return func(ctx context.Context) error {
+		before := time.Now().AddDate(0, 0, -retentionDays)
+		deleted, err := repo.PurgePublished(ctx, before)
+		if err != nil {
+			return err
+		}
+		log.InfoContext(ctx, "Published events purged", "deleted", deleted, "before", before.Format(time.RFC3339))
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+)
+
+// Definition 任务定义，调度参数由配置文件覆盖
+type Definition struct {
+	Name        string
+	Description string
+	Func        scheduler.JobFunc
+}
+
+// RegisterJobs 按配置注册所有定时任务，未在配置中启用的任务会被跳过
+func RegisterJobs(s *scheduler.Scheduler, cfg config.SchedulerConfig, definitions []Definition, log *slog.Logger) error {
+	for _, d := range definitions {
+		jobConfig, ok := cfg.Jobs[d.Name]
+		if !ok || !jobConfig.Enabled {
+			log.Info("Job disabled", "job", d.Name)
+			continue
+		}
+
+		fn := d.Func
+		err := s.Register(&scheduler.Job{
+			Name:        d.Name,
+			Description: d.Description,
+			Spec:        jobConfig.Spec,
+			Jitter:      time.Duration(jobConfig.Jitter) * time.Second,
+			Timeout:     time.Duration(jobConfig.Timeout) * time.Second,
+			// 定时任务在后台按最新数据执行，读主库避免从库复制延迟
+			Func: func(ctx context.Context) error {
+				return fn(database.ForcePrimary(ctx))
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Definitions 返回内置的任务定义
+func Definitions(notificationRepo domain.NotificationRepository, eventRepo domain.EventRepository, notificationConfig config.NotificationConfig, eventConfig config.EventConfig, log *slog.Logger) []Definition {
+	return []Definition{
+		{
+			Name:        NotificationPurgeJobName,
+			Description: "清理过期的已读通知",
+			Func:        NewNotificationPurgeJob(notificationRepo, notificationConfig.ReadRetentionDays, log),
+		},
+		{
+			Name:        EventPurgeJobName,
+			Description: "清理过期的已发布领域事件",
+			Func:        NewEventPurgeJob(eventRepo, eventConfig.RetentionDays, log),
+		},
+	}
+}
@@ -0,0 +1,30 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+)
+
+// NotificationPurgeJobName 已读通知清理任务名称
+const NotificationPurgeJobName = "notification_purge"
+
+// NewNotificationPurgeJob 创建已读通知清理任务，删除超过保留天数的已读通知
+func NewNotificationPurgeJob(repo domain.NotificationRepository, retentionDays int, log *slog.Logger) scheduler.JobFunc {
+	if retentionDays <= 0 {
+		retentionDays = 90
+	}
+
+	return func(ctx context.Context) error {
+		before := time.Now().AddDate(0, 0, -retentionDays)
+		deleted, err := repo.PurgeRead(ctx, before)
+		if err != nil {
+			return err
+		}
+		log.InfoContext(ctx, "Read notifications purged", "deleted", deleted, "before", before.Format(time.RFC3339))
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware 管理员权限中间件，需要在 AuthMiddleware 之后使用
+// 按令牌中的用户 ID 判断，用户名可以注册或修改，不作为授权依据
+func AdminMiddleware(adminConfig config.AdminConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !adminConfig.IsAdmin(c.GetUint("user_id")) {
+			response.Forbidden(c, "Admin permission required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := jwt.NewManager(config.JWTConfig{Secret: "secret", ExpireHours: 1})
+
+	request := func(adminConfig config.AdminConfig, userID uint, username string) int {
+		r := gin.New()
+		r.GET("/admin", AuthMiddleware(jwtManager, NewUserLocales(testUsers{}, slog.New(slog.DiscardHandler))), AdminMiddleware(adminConfig), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		token, err := jwtManager.GenerateToken(userID, username)
+		require.NoError(t, err)
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 默认没有管理员，用户名为 admin 也没有权限
+	assert.Equal(t, http.StatusForbidden, request(config.AdminConfig{}, 1, "admin"))
+
+	adminConfig := config.AdminConfig{UserIDs: []uint{7}}
+	assert.Equal(t, http.StatusNoContent, request(adminConfig, 7, "alice"))
+	assert.Equal(t, http.StatusForbidden, request(adminConfig, 8, "admin"))
+}
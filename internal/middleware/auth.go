@@ -1,21 +1,21 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/chenyl99x/toge-api/pkg/jwt"
+	"github.com/chenyl99x/toge-api/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware JWT 认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware JWT 认证中间件，语言偏好从用户记录读取并在进程内缓存
+func AuthMiddleware(jwtManager *jwt.Manager, locales *UserLocales) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取 token
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			response.Unauthorized(c, "Authorization header is required")
 			c.Abort()
 			return
 		}
@@ -23,7 +23,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 检查 Bearer 前缀
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+			response.Unauthorized(c, "Invalid authorization header format")
 			c.Abort()
 			return
 		}
@@ -31,9 +31,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		token := tokenParts[1]
 
 		// 解析 token
-		claims, err := jwt.ParseToken(token)
+		claims, err := jwtManager.ParseToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			response.Unauthorized(c, "Invalid token")
 			c.Abort()
 			return
 		}
@@ -41,13 +41,14 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 将用户信息存储到上下文中
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		setUserLocale(c, locales, claims.UserID)
 
 		c.Next()
 	}
 }
 
 // OptionalAuthMiddleware 可选的认证中间件（不强制要求认证）
-func OptionalAuthMiddleware() gin.HandlerFunc {
+func OptionalAuthMiddleware(jwtManager *jwt.Manager, locales *UserLocales) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -63,7 +64,7 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 
 		token := tokenParts[1]
 
-		claims, err := jwt.ParseToken(token)
+		claims, err := jwtManager.ParseToken(token)
 		if err != nil {
 			c.Next()
 			return
@@ -71,6 +72,7 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		setUserLocale(c, locales, claims.UserID)
 
 		c.Next()
 	}
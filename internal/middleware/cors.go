@@ -9,11 +9,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// CORSMiddleware 跨域中间件
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware 跨域中间件，从 reloader 读取当前 CORS 配置
+func CORSMiddleware(reloader *config.Reloader) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 使用配置文件中的CORS设置
-		corsConfig := config.GlobalConfig.CORS
+		// 每次请求读取当前配置，CORS 设置支持热更新
+		corsConfig := reloader.Current().CORS
 
 		origin := c.Request.Header.Get("Origin")
 
@@ -40,7 +40,14 @@ func CORSMiddleware() gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Headers", strings.Join(corsConfig.AllowedHeaders, ", "))
 		} else {
 			// 默认允许的请求头
-			c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, traceparent, tracestate, If-Match")
+		}
+
+		if len(corsConfig.ExposedHeaders) > 0 {
+			c.Header("Access-Control-Expose-Headers", strings.Join(corsConfig.ExposedHeaders, ", "))
+		} else {
+			// 默认允许读取 ETag，修改时通过 If-Match 传回
+			c.Header("Access-Control-Expose-Headers", "ETag")
 		}
 
 		if len(corsConfig.AllowedMethods) > 0 {
@@ -88,6 +95,10 @@ func CORSMiddlewareWithConfig(corsConfig config.CORSConfig) gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Headers", strings.Join(corsConfig.AllowedHeaders, ", "))
 		}
 
+		if len(corsConfig.ExposedHeaders) > 0 {
+			c.Header("Access-Control-Expose-Headers", strings.Join(corsConfig.ExposedHeaders, ", "))
+		}
+
 		if len(corsConfig.AllowedMethods) > 0 {
 			c.Header("Access-Control-Allow-Methods", strings.Join(corsConfig.AllowedMethods, ", "))
 		}
@@ -1,11 +1,13 @@
 package middleware
 
 import (
-	"github.com/chenyl99x/toge-api/pkg/config"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/chenyl99x/toge-api/pkg/config"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +17,7 @@ func TestCORSMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// 初始化测试配置
-	config.GlobalConfig = &config.Config{
+	cfg := &config.Config{
 		CORS: config.CORSConfig{
 			AllowedOrigins: []string{
 				"http://localhost:3000",
@@ -38,7 +40,7 @@ func TestCORSMiddleware(t *testing.T) {
 
 	// 创建测试路由
 	router := gin.New()
-	router.Use(CORSMiddleware())
+	router.Use(CORSMiddleware(config.NewReloader(cfg, slog.New(slog.DiscardHandler))))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "test"})
 	})
@@ -54,6 +56,7 @@ func TestCORSMiddleware(t *testing.T) {
 		assert.Equal(t, 200, w.Code)
 		assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
 		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "ETag", w.Header().Get("Access-Control-Expose-Headers"))
 	})
 
 	// 测试不允许的域名
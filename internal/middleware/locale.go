@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userLocaleTTL 用户语言偏好在进程内的缓存时间，其他实例修改的偏好最迟在这段时间后生效
+const userLocaleTTL = time.Minute
+
+// LocaleMiddleware 按 Accept-Language 请求头选择响应消息的语言，登录用户设置了语言偏好时由认证中间件覆盖
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		setLocale(c, i18n.Match(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// setLocale 设置请求的语言，并通过 Content-Language 响应头告知客户端
+func setLocale(c *gin.Context, locale i18n.Locale) {
+	c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+	c.Header("Content-Language", string(locale))
+}
+
+// setUserLocale 使用用户的语言偏好，未设置时保持 Accept-Language 选择的语言
+func setUserLocale(c *gin.Context, locales *UserLocales, userID uint) {
+	if locale, ok := locales.Get(c.Request.Context(), userID); ok {
+		setLocale(c, locale)
+	}
+}
+
+// UserLocales 用户语言偏好的进程内缓存，认证中间件每个请求都要读取偏好，缓存避免每个请求都查询用户
+// 本实例修改用户后调用 Invalidate 立即生效
+type UserLocales struct {
+	users domain.UserRepository
+	log   *slog.Logger
+
+	mu      sync.Mutex
+	entries map[uint]userLocale
+}
+
+// userLocale 缓存的语言偏好，locale 为空表示用户没有设置
+type userLocale struct {
+	locale  i18n.Locale
+	expires time.Time
+}
+
+// NewUserLocales 创建用户语言偏好缓存
+func NewUserLocales(users domain.UserRepository, log *slog.Logger) *UserLocales {
+	return &UserLocales{users: users, log: log, entries: map[uint]userLocale{}}
+}
+
+// Get 用户的语言偏好，用户没有设置或读取失败时 ok 为 false，读取失败只影响响应语言，不缓存
+func (l *UserLocales) Get(ctx context.Context, userID uint) (i18n.Locale, bool) {
+	now := time.Now()
+	l.mu.Lock()
+	entry, ok := l.entries[userID]
+	l.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.locale, entry.locale != ""
+	}
+
+	user, err := l.users.GetByID(ctx, userID)
+	if err != nil {
+		l.log.DebugContext(ctx, "Failed to load user locale", "error", err.Error(), "user_id", userID)
+		return "", false
+	}
+	locale, _ := i18n.Parse(user.Locale)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.evictExpired(now)
+	l.entries[userID] = userLocale{locale: locale, expires: now.Add(userLocaleTTL)}
+	return locale, locale != ""
+}
+
+// Invalidate 删除用户的缓存，修改或删除用户后调用
+func (l *UserLocales) Invalidate(userID uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, userID)
+}
+
+// evictExpired 缓存较多时清理过期的项，调用时需持有锁
+func (l *UserLocales) evictExpired(now time.Time) {
+	if len(l.entries) < 1024 {
+		return
+	}
+	for id, entry := range l.entries {
+		if !now.Before(entry.expires) {
+			delete(l.entries, id)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/i18n"
+	"github.com/chenyl99x/toge-api/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// testUsers 按 ID 返回用户的语言偏好，不存在的用户返回 gorm.ErrRecordNotFound
+type testUsers struct {
+	domain.UserRepository
+	locales map[uint]string
+	// lookups 按 ID 读取用户的次数
+	lookups map[uint]int
+}
+
+func (u testUsers) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	if u.lookups != nil {
+		u.lookups[id]++
+	}
+	locale, ok := u.locales[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &model.User{Locale: locale}, nil
+}
+
+func TestLocaleMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := jwt.NewManager(config.JWTConfig{Secret: "secret", ExpireHours: 1})
+
+	r := gin.New()
+	r.Use(LocaleMiddleware())
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, string(i18n.FromContext(c.Request.Context())))
+	}
+	r.GET("/public", handler)
+	users := testUsers{locales: map[uint]string{1: "zh-CN", 2: ""}, lookups: map[uint]int{}}
+	locales := NewUserLocales(users, slog.New(slog.DiscardHandler))
+	r.GET("/private", AuthMiddleware(jwtManager, locales), handler)
+
+	request := func(path, acceptLanguage, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/public", "", "")
+	assert.Equal(t, "en-US", w.Body.String())
+	assert.Equal(t, "en-US", w.Header().Get("Content-Language"))
+	assert.Contains(t, w.Header().Values("Vary"), "Accept-Language")
+
+	w = request("/public", "zh-CN,zh;q=0.9,en;q=0.8", "")
+	assert.Equal(t, "zh-CN", w.Body.String())
+	assert.Equal(t, "zh-CN", w.Header().Get("Content-Language"))
+
+	// 用户的语言偏好优先于 Accept-Language
+	token, err := jwtManager.GenerateToken(1, "alice")
+	require.NoError(t, err)
+	w = request("/private", "en-US", token)
+	assert.Equal(t, "zh-CN", w.Body.String())
+	assert.Equal(t, "zh-CN", w.Header().Get("Content-Language"))
+
+	// 偏好在进程内缓存，不是每个请求都读取用户
+	w = request("/private", "en-US", token)
+	assert.Equal(t, "zh-CN", w.Body.String())
+	assert.Equal(t, 1, users.lookups[1])
+
+	// 修改偏好并失效缓存后，同一个 token 的下一个请求即生效
+	users.locales[1] = "en-US"
+	locales.Invalidate(1)
+	w = request("/private", "zh-CN", token)
+	assert.Equal(t, "en-US", w.Body.String())
+	assert.Equal(t, 2, users.lookups[1])
+
+	// 没有设置偏好或读取用户失败时按 Accept-Language 选择
+	token, err = jwtManager.GenerateToken(2, "bob")
+	require.NoError(t, err)
+	w = request("/private", "zh", token)
+	assert.Equal(t, "zh-CN", w.Body.String())
+	token, err = jwtManager.GenerateToken(3, "carol")
+	require.NoError(t, err)
+	w = request("/private", "zh", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "zh-CN", w.Body.String())
+	// 读取失败不缓存，下一个请求重新读取
+	request("/private", "zh", token)
+	assert.Equal(t, 2, users.lookups[3])
+
+	// 未登录的错误响应按 Accept-Language 本地化
+	w = request("/private", "zh-CN", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "缺少 Authorization 请求头")
+}
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"github.com/chenyl99x/toge-api/pkg/logger"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +16,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 }
 
 // ErrorLoggerMiddleware 错误日志中间件
-func ErrorLoggerMiddleware() gin.HandlerFunc {
+func ErrorLoggerMiddleware(log *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 
@@ -25,7 +25,7 @@ func ErrorLoggerMiddleware() gin.HandlerFunc {
 		// 记录错误
 		if len(c.Errors) > 0 {
 			for _, err := range c.Errors {
-				logger.ErrorWithTrace(ctx, "Request Error",
+				log.ErrorContext(ctx, "Request Error",
 					"method", c.Request.Method,
 					"path", c.Request.URL.Path,
 					"error", err.Error(),
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute 未匹配任何路由的请求统一归为一类，避免扫描请求产生大量标签
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware 按路由模板、方法和状态码记录请求数和耗时
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.HTTPRequestStarted()
+
+		c.Next()
+
+		// 使用路由模板（如 /users/:id）而不是实际路径
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.HTTPRequestFinished(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bucketIdleTTL 超过该时间没有请求的客户端令牌桶会被清理
+const bucketIdleTTL = 10 * time.Minute
+
+// rateLimitExempt 不限流的路径，健康检查探针可能频繁来自同一地址
+var rateLimitExempt = map[string]bool{
+	"/health": true,
+	"/livez":  true,
+	"/readyz": true,
+}
+
+// RateLimiter 按客户端 IP 的令牌桶限流，配置可以在运行时更新
+type RateLimiter struct {
+	mu        sync.Mutex
+	cfg       config.RateLimitConfig
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter 创建限流器
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:       cfg,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Update 更新限流配置，已有客户端的令牌数不超过新的突发上限
+func (l *RateLimiter) Update(cfg config.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg = cfg
+	for _, b := range l.buckets {
+		b.tokens = math.Min(b.tokens, float64(cfg.Burst))
+	}
+}
+
+// Allow 消耗一个令牌，不足时返回需要等待的时间
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.cfg.Enabled {
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.cfg.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	// 按经过的时间补充令牌
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(l.cfg.Burst), b.tokens+elapsed*l.cfg.RequestsPerSecond)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.cfg.RequestsPerSecond * float64(time.Second))
+	return false, wait
+}
+
+// sweep 定期清理长时间没有请求的客户端，避免内存随 IP 数量增长
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Middleware 限流中间件，超出限制时返回 429 和 Retry-After
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rateLimitExempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		allowed, wait := l.Allow(c.ClientIP())
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, "Too many requests, please try again later")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
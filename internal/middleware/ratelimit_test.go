@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 2, Burst: 2})
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("1.1.1.1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("1.1.1.1")
+	assert.True(t, allowed)
+
+	allowed, wait := limiter.Allow("1.1.1.1")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// 不同客户端互不影响
+	allowed, _ = limiter.Allow("2.2.2.2")
+	assert.True(t, allowed)
+
+	// 0.5 秒补充一个令牌
+	now = now.Add(500 * time.Millisecond)
+	allowed, _ = limiter.Allow("1.1.1.1")
+	assert.True(t, allowed)
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{Enabled: false})
+	for i := 0; i < 10; i++ {
+		allowed, _ := limiter.Allow("1.1.1.1")
+		assert.True(t, allowed)
+	}
+
+	limiter.Update(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 1, Burst: 1})
+	allowed, _ := limiter.Allow("1.1.1.1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("1.1.1.1")
+	assert.False(t, allowed)
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 1, Burst: 1})
+	router := gin.New()
+	router.Use(limiter.Middleware())
+	router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/test").Code)
+	w := serve("/test")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// 健康检查不限流
+	assert.Equal(t, http.StatusOK, serve("/livez").Code)
+	assert.Equal(t, http.StatusOK, serve("/livez").Code)
+}
+
+func TestRateLimiterMiddlewareTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(proxies []string) *gin.Engine {
+		limiter := NewRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 1, Burst: 1})
+		router := gin.New()
+		require.NoError(t, router.SetTrustedProxies(proxies))
+		router.Use(limiter.Middleware())
+		router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+	serve := func(router *gin.Engine, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 未配置可信代理时忽略 X-Forwarded-For，伪造的 IP 不能换一个限流桶
+	router := newRouter(nil)
+	assert.Equal(t, http.StatusOK, serve(router, "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "203.0.113.2"))
+
+	// 来自可信代理的请求按 X-Forwarded-For 中的客户端 IP 限流
+	router = newRouter([]string{"192.0.2.0/24"})
+	assert.Equal(t, http.StatusOK, serve(router, "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, serve(router, "203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "203.0.113.1"))
+}
@@ -1,16 +1,16 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 
-	"github.com/chenyl99x/toge-api/pkg/logger"
 	"github.com/chenyl99x/toge-api/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
 
 // ResponseMiddleware 统一响应中间件
-func ResponseMiddleware() gin.HandlerFunc {
+func ResponseMiddleware(log *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取 context 中的 traceId
 		ctx := c.Request.Context()
@@ -21,12 +21,17 @@ func ResponseMiddleware() gin.HandlerFunc {
 		// 检查是否有错误
 		if len(c.Errors) > 0 {
 			err := c.Errors.Last()
-			logger.ErrorWithTrace(ctx, "Request error", "error", err.Error(), "path", c.Request.URL.Path)
+			log.ErrorContext(ctx, "Request error", "error", err.Error(), "path", c.Request.URL.Path)
+
+			// response.AppError 已写入响应，错误只用于日志和链路追踪
+			if c.Writer.Written() {
+				return
+			}
 
 			// 根据错误类型返回相应的响应
 			switch err.Type {
 			case gin.ErrorTypeBind:
-				response.ValidationError(c, err.Error())
+				response.BindError(c, err.Err)
 			case gin.ErrorTypePublic:
 				response.BadRequest(c, err.Error())
 			default:
@@ -55,10 +60,10 @@ func ResponseMiddleware() gin.HandlerFunc {
 }
 
 // Recovery 恢复中间件，处理 panic
-func Recovery() gin.HandlerFunc {
+func Recovery(log *slog.Logger) gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
 		ctx := c.Request.Context()
-		logger.ErrorWithTrace(ctx, "Panic recovered", "panic", recovered, "path", c.Request.URL.Path)
+		log.ErrorContext(ctx, "Panic recovered", "panic", recovered, "path", c.Request.URL.Path)
 		response.InternalServerError(c, "Internal server error")
 	})
 }
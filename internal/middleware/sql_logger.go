@@ -4,6 +4,7 @@ import (
 	"github.com/chenyl99x/toge-api/pkg/database"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // SQLLoggerMiddleware SQL 日志中间件
@@ -16,29 +17,19 @@ func SQLLoggerMiddleware() gin.HandlerFunc {
 }
 
 // GetDBStats 获取数据库统计信息
-func GetDBStats() map[string]any {
-	if database.DB == nil {
+func GetDBStats(db *gorm.DB) map[string]any {
+	if db == nil {
 		return map[string]any{
 			"error": "Database not initialized",
 		}
 	}
 
-	sqlDB, err := database.DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
 		return map[string]any{
 			"error": err.Error(),
 		}
 	}
 
-	stats := sqlDB.Stats()
-	return map[string]any{
-		"max_open_connections": stats.MaxOpenConnections,
-		"open_connections":     stats.OpenConnections,
-		"in_use":               stats.InUse,
-		"idle":                 stats.Idle,
-		"wait_count":           stats.WaitCount,
-		"wait_duration":        stats.WaitDuration.String(),
-		"max_idle_closed":      stats.MaxIdleClosed,
-		"max_lifetime_closed":  stats.MaxLifetimeClosed,
-	}
+	return database.PoolStats(sqlDB)
 }
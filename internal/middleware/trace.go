@@ -1,32 +1,60 @@
 package middleware
 
 import (
+	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TraceMiddleware traceId 中间件
-func TraceMiddleware() gin.HandlerFunc {
+// 从 W3C traceparent/tracestate 请求头继续上游链路，为每个请求创建 server span，
+// 日志中的 trace_id 与 span 的 trace ID 一致
+func TraceMiddleware(log *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 检查请求头中是否已有 traceId
-		traceID := c.GetHeader("X-Trace-ID")
-		if traceID == "" {
-			// 生成新的 traceId
-			traceID = string(logger.GenerateTraceID())
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// 使用路由模板作为 span 名称，未匹配路由时只使用方法名
+		spanName := c.Request.Method
+		if route := c.FullPath(); route != "" {
+			spanName += " " + route
 		}
+		ctx, span := tracing.Tracer().Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", c.FullPath()),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			))
+		defer span.End()
 
-		// 将 traceId 添加到 context
-		ctx := logger.WithTraceID(c.Request.Context(), logger.TraceID(traceID))
+		// 未启用链路追踪且上游没有 traceparent 时，兼容旧的 X-Trace-ID 请求头
+		traceID := string(logger.GetTraceID(ctx))
+		if traceID == "" {
+			traceID = c.GetHeader("X-Trace-ID")
+			if traceID == "" {
+				traceID = string(logger.GenerateTraceID())
+			}
+			ctx = logger.WithTraceID(ctx, logger.TraceID(traceID))
+		}
 		c.Request = c.Request.WithContext(ctx)
 
 		// 在响应头中添加 traceId
 		c.Header("X-Trace-ID", traceID)
 
 		// 记录请求开始日志
-		logger.InfoWithTrace(ctx, "HTTP Request Started",
+		log.InfoContext(ctx, "HTTP Request Started",
 			"method", c.Request.Method,
 			"path", c.Request.URL.Path,
 			"query", c.Request.URL.RawQuery,
@@ -43,8 +71,17 @@ func TraceMiddleware() gin.HandlerFunc {
 		// 计算请求处理时间
 		elapsed := time.Since(start)
 
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+
 		// 记录请求完成日志
-		logger.InfoWithTrace(ctx, "HTTP Request Completed",
+		log.InfoContext(ctx, "HTTP Request Completed",
 			"method", c.Request.Method,
 			"path", c.Request.URL.Path,
 			"status", c.Writer.Status(),
@@ -55,7 +92,7 @@ func TraceMiddleware() gin.HandlerFunc {
 		// 如果有错误，记录错误日志
 		if len(c.Errors) > 0 {
 			for _, err := range c.Errors {
-				logger.ErrorWithTrace(ctx, "HTTP Request Error",
+				log.ErrorContext(ctx, "HTTP Request Error",
 					"method", c.Request.Method,
 					"path", c.Request.URL.Path,
 					"error", err.Error(),
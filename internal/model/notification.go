@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification 站内通知模型
+// @Description 站内通知
+type Notification struct {
+	gorm.Model
+	UserID   uint       `gorm:"not null;index:idx_notification_user_read,priority:1;comment:接收用户ID" json:"user_id" example:"1"`         // 接收用户ID
+	Category string     `gorm:"type:varchar(30);not null;index;comment:通知类别" json:"category" example:"comment"`                         // 通知类别
+	Title    string     `gorm:"type:varchar(200);not null;comment:标题" json:"title" example:"你收到一条新评论"`                                  // 标题
+	Content  string     `gorm:"type:text;comment:内容" json:"content" example:"小明评论了你的动态"`                                                // 内容
+	Link     string     `gorm:"type:varchar(255);comment:跳转链接" json:"link" example:"/space/1"`                                          // 跳转链接
+	ReadAt   *time.Time `gorm:"index:idx_notification_user_read,priority:2;comment:阅读时间" json:"read_at" example:"2023-01-01T00:00:00Z"` // 阅读时间，为空表示未读
+	DedupKey *string    `gorm:"type:varchar(100);uniqueIndex;comment:去重键" json:"-"`                                                     // 去重键，任务重试时不会重复写入，为空表示不去重
+}
+
+// TableName 指定表名
+func (Notification) TableName() string {
+	return "notification"
+}
+
+// NotificationPreference 通知偏好模型，按用户和类别设置各渠道开关
+// @Description 通知偏好
+type NotificationPreference struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;uniqueIndex:idx_notification_preference_user_category,priority:1;comment:用户ID" json:"user_id" example:"1"`                         // 用户ID
+	Category string `gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_preference_user_category,priority:2;comment:通知类别" json:"category" example:"comment"` // 通知类别
+	InApp    bool   `gorm:"not null;comment:站内信" json:"in_app" example:"true"`                                                                                         // 站内信
+	Email    bool   `gorm:"not null;comment:邮件" json:"email" example:"false"`                                                                                          // 邮件
+	Push     bool   `gorm:"not null;comment:推送" json:"push" example:"true"`                                                                                            // 推送
+}
+
+// TableName 指定表名
+func (NotificationPreference) TableName() string {
+	return "notification_preference"
+}
+
+// NotificationSetting 用户通知设置（免打扰时段）
+// @Description 通知设置
+type NotificationSetting struct {
+	gorm.Model
+	UserID            uint   `gorm:"not null;uniqueIndex;comment:用户ID" json:"user_id" example:"1"`                     // 用户ID
+	QuietHoursEnabled bool   `gorm:"not null;default:false;comment:是否开启免打扰" json:"quiet_hours_enabled" example:"true"` // 是否开启免打扰
+	QuietHoursStart   string `gorm:"type:varchar(5);comment:免打扰开始时间" json:"quiet_hours_start" example:"22:00"`         // 免打扰开始时间 HH:MM
+	QuietHoursEnd     string `gorm:"type:varchar(5);comment:免打扰结束时间" json:"quiet_hours_end" example:"07:00"`           // 免打扰结束时间 HH:MM
+	Timezone          string `gorm:"type:varchar(64);comment:用户时区" json:"timezone" example:"Asia/Shanghai"`            // 用户时区
+}
+
+// TableName 指定表名
+func (NotificationSetting) TableName() string {
+	return "notification_setting"
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// OutboxEvent 领域事件发件箱，与业务数据在同一事务中写入，由 Relay 异步投递
+// @Description 领域事件
+type OutboxEvent struct {
+	ID            string     `gorm:"type:varchar(32);primaryKey;comment:事件ID" json:"id" example:"9b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"`                             // 事件ID
+	Type          string     `gorm:"type:varchar(100);not null;index;comment:事件类型" json:"type" example:"user.registered"`                                       // 事件类型
+	AggregateType string     `gorm:"type:varchar(50);not null;index:idx_outbox_event_aggregate,priority:1;comment:聚合类型" json:"aggregate_type" example:"user"`   // 聚合类型
+	AggregateID   string     `gorm:"type:varchar(64);not null;index:idx_outbox_event_aggregate,priority:2;comment:聚合ID" json:"aggregate_id" example:"1"`        // 聚合ID
+	Payload       string     `gorm:"type:text;comment:事件载荷(JSON)" json:"payload"`                                                                               // 事件载荷
+	TraceID       string     `gorm:"type:varchar(64);comment:产生事件的请求 trace_id" json:"trace_id" example:"a1b2c3d4e5f60718"`                                      // trace_id
+	OccurredAt    time.Time  `gorm:"not null;index:idx_outbox_event_occurred_at;comment:发生时间" json:"occurred_at"`                                               // 发生时间
+	Status        string     `gorm:"type:varchar(20);not null;index:idx_outbox_event_status_available,priority:1;comment:投递状态" json:"status" example:"pending"` // 投递状态:pending、published、failed
+	Attempts      int        `gorm:"not null;default:0;comment:尝试次数" json:"attempts" example:"0"`                                                               // 尝试次数
+	LastError     string     `gorm:"type:text;comment:最近一次错误" json:"last_error"`                                                                                // 最近一次错误
+	AvailableAt   time.Time  `gorm:"not null;index:idx_outbox_event_status_available,priority:2;comment:下次可投递时间" json:"available_at"`                           // 下次可投递时间
+	PublishedAt   *time.Time `gorm:"index;comment:投递成功时间" json:"published_at"`                                                                                  // 投递成功时间
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_event"
+}
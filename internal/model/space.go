@@ -1,6 +1,10 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Space 岛屿模型
 // @Description 空间信息
@@ -11,9 +15,27 @@ type Space struct {
 	OwnerUserID uint   `gorm:"not null;index;comment:岛屿拥有者ID" json:"owner_user_id" example:"1"`    // 岛屿拥有者ID
 	Type        string `gorm:"type:varchar(50);not null;comment:岛屿类型" json:"type" example:"情侣空间"`  // 岛屿类型:情侣空间、家庭空间
 	Description string `gorm:"type:text;comment:描述" json:"description" example:"这是一个美好的岛屿"`        // 描述
+	Version     uint   `gorm:"not null;default:1;comment:版本号" json:"version" example:"1"`          // 版本号，每次修改加一，用于 ETag 和 If-Match
+	Owner       *User  `gorm:"foreignKey:OwnerUserID;-:migration" json:"owner,omitempty"`          // 拥有者，只在 expand=owner 时加载
+	Members     []User `gorm:"many2many:space_member;-:migration" json:"members,omitempty"`        // 成员，不含拥有者，只在 expand=members 时加载
 }
 
 // TableName 指定表名
 func (Space) TableName() string {
 	return "space"
 }
+
+// SpaceMember 岛屿成员模型，记录加入岛屿的用户，拥有者不在成员表中
+// 成员记录直接删除，不做软删除，离开后可以重新加入
+// @Description 岛屿成员
+type SpaceMember struct {
+	ID        uint      `gorm:"primarykey" json:"id" example:"1"`
+	SpaceID   uint      `gorm:"not null;uniqueIndex:idx_space_member_space_user,priority:1;comment:岛屿ID" json:"space_id" example:"1"`      // 岛屿ID
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_space_member_space_user,priority:2;index;comment:用户ID" json:"user_id" example:"2"` // 用户ID
+	CreatedAt time.Time `json:"created_at"`                                                                                                // 加入时间
+}
+
+// TableName 指定表名
+func (SpaceMember) TableName() string {
+	return "space_member"
+}
@@ -15,8 +15,11 @@ type User struct {
 	Password  string         `json:"password,omitempty" gorm:"not null;size:255" swaggerignore:"true"`
 	Nickname  string         `json:"nickname" gorm:"size:50" example:"John Doe"`
 	Avatar    string         `json:"avatar" gorm:"size:255" example:"https://example.com/avatar.jpg"`
-	Status    int            `json:"status" gorm:"default:1" example:"1"` // 1: 正常, 0: 禁用
+	Status    int            `json:"status" gorm:"default:1" example:"1"`           // 1: 正常, 0: 禁用
+	Locale    string         `json:"locale" gorm:"size:16" example:"zh-CN"`         // 语言偏好，为空时按 Accept-Language 选择
+	Version   uint           `json:"version" gorm:"not null;default:1" example:"1"` // 版本号，每次修改加一，用于 ETag 和 If-Match
 	CreatedAt time.Time      `json:"created_at" example:"2023-01-01T00:00:00Z"`
 	UpdatedAt time.Time      `json:"updated_at" example:"2023-01-01T00:00:00Z"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggerignore:"true"`
+	Spaces    []Space        `json:"spaces,omitempty" gorm:"foreignKey:OwnerUserID;-:migration"` // 拥有的岛屿，只在 expand=spaces 时加载
 }
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// UserImportJob 用户导入任务，待处理的行在任务完成后清空，逐行结果保留到任务删除
+// @Description 用户导入任务
+type UserImportJob struct {
+	ID         uint       `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedBy  uint       `gorm:"not null;index;comment:发起用户ID" json:"created_by" example:"1"`                  // 发起用户ID
+	FileName   string     `gorm:"type:varchar(255);comment:文件名" json:"file_name" example:"users.xlsx"`          // 文件名
+	Format     string     `gorm:"type:varchar(10);not null;comment:文件格式" json:"format" example:"xlsx"`          // 文件格式:csv、xlsx
+	DryRun     bool       `gorm:"not null;default:false;comment:只校验不写入" json:"dry_run" example:"false"`         // 只校验不写入
+	Status     string     `gorm:"type:varchar(20);not null;index;comment:任务状态" json:"status" example:"running"` // 任务状态:pending、running、completed、failed
+	Total      int        `gorm:"not null;default:0;comment:总行数" json:"total" example:"1000"`                   // 总行数
+	Processed  int        `gorm:"not null;default:0;comment:已处理行数" json:"processed" example:"400"`              // 已处理行数
+	Succeeded  int        `gorm:"not null;default:0;comment:成功行数" json:"succeeded" example:"398"`               // 成功行数，只校验时为校验通过的行数
+	Failed     int        `gorm:"not null;default:0;comment:失败行数" json:"failed" example:"2"`                    // 失败行数
+	Rows       string     `gorm:"comment:待处理的行(JSON)" json:"-"`                                                 // 待处理的行，密码加密保存，任务结束后清空
+	Results    string     `gorm:"comment:逐行结果(JSON)" json:"-"`                                                  // 逐行结果
+	Error      string     `gorm:"type:text;comment:任务失败原因" json:"error,omitempty"`                              // 任务失败原因
+	StartedAt  *time.Time `gorm:"comment:开始时间" json:"started_at"`                                               // 开始时间
+	FinishedAt *time.Time `gorm:"comment:结束时间" json:"finished_at"`                                              // 结束时间
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserImportJob) TableName() string {
+	return "user_import_job"
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook 出站 webhook 模型，归属于用户或空间
+// @Description Webhook
+type Webhook struct {
+	gorm.Model
+	OwnerType           string     `gorm:"type:varchar(20);not null;index:idx_webhook_owner,priority:1;comment:归属类型" json:"owner_type" example:"space"` // 归属类型:user、space
+	OwnerID             uint       `gorm:"not null;index:idx_webhook_owner,priority:2;comment:归属ID" json:"owner_id" example:"1"`                        // 归属ID
+	CreatedBy           uint       `gorm:"not null;comment:创建用户ID" json:"created_by" example:"1"`                                                       // 创建用户ID
+	URL                 string     `gorm:"type:varchar(500);not null;comment:投递地址" json:"url" example:"https://example.com/hooks/toge"`                 // 投递地址
+	Secret              string     `gorm:"type:varchar(100);not null;comment:签名密钥" json:"-"`                                                            // 签名密钥
+	Events              []string   `gorm:"type:text;serializer:json;comment:订阅的事件类型" json:"events" example:"space.created,space.updated"`               // 订阅的事件类型，* 表示全部
+	Description         string     `gorm:"type:varchar(255);comment:描述" json:"description" example:"同步到合作方"`                                            // 描述
+	Enabled             bool       `gorm:"not null;comment:是否启用" json:"enabled" example:"true"`                                                         // 是否启用
+	ConsecutiveFailures int        `gorm:"not null;default:0;comment:连续失败次数" json:"consecutive_failures" example:"0"`                                   // 连续失败次数
+	DisabledAt          *time.Time `gorm:"comment:自动停用时间" json:"disabled_at"`                                                                           // 自动停用时间
+	DisabledReason      string     `gorm:"type:varchar(255);comment:停用原因" json:"disabled_reason"`                                                       // 停用原因
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+// Subscribes 是否订阅了指定事件类型
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery webhook 投递记录，每次尝试一条，保存请求和响应快照
+// @Description Webhook 投递记录
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID       uint   `gorm:"not null;index:idx_webhook_delivery_webhook_event,priority:1;comment:WebhookID" json:"webhook_id" example:"1"`                          // WebhookID
+	EventID         string `gorm:"type:varchar(32);not null;index:idx_webhook_delivery_webhook_event,priority:2;comment:事件ID" json:"event_id" example:"9b1c2d3e4f5a6b7c"` // 事件ID
+	EventType       string `gorm:"type:varchar(100);not null;comment:事件类型" json:"event_type" example:"space.created"`                                                     // 事件类型
+	Attempt         int    `gorm:"not null;comment:第几次尝试" json:"attempt" example:"1"`                                                                                     // 第几次尝试
+	Trigger         string `gorm:"type:varchar(20);not null;comment:触发方式" json:"trigger" example:"event"`                                                                 // 触发方式:event、manual
+	Status          string `gorm:"type:varchar(20);not null;index;comment:投递结果" json:"status" example:"success"`                                                          // 投递结果:success、failed
+	RequestURL      string `gorm:"type:varchar(500);comment:请求地址" json:"request_url" example:"https://example.com/hooks/toge"`                                            // 请求地址
+	RequestHeaders  string `gorm:"type:text;comment:请求头(JSON)" json:"request_headers"`                                                                                    // 请求头
+	RequestBody     string `gorm:"type:text;comment:请求体" json:"request_body"`                                                                                             // 请求体
+	ResponseStatus  int    `gorm:"comment:响应状态码" json:"response_status" example:"200"`                                                                                    // 响应状态码
+	ResponseHeaders string `gorm:"type:text;comment:响应头(JSON)" json:"response_headers"`                                                                                   // 响应头
+	ResponseBody    string `gorm:"type:text;comment:响应体" json:"response_body"`                                                                                            // 响应体
+	Error           string `gorm:"type:text;comment:错误信息" json:"error"`                                                                                                   // 错误信息
+	DurationMs      int64  `gorm:"comment:耗时(毫秒)" json:"duration_ms" example:"120"`                                                                                       // 耗时
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
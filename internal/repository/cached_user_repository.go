@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/cache"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+
+	"gorm.io/gorm"
+)
+
+// cachedUserRepository 为按 ID、用户名、邮箱查询用户增加 Redis 缓存，其他方法直接使用 UserRepository
+// 同一用户的所有缓存项使用 user:<id> 标签，写入在事务提交后按标签失效，避免其他请求在提交前读到旧数据并重新写入缓存
+// 事务中或要求读主库时跳过缓存，保证读到最新数据
+// 缓存中不保存密码哈希，从缓存读取的用户 Password 为空，需要哈希时读主库
+type cachedUserRepository struct {
+	domain.UserRepository
+	client     redis.Client
+	byID       *cache.Cache[uint, *model.User]
+	byUsername *cache.Cache[string, *model.User]
+	byEmail    *cache.Cache[string, *model.User]
+	log        *slog.Logger
+}
+
+// NewCachedUserRepository 创建带缓存的用户仓储
+func NewCachedUserRepository(repo domain.UserRepository, client redis.Client, cacheConfig config.CacheConfig, log *slog.Logger) domain.UserRepository {
+	opts := func(name string) cache.Options[*model.User] {
+		return cache.Options[*model.User]{
+			Name:        name,
+			TTL:         time.Duration(cacheConfig.TTL) * time.Second,
+			NegativeTTL: time.Duration(cacheConfig.NegativeTTL) * time.Second,
+			NotFound:    gorm.ErrRecordNotFound,
+			Tags:        func(user *model.User) []string { return []string{userTag(user.ID)} },
+			Logger:      log,
+		}
+	}
+	return &cachedUserRepository{
+		UserRepository: repo,
+		client:         client,
+		byID:           cache.New[uint](client, opts("user:id")),
+		byUsername:     cache.New[string](client, opts("user:username")),
+		byEmail:        cache.New[string](client, opts("user:email")),
+		log:            log,
+	}
+}
+
+// userTag 用户缓存项的标签
+func userTag(id uint) string {
+	return fmt.Sprintf("user:%d", id)
+}
+
+func (r *cachedUserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	// 缓存中不包含关联，预加载时直接查询
+	if database.ReadsPrimary(ctx) || len(database.Preloads(ctx)) > 0 {
+		return r.UserRepository.GetByID(ctx, id)
+	}
+	return r.byID.Get(ctx, id, func(ctx context.Context) (*model.User, error) {
+		return withoutPassword(r.UserRepository.GetByID(ctx, id))
+	})
+}
+
+func (r *cachedUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	if database.ReadsPrimary(ctx) {
+		return r.UserRepository.GetByUsername(ctx, username)
+	}
+	return r.byUsername.Get(ctx, username, func(ctx context.Context) (*model.User, error) {
+		return withoutPassword(r.UserRepository.GetByUsername(ctx, username))
+	})
+}
+
+func (r *cachedUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	if database.ReadsPrimary(ctx) {
+		return r.UserRepository.GetByEmail(ctx, email)
+	}
+	return r.byEmail.Get(ctx, email, func(ctx context.Context) (*model.User, error) {
+		return withoutPassword(r.UserRepository.GetByEmail(ctx, email))
+	})
+}
+
+// withoutPassword 清除写入缓存的用户的密码哈希
+func withoutPassword(user *model.User, err error) (*model.User, error) {
+	if user != nil {
+		user.Password = ""
+	}
+	return user, err
+}
+
+func (r *cachedUserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := r.UserRepository.Create(ctx, user); err != nil {
+		return err
+	}
+	// 删除注册前缓存的“不存在”
+	r.invalidate(ctx, user)
+	return nil
+}
+
+func (r *cachedUserRepository) Update(ctx context.Context, user *model.User) error {
+	if err := r.UserRepository.Update(ctx, user); err != nil {
+		return err
+	}
+	// 标签覆盖修改前的用户名和邮箱，新用户名和邮箱可能缓存了“不存在”
+	r.invalidate(ctx, user)
+	return nil
+}
+
+func (r *cachedUserRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.UserRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, &model.User{ID: id})
+	return nil
+}
+
+// invalidate 失效用户的所有缓存项，Redis 不可用时只记录日志，缓存最迟在 TTL 后过期
+func (r *cachedUserRepository) invalidate(ctx context.Context, user *model.User) {
+	database.AfterCommit(ctx, func() {
+		err := cache.InvalidateTags(ctx, r.client, userTag(user.ID))
+		if err == nil {
+			err = r.byID.Delete(ctx, user.ID)
+		}
+		if err == nil && user.Username != "" {
+			err = r.byUsername.Delete(ctx, user.Username)
+		}
+		if err == nil && user.Email != "" {
+			err = r.byEmail.Delete(ctx, user.Email)
+		}
+		if err != nil {
+			r.log.WarnContext(ctx, "Failed to invalidate user cache", "user_id", user.ID, "error", err.Error())
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestCachedUserRepository(t *testing.T) (*gorm.DB, *miniredis.Miniredis, domain.UserRepository, *database.TxManager) {
+	t.Helper()
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.User{}))
+
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	repo := NewCachedUserRepository(NewUserRepository(db, pagination.NewCursors("test")), client, config.CacheConfig{Enabled: true, TTL: 60, NegativeTTL: 10}, log)
+	return db, server, repo, database.NewTxManager(db, database.TxOptions{Logger: log})
+}
+
+func TestCachedUserRepository(t *testing.T) {
+	db, server, repo, tx := newTestCachedUserRepository(t)
+	ctx := context.Background()
+
+	// 注册前查询缓存了“不存在”，创建后失效
+	_, err := repo.GetByUsername(ctx, "alice")
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.True(t, server.Exists("cache:user:username:alice"))
+
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "hashed"}
+	require.NoError(t, repo.Create(ctx, user))
+	assert.False(t, server.Exists("cache:user:username:alice"))
+
+	// 缓存中不保存密码哈希，需要哈希时读主库
+	cached, err := repo.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Empty(t, cached.Password)
+	data, err := server.Get("cache:user:username:alice")
+	require.NoError(t, err)
+	assert.NotContains(t, data, "hashed")
+	cached, err = repo.GetByUsername(database.ForcePrimary(ctx), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hashed", cached.Password)
+	_, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	// 绕过仓储直接修改数据库，缓存命中时仍返回旧值
+	require.NoError(t, db.Model(&model.User{}).Where("id = ?", user.ID).Update("nickname", "direct").Error)
+	cached, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, cached.Nickname)
+
+	// 强制读主库时跳过缓存
+	fresh, err := repo.GetByID(database.ForcePrimary(ctx), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "direct", fresh.Nickname)
+
+	// 事务中更新，提交后按标签失效修改前的用户名
+	err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		fresh.Username = "alice2"
+		if err := repo.Update(ctx, fresh); err != nil {
+			return err
+		}
+		assert.True(t, server.Exists("cache:user:username:alice"), "invalidated before commit")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.False(t, server.Exists("cache:user:username:alice"))
+	assert.False(t, server.Exists("cache:user:id:1"))
+
+	_, err = repo.GetByUsername(ctx, "alice")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	cached, err = repo.GetByUsername(ctx, "alice2")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, cached.ID)
+
+	require.NoError(t, repo.Delete(ctx, user.ID))
+	_, err = repo.GetByUsername(ctx, "alice2")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestUserRepositoryUpdateVersion(t *testing.T) {
+	_, _, repo, _ := newTestCachedUserRepository(t)
+	ctx := context.Background()
+
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "hashed"}
+	require.NoError(t, repo.Create(ctx, user))
+	assert.Equal(t, uint(1), user.Version)
+
+	// 两个请求读到同一版本，先写入的成功，后写入的冲突且不覆盖
+	first := *user
+	second := *user
+	first.Nickname = "first"
+	require.NoError(t, repo.Update(ctx, &first))
+	assert.Equal(t, uint(2), first.Version)
+
+	second.Nickname = "second"
+	require.ErrorIs(t, repo.Update(ctx, &second), domain.ErrVersionConflict)
+	assert.Equal(t, uint(1), second.Version)
+
+	current, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "first", current.Nickname)
+	assert.Equal(t, uint(2), current.Version)
+}
+
+func TestUserRepositoryInvalidPageRequest(t *testing.T) {
+	_, _, repo, _ := newTestCachedUserRepository(t)
+	ctx := context.Background()
+
+	// 不允许的排序和搜索字段是客户端错误，返回 INVALID_ARGUMENT
+	_, _, err := repo.GetAllWithPagination(ctx, &pagination.PageRequest{Page: 1, PageSize: 10, SortBy: "password"})
+	assert.ErrorIs(t, err, pagination.ErrInvalidSortField)
+	_, _, err = repo.GetAllWithCursor(ctx, &pagination.PageRequest{Limit: 10, SortBy: "password"})
+	assert.ErrorIs(t, err, pagination.ErrInvalidSortField)
+	_, _, err = repo.GetAllWithCursor(ctx, &pagination.PageRequest{Limit: 10, Keyword: "a", SearchBy: "password"})
+	assert.ErrorIs(t, err, pagination.ErrInvalidSearchField)
+	assert.Equal(t, apperror.CodeInvalidArgument, apperror.From(err).Code)
+	assert.Equal(t, "invalid search field: password", apperror.From(err).Message)
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
+)
+
+type eventRepository struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) domain.EventRepository {
+	return &eventRepository{db: db}
+}
+
+// Append 写入发件箱，处于事务中时使用同一事务
+func (r *eventRepository) Append(ctx context.Context, events ...*event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rows := make([]model.OutboxEvent, 0, len(events))
+	for _, e := range events {
+		rows = append(rows, toOutboxEvent(e))
+	}
+	return database.Conn(ctx, r.db).Create(&rows).Error
+}
+
+func (r *eventRepository) Pending(ctx context.Context, now time.Time, limit int) ([]*event.Event, error) {
+	// 投递状态在主库更新，从库复制延迟时会再次读到刚投递的事件导致重复投递
+	ctx = database.ForcePrimary(ctx)
+
+	var rows []model.OutboxEvent
+	err := database.Conn(ctx, r.db).
+		Where("status = ? AND available_at <= ?", event.StatusPending, now).
+		Order("occurred_at ASC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toEvents(rows), nil
+}
+
+func (r *eventRepository) Update(ctx context.Context, e *event.Event) error {
+	return database.Conn(ctx, r.db).Model(&model.OutboxEvent{ID: e.ID}).Updates(map[string]interface{}{
+		"status":       e.Status,
+		"attempts":     e.Attempts,
+		"last_error":   e.LastError,
+		"available_at": e.AvailableAt,
+		"published_at": e.PublishedAt,
+	}).Error
+}
+
+func (r *eventRepository) GetAllWithPagination(ctx context.Context, filter *domain.EventFilter, page *pagination.PageRequest) ([]*event.Event, int64, error) {
+	var rows []model.OutboxEvent
+	var total int64
+
+	query := database.Conn(ctx, r.db).Model(&model.OutboxEvent{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.AggregateType != "" {
+		query = query.Where("aggregate_type = ?", filter.AggregateType)
+	}
+	if filter.AggregateID != "" {
+		query = query.Where("aggregate_id = ?", filter.AggregateID)
+	}
+
+	// 获取总记录数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 添加排序
+	if page.HasSort() {
+		allowedFields := []string{"occurred_at", "type", "status", "attempts", "published_at"}
+		if !page.ValidateSortField(allowedFields) {
+			return nil, 0, fmt.Errorf("%w: %s", pagination.ErrInvalidSortField, page.GetSortBy())
+		}
+		sortClause := page.GetSortBy()
+		if page.GetSortOrder() == "desc" {
+			sortClause += " DESC"
+		} else {
+			sortClause += " ASC"
+		}
+		query = query.Order(sortClause)
+	} else {
+		query = query.Order("occurred_at DESC")
+	}
+
+	err := query.Offset(page.GetOffset()).Limit(page.GetLimit()).Find(&rows).Error
+	return toEvents(rows), total, err
+}
+
+func (r *eventRepository) GetByID(ctx context.Context, id string) (*event.Event, error) {
+	var row model.OutboxEvent
+	if err := database.Conn(ctx, r.db).Where("id = ?", id).First(&row).Error; err != nil {
+		return nil, err
+	}
+	return toEvent(&row), nil
+}
+
+func (r *eventRepository) Replay(ctx context.Context, id string) error {
+	result := database.Conn(ctx, r.db).Model(&model.OutboxEvent{ID: id}).Updates(map[string]interface{}{
+		"status":       event.StatusPending,
+		"attempts":     0,
+		"last_error":   "",
+		"available_at": time.Now(),
+		"published_at": nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *eventRepository) ListAfter(ctx context.Context, after time.Time, afterID string, aggregateTypes []string, limit int) ([]*event.Event, error) {
+	// 游标前进后不再回看，读从库时复制延迟中的事件会被跳过
+	ctx = database.ForcePrimary(ctx)
+
+	var rows []model.OutboxEvent
+	query := database.Conn(ctx, r.db).
+		Where("occurred_at > ? OR (occurred_at = ? AND id > ?)", after, after, afterID)
+	if len(aggregateTypes) > 0 {
+		query = query.Where("aggregate_type IN ?", aggregateTypes)
+	}
+	err := query.Order("occurred_at ASC, id ASC").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toEvents(rows), nil
+}
+
+func (r *eventRepository) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	result := database.Conn(ctx, r.db).
+		Where("status = ? AND published_at < ?", event.StatusPublished, before).
+		Delete(&model.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+func toOutboxEvent(e *event.Event) model.OutboxEvent {
+	return model.OutboxEvent{
+		ID:            e.ID,
+		Type:          e.Type,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		Payload:       string(e.Payload),
+		TraceID:       e.TraceID,
+		OccurredAt:    e.OccurredAt,
+		Status:        e.Status,
+		Attempts:      e.Attempts,
+		LastError:     e.LastError,
+		AvailableAt:   e.AvailableAt,
+		PublishedAt:   e.PublishedAt,
+	}
+}
+
+func toEvent(row *model.OutboxEvent) *event.Event {
+	payload := json.RawMessage(row.Payload)
+	if len(payload) == 0 {
+		payload = json.RawMessage("null")
+	}
+	return &event.Event{
+		ID:            row.ID,
+		Type:          row.Type,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Payload:       payload,
+		TraceID:       row.TraceID,
+		OccurredAt:    row.OccurredAt,
+		Status:        row.Status,
+		Attempts:      row.Attempts,
+		LastError:     row.LastError,
+		AvailableAt:   row.AvailableAt,
+		PublishedAt:   row.PublishedAt,
+	}
+}
+
+func toEvents(rows []model.OutboxEvent) []*event.Event {
+	events := make([]*event.Event, 0, len(rows))
+	for i := range rows {
+		events = append(events, toEvent(&rows[i]))
+	}
+	return events
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) domain.NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	if notification.DedupKey == nil {
+		return database.Conn(ctx, r.db).Create(notification).Error
+	}
+	// 去重键已存在时跳过写入
+	return database.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dedup_key"}},
+		DoNothing: true,
+	}).Create(notification).Error
+}
+
+func (r *notificationRepository) GetAllWithPagination(ctx context.Context, userID uint, unreadOnly bool, page *pagination.PageRequest) ([]model.Notification, int64, error) {
+	var notifications []model.Notification
+	var total int64
+
+	query := database.Conn(ctx, r.db).Model(&model.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	// 获取总记录数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 添加排序
+	if page.HasSort() {
+		allowedFields := []string{"id", "category", "created_at", "read_at"}
+		if !page.ValidateSortField(allowedFields) {
+			return nil, 0, fmt.Errorf("%w: %s", pagination.ErrInvalidSortField, page.GetSortBy())
+		}
+
+		sortClause := page.GetSortBy()
+		if page.GetSortOrder() == "desc" {
+			sortClause += " DESC"
+		} else {
+			sortClause += " ASC"
+		}
+		query = query.Order(sortClause)
+	} else {
+		// 默认按创建时间倒序
+		query = query.Order("created_at DESC")
+	}
+
+	err := query.Offset(page.GetOffset()).Limit(page.GetLimit()).Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *notificationRepository) CountUnreadByCategory(ctx context.Context, userID uint) (map[string]int64, error) {
+	var rows []struct {
+		Category string
+		Count    int64
+	}
+	err := database.Conn(ctx, r.db).Model(&model.Notification{}).
+		Select("category, COUNT(*) AS count").
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *notificationRepository) MarkAsRead(ctx context.Context, userID uint, ids []uint) (int64, error) {
+	result := database.Conn(ctx, r.db).Model(&model.Notification{}).
+		Where("user_id = ? AND id IN ? AND read_at IS NULL", userID, ids).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) MarkAllAsRead(ctx context.Context, userID uint) (int64, error) {
+	result := database.Conn(ctx, r.db).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) Delete(ctx context.Context, userID uint, id uint) error {
+	return database.Conn(ctx, r.db).Where("user_id = ?", userID).Delete(&model.Notification{}, id).Error
+}
+
+func (r *notificationRepository) PurgeRead(ctx context.Context, before time.Time) (int64, error) {
+	// 物理删除，同时清理早于保留期的软删除记录
+	result := database.Conn(ctx, r.db).Unscoped().
+		Where("read_at < ? OR deleted_at < ?", before, before).
+		Delete(&model.Notification{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) GetPreferences(ctx context.Context, userID uint) ([]model.NotificationPreference, error) {
+	var preferences []model.NotificationPreference
+	err := database.Conn(ctx, r.db).Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *notificationRepository) SavePreference(ctx context.Context, preference *model.NotificationPreference) error {
+	return database.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "push", "updated_at"}),
+	}).Create(preference).Error
+}
+
+func (r *notificationRepository) GetSetting(ctx context.Context, userID uint) (*model.NotificationSetting, error) {
+	var setting model.NotificationSetting
+	err := database.Conn(ctx, r.db).Where("user_id = ?", userID).First(&setting).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *notificationRepository) SaveSetting(ctx context.Context, setting *model.NotificationSetting) error {
+	return database.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quiet_hours_enabled", "quiet_hours_start", "quiet_hours_end", "timezone", "updated_at"}),
+	}).Create(setting).Error
+}
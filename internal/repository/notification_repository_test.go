@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestNotificationRepository(t *testing.T) (*gorm.DB, domain.NotificationRepository) {
+	t.Helper()
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.Notification{}, &model.NotificationPreference{}, &model.NotificationSetting{}))
+	return db, NewNotificationRepository(db)
+}
+
+func TestNotificationRepositoryInbox(t *testing.T) {
+	_, repo := newTestNotificationRepository(t)
+	ctx := context.Background()
+
+	for _, n := range []*model.Notification{
+		{UserID: 1, Category: "comment", Title: "c1"},
+		{UserID: 1, Category: "comment", Title: "c2"},
+		{UserID: 1, Category: "mention", Title: "m1"},
+		{UserID: 2, Category: "comment", Title: "other"},
+	} {
+		require.NoError(t, repo.Create(ctx, n))
+	}
+
+	counts, err := repo.CountUnreadByCategory(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"comment": 2, "mention": 1}, counts)
+
+	// 只能标记自己的通知，其他用户的 ID 被忽略
+	updated, err := repo.MarkAsRead(ctx, 1, []uint{1, 4})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	// 已读的通知不会重复标记
+	updated, err = repo.MarkAsRead(ctx, 1, []uint{1})
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+
+	page := &pagination.PageRequest{Page: 1, PageSize: 10}
+	unread, total, err := repo.GetAllWithPagination(ctx, 1, true, page)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, unread, 2)
+	all, total, err := repo.GetAllWithPagination(ctx, 1, false, &pagination.PageRequest{Page: 1, PageSize: 10, SortBy: "id", SortOrder: "asc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, "c1", all[0].Title)
+	assert.NotNil(t, all[0].ReadAt)
+
+	_, _, err = repo.GetAllWithPagination(ctx, 1, false, &pagination.PageRequest{Page: 1, PageSize: 10, SortBy: "title"})
+	assert.ErrorIs(t, err, pagination.ErrInvalidSortField)
+
+	updated, err = repo.MarkAllAsRead(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+	counts, err = repo.CountUnreadByCategory(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	// 其他用户的未读数不受影响
+	counts, err = repo.CountUnreadByCategory(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"comment": 1}, counts)
+}
+
+func TestNotificationRepositoryDeleteAndPurge(t *testing.T) {
+	db, repo := newTestNotificationRepository(t)
+	ctx := context.Background()
+
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, repo.Create(ctx, &model.Notification{UserID: 1, Category: "system", Title: "read", ReadAt: &old}))
+	require.NoError(t, repo.Create(ctx, &model.Notification{UserID: 1, Category: "system", Title: "unread"}))
+	require.NoError(t, repo.Create(ctx, &model.Notification{UserID: 2, Category: "system", Title: "other"}))
+
+	// 删除其他用户的通知不生效
+	require.NoError(t, repo.Delete(ctx, 1, 3))
+	_, total, err := repo.GetAllWithPagination(ctx, 2, false, &pagination.PageRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	require.NoError(t, repo.Delete(ctx, 1, 2))
+	_, total, err = repo.GetAllWithPagination(ctx, 1, false, &pagination.PageRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// 刚删除的通知还在保留期内，只清理早于保留期的已读通知
+	purged, err := repo.PurgeRead(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	var remaining int64
+	require.NoError(t, db.Unscoped().Model(&model.Notification{}).Count(&remaining).Error)
+	assert.Equal(t, int64(2), remaining)
+}
+
+func TestNotificationRepositoryPreferences(t *testing.T) {
+	_, repo := newTestNotificationRepository(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.SavePreference(ctx, &model.NotificationPreference{UserID: 1, Category: "comment", InApp: true}))
+	// 同一类别再次保存时更新开关
+	require.NoError(t, repo.SavePreference(ctx, &model.NotificationPreference{UserID: 1, Category: "comment", Email: true}))
+	require.NoError(t, repo.SavePreference(ctx, &model.NotificationPreference{UserID: 2, Category: "comment", Push: true}))
+
+	preferences, err := repo.GetPreferences(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, preferences, 1)
+	assert.False(t, preferences[0].InApp)
+	assert.True(t, preferences[0].Email)
+
+	_, err = repo.GetSetting(ctx, 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, repo.SaveSetting(ctx, &model.NotificationSetting{UserID: 1, QuietHoursEnabled: true, QuietHoursStart: "22:00", QuietHoursEnd: "07:00", Timezone: "UTC"}))
+	require.NoError(t, repo.SaveSetting(ctx, &model.NotificationSetting{UserID: 1, QuietHoursStart: "23:00", QuietHoursEnd: "06:00", Timezone: "Asia/Shanghai"}))
+
+	setting, err := repo.GetSetting(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, setting.QuietHoursEnabled)
+	assert.Equal(t, "23:00", setting.QuietHoursStart)
+	assert.Equal(t, "Asia/Shanghai", setting.Timezone)
+}
@@ -8,37 +8,44 @@ import (
 	"github.com/chenyl99x/toge-api/internal/model"
 	"github.com/chenyl99x/toge-api/pkg/database"
 	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type spaceRepository struct {
+	db *gorm.DB
+	tx *database.TxManager
 }
 
 func (s spaceRepository) Create(ctx context.Context, space *model.Space) error {
-	return database.DB.WithContext(ctx).Create(space).Error
+	// 新记录从版本 1 开始
+	space.Version = 1
+	return database.Conn(ctx, s.db).Create(space).Error
 }
 
 func (s spaceRepository) GetByID(ctx context.Context, id uint) (*model.Space, error) {
 
 	var space model.Space
-	return &space, database.DB.WithContext(ctx).First(&space, id).Error
+	return &space, database.Preload(ctx, database.Conn(ctx, s.db)).First(&space, id).Error
 }
 
 func (s spaceRepository) GetAll(ctx context.Context) ([]model.Space, error) {
 	var spaces []model.Space
-	return spaces, database.DB.WithContext(ctx).Find(&spaces).Error
+	return spaces, database.Conn(ctx, s.db).Find(&spaces).Error
 }
 
 func (s spaceRepository) GetAllWithPagination(ctx context.Context, page *pagination.PageRequest) ([]model.Space, int64, error) {
 
 	var spaces []model.Space
 	var total int64
-	query := database.DB.WithContext(ctx)
+	query := database.Conn(ctx, s.db)
 
 	if page.HasSort() {
 		// 验证排序字段
 		allowedFields := []string{"id", "name", "created_at", "updated_at"}
 		if !page.ValidateSortField(allowedFields) {
-			return nil, 0, fmt.Errorf("invalid sort field: %s", page.GetSortBy())
+			return nil, 0, fmt.Errorf("%w: %s", pagination.ErrInvalidSortField, page.GetSortBy())
 		}
 
 		// 构建排序语句
@@ -57,19 +64,61 @@ func (s spaceRepository) GetAllWithPagination(ctx context.Context, page *paginat
 	// 获取分页数据
 	offset := page.GetOffset()
 	limit := page.GetLimit()
-	err := query.Offset(offset).Limit(limit).Find(&spaces).Error
+	err := database.Preload(ctx, query).Offset(offset).Limit(limit).Find(&spaces).Error
 
 	return spaces, total, err
 }
 
-func (s spaceRepository) Update(ctx context.Context, Space *model.Space) error {
-	return database.DB.WithContext(ctx).Updates(Space).Error
+func (s spaceRepository) GetIDsByUser(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	conn := database.Conn(ctx, s.db)
+	joined := conn.Model(&model.SpaceMember{}).Select("space_id").Where("user_id = ?", userID)
+	return ids, conn.Model(&model.Space{}).Where("owner_user_id = ? OR id IN (?)", userID, joined).Pluck("id", &ids).Error
+}
+
+func (s spaceRepository) Update(ctx context.Context, space *model.Space) error {
+	// 只有版本号未变时才写入，写入所有字段并把版本号加一，PATCH 清空的描述也会写入
+	version := space.Version
+	space.Version++
+	result := database.Conn(ctx, s.db).Model(space).Where("version = ?", version).Select("*").Omit(clause.Associations).Updates(space)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = domain.ErrVersionConflict
+	}
+	if result.Error != nil {
+		space.Version = version
+	}
+	return result.Error
 }
 
 func (s spaceRepository) Delete(ctx context.Context, id uint) error {
-	return database.DB.WithContext(ctx).Delete(&model.Space{}, id).Error
+	// 成员记录和岛屿一起删除，不留下指向已删除岛屿的成员
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := database.Conn(ctx, s.db).Where("space_id = ?", id).Delete(&model.SpaceMember{}).Error; err != nil {
+			return err
+		}
+		return database.Conn(ctx, s.db).Delete(&model.Space{}, id).Error
+	})
 }
 
-func NewSpaceRepository() domain.SpaceRepository {
-	return &spaceRepository{}
+func (s spaceRepository) AddMember(ctx context.Context, member *model.SpaceMember) error {
+	return database.Conn(ctx, s.db).Create(member).Error
+}
+
+func (s spaceRepository) GetMembers(ctx context.Context, spaceID uint) ([]model.SpaceMember, error) {
+	var members []model.SpaceMember
+	return members, database.Conn(ctx, s.db).Where("space_id = ?", spaceID).Order("created_at, id").Find(&members).Error
+}
+
+func (s spaceRepository) IsMember(ctx context.Context, spaceID, userID uint) (bool, error) {
+	var count int64
+	err := database.Conn(ctx, s.db).Model(&model.SpaceMember{}).Where("space_id = ? AND user_id = ?", spaceID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+func NewSpaceRepository(db *gorm.DB, tx *database.TxManager) domain.SpaceRepository {
+	// 成员关联通过成员模型读取中间表
+	if err := db.SetupJoinTable(&model.Space{}, "Members", &model.SpaceMember{}); err != nil {
+		panic(fmt.Sprintf("setup space member join table: %v", err))
+	}
+	return &spaceRepository{db: db, tx: tx}
 }
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/fieldset"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSpaceRepositoryPreloadsMembers(t *testing.T) {
+	db, repo := newSpaceRepository(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"owner", "alice", "bob", "carol"} {
+		require.NoError(t, db.Create(&model.User{Username: name, Email: name + "@example.com", Password: "hashed"}).Error)
+	}
+	space := &model.Space{Name: "island", OwnerUserID: 1, Type: "家庭空间"}
+	require.NoError(t, repo.Create(ctx, space))
+	for _, userID := range []uint{2, 3, 4} {
+		require.NoError(t, repo.AddMember(ctx, &model.SpaceMember{SpaceID: space.ID, UserID: userID}))
+	}
+	// 离开的成员不会被展开
+	require.NoError(t, db.Where("user_id = ?", 4).Delete(&model.SpaceMember{}).Error)
+
+	// 只有要求展开时才加载成员
+	got, err := repo.GetByID(ctx, space.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.Members)
+
+	sel, err := domain.SpaceFieldset.Parse(url.Values{fieldset.ExpandParam: {"members"}})
+	require.NoError(t, err)
+	got, err = repo.GetByID(database.WithPreload(ctx, sel.Preloads()...), space.ID)
+	require.NoError(t, err)
+	var usernames []string
+	for _, member := range got.Members {
+		usernames = append(usernames, member.Username)
+	}
+	assert.ElementsMatch(t, []string{"alice", "bob"}, usernames)
+
+	// 成员按用户白名单输出，不包含密码
+	projected, err := sel.Project(got)
+	require.NoError(t, err)
+	data, err := json.Marshal(projected)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"username":"alice"`)
+	assert.NotContains(t, string(data), "hashed")
+	assert.NotContains(t, string(data), "password")
+}
+
+func TestSpaceRepositoryMembersAreHardDeleted(t *testing.T) {
+	db, repo := newSpaceRepository(t)
+	ctx := context.Background()
+
+	space := &model.Space{Name: "island", OwnerUserID: 1, Type: "家庭空间"}
+	require.NoError(t, repo.Create(ctx, space))
+	require.NoError(t, repo.AddMember(ctx, &model.SpaceMember{SpaceID: space.ID, UserID: 2}))
+	assert.ErrorIs(t, repo.AddMember(ctx, &model.SpaceMember{SpaceID: space.ID, UserID: 2}), gorm.ErrDuplicatedKey)
+
+	// 离开后可以重新加入，唯一索引不会被已删除的记录占用
+	require.NoError(t, db.Where("space_id = ? AND user_id = ?", space.ID, 2).Delete(&model.SpaceMember{}).Error)
+	require.NoError(t, repo.AddMember(ctx, &model.SpaceMember{SpaceID: space.ID, UserID: 2}))
+
+	// 删除岛屿时一起删除成员
+	require.NoError(t, repo.Delete(ctx, space.ID))
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&model.SpaceMember{}).Where("space_id = ?", space.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func newSpaceRepository(t *testing.T) (*gorm.DB, domain.SpaceRepository) {
+	t.Helper()
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Space{}, &model.SpaceMember{}))
+	return db, NewSpaceRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log}))
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+type userImportRepository struct {
+	db *gorm.DB
+}
+
+func NewUserImportRepository(db *gorm.DB) domain.UserImportRepository {
+	return &userImportRepository{db: db}
+}
+
+func (r *userImportRepository) Create(ctx context.Context, job *model.UserImportJob) error {
+	return database.Conn(ctx, r.db).Create(job).Error
+}
+
+func (r *userImportRepository) GetByID(ctx context.Context, id uint) (*model.UserImportJob, error) {
+	var job model.UserImportJob
+	return &job, database.Conn(ctx, r.db).First(&job, id).Error
+}
+
+func (r *userImportRepository) Update(ctx context.Context, job *model.UserImportJob) error {
+	return database.Conn(ctx, r.db).Save(job).Error
+}
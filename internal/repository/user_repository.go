@@ -8,21 +8,32 @@ import (
 	"github.com/chenyl99x/toge-api/internal/model"
 	"github.com/chenyl99x/toge-api/pkg/database"
 	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type userRepository struct{}
+// userSortFields 用户列表允许的排序字段
+var userSortFields = []string{"id", "username", "email", "nickname", "status", "created_at", "updated_at"}
+
+type userRepository struct {
+	db      *gorm.DB
+	cursors *pagination.Cursors
+}
 
-func NewUserRepository() domain.UserRepository {
-	return &userRepository{}
+func NewUserRepository(db *gorm.DB, cursors *pagination.Cursors) domain.UserRepository {
+	return &userRepository{db: db, cursors: cursors}
 }
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
-	return database.DB.WithContext(ctx).Create(user).Error
+	// 新记录从版本 1 开始
+	user.Version = 1
+	return database.Conn(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	err := database.DB.WithContext(ctx).First(&user, id).Error
+	err := database.Preload(ctx, database.Conn(ctx, r.db)).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +42,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, err
 
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := database.DB.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	err := database.Conn(ctx, r.db).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +51,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := database.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := database.Conn(ctx, r.db).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +60,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 
 func (r *userRepository) GetAll(ctx context.Context) ([]model.User, error) {
 	var users []model.User
-	err := database.DB.WithContext(ctx).Find(&users).Error
+	err := database.Conn(ctx, r.db).Find(&users).Error
 	return users, err
 }
 
@@ -58,27 +69,9 @@ func (r *userRepository) GetAllWithPagination(ctx context.Context, page *paginat
 	var total int64
 
 	// 构建查询
-	query := database.DB.WithContext(ctx)
-
-	// 添加搜索条件
-	if page.HasSearch() {
-		// 验证搜索字段
-		allowedSearchFields := []string{"username", "email", "nickname"}
-		if !page.ValidateSearchField(allowedSearchFields) {
-			return nil, 0, fmt.Errorf("invalid search field: %s", page.GetSearchBy())
-		}
-
-		// 如果指定了搜索字段，使用该字段进行搜索
-		if page.GetSearchBy() != "" {
-			searchField := page.GetSearchBy()
-			keyword := page.GetKeyword()
-			query = query.Where(fmt.Sprintf("%s LIKE ?", searchField), "%"+keyword+"%")
-		} else {
-			// 如果没有指定搜索字段，在所有可搜索字段中搜索
-			keyword := page.GetKeyword()
-			query = query.Where("username LIKE ? OR email LIKE ? OR nickname LIKE ?",
-				"%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
-		}
+	query, err := r.conditions(database.Conn(ctx, r.db), page)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// 获取总记录数
@@ -89,9 +82,8 @@ func (r *userRepository) GetAllWithPagination(ctx context.Context, page *paginat
 	// 添加排序
 	if page.HasSort() {
 		// 验证排序字段
-		allowedFields := []string{"id", "username", "email", "nickname", "status", "created_at", "updated_at"}
-		if !page.ValidateSortField(allowedFields) {
-			return nil, 0, fmt.Errorf("invalid sort field: %s", page.GetSortBy())
+		if !page.ValidateSortField(userSortFields) {
+			return nil, 0, fmt.Errorf("%w: %s", pagination.ErrInvalidSortField, page.GetSortBy())
 		}
 
 		// 构建排序语句
@@ -110,15 +102,83 @@ func (r *userRepository) GetAllWithPagination(ctx context.Context, page *paginat
 	// 获取分页数据
 	offset := page.GetOffset()
 	limit := page.GetLimit()
-	err := query.Offset(offset).Limit(limit).Find(&users).Error
+	err = database.Preload(ctx, query).Offset(offset).Limit(limit).Find(&users).Error
 
 	return users, total, err
 }
 
+func (r *userRepository) GetAllWithCursor(ctx context.Context, page *pagination.PageRequest) ([]model.User, *pagination.CursorPage, error) {
+	// 与页码分页相同，默认按创建时间倒序
+	q, err := r.cursors.Query(page, "created_at", userSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	query, err := r.conditions(database.Conn(ctx, r.db), page)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pagination.FindCursorPage[model.User](database.Preload(ctx, query), q)
+}
+
+func (r *userRepository) FindInBatches(ctx context.Context, page *pagination.PageRequest, batchSize int, fn func(users []model.User) error) error {
+	query, err := r.conditions(database.Conn(ctx, r.db), page)
+	if err != nil {
+		return err
+	}
+	var users []model.User
+	return query.FindInBatches(&users, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(users)
+	}).Error
+}
+
+// conditions 添加搜索和过滤条件
+func (r *userRepository) conditions(query *gorm.DB, page *pagination.PageRequest) (*gorm.DB, error) {
+	f, err := domain.UserFilters.Parse(page.Filters)
+	if err != nil {
+		return nil, err
+	}
+	query = f.Scope(query)
+
+	if page.HasSearch() {
+		// 验证搜索字段
+		allowedSearchFields := []string{"username", "email", "nickname"}
+		if !page.ValidateSearchField(allowedSearchFields) {
+			return nil, fmt.Errorf("%w: %s", pagination.ErrInvalidSearchField, page.GetSearchBy())
+		}
+
+		// PostgreSQL 使用 ILIKE，与 MySQL 一样不区分大小写
+		like := database.LikeOperator(r.db)
+
+		// 如果指定了搜索字段，使用该字段进行搜索
+		if page.GetSearchBy() != "" {
+			searchField := page.GetSearchBy()
+			keyword := page.GetKeyword()
+			query = query.Where(fmt.Sprintf("%s %s ?", searchField, like), "%"+keyword+"%")
+		} else {
+			// 如果没有指定搜索字段，在所有可搜索字段中搜索
+			keyword := page.GetKeyword()
+			query = query.Where(fmt.Sprintf("username %[1]s ? OR email %[1]s ? OR nickname %[1]s ?", like),
+				"%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
+		}
+	}
+
+	return query, nil
+}
+
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
-	return database.DB.WithContext(ctx).Save(user).Error
+	// 只有版本号未变时才写入，写入所有字段并把版本号加一
+	version := user.Version
+	user.Version++
+	result := database.Conn(ctx, r.db).Model(user).Where("version = ?", version).Select("*").Omit(clause.Associations).Updates(user)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = domain.ErrVersionConflict
+	}
+	if result.Error != nil {
+		user.Version = version
+	}
+	return result.Error
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
-	return database.DB.WithContext(ctx).Delete(&model.User{}, id).Error
+	return database.Conn(ctx, r.db).Delete(&model.User{}, id).Error
 }
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+	tx *database.TxManager
+}
+
+func NewWebhookRepository(db *gorm.DB, tx *database.TxManager) domain.WebhookRepository {
+	return &webhookRepository{db: db, tx: tx}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, hook *model.Webhook) error {
+	return database.Conn(ctx, r.db).Create(hook).Error
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id uint) (*model.Webhook, error) {
+	var hook model.Webhook
+	if err := database.Conn(ctx, r.db).First(&hook, id).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) GetByOwner(ctx context.Context, ownerType string, ownerID uint) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	err := database.Conn(ctx, r.db).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("id ASC").
+		Find(&hooks).Error
+	return hooks, err
+}
+
+func (r *webhookRepository) GetEnabledByOwner(ctx context.Context, ownerType string, ownerID uint) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	err := database.Conn(ctx, r.db).
+		Where("owner_type = ? AND owner_id = ? AND enabled = ?", ownerType, ownerID, true).
+		Find(&hooks).Error
+	return hooks, err
+}
+
+func (r *webhookRepository) Update(ctx context.Context, hook *model.Webhook) error {
+	// 使用 Select 保证 enabled=false 等零值也会被更新
+	return database.Conn(ctx, r.db).Model(hook).
+		Select("url", "secret", "events", "description", "enabled", "consecutive_failures", "disabled_at", "disabled_reason").
+		Updates(hook).Error
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id uint) error {
+	return database.Conn(ctx, r.db).Delete(&model.Webhook{}, id).Error
+}
+
+func (r *webhookRepository) RecordResult(ctx context.Context, id uint, success bool, disableAfter int) (*model.Webhook, error) {
+	var hook model.Webhook
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// 加行锁，避免并发投递时计数丢失
+		if err := database.Conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&hook, id).Error; err != nil {
+			return err
+		}
+
+		if success {
+			hook.ConsecutiveFailures = 0
+		} else {
+			hook.ConsecutiveFailures++
+			if disableAfter > 0 && hook.ConsecutiveFailures >= disableAfter && hook.Enabled {
+				now := time.Now()
+				hook.Enabled = false
+				hook.DisabledAt = &now
+				hook.DisabledReason = fmt.Sprintf("disabled after %d consecutive failures", hook.ConsecutiveFailures)
+			}
+		}
+
+		return database.Conn(ctx, r.db).Model(&hook).
+			Select("consecutive_failures", "enabled", "disabled_at", "disabled_reason").
+			Updates(&hook).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return database.Conn(ctx, r.db).Create(delivery).Error
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, webhookID uint, id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := database.Conn(ctx, r.db).Where("webhook_id = ?", webhookID).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveriesWithPagination(ctx context.Context, webhookID uint, page *pagination.PageRequest) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+
+	query := database.Conn(ctx, r.db).Model(&model.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	// 获取总记录数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 添加排序
+	if page.HasSort() {
+		allowedFields := []string{"id", "event_type", "status", "created_at"}
+		if !page.ValidateSortField(allowedFields) {
+			return nil, 0, fmt.Errorf("%w: %s", pagination.ErrInvalidSortField, page.GetSortBy())
+		}
+
+		sortClause := page.GetSortBy()
+		if page.GetSortOrder() == "desc" {
+			sortClause += " DESC"
+		} else {
+			sortClause += " ASC"
+		}
+		query = query.Order(sortClause)
+	} else {
+		query = query.Order("id DESC")
+	}
+
+	err := query.Offset(page.GetOffset()).Limit(page.GetLimit()).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *webhookRepository) CountAttempts(ctx context.Context, webhookID uint, eventID string) (int64, error) {
+	var count int64
+	err := database.Conn(ctx, r.db).Model(&model.WebhookDelivery{}).
+		Where("webhook_id = ? AND event_id = ?", webhookID, eventID).
+		Count(&count).Error
+	return count, err
+}
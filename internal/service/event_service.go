@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+)
+
+type eventService struct {
+	repo domain.EventRepository
+	log  *slog.Logger
+}
+
+func NewEventService(repo domain.EventRepository, log *slog.Logger) domain.EventService {
+	return &eventService{repo: repo, log: log}
+}
+
+func (s *eventService) GetAllWithPagination(ctx context.Context, filter *domain.EventFilter, page *pagination.PageRequest) (*pagination.PageResponse, error) {
+	events, total, err := s.repo.GetAllWithPagination(ctx, filter, page)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get events with pagination", "error", err.Error(), "status", filter.Status, "type", filter.Type)
+		return nil, err
+	}
+
+	s.log.InfoContext(ctx, "Events retrieved with pagination", "count", len(events), "total", total, "page", page.Page, "pageSize", page.PageSize)
+	return pagination.NewPageResponse(events, total, page.Page, page.PageSize), nil
+}
+
+func (s *eventService) GetByID(ctx context.Context, id string) (*event.Event, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get event by ID", "error", err.Error(), "event_id", id)
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *eventService) Replay(ctx context.Context, id string) (*event.Event, error) {
+	if err := s.repo.Replay(ctx, id); err != nil {
+		s.log.ErrorContext(ctx, "Failed to replay event", "error", err.Error(), "event_id", id)
+		return nil, err
+	}
+
+	s.log.InfoContext(ctx, "Event scheduled for replay", "event_id", id)
+	return s.repo.GetByID(ctx, id)
+}
+
+// publish 创建领域事件并写入发件箱，需要在业务数据所在的事务中调用
+func publish(ctx context.Context, events domain.EventRepository, eventType, aggregateType string, aggregateID uint, payload any) error {
+	e, err := event.New(ctx, eventType, aggregateType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+	return events.Append(ctx, e)
+}
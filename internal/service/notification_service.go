@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/chenyl99x/toge-api/internal/consts"
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/notifier"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
+)
+
+type notificationService struct {
+	repo       domain.NotificationRepository
+	userRepo   domain.UserRepository
+	dispatcher *notifier.Dispatcher
+	timezone   string
+	log        *slog.Logger
+}
+
+func NewNotificationService(repo domain.NotificationRepository, userRepo domain.UserRepository, dispatcher *notifier.Dispatcher, timezoneConfig config.TimezoneConfig, log *slog.Logger) domain.NotificationService {
+	return &notificationService{repo: repo, userRepo: userRepo, dispatcher: dispatcher, timezone: timezoneConfig.Timezone, log: log}
+}
+
+func (s *notificationService) Notify(ctx context.Context, req *domain.NotifyRequest) error {
+	if !req.Category.IsValid() {
+		return fmt.Errorf("invalid notification category: %s", req.Category)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get notification recipient", "error", err.Error(), "user_id", req.UserID)
+		return err
+	}
+
+	pref, err := s.loadPreference(ctx, req.UserID, req.Category)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to load notification preference", "error", err.Error(), "user_id", req.UserID)
+		return err
+	}
+
+	msg := &notifier.Message{
+		Recipient: notifier.Recipient{UserID: user.ID, Email: user.Email},
+		Category:  string(req.Category),
+		Title:     req.Title,
+		Content:   req.Content,
+		Link:      req.Link,
+		DedupKey:  req.DedupKey,
+	}
+
+	result, err := s.dispatcher.Dispatch(ctx, msg, pref)
+	if err != nil {
+		failed := map[string]string{}
+		if result != nil {
+			for channel, e := range result.Failed {
+				failed[channel] = e.Error()
+			}
+		}
+		s.log.ErrorContext(ctx, "Failed to deliver notification", "error", err.Error(), "user_id", req.UserID, "category", req.Category, "failed", failed)
+		return err
+	}
+
+	s.log.InfoContext(ctx, "Notification delivered", "user_id", req.UserID, "category", req.Category, "delivered", result.Delivered, "skipped", result.Skipped)
+	return nil
+}
+
+func (s *notificationService) GetAllWithPagination(ctx context.Context, userID uint, unreadOnly bool, page *pagination.PageRequest) (*pagination.PageResponse, error) {
+	notifications, total, err := s.repo.GetAllWithPagination(ctx, userID, unreadOnly, page)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get notifications with pagination", "error", err.Error(), "user_id", userID, "page", page.Page, "pageSize", page.PageSize)
+		return nil, err
+	}
+
+	pageResponse := pagination.NewPageResponse(notifications, total, page.Page, page.PageSize)
+	s.log.InfoContext(ctx, "Notifications retrieved with pagination", "user_id", userID, "count", len(notifications), "total", total)
+	return pageResponse, nil
+}
+
+func (s *notificationService) GetUnreadCount(ctx context.Context, userID uint) (*domain.UnreadCountResponse, error) {
+	counts, err := s.repo.CountUnreadByCategory(ctx, userID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to count unread notifications", "error", err.Error(), "user_id", userID)
+		return nil, err
+	}
+
+	resp := &domain.UnreadCountResponse{ByCategory: counts}
+	for _, count := range counts {
+		resp.Total += count
+	}
+	return resp, nil
+}
+
+func (s *notificationService) MarkAsRead(ctx context.Context, userID uint, ids []uint) (int64, error) {
+	updated, err := s.repo.MarkAsRead(ctx, userID, ids)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to mark notifications as read", "error", err.Error(), "user_id", userID, "ids", ids)
+		return 0, err
+	}
+	s.log.InfoContext(ctx, "Notifications marked as read", "user_id", userID, "updated", updated)
+	return updated, nil
+}
+
+func (s *notificationService) MarkAllAsRead(ctx context.Context, userID uint) (int64, error) {
+	updated, err := s.repo.MarkAllAsRead(ctx, userID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to mark all notifications as read", "error", err.Error(), "user_id", userID)
+		return 0, err
+	}
+	s.log.InfoContext(ctx, "All notifications marked as read", "user_id", userID, "updated", updated)
+	return updated, nil
+}
+
+func (s *notificationService) Delete(ctx context.Context, userID uint, id uint) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		s.log.ErrorContext(ctx, "Failed to delete notification", "error", err.Error(), "user_id", userID, "id", id)
+		return err
+	}
+	s.log.InfoContext(ctx, "Notification deleted successfully", "user_id", userID, "id", id)
+	return nil
+}
+
+func (s *notificationService) GetPreferences(ctx context.Context, userID uint) (*domain.NotificationPreferencesResponse, error) {
+	preferences, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get notification preferences", "error", err.Error(), "user_id", userID)
+		return nil, err
+	}
+
+	saved := make(map[string]model.NotificationPreference, len(preferences))
+	for _, p := range preferences {
+		saved[p.Category] = p
+	}
+
+	resp := &domain.NotificationPreferencesResponse{}
+	for _, category := range consts.NotificationCategories {
+		p, ok := saved[string(category)]
+		if !ok {
+			p = defaultPreference(userID, category)
+		}
+		resp.Categories = append(resp.Categories, domain.CategoryPreference{
+			Category: p.Category,
+			InApp:    p.InApp,
+			Email:    p.Email,
+			Push:     p.Push,
+		})
+	}
+
+	setting, err := s.getSetting(ctx, userID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get notification setting", "error", err.Error(), "user_id", userID)
+		return nil, err
+	}
+	resp.QuietHours = domain.QuietHours{
+		Enabled:  setting.QuietHoursEnabled,
+		Start:    setting.QuietHoursStart,
+		End:      setting.QuietHoursEnd,
+		Timezone: setting.Timezone,
+	}
+
+	return resp, nil
+}
+
+func (s *notificationService) UpdatePreferences(ctx context.Context, userID uint, req *domain.UpdateNotificationPreferencesRequest) (*domain.NotificationPreferencesResponse, error) {
+	for _, p := range req.Categories {
+		preference := &model.NotificationPreference{
+			UserID:   userID,
+			Category: p.Category,
+			InApp:    p.InApp,
+			Email:    p.Email,
+			Push:     p.Push,
+		}
+		if err := s.repo.SavePreference(ctx, preference); err != nil {
+			s.log.ErrorContext(ctx, "Failed to save notification preference", "error", err.Error(), "user_id", userID, "category", p.Category)
+			return nil, err
+		}
+	}
+
+	if req.QuietHours != nil {
+		setting := &model.NotificationSetting{
+			UserID:            userID,
+			QuietHoursEnabled: req.QuietHours.Enabled,
+			QuietHoursStart:   req.QuietHours.Start,
+			QuietHoursEnd:     req.QuietHours.End,
+			Timezone:          req.QuietHours.Timezone,
+		}
+		if setting.Timezone == "" {
+			setting.Timezone = s.timezone
+		}
+		if err := s.repo.SaveSetting(ctx, setting); err != nil {
+			s.log.ErrorContext(ctx, "Failed to save notification setting", "error", err.Error(), "user_id", userID)
+			return nil, err
+		}
+	}
+
+	s.log.InfoContext(ctx, "Notification preferences updated", "user_id", userID)
+	return s.GetPreferences(ctx, userID)
+}
+
+// loadPreference 组装用户在某一类别下的投递偏好
+func (s *notificationService) loadPreference(ctx context.Context, userID uint, category consts.NotificationCategory) (*notifier.Preference, error) {
+	preferences, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := defaultPreference(userID, category)
+	for _, saved := range preferences {
+		if saved.Category == string(category) {
+			p = saved
+			break
+		}
+	}
+
+	pref := &notifier.Preference{
+		Channels: map[string]bool{
+			notifier.ChannelInApp: p.InApp,
+			notifier.ChannelEmail: p.Email,
+			notifier.ChannelPush:  p.Push,
+		},
+	}
+
+	setting, err := s.getSetting(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if setting.QuietHoursEnabled {
+		pref.QuietHours = &notifier.QuietHours{
+			Start:    setting.QuietHoursStart,
+			End:      setting.QuietHoursEnd,
+			Timezone: setting.Timezone,
+		}
+	}
+	return pref, nil
+}
+
+// getSetting 获取用户通知设置，不存在时返回默认设置
+func (s *notificationService) getSetting(ctx context.Context, userID uint) (*model.NotificationSetting, error) {
+	setting, err := s.repo.GetSetting(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.NotificationSetting{
+			UserID:          userID,
+			QuietHoursStart: "22:00",
+			QuietHoursEnd:   "07:00",
+			Timezone:        s.timezone,
+		}, nil
+	}
+	return setting, err
+}
+
+// defaultPreference 默认开启站内信和推送，关闭邮件
+func defaultPreference(userID uint, category consts.NotificationCategory) model.NotificationPreference {
+	return model.NotificationPreference{
+		UserID:   userID,
+		Category: string(category),
+		InApp:    true,
+		Email:    false,
+		Push:     true,
+	}
+}
+
+// inAppChannel 站内信渠道，将消息写入用户收件箱
+type inAppChannel struct {
+	repo domain.NotificationRepository
+}
+
+// NewInAppChannel 创建站内信渠道
+func NewInAppChannel(repo domain.NotificationRepository) notifier.Channel {
+	return &inAppChannel{repo: repo}
+}
+
+func (c *inAppChannel) Name() string {
+	return notifier.ChannelInApp
+}
+
+func (c *inAppChannel) Send(ctx context.Context, msg *notifier.Message) error {
+	notification := &model.Notification{
+		UserID:   msg.Recipient.UserID,
+		Category: msg.Category,
+		Title:    msg.Title,
+		Content:  msg.Content,
+		Link:     msg.Link,
+	}
+	// 其他渠道失败导致任务重试时，已写入的站内信不会重复写入
+	if msg.DedupKey != "" {
+		notification.DedupKey = &msg.DedupKey
+	}
+	return c.repo.Create(ctx, notification)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/consts"
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/internal/repository"
+	"github.com/chenyl99x/toge-api/internal/task"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/notifier"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNotificationService struct {
+	domain.NotificationService
+	users domain.UserService
+	email *notifier.FakeChannel
+	push  *notifier.FakeChannel
+	user  *model.User
+}
+
+func newTestNotificationService(t *testing.T) *testNotificationService {
+	t.Helper()
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Notification{}, &model.NotificationPreference{}, &model.NotificationSetting{}, &model.OutboxEvent{}))
+
+	userRepo := repository.NewUserRepository(db, pagination.NewCursors("test"))
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "hashed"}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	repo := repository.NewNotificationRepository(db)
+	email := notifier.NewFakeChannel(notifier.ChannelEmail)
+	push := notifier.NewFakeChannel(notifier.ChannelPush)
+	dispatcher := notifier.NewDispatcher(NewInAppChannel(repo), email, push)
+	svc := NewNotificationService(repo, userRepo, dispatcher, config.TimezoneConfig{Timezone: "Asia/Shanghai"}, log)
+	users := NewUserService(database.NewTxManager(db, database.TxOptions{Logger: log}), userRepo, repository.NewEventRepository(db), log)
+	return &testNotificationService{NotificationService: svc, users: users, email: email, push: push, user: user}
+}
+
+func TestNotificationServiceNotify(t *testing.T) {
+	s := newTestNotificationService(t)
+	ctx := context.Background()
+
+	err := s.Notify(ctx, &domain.NotifyRequest{UserID: s.user.ID, Category: "unknown", Title: "x"})
+	assert.Error(t, err)
+
+	// 默认偏好投递站内信和推送，不发邮件
+	req := &domain.NotifyRequest{UserID: s.user.ID, Category: consts.NotificationCategoryComment, Title: "新评论", Content: "小明评论了你的动态"}
+	require.NoError(t, s.Notify(ctx, req))
+	assert.Len(t, s.push.Messages(), 1)
+	assert.Empty(t, s.email.Messages())
+
+	inbox, err := s.GetAllWithPagination(ctx, s.user.ID, true, &pagination.PageRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), inbox.Total)
+	assert.Equal(t, "新评论", inbox.Data.([]model.Notification)[0].Title)
+
+	// 开启邮件后邮件渠道收到收件人地址
+	_, err = s.UpdatePreferences(ctx, s.user.ID, &domain.UpdateNotificationPreferencesRequest{
+		Categories: []domain.CategoryPreference{{Category: string(consts.NotificationCategoryComment), InApp: true, Email: true}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Notify(ctx, req))
+	require.Len(t, s.email.Messages(), 1)
+	assert.Equal(t, "alice@example.com", s.email.Messages()[0].Recipient.Email)
+	assert.Len(t, s.push.Messages(), 1)
+
+	// 渠道失败时返回错误，其他渠道照常投递
+	s.email.FailWith(errors.New("smtp down"))
+	assert.Error(t, s.Notify(ctx, req))
+
+	count, err := s.GetUnreadCount(ctx, s.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count.Total)
+	assert.Equal(t, int64(3), count.ByCategory["comment"])
+}
+
+func TestNotificationSendRetryWritesInAppOnce(t *testing.T) {
+	s := newTestNotificationService(t)
+	ctx := context.Background()
+
+	_, err := s.UpdatePreferences(ctx, s.user.ID, &domain.UpdateNotificationPreferencesRequest{
+		Categories: []domain.CategoryPreference{{Category: string(consts.NotificationCategoryComment), InApp: true, Email: true}},
+	})
+	require.NoError(t, err)
+	s.email.FailWith(errors.New("smtp down"))
+
+	broker := queue.NewMemoryBroker()
+	_, err = task.EnqueueNotification(ctx, queue.NewClient(broker, 3, slog.New(slog.DiscardHandler)), &domain.NotifyRequest{UserID: s.user.ID, Category: consts.NotificationCategoryComment, Title: "新评论"})
+	require.NoError(t, err)
+	send, err := broker.Pop(ctx, queue.DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, send)
+
+	// 邮件失败导致任务重试，站内信只写入一条
+	handler := task.NewNotificationSendHandler(s)
+	assert.Error(t, handler(ctx, send))
+	assert.Error(t, handler(ctx, send))
+
+	count, err := s.GetUnreadCount(ctx, s.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count.Total)
+}
+
+func TestUserWelcomeUsesRecipientLocale(t *testing.T) {
+	s := newTestNotificationService(t)
+	ctx := context.Background()
+	welcome := task.NewUserWelcomeHandler(s, s.users)
+
+	// 未设置语言偏好时使用默认语言
+	e, err := event.New(ctx, domain.EventUserRegistered, domain.AggregateUser, s.user.ID, domain.UserEventPayload{UserID: s.user.ID, Username: s.user.Username})
+	require.NoError(t, err)
+	require.NoError(t, welcome(ctx, *e))
+	messages := s.push.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Welcome to Together", messages[0].Title)
+	assert.Equal(t, "Hi alice, create or join a space to start recording your life together.", messages[0].Content)
+
+	s.user.Locale = "zh-CN"
+	require.NoError(t, s.users.Update(ctx, s.user))
+	e, err = event.New(ctx, domain.EventUserRegistered, domain.AggregateUser, s.user.ID, domain.UserEventPayload{UserID: s.user.ID, Username: s.user.Username})
+	require.NoError(t, err)
+	require.NoError(t, welcome(ctx, *e))
+	messages = s.push.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "欢迎加入 Together", messages[1].Title)
+	assert.Equal(t, "你好 alice，创建或加入一个空间，开始记录你们的生活吧。", messages[1].Content)
+}
+
+func TestNotificationServiceQuietHours(t *testing.T) {
+	s := newTestNotificationService(t)
+	ctx := context.Background()
+
+	// 当前时间前后一小时为免打扰时段，只投递站内信
+	now := time.Now().UTC()
+	_, err := s.UpdatePreferences(ctx, s.user.ID, &domain.UpdateNotificationPreferencesRequest{
+		Categories: []domain.CategoryPreference{{Category: string(consts.NotificationCategoryReminder), InApp: true, Email: true, Push: true}},
+		QuietHours: &domain.QuietHours{Enabled: true, Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04"), Timezone: "UTC"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Notify(ctx, &domain.NotifyRequest{UserID: s.user.ID, Category: consts.NotificationCategoryReminder, Title: "纪念日"}))
+	assert.Empty(t, s.email.Messages())
+	assert.Empty(t, s.push.Messages())
+	count, err := s.GetUnreadCount(ctx, s.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count.Total)
+}
+
+func TestNotificationServicePreferences(t *testing.T) {
+	s := newTestNotificationService(t)
+	ctx := context.Background()
+
+	// 没有保存过的类别和设置使用默认值
+	prefs, err := s.GetPreferences(ctx, s.user.ID)
+	require.NoError(t, err)
+	assert.Len(t, prefs.Categories, len(consts.NotificationCategories))
+	for _, p := range prefs.Categories {
+		assert.True(t, p.InApp)
+		assert.False(t, p.Email)
+		assert.True(t, p.Push)
+	}
+	assert.Equal(t, domain.QuietHours{Start: "22:00", End: "07:00", Timezone: "Asia/Shanghai"}, prefs.QuietHours)
+
+	// 未指定时区时使用系统时区
+	prefs, err = s.UpdatePreferences(ctx, s.user.ID, &domain.UpdateNotificationPreferencesRequest{
+		Categories: []domain.CategoryPreference{{Category: string(consts.NotificationCategoryMention), Email: true}},
+		QuietHours: &domain.QuietHours{Enabled: true, Start: "23:00", End: "06:00"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.QuietHours{Enabled: true, Start: "23:00", End: "06:00", Timezone: "Asia/Shanghai"}, prefs.QuietHours)
+	for _, p := range prefs.Categories {
+		if p.Category == string(consts.NotificationCategoryMention) {
+			assert.Equal(t, domain.CategoryPreference{Category: p.Category, Email: true}, p)
+		} else {
+			assert.True(t, p.InApp, p.Category)
+		}
+	}
+}
+
+func TestNotificationServiceMarkAsRead(t *testing.T) {
+	s := newTestNotificationService(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.Notify(ctx, &domain.NotifyRequest{UserID: s.user.ID, Category: consts.NotificationCategorySystem, Title: "系统通知"}))
+	}
+
+	updated, err := s.MarkAsRead(ctx, s.user.ID, []uint{1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	require.NoError(t, s.Delete(ctx, s.user.ID, 2))
+
+	updated, err = s.MarkAllAsRead(ctx, s.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	count, err := s.GetUnreadCount(ctx, s.user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count.Total)
+	assert.Empty(t, count.ByCategory)
+}
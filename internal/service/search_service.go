@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/search"
+
+	"gorm.io/gorm"
+)
+
+// scopePublic 所有登录用户可见的内容
+const scopePublic = "public"
+
+// spaceScope 空间内容的可见范围，属于该空间的用户可见
+func spaceScope(spaceID uint) string {
+	return "space:" + strconv.FormatUint(uint64(spaceID), 10)
+}
+
+type searchService struct {
+	index  search.Indexer
+	spaces domain.SpaceRepository
+	log    *slog.Logger
+}
+
+func NewSearchService(index search.Indexer, spaces domain.SpaceRepository, log *slog.Logger) domain.SearchService {
+	return &searchService{index: index, spaces: spaces, log: log}
+}
+
+func (s *searchService) Search(ctx context.Context, userID uint, text string, types []string, page *pagination.PageRequest) (*search.Result, error) {
+	// 所属的空间包括拥有的和加入的空间
+	spaceIDs, err := s.spaces.GetIDsByUser(ctx, userID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get spaces of user", "error", err.Error(), "user_id", userID)
+		return nil, err
+	}
+	scopes := []string{scopePublic}
+	for _, id := range spaceIDs {
+		scopes = append(scopes, spaceScope(id))
+	}
+
+	result, err := s.index.Search(ctx, search.Query{
+		Text:   text,
+		Types:  types,
+		Scopes: scopes,
+		Offset: page.GetOffset(),
+		Limit:  page.GetLimit(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.log.InfoContext(ctx, "search completed", "user_id", userID, "total", result.Total, "page", page.Page, "pageSize", page.PageSize)
+	return result, nil
+}
+
+// searchSource 从用户和空间表读取索引数据，按发件箱中的用户和空间事件增量更新
+// 新增可搜索的内容类型时，在 Rebuild 中写入全量数据，在 Apply 中处理对应聚合的事件
+type searchSource struct {
+	users  domain.UserRepository
+	spaces domain.SpaceRepository
+	events domain.EventRepository
+}
+
+func NewSearchSource(users domain.UserRepository, spaces domain.SpaceRepository, events domain.EventRepository) search.Source {
+	return &searchSource{users: users, spaces: spaces, events: events}
+}
+
+func (s *searchSource) Rebuild(ctx context.Context, index search.Indexer) error {
+	users, err := s.users.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("load users: %w", err)
+	}
+	docs := make([]search.Document, 0, len(users))
+	for i := range users {
+		docs = append(docs, userDocument(&users[i]))
+	}
+	if err := index.Index(ctx, docs...); err != nil {
+		return err
+	}
+
+	spaces, err := s.spaces.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("load spaces: %w", err)
+	}
+	docs = make([]search.Document, 0, len(spaces))
+	for i := range spaces {
+		docs = append(docs, spaceDocument(&spaces[i]))
+	}
+	return index.Index(ctx, docs...)
+}
+
+func (s *searchSource) Events(ctx context.Context, after time.Time, afterID string, limit int) ([]*event.Event, error) {
+	return s.events.ListAfter(ctx, after, afterID, []string{domain.AggregateUser, domain.AggregateSpace}, limit)
+}
+
+// Apply 从主库重新读取聚合的最新数据，不依赖事件载荷，事件重复或乱序应用时结果一致
+func (s *searchSource) Apply(ctx context.Context, index search.Indexer, e *event.Event) error {
+	id, err := strconv.ParseUint(e.AggregateID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	ctx = database.ForcePrimary(ctx)
+
+	switch e.AggregateType {
+	case domain.AggregateUser:
+		user, err := s.users.GetByID(ctx, uint(id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return index.Delete(ctx, domain.SearchTypeUser, e.AggregateID)
+		}
+		if err != nil {
+			return err
+		}
+		return index.Index(ctx, userDocument(user))
+	case domain.AggregateSpace:
+		space, err := s.spaces.GetByID(ctx, uint(id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return index.Delete(ctx, domain.SearchTypeSpace, e.AggregateID)
+		}
+		if err != nil {
+			return err
+		}
+		return index.Index(ctx, spaceDocument(space))
+	}
+	return nil
+}
+
+// userDocument 用户文档所有人可见，只索引用户名和昵称，不索引邮箱，避免通过搜索枚举和读取其他用户的邮箱
+func userDocument(user *model.User) search.Document {
+	return search.Document{
+		Type:   domain.SearchTypeUser,
+		ID:     strconv.FormatUint(uint64(user.ID), 10),
+		Title:  user.Username,
+		Body:   user.Nickname,
+		Scopes: []string{scopePublic},
+	}
+}
+
+func spaceDocument(space *model.Space) search.Document {
+	return search.Document{
+		Type:   domain.SearchTypeSpace,
+		ID:     strconv.FormatUint(uint64(space.ID), 10),
+		Title:  space.Name,
+		Body:   joinNonEmpty(space.Type, space.Description),
+		Scopes: []string{spaceScope(space.ID)},
+	}
+}
+
+// joinNonEmpty 用换行连接非空字段
+func joinNonEmpty(values ...string) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/internal/repository"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchServiceScopes(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.Space{}, &model.SpaceMember{}))
+
+	spaces := repository.NewSpaceRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log}))
+	ctx := context.Background()
+	owned := &model.Space{Name: "海边小屋", OwnerUserID: 1}
+	joined := &model.Space{Name: "海边营地", OwnerUserID: 2}
+	other := &model.Space{Name: "海边灯塔", OwnerUserID: 2}
+	for _, space := range []*model.Space{owned, joined, other} {
+		require.NoError(t, spaces.Create(ctx, space))
+	}
+	require.NoError(t, spaces.AddMember(ctx, &model.SpaceMember{SpaceID: joined.ID, UserID: 1}))
+
+	index := search.NewMemoryIndex()
+	for _, space := range []*model.Space{owned, joined, other} {
+		require.NoError(t, index.Index(ctx, spaceDocument(space)))
+	}
+	require.NoError(t, index.Index(ctx, userDocument(&model.User{ID: 2, Username: "bob", Nickname: "小白", Email: "bob.secret@example.com"})))
+
+	svc := NewSearchService(index, spaces, log)
+	page := &pagination.PageRequest{Page: 1, PageSize: 10}
+
+	// 拥有和加入的空间可见，其他空间不可见
+	result, err := svc.Search(ctx, 1, "海边", []string{domain.SearchTypeSpace}, page)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+
+	// 用户按用户名和昵称搜索，邮箱不会被索引和返回
+	result, err = svc.Search(ctx, 1, "小白", nil, page)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	result, err = svc.Search(ctx, 1, "secret", nil, page)
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+}
@@ -2,78 +2,186 @@ package service
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 
 	"github.com/chenyl99x/toge-api/internal/domain"
 	"github.com/chenyl99x/toge-api/internal/model"
-	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/database"
 	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
 )
 
 type spaceService struct {
-	repo domain.SpaceRepository
+	tx     *database.TxManager
+	repo   domain.SpaceRepository
+	events domain.EventRepository
+	log    *slog.Logger
 }
 
 func (s spaceService) Create(ctx context.Context, space *model.Space) error {
-	if err := s.repo.Create(ctx, space); err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to create space", "error", err.Error(), "name", space.Name)
+	// 空间和创建事件在同一事务中写入
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, space); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, domain.EventSpaceCreated, domain.AggregateSpace, space.ID, spaceEventPayload(space))
+	})
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to create space", "error", err.Error(), "name", space.Name)
 		return err
 	}
 
-	logger.InfoWithTrace(ctx, "space created successfully", "id", space.ID, "name", space.Name)
+	s.log.InfoContext(ctx, "space created successfully", "id", space.ID, "name", space.Name)
 	return nil
 }
 
 func (s spaceService) GetByID(ctx context.Context, id uint) (*model.Space, error) {
 	space, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.Wrap(apperror.CodeNotFound, err, "space not found")
+	}
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get space by ID", "error", err.Error(), "id", id)
+		s.log.ErrorContext(ctx, "Failed to get space by ID", "error", err.Error(), "id", id)
 		return nil, err
 	}
-	logger.InfoWithTrace(ctx, "space retrieved by ID", "id", id)
+	s.log.InfoContext(ctx, "space retrieved by ID", "id", id)
 	return space, nil
 }
 
 func (s spaceService) GetAll(ctx context.Context) ([]model.Space, error) {
 	spaces, err := s.repo.GetAll(ctx)
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get all spaces", "error", err.Error())
+		s.log.ErrorContext(ctx, "Failed to get all spaces", "error", err.Error())
 		return nil, err
 	}
-	logger.InfoWithTrace(ctx, "All spaces retrieved", "count", len(spaces))
+	s.log.InfoContext(ctx, "All spaces retrieved", "count", len(spaces))
 	return spaces, nil
 }
 
 func (s spaceService) GetAllWithPagination(ctx context.Context, page *pagination.PageRequest) (*pagination.PageResponse, error) {
 	spaces, total, err := s.repo.GetAllWithPagination(ctx, page)
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get spaces with pagination", "error", err.Error(), "page", page.Page, "pageSize", page.PageSize)
+		s.log.ErrorContext(ctx, "Failed to get spaces with pagination", "error", err.Error(), "page", page.Page, "pageSize", page.PageSize)
 		return nil, err
 	}
 
 	pageResponse := pagination.NewPageResponse(spaces, total, page.Page, page.PageSize)
-	logger.InfoWithTrace(ctx, "spaces retrieved with pagination", "count", len(spaces), "total", total, "page", page.Page, "pageSize", page.PageSize)
+	s.log.InfoContext(ctx, "spaces retrieved with pagination", "count", len(spaces), "total", total, "page", page.Page, "pageSize", page.PageSize)
 	return pageResponse, nil
 }
 
 func (s spaceService) Update(ctx context.Context, space *model.Space) error {
-	if err := s.repo.Update(ctx, space); err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to update space", "error", err.Error(), "id", space.ID)
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, space); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, domain.EventSpaceUpdated, domain.AggregateSpace, space.ID, spaceEventPayload(space))
+	})
+	if errors.Is(err, domain.ErrVersionConflict) {
+		s.log.WarnContext(ctx, "space modified concurrently", "id", space.ID, "version", space.Version)
+		return err
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to update space", "error", err.Error(), "id", space.ID)
 		return err
 	}
 
-	logger.InfoWithTrace(ctx, "space updated successfully", "space_id", space.ID, "name", space.Name)
+	s.log.InfoContext(ctx, "space updated successfully", "space_id", space.ID, "name", space.Name)
 	return nil
 }
 
 func (s spaceService) Delete(ctx context.Context, id uint) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to delete space", "error", err.Error(), "id", id)
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, domain.EventSpaceDeleted, domain.AggregateSpace, id, domain.SpaceEventPayload{SpaceID: id})
+	})
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to delete space", "error", err.Error(), "id", id)
 		return err
 	}
-	logger.InfoWithTrace(ctx, "space deleted successfully", "id", id)
+	s.log.InfoContext(ctx, "space deleted successfully", "id", id)
 	return nil
 }
 
-func NewSpaceService(repo domain.SpaceRepository) domain.SpaceService {
-	return &spaceService{repo: repo}
+func (s spaceService) AddMember(ctx context.Context, spaceID, ownerID, userID uint) (*model.SpaceMember, error) {
+	space, err := s.GetByID(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	if space.OwnerUserID != ownerID {
+		s.log.WarnContext(ctx, "non-owner tried to add space member", "space_id", spaceID, "user_id", ownerID)
+		return nil, domain.ErrNotSpaceOwner
+	}
+	if userID == space.OwnerUserID {
+		return nil, domain.ErrAlreadySpaceMember
+	}
+
+	// 成员和加入事件在同一事务中写入
+	member := &model.SpaceMember{SpaceID: spaceID, UserID: userID}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddMember(ctx, member); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, domain.EventSpaceMemberJoined, domain.AggregateSpace, spaceID, domain.SpaceMemberEventPayload{SpaceID: spaceID, UserID: userID})
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, domain.ErrAlreadySpaceMember
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to add space member", "error", err.Error(), "space_id", spaceID, "user_id", userID)
+		return nil, err
+	}
+
+	s.log.InfoContext(ctx, "space member added successfully", "space_id", spaceID, "user_id", userID)
+	return member, nil
+}
+
+func (s spaceService) GetMembers(ctx context.Context, spaceID, userID uint) ([]model.SpaceMember, error) {
+	space, err := s.GetByID(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckMember(ctx, space, userID); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetMembers(ctx, spaceID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get space members", "error", err.Error(), "space_id", spaceID)
+		return nil, err
+	}
+	return members, nil
+}
+
+func (s spaceService) CheckMember(ctx context.Context, space *model.Space, userID uint) error {
+	if space.OwnerUserID == userID {
+		return nil
+	}
+	member, err := s.repo.IsMember(ctx, space.ID, userID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to check space member", "error", err.Error(), "space_id", space.ID, "user_id", userID)
+		return err
+	}
+	if !member {
+		return domain.ErrNotSpaceMember
+	}
+	return nil
+}
+
+func NewSpaceService(tx *database.TxManager, repo domain.SpaceRepository, events domain.EventRepository, log *slog.Logger) domain.SpaceService {
+	return &spaceService{tx: tx, repo: repo, events: events, log: log}
+}
+
+// spaceEventPayload 空间事件载荷
+func spaceEventPayload(space *model.Space) domain.SpaceEventPayload {
+	return domain.SpaceEventPayload{
+		SpaceID:     space.ID,
+		OwnerUserID: space.OwnerUserID,
+		Name:        space.Name,
+		Type:        space.Type,
+	}
 }
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/internal/repository"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpaceServiceAddMember(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.Space{}, &model.SpaceMember{}, &model.OutboxEvent{}))
+
+	events := repository.NewEventRepository(db)
+	svc := NewSpaceService(database.NewTxManager(db, database.TxOptions{Logger: log}), repository.NewSpaceRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log})), events, log)
+	ctx := context.Background()
+
+	space := &model.Space{Name: "我们的岛屿", OwnerUserID: 1, Type: "情侣空间"}
+	require.NoError(t, svc.Create(ctx, space))
+
+	// 只有拥有者可以添加成员，用户不能自己加入
+	_, err = svc.AddMember(ctx, space.ID, 3, 3)
+	assert.ErrorIs(t, err, domain.ErrNotSpaceOwner)
+	assert.Equal(t, http.StatusForbidden, apperror.From(err).HTTPStatus())
+
+	member, err := svc.AddMember(ctx, space.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), member.UserID)
+
+	// 成员不能继续添加成员
+	_, err = svc.AddMember(ctx, space.ID, 2, 3)
+	assert.ErrorIs(t, err, domain.ErrNotSpaceOwner)
+
+	// 成员和加入事件一起写入发件箱
+	pending, err := events.Pending(ctx, time.Now().Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	joined := pending[1]
+	assert.Equal(t, domain.EventSpaceMemberJoined, joined.Type)
+	assert.Equal(t, domain.AggregateSpace, joined.AggregateType)
+	var payload domain.SpaceMemberEventPayload
+	require.NoError(t, json.Unmarshal(joined.Payload, &payload))
+	assert.Equal(t, domain.SpaceMemberEventPayload{SpaceID: space.ID, UserID: 2}, payload)
+
+	// 重复添加和添加拥有者返回 409，不产生事件
+	_, err = svc.AddMember(ctx, space.ID, 1, 2)
+	assert.ErrorIs(t, err, domain.ErrAlreadySpaceMember)
+	_, err = svc.AddMember(ctx, space.ID, 1, 1)
+	assert.ErrorIs(t, err, domain.ErrAlreadySpaceMember)
+	pending, err = events.Pending(ctx, time.Now().Add(time.Second), 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	_, err = svc.AddMember(ctx, 999, 1, 2)
+	assert.Equal(t, apperror.CodeNotFound, apperror.From(err).Code)
+}
+
+func TestSpaceServiceGetMembers(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.Space{}, &model.SpaceMember{}, &model.OutboxEvent{}))
+
+	svc := NewSpaceService(database.NewTxManager(db, database.TxOptions{Logger: log}), repository.NewSpaceRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log})), repository.NewEventRepository(db), log)
+	ctx := context.Background()
+
+	space := &model.Space{Name: "我们的岛屿", OwnerUserID: 1, Type: "情侣空间"}
+	require.NoError(t, svc.Create(ctx, space))
+	_, err = svc.AddMember(ctx, space.ID, 1, 2)
+	require.NoError(t, err)
+
+	// 拥有者和成员可以查看成员
+	for _, userID := range []uint{1, 2} {
+		members, err := svc.GetMembers(ctx, space.ID, userID)
+		require.NoError(t, err)
+		require.Len(t, members, 1)
+		assert.Equal(t, uint(2), members[0].UserID)
+	}
+
+	// 其他用户返回 403
+	_, err = svc.GetMembers(ctx, space.ID, 3)
+	assert.ErrorIs(t, err, domain.ErrNotSpaceMember)
+	assert.Equal(t, http.StatusForbidden, apperror.From(err).HTTPStatus())
+	assert.ErrorIs(t, svc.CheckMember(ctx, space, 3), domain.ErrNotSpaceMember)
+}
@@ -3,87 +3,111 @@ package service
 import (
 	"context"
 	"errors"
+	"log/slog"
 
 	"github.com/chenyl99x/toge-api/internal/domain"
 	"github.com/chenyl99x/toge-api/internal/model"
-	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/database"
 	"github.com/chenyl99x/toge-api/pkg/pagination"
+
+	"gorm.io/gorm"
 )
 
 type userService struct {
-	repo domain.UserRepository
+	tx     *database.TxManager
+	repo   domain.UserRepository
+	events domain.EventRepository
+	log    *slog.Logger
 }
 
-func NewUserService(repo domain.UserRepository) domain.UserService {
-	return &userService{repo: repo}
+func NewUserService(tx *database.TxManager, repo domain.UserRepository, events domain.EventRepository, log *slog.Logger) domain.UserService {
+	return &userService{tx: tx, repo: repo, events: events, log: log}
 }
 
 func (s *userService) Create(ctx context.Context, user *model.User) error {
+	// 唯一性检查读主库，避免从库复制延迟时漏掉刚注册的用户
+	ctx = database.ForcePrimary(ctx)
+
 	// 检查用户名是否已存在
 	if _, err := s.repo.GetByUsername(ctx, user.Username); err == nil {
-		logger.WarnWithTrace(ctx, "Username already exists", "username", user.Username)
-		return errors.New("username already exists")
+		s.log.WarnContext(ctx, "Username already exists", "username", user.Username)
+		return domain.ErrUsernameExists
 	}
 
 	// 检查邮箱是否已存在
 	if _, err := s.repo.GetByEmail(ctx, user.Email); err == nil {
-		logger.WarnWithTrace(ctx, "Email already exists", "email", user.Email)
-		return errors.New("email already exists")
+		s.log.WarnContext(ctx, "Email already exists", "email", user.Email)
+		return domain.ErrEmailExists
 	}
 
-	if err := s.repo.Create(ctx, user); err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to create user", "error", err.Error(), "username", user.Username)
+	// 用户和注册事件在同一事务中写入
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, domain.EventUserRegistered, domain.AggregateUser, user.ID, domain.UserEventPayload{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	})
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to create user", "error", err.Error(), "username", user.Username)
 		return err
 	}
 
-	logger.InfoWithTrace(ctx, "User created successfully", "user_id", user.ID, "username", user.Username)
+	s.log.InfoContext(ctx, "User created successfully", "user_id", user.ID, "username", user.Username)
 	return nil
 }
 
 func (s *userService) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	user, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.Wrap(apperror.CodeNotFound, err, "user not found")
+	}
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get user by ID", "error", err.Error(), "user_id", id)
+		s.log.ErrorContext(ctx, "Failed to get user by ID", "error", err.Error(), "user_id", id)
 		return nil, err
 	}
-	logger.InfoWithTrace(ctx, "User retrieved by ID", "user_id", id)
+	s.log.InfoContext(ctx, "User retrieved by ID", "user_id", id)
 	return user, nil
 }
 
 func (s *userService) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	user, err := s.repo.GetByUsername(ctx, username)
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get user by username", "error", err.Error(), "username", username)
+		s.log.ErrorContext(ctx, "Failed to get user by username", "error", err.Error(), "username", username)
 		return nil, err
 	}
-	logger.InfoWithTrace(ctx, "User retrieved by username", "username", username)
+	s.log.InfoContext(ctx, "User retrieved by username", "username", username)
 	return user, nil
 }
 
 func (s *userService) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	user, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get user by email", "error", err.Error(), "email", email)
+		s.log.ErrorContext(ctx, "Failed to get user by email", "error", err.Error(), "email", email)
 		return nil, err
 	}
-	logger.InfoWithTrace(ctx, "User retrieved by email", "email", email)
+	s.log.InfoContext(ctx, "User retrieved by email", "email", email)
 	return user, nil
 }
 
 func (s *userService) GetAll(ctx context.Context) ([]model.User, error) {
 	users, err := s.repo.GetAll(ctx)
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get all users", "error", err.Error())
+		s.log.ErrorContext(ctx, "Failed to get all users", "error", err.Error())
 		return nil, err
 	}
-	logger.InfoWithTrace(ctx, "All users retrieved", "count", len(users))
+	s.log.InfoContext(ctx, "All users retrieved", "count", len(users))
 	return users, nil
 }
 
 func (s *userService) GetAllWithPagination(ctx context.Context, page *pagination.PageRequest) (*pagination.PageResponse, error) {
 	users, total, err := s.repo.GetAllWithPagination(ctx, page)
 	if err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to get users with pagination", "error", err.Error(), "page", page.Page, "pageSize", page.PageSize)
+		s.log.ErrorContext(ctx, "Failed to get users with pagination", "error", err.Error(), "page", page.Page, "pageSize", page.PageSize)
 		return nil, err
 	}
 
@@ -93,37 +117,76 @@ func (s *userService) GetAllWithPagination(ctx context.Context, page *pagination
 	}
 
 	pageResponse := pagination.NewPageResponse(users, total, page.Page, page.PageSize)
-	logger.InfoWithTrace(ctx, "Users retrieved with pagination", "count", len(users), "total", total, "page", page.Page, "pageSize", page.PageSize)
+	s.log.InfoContext(ctx, "Users retrieved with pagination", "count", len(users), "total", total, "page", page.Page, "pageSize", page.PageSize)
 	return pageResponse, nil
 }
 
+func (s *userService) GetAllWithCursor(ctx context.Context, page *pagination.PageRequest) (*pagination.CursorResponse, error) {
+	users, cursorPage, err := s.repo.GetAllWithCursor(ctx, page)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get users with cursor", "error", err.Error(), "limit", page.Limit)
+		return nil, err
+	}
+
+	// 不返回密码
+	for i := range users {
+		users[i].Password = ""
+	}
+
+	s.log.InfoContext(ctx, "Users retrieved with cursor", "count", len(users), "limit", page.Limit, "hasNext", cursorPage.HasNext)
+	return pagination.NewCursorResponse(users, cursorPage), nil
+}
+
 func (s *userService) Update(ctx context.Context, user *model.User) error {
+	// 唯一性检查读主库，避免从库复制延迟时漏掉刚注册的用户
+	ctx = database.ForcePrimary(ctx)
+
 	// 检查用户名是否已被其他用户使用
 	if existingUser, err := s.repo.GetByUsername(ctx, user.Username); err == nil && existingUser.ID != user.ID {
-		logger.WarnWithTrace(ctx, "Username already exists for update", "username", user.Username, "user_id", user.ID)
-		return errors.New("username already exists")
+		s.log.WarnContext(ctx, "Username already exists for update", "username", user.Username, "user_id", user.ID)
+		return domain.ErrUsernameExists
 	}
 
 	// 检查邮箱是否已被其他用户使用
 	if existingUser, err := s.repo.GetByEmail(ctx, user.Email); err == nil && existingUser.ID != user.ID {
-		logger.WarnWithTrace(ctx, "Email already exists for update", "email", user.Email, "user_id", user.ID)
-		return errors.New("email already exists")
+		s.log.WarnContext(ctx, "Email already exists for update", "email", user.Email, "user_id", user.ID)
+		return domain.ErrEmailExists
 	}
 
-	if err := s.repo.Update(ctx, user); err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to update user", "error", err.Error(), "user_id", user.ID)
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, domain.EventUserUpdated, domain.AggregateUser, user.ID, domain.UserEventPayload{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	})
+	if errors.Is(err, domain.ErrVersionConflict) {
+		s.log.WarnContext(ctx, "User modified concurrently", "user_id", user.ID, "version", user.Version)
+		return err
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to update user", "error", err.Error(), "user_id", user.ID)
 		return err
 	}
 
-	logger.InfoWithTrace(ctx, "User updated successfully", "user_id", user.ID, "username", user.Username)
+	s.log.InfoContext(ctx, "User updated successfully", "user_id", user.ID, "username", user.Username)
 	return nil
 }
 
 func (s *userService) Delete(ctx context.Context, id uint) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
-		logger.ErrorWithTrace(ctx, "Failed to delete user", "error", err.Error(), "user_id", id)
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, domain.EventUserDeleted, domain.AggregateUser, id, domain.UserEventPayload{UserID: id})
+	})
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to delete user", "error", err.Error(), "user_id", id)
 		return err
 	}
-	logger.InfoWithTrace(ctx, "User deleted successfully", "user_id", id)
+	s.log.InfoContext(ctx, "User deleted successfully", "user_id", id)
 	return nil
 }
@@ -0,0 +1,612 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/internal/task"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/password"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/secretbox"
+	"github.com/chenyl99x/toge-api/pkg/tabular"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultExportBatchSize   = 500
+	defaultImportMaxFileSize = 10 // MB
+	defaultImportMaxRows     = 10000
+	defaultImportJobTimeout  = time.Hour
+
+	// importCheckpointRows 每处理多少行保存一次进度
+	importCheckpointRows = 100
+)
+
+// userFieldMaxLength 与数据库列长度一致的字段长度上限
+var userFieldMaxLength = map[string]int{
+	"username": 50,
+	"email":    100,
+	"nickname": 50,
+	"avatar":   255,
+}
+
+type userTransferService struct {
+	users  domain.UserService
+	repo   domain.UserRepository
+	jobs   domain.UserImportRepository
+	client *queue.Client
+	box    *secretbox.Box // 加密保存在任务中的明文密码
+	cfg    config.TransferConfig
+	log    *slog.Logger
+}
+
+func NewUserTransferService(users domain.UserService, repo domain.UserRepository, jobs domain.UserImportRepository, client *queue.Client, box *secretbox.Box, transferConfig config.TransferConfig, log *slog.Logger) domain.UserTransferService {
+	if transferConfig.ExportBatchSize <= 0 {
+		transferConfig.ExportBatchSize = defaultExportBatchSize
+	}
+	if transferConfig.ImportMaxFileSize <= 0 {
+		transferConfig.ImportMaxFileSize = defaultImportMaxFileSize
+	}
+	if transferConfig.ImportMaxRows <= 0 {
+		transferConfig.ImportMaxRows = defaultImportMaxRows
+	}
+	return &userTransferService{users: users, repo: repo, jobs: jobs, client: client, box: box, cfg: transferConfig, log: log}
+}
+
+func (s *userTransferService) Export(ctx context.Context, page *pagination.PageRequest, format string, w io.Writer) error {
+	if !slices.Contains(tabular.Formats, format) {
+		return fmt.Errorf("%w: %q", tabular.ErrUnsupportedFormat, format)
+	}
+
+	// 读到第一批数据后再写入，查询条件无效时调用方仍可以返回错误响应
+	var out tabular.Writer
+	open := func() error {
+		var err error
+		if out, err = tabular.NewWriter(w, format); err != nil {
+			return err
+		}
+		return out.Write(domain.UserExportColumns)
+	}
+
+	count := 0
+	err := s.repo.FindInBatches(ctx, page, s.cfg.ExportBatchSize, func(users []model.User) error {
+		if out == nil {
+			if err := open(); err != nil {
+				return err
+			}
+		}
+		for i := range users {
+			if err := out.Write(userRecord(&users[i])); err != nil {
+				return err
+			}
+		}
+		count += len(users)
+		return out.Flush()
+	})
+	if err == nil && out == nil {
+		err = open()
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to export users", "error", err.Error(), "format", format, "count", count)
+		return err
+	}
+
+	s.log.InfoContext(ctx, "users exported", "format", format, "count", count)
+	return nil
+}
+
+// userRecord 导出的一行，列与 domain.UserExportColumns 对应
+func userRecord(user *model.User) []string {
+	return []string{
+		strconv.FormatUint(uint64(user.ID), 10),
+		user.Username,
+		user.Email,
+		user.Nickname,
+		user.Avatar,
+		strconv.Itoa(user.Status),
+		user.CreatedAt.Format(time.RFC3339),
+		user.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func (s *userTransferService) Import(ctx context.Context, userID uint, req *domain.UserImportRequest, fileName string, file io.Reader) (*domain.UserImportJobResponse, bool, error) {
+	format := req.Format
+	if format == "" {
+		format = tabular.FormatFromFilename(fileName)
+	}
+	if format == "" {
+		return nil, false, fmt.Errorf("%w: cannot detect format of %q, use csv or xlsx", domain.ErrInvalidImport, fileName)
+	}
+
+	rows, err := s.parse(file, format, req.Mapping)
+	if err != nil {
+		s.log.WarnContext(ctx, "Invalid user import file", "error", err.Error(), "file", fileName)
+		return nil, false, err
+	}
+	// 请求中只做校验并加密密码，计算哈希留给执行任务时，数据库、备份和从库中不出现明文
+	if err := s.prepareImportRows(rows, req.DryRun); err != nil {
+		return nil, false, err
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	job := &model.UserImportJob{
+		CreatedBy: userID,
+		FileName:  fileName,
+		Format:    format,
+		DryRun:    req.DryRun,
+		Status:    domain.ImportStatusPending,
+		Total:     len(rows),
+		Rows:      string(data),
+	}
+	if err := s.jobs.Create(ctx, job); err != nil {
+		s.log.ErrorContext(ctx, "Failed to create user import job", "error", err.Error())
+		return nil, false, err
+	}
+
+	// 行数较多时放入后台队列，调用方轮询任务进度
+	if len(rows) > s.cfg.ImportAsyncThreshold {
+		timeout := time.Duration(s.cfg.ImportJobTimeout) * time.Second
+		if timeout <= 0 {
+			timeout = defaultImportJobTimeout
+		}
+		if _, err := task.EnqueueUserImport(ctx, s.client, &domain.UserImportPayload{JobID: job.ID}, queue.Timeout(timeout)); err != nil {
+			s.log.ErrorContext(ctx, "Failed to enqueue user import job", "error", err.Error(), "job_id", job.ID)
+			s.finish(ctx, job, nil, err)
+			return nil, false, err
+		}
+		s.log.InfoContext(ctx, "user import job enqueued", "job_id", job.ID, "rows", len(rows), "dry_run", job.DryRun)
+		job.Rows = ""
+		return &domain.UserImportJobResponse{UserImportJob: job}, true, nil
+	}
+
+	// 请求被取消时继续执行，避免任务停留在执行中
+	if err := s.RunImportJob(context.WithoutCancel(ctx), job.ID); err != nil {
+		return nil, false, err
+	}
+	resp, err := s.GetImportJob(database.ForcePrimary(ctx), job.ID)
+	return resp, false, err
+}
+
+// parse 读取表头和数据行，按列映射转换为字段，跳过空行
+func (s *userTransferService) parse(file io.Reader, format, mapping string) ([]domain.UserImportRow, error) {
+	maxSize := int64(s.cfg.ImportMaxFileSize) << 20
+	limited := &io.LimitedReader{R: file, N: maxSize + 1}
+	tooLarge := func() error {
+		return fmt.Errorf("%w: file is larger than %d MB", domain.ErrInvalidImport, s.cfg.ImportMaxFileSize)
+	}
+
+	reader, err := tabular.NewReader(limited, format)
+	if limited.N <= 0 {
+		return nil, tooLarge()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", domain.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+	}
+	columns, err := mapColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.UserImportRow
+	for {
+		record, err := reader.Read()
+		if limited.N <= 0 {
+			return nil, tooLarge()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+		}
+
+		values := make(map[string]string, len(columns))
+		empty := true
+		for i, field := range columns {
+			if field == "" || i >= len(record) {
+				continue
+			}
+			value := record[i]
+			if field != "password" {
+				value = strings.TrimSpace(value)
+			}
+			if value != "" {
+				empty = false
+			}
+			values[field] = value
+		}
+		if empty {
+			continue
+		}
+		if len(rows) == s.cfg.ImportMaxRows {
+			return nil, fmt.Errorf("%w: file has more than %d rows", domain.ErrInvalidImport, s.cfg.ImportMaxRows)
+		}
+		rows = append(rows, domain.UserImportRow{Row: reader.Row(), Values: values})
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: file has no data rows", domain.ErrInvalidImport)
+	}
+	return rows, nil
+}
+
+// mapColumns 返回每一列对应的字段，未映射的列为空字符串
+// mapping 为表头到字段的 JSON 对象，值为空字符串时忽略该列；没有出现在 mapping 中的表头与字段同名（不区分大小写）时自动映射
+func mapColumns(header []string, mapping string) ([]string, error) {
+	explicit := map[string]string{}
+	if mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &explicit); err != nil {
+			return nil, fmt.Errorf("%w: mapping must be a JSON object of column to field", domain.ErrInvalidImport)
+		}
+	}
+	for column, field := range explicit {
+		if field != "" && !slices.Contains(domain.UserImportFields, field) {
+			return nil, fmt.Errorf("%w: column %q is mapped to unknown field %q, allowed fields: %s",
+				domain.ErrInvalidImport, column, field, strings.Join(domain.UserImportFields, ", "))
+		}
+	}
+
+	columns := make([]string, len(header))
+	found := map[string]bool{}
+	mapped := map[string]string{} // 字段 -> 表头
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+		field, ok := explicit[h]
+		if ok {
+			found[h] = true
+		} else if lower := strings.ToLower(h); slices.Contains(domain.UserImportFields, lower) {
+			field = lower
+		}
+		if field == "" {
+			continue
+		}
+		if prev, ok := mapped[field]; ok {
+			return nil, fmt.Errorf("%w: columns %q and %q are both mapped to %s", domain.ErrInvalidImport, prev, h, field)
+		}
+		mapped[field] = h
+		columns[i] = field
+	}
+
+	for column := range explicit {
+		if !found[column] {
+			return nil, fmt.Errorf("%w: column %q in mapping is not in the header", domain.ErrInvalidImport, column)
+		}
+	}
+	for _, field := range domain.UserImportRequiredFields {
+		if _, ok := mapped[field]; !ok {
+			return nil, fmt.Errorf("%w: no column is mapped to required field %s", domain.ErrInvalidImport, field)
+		}
+	}
+	return columns, nil
+}
+
+func (s *userTransferService) GetImportJob(ctx context.Context, id uint) (*domain.UserImportJobResponse, error) {
+	job, err := s.jobs.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &domain.UserImportJobResponse{UserImportJob: job}
+	if job.Status == domain.ImportStatusCompleted && job.Results != "" {
+		if err := json.Unmarshal([]byte(job.Results), &resp.Results); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *userTransferService) RunImportJob(ctx context.Context, id uint) error {
+	// 任务状态和唯一性检查都需要读主库
+	ctx = database.ForcePrimary(ctx)
+	job, err := s.jobs.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.Status == domain.ImportStatusCompleted || job.Status == domain.ImportStatusFailed {
+		return nil
+	}
+
+	var rows []domain.UserImportRow
+	var results []domain.UserImportRowResult
+	if err := json.Unmarshal([]byte(job.Rows), &rows); err != nil {
+		s.finish(ctx, job, nil, fmt.Errorf("decode rows: %w", err))
+		return nil
+	}
+	if job.Results != "" {
+		if err := json.Unmarshal([]byte(job.Results), &results); err != nil {
+			s.finish(ctx, job, nil, fmt.Errorf("decode results: %w", err))
+			return nil
+		}
+	}
+	if job.Status == domain.ImportStatusPending {
+		now := time.Now()
+		job.Status = domain.ImportStatusRunning
+		job.StartedAt = &now
+		if err := s.jobs.Update(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	// 中断后继续执行时，已处理的行参与文件内的重复检查
+	seen := newImportSeen()
+	for i := range results {
+		seen.add(&rows[i])
+	}
+
+	s.log.InfoContext(ctx, "user import job started", "job_id", job.ID, "rows", len(rows), "resume_from", len(results), "dry_run", job.DryRun)
+	for i := len(results); i < len(rows); i++ {
+		result := s.importRow(ctx, job.DryRun, &rows[i], seen)
+		if ctx.Err() != nil {
+			// 被取消的行不计入结果，重新执行时再处理
+			s.checkpoint(ctx, job, results)
+			return ctx.Err()
+		}
+		results = append(results, result)
+		if len(results)%importCheckpointRows == 0 {
+			if err := s.checkpoint(ctx, job, results); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.finish(ctx, job, results, nil)
+	s.log.InfoContext(ctx, "user import job completed", "job_id", job.ID, "succeeded", job.Succeeded, "failed", job.Failed, "dry_run", job.DryRun)
+	return nil
+}
+
+// importRow 导入一行，只校验时检查用户名和邮箱是否已被占用，否则创建用户
+func (s *userTransferService) importRow(ctx context.Context, dryRun bool, row *domain.UserImportRow, seen *importSeen) domain.UserImportRowResult {
+	result := domain.UserImportRowResult{Row: row.Row, Username: row.Values["username"]}
+	errs := append(slices.Clone(row.Errors), seen.check(row)...)
+	seen.add(row)
+	if len(errs) > 0 {
+		result.Status = domain.ImportRowInvalid
+		result.Errors = errs
+		return result
+	}
+
+	if dryRun {
+		if _, err := s.repo.GetByUsername(ctx, row.Values["username"]); err == nil {
+			errs = append(errs, domain.ErrUsernameExists.Error())
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			errs = append(errs, err.Error())
+		}
+		if _, err := s.repo.GetByEmail(ctx, row.Values["email"]); err == nil {
+			errs = append(errs, domain.ErrEmailExists.Error())
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			errs = append(errs, err.Error())
+		}
+		result.Status = domain.ImportRowValid
+		if len(errs) > 0 {
+			result.Status = domain.ImportRowInvalid
+			result.Errors = errs
+		}
+		return result
+	}
+
+	hashedPassword, err := s.hashImportPassword(row)
+	if err != nil {
+		result.Status = domain.ImportRowFailed
+		result.Errors = []string{err.Error()}
+		return result
+	}
+	status, _ := strconv.Atoi(row.Values["status"])
+	user := &model.User{
+		Username: row.Values["username"],
+		Email:    row.Values["email"],
+		Password: hashedPassword,
+		Nickname: row.Values["nickname"],
+		Avatar:   row.Values["avatar"],
+		Status:   status,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		result.Status = domain.ImportRowFailed
+		result.Errors = []string{err.Error()}
+		return result
+	}
+	result.Status = domain.ImportRowCreated
+	result.UserID = user.ID
+	return result
+}
+
+// prepareImportRows 校验每一行并从字段中移除明文密码，需要创建用户的行保存加密后的密码，只校验或校验失败时直接丢弃
+func (s *userTransferService) prepareImportRows(rows []domain.UserImportRow, dryRun bool) error {
+	for i := range rows {
+		row := &rows[i]
+		plain := row.Values["password"]
+		delete(row.Values, "password")
+
+		req, errs := validateImportRow(row.Values, plain)
+		row.Values["status"] = strconv.Itoa(req.Status)
+		row.Errors = errs
+		if dryRun || len(errs) > 0 {
+			continue
+		}
+		sealed, err := s.box.Seal(plain)
+		if err != nil {
+			return err
+		}
+		row.EncryptedPassword = sealed
+	}
+	return nil
+}
+
+// hashImportPassword 解密行中的密码并计算 bcrypt 哈希，每行约 50ms，在执行任务时计算
+func (s *userTransferService) hashImportPassword(row *domain.UserImportRow) (string, error) {
+	plain, err := s.box.Open(row.EncryptedPassword)
+	if err != nil {
+		return "", errors.New("cannot decrypt password, the encryption key may have changed")
+	}
+	return password.HashPassword(plain)
+}
+
+// validateImportRow 按创建用户接口的规则校验一行，包括密码策略，并检查数据库列长度
+func validateImportRow(values map[string]string, plainPassword string) (*domain.CreateUserRequest, []string) {
+	var errs []string
+	req := &domain.CreateUserRequest{
+		Username: values["username"],
+		Email:    values["email"],
+		Password: plainPassword,
+		Nickname: values["nickname"],
+		Avatar:   values["avatar"],
+		Status:   1,
+	}
+	if v := values["status"]; v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil || (status != 0 && status != 1) {
+			errs = append(errs, "status must be 0 or 1")
+		}
+		req.Status = status
+	}
+
+	passwordChecked := false
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return req, append(errs, err.Error())
+		}
+		for _, fe := range fieldErrs {
+			errs = append(errs, fieldErrorMessage(fe))
+			passwordChecked = passwordChecked || fe.StructField() == "Password"
+		}
+	}
+	// 与注册、创建和修改用户一样执行密码策略，密码已有格式错误时不重复报告
+	if !passwordChecked {
+		if err := password.ValidatePassword(plainPassword); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	for _, field := range domain.UserImportFields {
+		if limit, ok := userFieldMaxLength[field]; ok && utf8.RuneCountInString(values[field]) > limit {
+			errs = append(errs, fmt.Sprintf("%s must be at most %d characters", field, limit))
+		}
+	}
+	return req, errs
+}
+
+// fieldErrorMessage 校验错误的说明，字段使用导入字段名
+func fieldErrorMessage(fe validator.FieldError) string {
+	field := strings.ToLower(fe.Field())
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "email":
+		return field + " is not a valid email address"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", field, fe.Param())
+	}
+	return fmt.Sprintf("%s is invalid (%s)", field, fe.Tag())
+}
+
+// importSeen 记录文件中已出现的用户名和邮箱，不区分大小写
+type importSeen struct {
+	usernames map[string]int
+	emails    map[string]int
+}
+
+func newImportSeen() *importSeen {
+	return &importSeen{usernames: map[string]int{}, emails: map[string]int{}}
+}
+
+func (s *importSeen) check(row *domain.UserImportRow) []string {
+	var errs []string
+	if prev, ok := s.usernames[strings.ToLower(row.Values["username"])]; ok {
+		errs = append(errs, fmt.Sprintf("username duplicates row %d", prev))
+	}
+	if prev, ok := s.emails[strings.ToLower(row.Values["email"])]; ok {
+		errs = append(errs, fmt.Sprintf("email duplicates row %d", prev))
+	}
+	return errs
+}
+
+func (s *importSeen) add(row *domain.UserImportRow) {
+	if username := strings.ToLower(row.Values["username"]); username != "" {
+		if _, ok := s.usernames[username]; !ok {
+			s.usernames[username] = row.Row
+		}
+	}
+	if email := strings.ToLower(row.Values["email"]); email != "" {
+		if _, ok := s.emails[email]; !ok {
+			s.emails[email] = row.Row
+		}
+	}
+}
+
+// checkpoint 保存已处理的行和统计，取消后仍然写入
+func (s *userTransferService) checkpoint(ctx context.Context, job *model.UserImportJob, results []domain.UserImportRowResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	job.Results = string(data)
+	countResults(job, results)
+	if err := s.jobs.Update(context.WithoutCancel(ctx), job); err != nil {
+		s.log.ErrorContext(ctx, "Failed to save user import progress", "error", err.Error(), "job_id", job.ID)
+		return err
+	}
+	return nil
+}
+
+// finish 结束任务并清空待处理的行，cause 不为空时任务失败
+func (s *userTransferService) finish(ctx context.Context, job *model.UserImportJob, results []domain.UserImportRowResult, cause error) {
+	now := time.Now()
+	job.Status = domain.ImportStatusCompleted
+	if cause != nil {
+		job.Status = domain.ImportStatusFailed
+		job.Error = cause.Error()
+	}
+	job.Rows = ""
+	job.FinishedAt = &now
+	if results != nil {
+		if data, err := json.Marshal(results); err == nil {
+			job.Results = string(data)
+		}
+		countResults(job, results)
+	}
+	if err := s.jobs.Update(context.WithoutCancel(ctx), job); err != nil {
+		s.log.ErrorContext(ctx, "Failed to finish user import job", "error", err.Error(), "job_id", job.ID)
+	}
+}
+
+// countResults 根据逐行结果更新任务统计
+func countResults(job *model.UserImportJob, results []domain.UserImportRowResult) {
+	job.Processed, job.Succeeded, job.Failed = len(results), 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case domain.ImportRowValid, domain.ImportRowCreated:
+			job.Succeeded++
+		default:
+			job.Failed++
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/internal/repository"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/i18n"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/password"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/secretbox"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingImportRepository 在每次保存任务时回调，用于观察进度保存和模拟中断
+type recordingImportRepository struct {
+	domain.UserImportRepository
+	onUpdate func(job *model.UserImportJob)
+}
+
+func (r *recordingImportRepository) Update(ctx context.Context, job *model.UserImportJob) error {
+	if err := r.UserImportRepository.Update(ctx, job); err != nil {
+		return err
+	}
+	if r.onUpdate != nil {
+		r.onUpdate(job)
+	}
+	return nil
+}
+
+var registerValidator = sync.OnceValue(func() error {
+	return i18n.RegisterValidator(binding.Validator.Engine().(*validator.Validate))
+})
+
+type testUserTransferService struct {
+	*userTransferService
+	users domain.UserRepository
+	jobs  *recordingImportRepository
+	box   *secretbox.Box
+}
+
+func newTestUserTransferService(t *testing.T) *testUserTransferService {
+	t.Helper()
+	require.NoError(t, registerValidator())
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.UserImportJob{}, &model.OutboxEvent{}))
+
+	users := repository.NewUserRepository(db, pagination.NewCursors("test"))
+	userService := NewUserService(database.NewTxManager(db, database.TxOptions{Logger: log}), users, repository.NewEventRepository(db), log)
+	jobs := &recordingImportRepository{UserImportRepository: repository.NewUserImportRepository(db)}
+	// 阈值为 0 时始终放入后台队列，测试中手动执行任务
+	box := secretbox.New("test", "user import password")
+	svc := NewUserTransferService(userService, users, jobs, queue.NewClient(queue.NewMemoryBroker(), 0, log), box, config.TransferConfig{}, log)
+	return &testUserTransferService{userTransferService: svc.(*userTransferService), users: users, jobs: jobs, box: box}
+}
+
+func TestMapColumns(t *testing.T) {
+	// 表头与字段同名时不区分大小写自动映射，没有映射的列为空
+	columns, err := mapColumns([]string{" Username ", "EMAIL", "password", "备注"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"username", "email", "password", ""}, columns)
+
+	// 显式映射优先，值为空字符串时忽略该列
+	columns, err = mapColumns([]string{"用户名", "邮箱", "密码", "nickname"}, `{"用户名":"username","邮箱":"email","密码":"password","nickname":""}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"username", "email", "password", ""}, columns)
+
+	for name, tc := range map[string]struct {
+		header  []string
+		mapping string
+		message string
+	}{
+		"invalid mapping":  {[]string{"username"}, `["username"]`, "mapping must be a JSON object"},
+		"unknown field":    {[]string{"name", "email", "password"}, `{"name":"login"}`, `mapped to unknown field "login"`},
+		"missing column":   {[]string{"username", "email", "password"}, `{"用户名":"username"}`, `column "用户名" in mapping is not in the header`},
+		"duplicate field":  {[]string{"username", "登录名", "email", "password"}, `{"登录名":"username"}`, `columns "username" and "登录名" are both mapped to username`},
+		"missing required": {[]string{"username", "email"}, "", "no column is mapped to required field password"},
+	} {
+		_, err := mapColumns(tc.header, tc.mapping)
+		assert.ErrorIs(t, err, domain.ErrInvalidImport, name)
+		assert.ErrorContains(t, err, tc.message, name)
+	}
+}
+
+func TestValidateImportRow(t *testing.T) {
+	// 与 API 服务一样注册 locale 规则
+	require.NoError(t, registerValidator())
+
+	values := map[string]string{"username": "alice", "email": "alice@example.com", "status": "0"}
+	req, errs := validateImportRow(values, "Sup3r-Secret")
+	assert.Empty(t, errs)
+	assert.Equal(t, 0, req.Status)
+
+	// 未填写状态时默认为启用
+	req, errs = validateImportRow(map[string]string{"username": "alice", "email": "alice@example.com"}, "Sup3r-Secret")
+	assert.Empty(t, errs)
+	assert.Equal(t, 1, req.Status)
+
+	// 密码过短只报告一次
+	_, errs = validateImportRow(values, "abc")
+	assert.Equal(t, []string{"password must be at least 6 characters"}, errs)
+
+	// 通过格式校验的密码还要满足密码策略
+	_, errs = validateImportRow(values, "")
+	assert.Equal(t, []string{"password is required"}, errs)
+
+	_, errs = validateImportRow(map[string]string{
+		"username": strings.Repeat("a", 51),
+		"email":    "not-an-email",
+		"status":   "2",
+	}, "Sup3r-Secret")
+	assert.ElementsMatch(t, []string{
+		"status must be 0 or 1",
+		"email is not a valid email address",
+		"username must be at most 50 characters",
+	}, errs)
+}
+
+func TestUserImportEncryptsPasswords(t *testing.T) {
+	s := newTestUserTransferService(t)
+	ctx := context.Background()
+
+	file := "username,email,password\nalice,alice@example.com,Sup3r-Secret\nbob,bob@example.com,abc\n"
+	resp, async, err := s.Import(ctx, 1, &domain.UserImportRequest{}, "users.csv", strings.NewReader(file))
+	require.NoError(t, err)
+	require.True(t, async)
+
+	// 保存的行中只有通过校验的密码的密文，没有明文
+	job, err := s.jobs.GetByID(ctx, resp.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, job.Rows, "Sup3r-Secret")
+	assert.NotContains(t, job.Rows, `"password"`)
+	var rows []domain.UserImportRow
+	require.NoError(t, json.Unmarshal([]byte(job.Rows), &rows))
+	require.Len(t, rows, 2)
+	plain, err := s.box.Open(rows[0].EncryptedPassword)
+	require.NoError(t, err)
+	assert.Equal(t, "Sup3r-Secret", plain)
+	assert.Empty(t, rows[1].EncryptedPassword)
+	assert.Equal(t, []string{"password must be at least 6 characters"}, rows[1].Errors)
+
+	require.NoError(t, s.RunImportJob(ctx, job.ID))
+	result, err := s.GetImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportStatusCompleted, result.Status)
+	require.Len(t, result.Results, 2)
+	assert.Equal(t, domain.ImportRowCreated, result.Results[0].Status)
+	assert.Equal(t, domain.ImportRowInvalid, result.Results[1].Status)
+
+	user, err := s.users.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, password.CheckPassword("Sup3r-Secret", user.Password))
+
+	// 只校验时密码直接丢弃
+	resp, _, err = s.Import(ctx, 1, &domain.UserImportRequest{DryRun: true}, "users.csv", strings.NewReader(file))
+	require.NoError(t, err)
+	job, err = s.jobs.GetByID(ctx, resp.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, job.Rows, "Sup3r-Secret")
+	assert.NotContains(t, job.Rows, "encrypted_password")
+}
+
+func TestRunImportJobRejectsUndecryptablePassword(t *testing.T) {
+	s := newTestUserTransferService(t)
+	ctx := context.Background()
+
+	// 使用其他密钥加密的密码，例如加密后更换了 JWT 密钥
+	sealed, err := secretbox.New("old", "user import password").Seal("Sup3r-Secret")
+	require.NoError(t, err)
+	data, err := json.Marshal([]domain.UserImportRow{{Row: 2, Values: map[string]string{"username": "alice", "email": "alice@example.com", "status": "1"}, EncryptedPassword: sealed}})
+	require.NoError(t, err)
+	job := &model.UserImportJob{CreatedBy: 1, Format: "csv", Status: domain.ImportStatusPending, Total: 1, Rows: string(data)}
+	require.NoError(t, s.jobs.Create(ctx, job))
+
+	require.NoError(t, s.RunImportJob(ctx, job.ID))
+	result, err := s.GetImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	assert.Equal(t, domain.ImportRowFailed, result.Results[0].Status)
+	assert.Contains(t, result.Results[0].Errors[0], "cannot decrypt password")
+	_, err = s.users.GetByUsername(ctx, "alice")
+	assert.Error(t, err)
+}
+
+func TestRunImportJobResumesFromCheckpoint(t *testing.T) {
+	s := newTestUserTransferService(t)
+	require.NoError(t, s.users.Create(context.Background(), &model.User{Username: "taken", Email: "taken@example.com", Password: "hashed"}))
+
+	// 只校验的任务，第 121 行与第 6 行的用户名重复，最后一行用户名已存在
+	total := importCheckpointRows + 50
+	rows := make([]domain.UserImportRow, total)
+	for i := range rows {
+		rows[i] = domain.UserImportRow{Row: i + 2, Values: map[string]string{
+			"username": fmt.Sprintf("user%d", i),
+			"email":    fmt.Sprintf("user%d@example.com", i),
+			"status":   "1",
+		}}
+	}
+	rows[119].Values["username"] = "user4"
+	rows[total-1].Values["username"] = "taken"
+	data, err := json.Marshal(rows)
+	require.NoError(t, err)
+	job := &model.UserImportJob{CreatedBy: 1, Format: "csv", DryRun: true, Status: domain.ImportStatusPending, Total: total, Rows: string(data)}
+	require.NoError(t, s.jobs.Create(context.Background(), job))
+
+	// 第一次保存进度后中断，被中断的行不计入结果
+	ctx, cancel := context.WithCancel(context.Background())
+	var processed []int
+	s.jobs.onUpdate = func(job *model.UserImportJob) {
+		processed = append(processed, job.Processed)
+		if job.Processed == importCheckpointRows {
+			cancel()
+		}
+	}
+	assert.ErrorIs(t, s.RunImportJob(ctx, job.ID), context.Canceled)
+	assert.Equal(t, []int{0, importCheckpointRows, importCheckpointRows}, processed)
+
+	interrupted, err := s.GetImportJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportStatusRunning, interrupted.Status)
+	assert.Equal(t, importCheckpointRows, interrupted.Processed)
+	assert.NotEmpty(t, interrupted.Rows)
+
+	// 重新执行时从保存的进度继续，已处理的行参与重复检查
+	processed = nil
+	require.NoError(t, s.RunImportJob(context.Background(), job.ID))
+	assert.Equal(t, []int{total}, processed)
+
+	done, err := s.GetImportJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportStatusCompleted, done.Status)
+	assert.Empty(t, done.Rows)
+	require.Len(t, done.Results, total)
+	for i, result := range done.Results {
+		assert.Equal(t, rows[i].Row, result.Row)
+	}
+	assert.Equal(t, total-2, done.Succeeded)
+	assert.Equal(t, 2, done.Failed)
+	assert.Equal(t, []string{"username duplicates row 6"}, done.Results[119].Errors)
+	assert.Equal(t, []string{domain.ErrUsernameExists.Error()}, done.Results[total-1].Errors)
+
+	// 已结束的任务不会重复执行
+	processed = nil
+	require.NoError(t, s.RunImportJob(context.Background(), job.ID))
+	assert.Empty(t, processed)
+}
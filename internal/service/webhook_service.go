@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/internal/task"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/webhook"
+
+	"gorm.io/gorm"
+)
+
+type webhookService struct {
+	repo      domain.WebhookRepository
+	spaceRepo domain.SpaceRepository
+	client    *queue.Client
+	sender    *webhook.Sender
+	cfg       config.WebhookConfig
+	log       *slog.Logger
+}
+
+func NewWebhookService(repo domain.WebhookRepository, spaceRepo domain.SpaceRepository, client *queue.Client, sender *webhook.Sender, webhookConfig config.WebhookConfig, log *slog.Logger) domain.WebhookService {
+	return &webhookService{repo: repo, spaceRepo: spaceRepo, client: client, sender: sender, cfg: webhookConfig, log: log}
+}
+
+func (s *webhookService) Create(ctx context.Context, userID uint, req *domain.CreateWebhookRequest) (*domain.CreateWebhookResponse, error) {
+	ownerID := req.OwnerID
+	if req.OwnerType == domain.WebhookOwnerUser {
+		ownerID = userID
+	}
+	if err := s.authorize(ctx, userID, req.OwnerType, ownerID); err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &model.Webhook{
+		OwnerType:   req.OwnerType,
+		OwnerID:     ownerID,
+		CreatedBy:   userID,
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      req.Events,
+		Description: req.Description,
+		Enabled:     true,
+	}
+	if err := s.repo.Create(ctx, hook); err != nil {
+		s.log.ErrorContext(ctx, "Failed to create webhook", "error", err.Error(), "owner_type", req.OwnerType, "owner_id", ownerID)
+		return nil, err
+	}
+
+	s.log.InfoContext(ctx, "Webhook created", "webhook_id", hook.ID, "owner_type", hook.OwnerType, "owner_id", hook.OwnerID, "events", hook.Events)
+	return &domain.CreateWebhookResponse{Webhook: hook, Secret: secret}, nil
+}
+
+func (s *webhookService) GetAll(ctx context.Context, userID uint, ownerType string, ownerID uint) ([]model.Webhook, error) {
+	if ownerType == "" || ownerType == domain.WebhookOwnerUser {
+		ownerType, ownerID = domain.WebhookOwnerUser, userID
+	}
+	if err := s.authorize(ctx, userID, ownerType, ownerID); err != nil {
+		return nil, err
+	}
+
+	hooks, err := s.repo.GetByOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get webhooks", "error", err.Error(), "owner_type", ownerType, "owner_id", ownerID)
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (s *webhookService) GetByID(ctx context.Context, userID uint, id uint) (*model.Webhook, error) {
+	return s.getAuthorized(ctx, userID, id)
+}
+
+func (s *webhookService) Update(ctx context.Context, userID uint, id uint, req *domain.UpdateWebhookRequest) (*model.Webhook, error) {
+	hook, err := s.getAuthorized(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		hook.Events = req.Events
+	}
+	if req.Description != nil {
+		hook.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		if *req.Enabled && !hook.Enabled {
+			// 重新启用时清除自动停用状态
+			hook.ConsecutiveFailures = 0
+			hook.DisabledAt = nil
+			hook.DisabledReason = ""
+		}
+		hook.Enabled = *req.Enabled
+	}
+
+	if err := s.repo.Update(ctx, hook); err != nil {
+		s.log.ErrorContext(ctx, "Failed to update webhook", "error", err.Error(), "webhook_id", id)
+		return nil, err
+	}
+
+	s.log.InfoContext(ctx, "Webhook updated", "webhook_id", id, "enabled", hook.Enabled)
+	return hook, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, userID uint, id uint) error {
+	if _, err := s.getAuthorized(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.log.ErrorContext(ctx, "Failed to delete webhook", "error", err.Error(), "webhook_id", id)
+		return err
+	}
+
+	s.log.InfoContext(ctx, "Webhook deleted", "webhook_id", id)
+	return nil
+}
+
+func (s *webhookService) RotateSecret(ctx context.Context, userID uint, id uint) (*domain.CreateWebhookResponse, error) {
+	hook, err := s.getAuthorized(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = secret
+
+	if err := s.repo.Update(ctx, hook); err != nil {
+		s.log.ErrorContext(ctx, "Failed to rotate webhook secret", "error", err.Error(), "webhook_id", id)
+		return nil, err
+	}
+
+	s.log.InfoContext(ctx, "Webhook secret rotated", "webhook_id", id)
+	return &domain.CreateWebhookResponse{Webhook: hook, Secret: secret}, nil
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, userID uint, id uint, page *pagination.PageRequest) (*pagination.PageResponse, error) {
+	if _, err := s.getAuthorized(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	deliveries, total, err := s.repo.GetDeliveriesWithPagination(ctx, id, page)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to get webhook deliveries", "error", err.Error(), "webhook_id", id)
+		return nil, err
+	}
+	return pagination.NewPageResponse(deliveries, total, page.Page, page.PageSize), nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, userID uint, id uint, deliveryID uint) error {
+	hook, err := s.getAuthorized(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !hook.Enabled {
+		return domain.ErrWebhookDisabled
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	// 手动重新投递只尝试一次，结果记录在投递日志中
+	_, err = task.EnqueueWebhookDelivery(ctx, s.client, &domain.WebhookDeliveryPayload{
+		WebhookID: hook.ID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Body:      delivery.RequestBody,
+		Trigger:   domain.WebhookTriggerManual,
+	}, queue.MaxRetries(0))
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to enqueue webhook redelivery", "error", err.Error(), "webhook_id", id, "delivery_id", deliveryID)
+		return err
+	}
+
+	s.log.InfoContext(ctx, "Webhook redelivery enqueued", "webhook_id", id, "delivery_id", deliveryID, "event_id", delivery.EventID)
+	return nil
+}
+
+func (s *webhookService) Fanout(ctx context.Context, e *event.Event) error {
+	ownerID, err := strconv.ParseUint(e.AggregateID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	ownerType := e.AggregateType
+	if ownerType != domain.WebhookOwnerUser && ownerType != domain.WebhookOwnerSpace {
+		return nil
+	}
+
+	// 后台投递读主库，避免从库复制延迟时漏掉刚创建的 webhook 或投递给已禁用的 webhook
+	ctx = database.ForcePrimary(ctx)
+	hooks, err := s.repo.GetEnabledByOwner(ctx, ownerType, uint(ownerID))
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	for i := range hooks {
+		hook := &hooks[i]
+		if !hook.Subscribes(e.Type) {
+			continue
+		}
+
+		if body == nil {
+			body, err = json.Marshal(domain.WebhookBody{
+				EventID:       e.ID,
+				Type:          e.Type,
+				AggregateType: e.AggregateType,
+				AggregateID:   e.AggregateID,
+				OccurredAt:    e.OccurredAt.UTC().Format(time.RFC3339),
+				Data:          e.Payload,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// 唯一键保留到过期，投递完成后事件重试也不会重复投递给同一个 webhook
+		maxAttempts := s.cfg.MaxAttempts
+		_, err := task.EnqueueWebhookDelivery(ctx, s.client, &domain.WebhookDeliveryPayload{
+			WebhookID: hook.ID,
+			EventID:   e.ID,
+			EventType: e.Type,
+			Body:      string(body),
+			Trigger:   domain.WebhookTriggerEvent,
+		}, queue.MaxRetries(maxAttempts-1), queue.Unique(fmt.Sprintf("webhook:%d:%s", hook.ID, e.ID), 24*time.Hour), queue.KeepUnique())
+		if err != nil && !errors.Is(err, queue.ErrDuplicateTask) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *webhookService) Deliver(ctx context.Context, payload *domain.WebhookDeliveryPayload) error {
+	// 启用状态和已尝试次数读主库，从库复制延迟时会投递给已禁用的 webhook 或重复计算尝试次数
+	ctx = database.ForcePrimary(ctx)
+
+	hook, err := s.repo.GetByID(ctx, payload.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: webhook %d not found", queue.ErrSkipRetry, payload.WebhookID)
+	}
+	if err != nil {
+		return err
+	}
+	if !hook.Enabled {
+		return fmt.Errorf("%w: %w", queue.ErrSkipRetry, domain.ErrWebhookDisabled)
+	}
+
+	attempts, err := s.repo.CountAttempts(ctx, hook.ID, payload.EventID)
+	if err != nil {
+		return err
+	}
+
+	result := s.sender.Send(ctx, &webhook.Request{
+		URL:        hook.URL,
+		Secret:     hook.Secret,
+		EventType:  payload.EventType,
+		EventID:    payload.EventID,
+		DeliveryID: fmt.Sprintf("%s-%d", payload.EventID, hook.ID),
+		Body:       []byte(payload.Body),
+	})
+
+	delivery := &model.WebhookDelivery{
+		WebhookID:       hook.ID,
+		EventID:         payload.EventID,
+		EventType:       payload.EventType,
+		Attempt:         int(attempts) + 1,
+		Trigger:         payload.Trigger,
+		Status:          domain.WebhookDeliverySuccess,
+		RequestURL:      hook.URL,
+		RequestHeaders:  marshalHeaders(result.RequestHeaders),
+		RequestBody:     result.RequestBody,
+		ResponseStatus:  result.StatusCode,
+		ResponseHeaders: marshalHeaders(result.ResponseHeaders),
+		ResponseBody:    result.ResponseBody,
+		Error:           result.Error(),
+		DurationMs:      result.Duration.Milliseconds(),
+	}
+	if !result.OK() {
+		delivery.Status = domain.WebhookDeliveryFailed
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		s.log.ErrorContext(ctx, "Failed to save webhook delivery", "error", err.Error(), "webhook_id", hook.ID)
+	}
+
+	updated, err := s.repo.RecordResult(ctx, hook.ID, result.OK(), s.cfg.DisableAfter)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to record webhook result", "error", err.Error(), "webhook_id", hook.ID)
+	}
+
+	if result.OK() {
+		s.log.InfoContext(ctx, "Webhook delivered", "webhook_id", hook.ID, "event_id", payload.EventID, "status", result.StatusCode, "duration_ms", delivery.DurationMs)
+		return nil
+	}
+
+	s.log.WarnContext(ctx, "Webhook delivery failed", "webhook_id", hook.ID, "event_id", payload.EventID, "attempt", delivery.Attempt, "error", delivery.Error)
+	if updated != nil && !updated.Enabled {
+		s.log.WarnContext(ctx, "Webhook disabled after repeated failures", "webhook_id", hook.ID, "consecutive_failures", updated.ConsecutiveFailures)
+		return fmt.Errorf("%w: %s", queue.ErrSkipRetry, updated.DisabledReason)
+	}
+	return errors.New(delivery.Error)
+}
+
+// authorize 校验用户是否可以管理归属对象的 webhook：用户只能管理自己的，空间由拥有者管理
+func (s *webhookService) authorize(ctx context.Context, userID uint, ownerType string, ownerID uint) error {
+	switch ownerType {
+	case domain.WebhookOwnerUser:
+		if ownerID != userID {
+			return domain.ErrWebhookForbidden
+		}
+		return nil
+	case domain.WebhookOwnerSpace:
+		space, err := s.spaceRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		if space.OwnerUserID != userID {
+			return domain.ErrWebhookForbidden
+		}
+		return nil
+	default:
+		return domain.ErrWebhookForbidden
+	}
+}
+
+// getAuthorized 获取 webhook 并校验权限
+func (s *webhookService) getAuthorized(ctx context.Context, userID uint, id uint) (*model.Webhook, error) {
+	hook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, userID, hook.OwnerType, hook.OwnerID); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// newWebhookSecret 生成签名密钥
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("whsec_%x", b), nil
+}
+
+// marshalHeaders 将请求头编码为 JSON 保存
+func marshalHeaders(h map[string][]string) string {
+	if len(h) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
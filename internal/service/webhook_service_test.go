@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/internal/repository"
+	"github.com/chenyl99x/toge-api/internal/task"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookFanoutSpaceMemberJoined(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.Space{}, &model.Webhook{}, &model.WebhookDelivery{}))
+
+	repo := repository.NewWebhookRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log}))
+	broker := queue.NewMemoryBroker()
+	svc := NewWebhookService(repo, repository.NewSpaceRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log})), queue.NewClient(broker, 3, log), nil, config.WebhookConfig{MaxAttempts: 3}, log)
+	ctx := context.Background()
+
+	// 成员加入事件可以订阅
+	req := &domain.CreateWebhookRequest{OwnerType: domain.WebhookOwnerSpace, OwnerID: 1, URL: "https://example.com/hooks/toge", Events: []string{domain.EventSpaceMemberJoined}}
+	require.NoError(t, req.Validate())
+
+	subscribed := &model.Webhook{OwnerType: domain.WebhookOwnerSpace, OwnerID: 1, URL: req.URL, Secret: "s", Events: req.Events, Enabled: true}
+	other := &model.Webhook{OwnerType: domain.WebhookOwnerSpace, OwnerID: 1, URL: req.URL, Secret: "s", Events: []string{domain.EventSpaceUpdated}, Enabled: true}
+	require.NoError(t, repo.Create(ctx, subscribed))
+	require.NoError(t, repo.Create(ctx, other))
+
+	e, err := event.New(ctx, domain.EventSpaceMemberJoined, domain.AggregateSpace, 1, domain.SpaceMemberEventPayload{SpaceID: 1, UserID: 2})
+	require.NoError(t, err)
+	require.NoError(t, svc.Fanout(ctx, e))
+
+	// 只为订阅了该事件的 webhook 创建投递任务
+	delivery, err := broker.Pop(ctx, task.WebhookQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, delivery)
+	var payload domain.WebhookDeliveryPayload
+	require.NoError(t, delivery.Unmarshal(&payload))
+	assert.Equal(t, subscribed.ID, payload.WebhookID)
+	assert.Equal(t, domain.EventSpaceMemberJoined, payload.EventType)
+	assert.Contains(t, payload.Body, `"user_id":2`)
+
+	delivery, err = broker.Pop(ctx, task.WebhookQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, delivery)
+}
+
+func TestWebhookFanoutDoesNotRedeliverAfterCompletion(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	require.NoError(t, db.AutoMigrate(&model.Space{}, &model.Webhook{}, &model.WebhookDelivery{}))
+
+	repo := repository.NewWebhookRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log}))
+	broker := queue.NewMemoryBroker()
+	svc := NewWebhookService(repo, repository.NewSpaceRepository(db, database.NewTxManager(db, database.TxOptions{Logger: log})), queue.NewClient(broker, 3, log), nil, config.WebhookConfig{MaxAttempts: 3}, log)
+	ctx := context.Background()
+
+	hook := &model.Webhook{OwnerType: domain.WebhookOwnerSpace, OwnerID: 1, URL: "https://example.com/hooks/toge", Secret: "s", Events: []string{domain.EventSpaceUpdated}, Enabled: true}
+	require.NoError(t, repo.Create(ctx, hook))
+
+	var delivered atomic.Int32
+	worker := queue.NewWorker(broker, queue.WorkerOptions{Queues: map[string]int{task.WebhookQueue: 1}, PollInterval: 10 * time.Millisecond, Logger: log})
+	worker.Register(task.TypeWebhookDeliver, func(ctx context.Context, _ *queue.Task) error {
+		delivered.Add(1)
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	e, err := event.New(ctx, domain.EventSpaceUpdated, domain.AggregateSpace, 1, nil)
+	require.NoError(t, err)
+	require.NoError(t, svc.Fanout(ctx, e))
+	require.Eventually(t, func() bool { return delivered.Load() == 1 }, 3*time.Second, 5*time.Millisecond)
+
+	// 投递已完成后事件重试，不会再次投递给同一个 webhook
+	require.NoError(t, svc.Fanout(ctx, e))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), delivered.Load())
+}
@@ -0,0 +1,35 @@
+package subscriber
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/event"
+)
+
+// Register 注册进程内订阅者和需要转发到任务队列的事件
+// 耗时或依赖外部服务的处理放到任务队列，由 worker 执行
+func Register(bus *event.Bus, relay *event.Relay, webhookService domain.WebhookService, log *slog.Logger) {
+	bus.Subscribe(event.Wildcard, "audit_log", auditLog(log))
+
+	// 为订阅了事件的 webhook 创建投递任务
+	bus.Subscribe(event.Wildcard, "webhook_fanout", webhookService.Fanout)
+
+	// 新用户欢迎通知，由 worker 处理
+	relay.Forward(domain.EventUserRegistered)
+}
+
+// auditLog 记录所有领域事件，日志沿用产生事件的请求 trace_id
+func auditLog(log *slog.Logger) event.Handler {
+	return func(ctx context.Context, e *event.Event) error {
+		log.InfoContext(ctx, "Domain event",
+			"event_id", e.ID,
+			"type", e.Type,
+			"aggregate_type", e.AggregateType,
+			"aggregate_id", e.AggregateID,
+			"occurred_at", e.OccurredAt,
+		)
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+package task
+
+import (
+	"context"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+)
+
+// TypeNotificationSend 异步发送通知任务类型
+const TypeNotificationSend = "notification:send"
+
+// EnqueueNotification 将通知发送放入队列，适用于邮件、推送等较慢的外部渠道
+func EnqueueNotification(ctx context.Context, client *queue.Client, req *domain.NotifyRequest, opts ...queue.Option) (*queue.Task, error) {
+	return client.Enqueue(ctx, TypeNotificationSend, req, opts...)
+}
+
+// NewNotificationSendHandler 创建通知发送任务处理函数
+// 未指定去重键时使用任务 ID，重试时站内信不会重复写入
+func NewNotificationSendHandler(notificationService domain.NotificationService) queue.Handler {
+	return func(ctx context.Context, task *queue.Task) error {
+		var req domain.NotifyRequest
+		if err := task.Unmarshal(&req); err != nil {
+			return err
+		}
+		if req.DedupKey == "" {
+			req.DedupKey = "task:" + task.ID
+		}
+		return notificationService.Notify(ctx, &req)
+	}
+}
@@ -0,0 +1,17 @@
+package task
+
+import (
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+)
+
+// RegisterHandlers 注册所有后台任务的处理函数
+func RegisterHandlers(w *queue.Worker, notificationService domain.NotificationService, userService domain.UserService, webhookService domain.WebhookService, transferService domain.UserTransferService) {
+	w.Register(TypeNotificationSend, NewNotificationSendHandler(notificationService))
+	queue.Handle(w, TypeWebhookDeliver, NewWebhookDeliverHandler(webhookService))
+	queue.Handle(w, TypeUserImport, NewUserImportHandler(transferService))
+
+	// 由事件 Relay 转发的领域事件
+	queue.Handle(w, event.TaskType(domain.EventUserRegistered), NewUserWelcomeHandler(notificationService, userService))
+}
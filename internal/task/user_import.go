@@ -0,0 +1,31 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+
+	"gorm.io/gorm"
+)
+
+// TypeUserImport 用户批量导入任务类型
+const TypeUserImport = "user:import"
+
+// EnqueueUserImport 将导入任务放入队列
+func EnqueueUserImport(ctx context.Context, client *queue.Client, payload *domain.UserImportPayload, opts ...queue.Option) (*queue.Task, error) {
+	return client.Enqueue(ctx, TypeUserImport, payload, opts...)
+}
+
+// NewUserImportHandler 创建用户导入任务处理函数，超时或中断后重试时从保存的进度继续
+func NewUserImportHandler(transferService domain.UserTransferService) func(ctx context.Context, payload domain.UserImportPayload) error {
+	return func(ctx context.Context, payload domain.UserImportPayload) error {
+		err := transferService.RunImportJob(ctx, payload.JobID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("user import job %d: %w", payload.JobID, errors.Join(err, queue.ErrSkipRetry))
+		}
+		return err
+	}
+}
@@ -0,0 +1,49 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chenyl99x/toge-api/internal/consts"
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/i18n"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+
+	"gorm.io/gorm"
+)
+
+// NewUserWelcomeHandler 创建新用户欢迎通知处理函数，处理转发的 user.registered 事件
+// 通知文案使用接收人的语言偏好，未设置时使用默认语言
+func NewUserWelcomeHandler(notificationService domain.NotificationService, userService domain.UserService) func(ctx context.Context, e event.Event) error {
+	return func(ctx context.Context, e event.Event) error {
+		var payload domain.UserEventPayload
+		if err := e.Unmarshal(&payload); err != nil {
+			return err
+		}
+
+		// 读主库，刚注册的用户可能还没有复制到从库
+		user, err := userService.GetByID(database.ForcePrimary(ctx), payload.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: user %d not found", queue.ErrSkipRetry, payload.UserID)
+		}
+		if err != nil {
+			return err
+		}
+		locale, ok := i18n.Parse(user.Locale)
+		if !ok {
+			locale = i18n.Default
+		}
+
+		return notificationService.Notify(ctx, &domain.NotifyRequest{
+			UserID:   user.ID,
+			Category: consts.NotificationCategorySystem,
+			Title:    i18n.T(locale, "Welcome to Together"),
+			Content:  i18n.Sprintf(locale, "Hi %s, create or join a space to start recording your life together.", user.Username),
+			Link:     "/space",
+			DedupKey: "welcome:" + e.ID,
+		})
+	}
+}
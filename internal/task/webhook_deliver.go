@@ -0,0 +1,27 @@
+package task
+
+import (
+	"context"
+
+	"github.com/chenyl99x/toge-api/internal/domain"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+)
+
+// TypeWebhookDeliver webhook 投递任务类型
+const TypeWebhookDeliver = "webhook:deliver"
+
+// WebhookQueue webhook 投递使用独立队列，避免慢速接收方占满默认队列
+const WebhookQueue = "webhook"
+
+// EnqueueWebhookDelivery 将 webhook 投递放入队列
+func EnqueueWebhookDelivery(ctx context.Context, client *queue.Client, payload *domain.WebhookDeliveryPayload, opts ...queue.Option) (*queue.Task, error) {
+	opts = append([]queue.Option{queue.Queue(WebhookQueue)}, opts...)
+	return client.Enqueue(ctx, TypeWebhookDeliver, payload, opts...)
+}
+
+// NewWebhookDeliverHandler 创建 webhook 投递任务处理函数
+func NewWebhookDeliverHandler(webhookService domain.WebhookService) func(ctx context.Context, payload domain.WebhookDeliveryPayload) error {
+	return func(ctx context.Context, payload domain.WebhookDeliveryPayload) error {
+		return webhookService.Deliver(ctx, &payload)
+	}
+}
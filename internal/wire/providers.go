@@ -1,45 +1,318 @@
 package wire
 
 import (
+	"fmt"
+	"log/slog"
+	"time"
+
 	"github.com/chenyl99x/toge-api/internal/app"
+	"github.com/chenyl99x/toge-api/internal/domain"
 	"github.com/chenyl99x/toge-api/internal/handler"
+	"github.com/chenyl99x/toge-api/internal/job"
+	"github.com/chenyl99x/toge-api/internal/middleware"
 	"github.com/chenyl99x/toge-api/internal/repository"
 	"github.com/chenyl99x/toge-api/internal/service"
+	"github.com/chenyl99x/toge-api/internal/subscriber"
+	"github.com/chenyl99x/toge-api/internal/task"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/event"
+	"github.com/chenyl99x/toge-api/pkg/health"
+	"github.com/chenyl99x/toge-api/pkg/jwt"
+	"github.com/chenyl99x/toge-api/pkg/lifecycle"
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/migrate"
+	"github.com/chenyl99x/toge-api/pkg/notifier"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+	"github.com/chenyl99x/toge-api/pkg/search"
+	"github.com/chenyl99x/toge-api/pkg/secretbox"
+	"github.com/chenyl99x/toge-api/pkg/webhook"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
+	"gorm.io/gorm"
 )
 
 // ProviderSet 是 wire 的提供者集合
 var ProviderSet = wire.NewSet(
+	// 配置，按模块拆分后注入，组件只依赖自己需要的配置
+	wire.FieldsOf(new(*config.Config),
+		"Database", "Redis", "Cache", "Log", "JWT", "Timezone", "Notification",
+		"Queue", "Event", "Search", "Transfer", "Webhook", "Health", "RateLimit", "Admin"),
+
+	// 基础设施，连接在生命周期的启动阶段检查
+	logger.NewLevel,
+	logger.New,
+	database.New,
+	database.NewReplicas,
+	ProvideTxManager,
+	redis.New,
+	jwt.NewManager,
+	migrate.New,
+
 	// Repository 层
-	repository.NewUserRepository,
+	ProvidePaginationCursors,
+	ProvideUserRepository,
 	repository.NewSpaceRepository,
+	repository.NewNotificationRepository,
+	repository.NewEventRepository,
+	repository.NewWebhookRepository,
+	repository.NewUserImportRepository,
 
 	// Service 层
 	service.NewUserService,
 	service.NewSpaceService,
+	service.NewNotificationService,
+	service.NewEventService,
+	service.NewWebhookService,
+	service.NewSearchService,
+	ProvideUserImportBox,
+	service.NewUserTransferService,
 	// Handler 层
 	handler.NewAuthHandler,
 	handler.NewHealthHandler,
 	handler.NewTimezoneHandler,
+	middleware.NewUserLocales,
 	handler.NewUserHandler,
 	handler.NewSpaceHandler,
+	handler.NewNotificationHandler,
+	handler.NewJobHandler,
+	handler.NewQueueHandler,
+	handler.NewEventHandler,
+	handler.NewWebhookHandler,
+	handler.NewSearchHandler,
+	handler.NewUserTransferHandler,
+
+	// 通知分发器
+	ProvideNotificationDispatcher,
+
+	// 定时任务调度器
+	ProvideScheduler,
+
+	// 后台任务队列
+	ProvideQueueBroker,
+	ProvideQueueClient,
+	ProvideQueueWorker,
+
+	// 领域事件
+	ProvideEventBus,
+	ProvideEventRelay,
+
+	// 全文搜索
+	ProvideSearchIndex,
+	service.NewSearchSource,
+	ProvideSearchSyncer,
+
+	// Webhook 发送器
+	ProvideWebhookSender,
+
+	// 组件生命周期
+	lifecycle.New,
+
+	// 依赖检查
+	ProvideHealthRegistry,
+
+	// 配置热更新
+	config.NewReloader,
+
+	// 限流
+	ProvideRateLimiter,
 
 	// 提供 gin 引擎
 	ProvideGinEngine,
 
 	// 提供应用实例
 	app.NewApp,
-	app.InitializeDatabase,
+	app.NewWorker,
 )
 
 // ProvideGinEngine 提供 gin 引擎
-func ProvideGinEngine() *gin.Engine {
+func ProvideGinEngine(cfg *config.Config) (*gin.Engine, error) {
 	// 使用 gin.New() 而不是 gin.Default() 来避免默认的日志中间件
 	engine := gin.New()
 
 	// 只添加必要的中间件，不包含默认的日志中间件
 	// 我们使用自定义的日志中间件来替代
-	return engine
+
+	// gin 默认信任所有代理，客户端可以通过 X-Forwarded-For 伪造 IP 绕过按 IP 限流
+	// 只信任配置的代理，未配置时使用连接的对端地址
+	if err := engine.SetTrustedProxies(cfg.App.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("failed to set trusted proxies: %w", err)
+	}
+	return engine, nil
+}
+
+// ProvideUserRepository 提供用户仓储，开启缓存时按 ID、用户名、邮箱的查询先读 Redis
+func ProvideUserRepository(db *gorm.DB, cursors *pagination.Cursors, client redis.Client, cacheConfig config.CacheConfig, log *slog.Logger) domain.UserRepository {
+	repo := repository.NewUserRepository(db, cursors)
+	if !cacheConfig.Enabled {
+		return repo
+	}
+	return repository.NewCachedUserRepository(repo, client, cacheConfig, log)
+}
+
+// ProvidePaginationCursors 提供分页游标签名器，签名密钥由 JWT 密钥派生
+func ProvidePaginationCursors(jwtConfig config.JWTConfig) *pagination.Cursors {
+	return pagination.NewCursors(jwtConfig.Secret)
+}
+
+// ProvideUserImportBox 提供导入任务中明文密码的加密器，密钥由 JWT 密钥派生
+func ProvideUserImportBox(jwtConfig config.JWTConfig) *secretbox.Box {
+	return secretbox.New(jwtConfig.Secret, "user import password")
+}
+
+// ProvideNotificationDispatcher 提供通知分发器
+// 站内信始终注册，邮件和推送按配置开启
+func ProvideNotificationDispatcher(repo domain.NotificationRepository, notificationConfig config.NotificationConfig) *notifier.Dispatcher {
+	channels := []notifier.Channel{service.NewInAppChannel(repo)}
+
+	if notificationConfig.Email.Enabled {
+		channels = append(channels, notifier.NewEmailChannel(notificationConfig.Email))
+	}
+	if notificationConfig.Push.Enabled {
+		channels = append(channels, notifier.NewPushChannel(notificationConfig.Push))
+	}
+
+	return notifier.NewDispatcher(channels...)
+}
+
+// ProvideScheduler 提供定时任务调度器并注册内置任务
+// 使用 Redis 锁保证多实例部署时每次触发只有一个实例执行
+func ProvideScheduler(cfg *config.Config, client redis.Client, notificationRepo domain.NotificationRepository, eventRepo domain.EventRepository, log *slog.Logger) (*scheduler.Scheduler, error) {
+	schedulerConfig := cfg.Scheduler
+	tz := schedulerConfig.Timezone
+	if tz == "" {
+		tz = cfg.Timezone.Timezone
+	}
+
+	s, err := scheduler.New(scheduler.Options{
+		Timezone: tz,
+		Locker:   scheduler.NewRedisLocker(client, "scheduler:lock:"),
+		History:  scheduler.NewRedisHistory(client, "scheduler:history:", schedulerConfig.HistorySize),
+		Logger:   log,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := job.Definitions(notificationRepo, eventRepo, cfg.Notification, cfg.Event, log)
+	if err := job.RegisterJobs(s, schedulerConfig, definitions, log); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ProvideQueueBroker 提供基于 Redis 的任务存储
+func ProvideQueueBroker(client redis.Client, queueConfig config.QueueConfig) queue.Broker {
+	return queue.NewRedisBroker(client, "queue:", queueConfig.DeadSize)
+}
+
+// ProvideQueueClient 提供任务生产者
+func ProvideQueueClient(broker queue.Broker, queueConfig config.QueueConfig, log *slog.Logger) *queue.Client {
+	return queue.NewClient(broker, queueConfig.MaxRetries, log)
+}
+
+// ProvideQueueWorker 提供任务消费者并注册所有任务处理函数
+func ProvideQueueWorker(broker queue.Broker, queueConfig config.QueueConfig, notificationService domain.NotificationService, userService domain.UserService, webhookService domain.WebhookService, transferService domain.UserTransferService, log *slog.Logger) *queue.Worker {
+	w := queue.NewWorker(broker, queue.WorkerOptions{
+		Queues:            queueConfig.Queues,
+		PollInterval:      time.Duration(queueConfig.PollInterval) * time.Millisecond,
+		BackoffBase:       time.Duration(queueConfig.BackoffBase) * time.Second,
+		BackoffMax:        time.Duration(queueConfig.BackoffMax) * time.Second,
+		Timeout:           time.Duration(queueConfig.Timeout) * time.Second,
+		VisibilityTimeout: time.Duration(queueConfig.VisibilityTimeout) * time.Second,
+		Logger:            log,
+	})
+	task.RegisterHandlers(w, notificationService, userService, webhookService, transferService)
+	return w
+}
+
+// ProvideEventBus 提供进程内事件总线
+func ProvideEventBus(log *slog.Logger) *event.Bus {
+	return event.NewBus(log)
+}
+
+// ProvideEventRelay 提供发件箱 Relay 并注册订阅者
+// 使用 Redis 锁保证多实例部署时同一时刻只有一个实例在投递
+func ProvideEventRelay(store domain.EventRepository, bus *event.Bus, client *queue.Client, redisClient redis.Client, webhookService domain.WebhookService, eventConfig config.EventConfig, log *slog.Logger) *event.Relay {
+	relay := event.NewRelay(store, bus, client, event.RelayOptions{
+		PollInterval: time.Duration(eventConfig.PollInterval) * time.Millisecond,
+		BatchSize:    eventConfig.BatchSize,
+		MaxAttempts:  eventConfig.MaxAttempts,
+		BackoffBase:  time.Duration(eventConfig.BackoffBase) * time.Second,
+		BackoffMax:   time.Duration(eventConfig.BackoffMax) * time.Second,
+		Locker:       scheduler.NewRedisLocker(redisClient, "event:lock:"),
+		Logger:       log,
+	})
+	subscriber.Register(bus, relay, webhookService, log)
+	return relay
+}
+
+// ProvideSearchIndex 提供进程内全文索引
+func ProvideSearchIndex() search.Indexer {
+	return search.NewMemoryIndex()
+}
+
+// ProvideSearchSyncer 提供索引同步器，每个实例独立读取发件箱事件更新自己的索引
+func ProvideSearchSyncer(index search.Indexer, source search.Source, searchConfig config.SearchConfig, log *slog.Logger) *search.Syncer {
+	return search.NewSyncer(index, source, search.SyncOptions{
+		Interval:  time.Duration(searchConfig.SyncInterval) * time.Millisecond,
+		BatchSize: searchConfig.BatchSize,
+		Lookback:  time.Duration(searchConfig.Lookback) * time.Second,
+		Logger:    log,
+	})
+}
+
+// ProvideWebhookSender 提供 webhook 发送器
+func ProvideWebhookSender(webhookConfig config.WebhookConfig) *webhook.Sender {
+	return webhook.NewSender(webhook.Options{
+		Timeout:              time.Duration(webhookConfig.Timeout) * time.Second,
+		AllowPrivateNetworks: webhookConfig.AllowPrivateNetworks,
+	})
+}
+
+// ProvideHealthRegistry 提供依赖检查注册表
+// 数据库和迁移为关键检查，失败时摘除流量；从库、Redis 和磁盘空间失败时降级运行
+func ProvideHealthRegistry(db *gorm.DB, replicas *database.Replicas, client redis.Client, migrator *migrate.Migrator, healthConfig config.HealthConfig, logConfig config.LogConfig) (*health.Registry, error) {
+	registry := health.NewRegistry(health.Options{
+		Timeout:  time.Duration(healthConfig.Timeout) * time.Millisecond,
+		CacheTTL: time.Duration(healthConfig.CacheTTL) * time.Millisecond,
+	})
+
+	checks := []health.Check{
+		{Name: "database", Critical: true, Func: health.Database(db)},
+		{Name: "redis", Func: health.Redis(client)},
+		{
+			Name: "disk",
+			Func: health.DiskSpace(health.LogDir(logConfig.File.Path), uint64(healthConfig.DiskMinFreeMB)<<20),
+		},
+		// 迁移只在发布时变化，缓存更久
+		{Name: "migrations", Critical: true, CacheTTL: time.Minute, Func: health.Migrations(migrator.PendingMigrations)},
+	}
+	if replicas.Len() > 0 {
+		checks = append(checks, health.Check{Name: "database_replicas", Func: health.DatabaseReplicas(replicas)})
+	}
+	for _, check := range checks {
+		if err := registry.Register(check); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// ProvideTxManager 提供事务管理器，死锁时按配置重试整个事务
+func ProvideTxManager(db *gorm.DB, dbConfig config.DatabaseConfig, log *slog.Logger) *database.TxManager {
+	return database.NewTxManager(db, database.TxOptions{
+		MaxRetries:   dbConfig.TxMaxRetries,
+		RetryBackoff: time.Duration(dbConfig.TxRetryBackoff) * time.Millisecond,
+		Logger:       log,
+	})
+}
+
+// ProvideRateLimiter 提供按客户端 IP 的限流器，配置热更新时由 App 更新
+func ProvideRateLimiter(rateLimitConfig config.RateLimitConfig) *middleware.RateLimiter {
+	return middleware.NewRateLimiter(rateLimitConfig)
 }
@@ -5,12 +5,19 @@ package wire
 
 import (
 	"github.com/chenyl99x/toge-api/internal/app"
+	"github.com/chenyl99x/toge-api/pkg/config"
 
 	"github.com/google/wire"
 )
 
 // InitializeApp 初始化应用
-func InitializeApp() (*app.App, error) {
+func InitializeApp(cfg *config.Config) (*app.App, error) {
 	wire.Build(ProviderSet)
 	return &app.App{}, nil
 }
+
+// InitializeWorker 初始化后台任务进程，与 API 共用同一套依赖
+func InitializeWorker(cfg *config.Config) (*app.Worker, error) {
+	wire.Build(ProviderSet)
+	return &app.Worker{}, nil
+}
@@ -9,24 +9,150 @@ package wire
 import (
 	"github.com/chenyl99x/toge-api/internal/app"
 	"github.com/chenyl99x/toge-api/internal/handler"
+	"github.com/chenyl99x/toge-api/internal/middleware"
 	"github.com/chenyl99x/toge-api/internal/repository"
 	"github.com/chenyl99x/toge-api/internal/service"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/jwt"
+	"github.com/chenyl99x/toge-api/pkg/lifecycle"
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/migrate"
+	"github.com/chenyl99x/toge-api/pkg/redis"
 )
 
 // Injectors from wire.go:
 
 // InitializeApp 初始化应用
-func InitializeApp() (*app.App, error) {
-	engine := ProvideGinEngine()
-	userRepository := repository.NewUserRepository()
-	userService := service.NewUserService(userRepository)
-	authHandler := handler.NewAuthHandler(userService)
-	healthHandler := handler.NewHealthHandler()
-	userHandler := handler.NewUserHandler(userService)
-	spaceRepository := repository.NewSpaceRepository()
-	spaceService := service.NewSpaceService(spaceRepository)
+func InitializeApp(cfg *config.Config) (*app.App, error) {
+	databaseConfig := cfg.Database
+	logConfig := cfg.Log
+	levelVar := logger.NewLevel(logConfig)
+	slogLogger := logger.New(logConfig, levelVar)
+	db, err := database.New(databaseConfig, logConfig, slogLogger)
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := database.NewReplicas(db, databaseConfig, slogLogger)
+	if err != nil {
+		return nil, err
+	}
+	redisConfig := cfg.Redis
+	v := redis.New(redisConfig)
+	jwtConfig := cfg.JWT
+	manager := jwt.NewManager(jwtConfig)
+	cursors := ProvidePaginationCursors(jwtConfig)
+	cacheConfig := cfg.Cache
+	userRepository := ProvideUserRepository(db, cursors, v, cacheConfig, slogLogger)
+	userLocales := middleware.NewUserLocales(userRepository, slogLogger)
+	engine, err := ProvideGinEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+	txManager := ProvideTxManager(db, databaseConfig, slogLogger)
+	eventRepository := repository.NewEventRepository(db)
+	userService := service.NewUserService(txManager, userRepository, eventRepository, slogLogger)
+	adminConfig := cfg.Admin
+	authHandler := handler.NewAuthHandler(userService, manager, v, adminConfig, slogLogger)
+	lifecycleManager := lifecycle.New(slogLogger)
+	migrator := migrate.New(db, slogLogger)
+	healthConfig := cfg.Health
+	registry, err := ProvideHealthRegistry(db, replicas, v, migrator, healthConfig, logConfig)
+	if err != nil {
+		return nil, err
+	}
+	reloader := config.NewReloader(cfg, slogLogger)
+	healthHandler := handler.NewHealthHandler(lifecycleManager, registry, db, replicas, reloader)
+	userHandler := handler.NewUserHandler(userService, userLocales)
+	userImportRepository := repository.NewUserImportRepository(db)
+	queueConfig := cfg.Queue
+	broker := ProvideQueueBroker(v, queueConfig)
+	client := ProvideQueueClient(broker, queueConfig, slogLogger)
+	box := ProvideUserImportBox(jwtConfig)
+	transferConfig := cfg.Transfer
+	userTransferService := service.NewUserTransferService(userService, userRepository, userImportRepository, client, box, transferConfig, slogLogger)
+	userTransferHandler := handler.NewUserTransferHandler(userTransferService)
+	spaceRepository := repository.NewSpaceRepository(db, txManager)
+	spaceService := service.NewSpaceService(txManager, spaceRepository, eventRepository, slogLogger)
 	spaceHandler := handler.NewSpaceHandler(spaceService)
 	timezoneHandler := handler.NewTimezoneHandler()
-	appApp := app.NewApp(engine, authHandler, healthHandler, userHandler, spaceHandler, timezoneHandler)
+	notificationRepository := repository.NewNotificationRepository(db)
+	notificationConfig := cfg.Notification
+	dispatcher := ProvideNotificationDispatcher(notificationRepository, notificationConfig)
+	timezoneConfig := cfg.Timezone
+	notificationService := service.NewNotificationService(notificationRepository, userRepository, dispatcher, timezoneConfig, slogLogger)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	scheduler, err := ProvideScheduler(cfg, v, notificationRepository, eventRepository, slogLogger)
+	if err != nil {
+		return nil, err
+	}
+	jobHandler := handler.NewJobHandler(scheduler, slogLogger)
+	queueHandler := handler.NewQueueHandler(client, slogLogger)
+	eventService := service.NewEventService(eventRepository, slogLogger)
+	eventHandler := handler.NewEventHandler(eventService, slogLogger)
+	webhookRepository := repository.NewWebhookRepository(db, txManager)
+	webhookConfig := cfg.Webhook
+	sender := ProvideWebhookSender(webhookConfig)
+	webhookService := service.NewWebhookService(webhookRepository, spaceRepository, client, sender, webhookConfig, slogLogger)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	indexer := ProvideSearchIndex()
+	searchService := service.NewSearchService(indexer, spaceRepository, slogLogger)
+	searchHandler := handler.NewSearchHandler(searchService)
+	bus := ProvideEventBus(slogLogger)
+	eventConfig := cfg.Event
+	relay := ProvideEventRelay(eventRepository, bus, client, v, webhookService, eventConfig, slogLogger)
+	source := service.NewSearchSource(userRepository, spaceRepository, eventRepository)
+	searchConfig := cfg.Search
+	syncer := ProvideSearchSyncer(indexer, source, searchConfig, slogLogger)
+	rateLimitConfig := cfg.RateLimit
+	rateLimiter := ProvideRateLimiter(rateLimitConfig)
+	appApp := app.NewApp(cfg, db, replicas, v, slogLogger, levelVar, manager, userLocales, engine, authHandler, healthHandler, userHandler, userTransferHandler, spaceHandler, timezoneHandler, notificationHandler, jobHandler, queueHandler, eventHandler, webhookHandler, searchHandler, scheduler, relay, syncer, lifecycleManager, reloader, rateLimiter)
 	return appApp, nil
 }
+
+// InitializeWorker 初始化后台任务进程，与 API 共用同一套依赖
+func InitializeWorker(cfg *config.Config) (*app.Worker, error) {
+	databaseConfig := cfg.Database
+	logConfig := cfg.Log
+	levelVar := logger.NewLevel(logConfig)
+	slogLogger := logger.New(logConfig, levelVar)
+	db, err := database.New(databaseConfig, logConfig, slogLogger)
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := database.NewReplicas(db, databaseConfig, slogLogger)
+	if err != nil {
+		return nil, err
+	}
+	redisConfig := cfg.Redis
+	v := redis.New(redisConfig)
+	queueConfig := cfg.Queue
+	broker := ProvideQueueBroker(v, queueConfig)
+	notificationRepository := repository.NewNotificationRepository(db)
+	jwtConfig := cfg.JWT
+	cursors := ProvidePaginationCursors(jwtConfig)
+	cacheConfig := cfg.Cache
+	userRepository := ProvideUserRepository(db, cursors, v, cacheConfig, slogLogger)
+	notificationConfig := cfg.Notification
+	dispatcher := ProvideNotificationDispatcher(notificationRepository, notificationConfig)
+	timezoneConfig := cfg.Timezone
+	notificationService := service.NewNotificationService(notificationRepository, userRepository, dispatcher, timezoneConfig, slogLogger)
+	txManager := ProvideTxManager(db, databaseConfig, slogLogger)
+	eventRepository := repository.NewEventRepository(db)
+	userService := service.NewUserService(txManager, userRepository, eventRepository, slogLogger)
+	webhookRepository := repository.NewWebhookRepository(db, txManager)
+	spaceRepository := repository.NewSpaceRepository(db, txManager)
+	client := ProvideQueueClient(broker, queueConfig, slogLogger)
+	webhookConfig := cfg.Webhook
+	sender := ProvideWebhookSender(webhookConfig)
+	webhookService := service.NewWebhookService(webhookRepository, spaceRepository, client, sender, webhookConfig, slogLogger)
+	userImportRepository := repository.NewUserImportRepository(db)
+	box := ProvideUserImportBox(jwtConfig)
+	transferConfig := cfg.Transfer
+	userTransferService := service.NewUserTransferService(userService, userRepository, userImportRepository, client, box, transferConfig, slogLogger)
+	worker := ProvideQueueWorker(broker, queueConfig, notificationService, userService, webhookService, userTransferService, slogLogger)
+	manager := lifecycle.New(slogLogger)
+	reloader := config.NewReloader(cfg, slogLogger)
+	appWorker := app.NewWorker(cfg, db, replicas, v, slogLogger, levelVar, worker, manager, reloader)
+	return appWorker, nil
+}
@@ -0,0 +1,143 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Code 业务错误码，随错误响应的 error_code 返回，取值稳定，客户端按错误码而不是错误信息判断错误类型
+type Code string
+
+const (
+	CodeInvalidArgument      Code = "INVALID_ARGUMENT"       // 请求参数错误，HTTP 400
+	CodeValidationFailed     Code = "VALIDATION_FAILED"      // 请求参数校验失败，HTTP 400
+	CodeUnauthenticated      Code = "UNAUTHENTICATED"        // 未登录或令牌无效，HTTP 401
+	CodePermissionDenied     Code = "PERMISSION_DENIED"      // 没有权限，HTTP 403
+	CodeNotFound             Code = "NOT_FOUND"              // 资源不存在，HTTP 404
+	CodeMethodNotAllowed     Code = "METHOD_NOT_ALLOWED"     // 请求方法不支持，HTTP 405
+	CodeConflict             Code = "CONFLICT"               // 资源已存在或状态冲突，HTTP 409
+	CodeUsernameExists       Code = "USERNAME_EXISTS"        // 用户名已存在，HTTP 409
+	CodeEmailExists          Code = "EMAIL_EXISTS"           // 邮箱已存在，HTTP 409
+	CodeVersionConflict      Code = "VERSION_CONFLICT"       // 资源已被其他请求修改，HTTP 409
+	CodePreconditionFailed   Code = "PRECONDITION_FAILED"    // If-Match 与资源的当前版本不一致，HTTP 412
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE" // 不支持的请求体格式，HTTP 415
+	CodeInvalidImport        Code = "INVALID_IMPORT"         // 导入文件或列映射无效，HTTP 400
+	CodePreconditionRequired Code = "PRECONDITION_REQUIRED"  // 缺少 If-Match，HTTP 428
+	CodeRateLimited          Code = "RATE_LIMITED"           // 请求过于频繁，HTTP 429
+	CodeInternal             Code = "INTERNAL"               // 服务器内部错误，HTTP 500
+	CodeUnavailable          Code = "UNAVAILABLE"            // 服务暂不可用，HTTP 503
+)
+
+// statuses 错误码对应的 HTTP 状态码
+var statuses = map[Code]int{
+	CodeInvalidArgument:      http.StatusBadRequest,
+	CodeValidationFailed:     http.StatusBadRequest,
+	CodeUnauthenticated:      http.StatusUnauthorized,
+	CodePermissionDenied:     http.StatusForbidden,
+	CodeNotFound:             http.StatusNotFound,
+	CodeMethodNotAllowed:     http.StatusMethodNotAllowed,
+	CodeConflict:             http.StatusConflict,
+	CodeUsernameExists:       http.StatusConflict,
+	CodeEmailExists:          http.StatusConflict,
+	CodeVersionConflict:      http.StatusConflict,
+	CodePreconditionFailed:   http.StatusPreconditionFailed,
+	CodeUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	CodeInvalidImport:        http.StatusBadRequest,
+	CodePreconditionRequired: http.StatusPreconditionRequired,
+	CodeRateLimited:          http.StatusTooManyRequests,
+	CodeInternal:             http.StatusInternalServerError,
+	CodeUnavailable:          http.StatusServiceUnavailable,
+}
+
+// defaults 只知道 HTTP 状态码时使用的通用错误码
+var defaults = map[int]Code{
+	http.StatusBadRequest:           CodeInvalidArgument,
+	http.StatusUnauthorized:         CodeUnauthenticated,
+	http.StatusForbidden:            CodePermissionDenied,
+	http.StatusNotFound:             CodeNotFound,
+	http.StatusMethodNotAllowed:     CodeMethodNotAllowed,
+	http.StatusConflict:             CodeConflict,
+	http.StatusPreconditionFailed:   CodePreconditionFailed,
+	http.StatusUnsupportedMediaType: CodeUnsupportedMediaType,
+	http.StatusPreconditionRequired: CodePreconditionRequired,
+	http.StatusTooManyRequests:      CodeRateLimited,
+	http.StatusServiceUnavailable:   CodeUnavailable,
+}
+
+// HTTPStatus 错误码对应的 HTTP 状态码，未知的错误码按 500 处理
+func (c Code) HTTPStatus() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeForStatus HTTP 状态码对应的通用错误码，其他 4xx 为 INVALID_ARGUMENT，5xx 为 INTERNAL
+func CodeForStatus(status int) Code {
+	if code, ok := defaults[status]; ok {
+		return code
+	}
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		return CodeInvalidArgument
+	}
+	return CodeInternal
+}
+
+// Error 带错误码的应用错误，Message 返回给客户端，Err 为原始错误，只用于日志和链路追踪
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+// New 创建应用错误，通常作为领域层的哨兵错误，用 errors.Is 比较
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap 用错误码和对外的错误信息包装原始错误
+func Wrap(code Code, err error, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus 错误对应的 HTTP 状态码
+func (e *Error) HTTPStatus() int {
+	return e.Code.HTTPStatus()
+}
+
+// From 把任意错误转换为应用错误：
+//   - 错误链中的 *Error 保留其错误码，被 fmt.Errorf 包装的 4xx 错误使用完整的错误信息，例如 invalid import: file is empty
+//   - gorm.ErrRecordNotFound 为 NOT_FOUND，gorm.ErrDuplicatedKey（唯一索引冲突）为 CONFLICT
+//   - 其他错误为 INTERNAL，不向客户端暴露原始错误信息
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	switch {
+	case errors.As(err, &appErr):
+		if appErr == err || appErr.HTTPStatus() >= http.StatusInternalServerError {
+			return appErr
+		}
+		return &Error{Code: appErr.Code, Message: err.Error(), Err: err}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return Wrap(CodeNotFound, err, "resource not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return Wrap(CodeConflict, err, "resource already exists")
+	}
+	return Wrap(CodeInternal, err, "internal server error")
+}
@@ -0,0 +1,58 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCodeHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, CodeNotFound.HTTPStatus())
+	assert.Equal(t, http.StatusConflict, CodeUsernameExists.HTTPStatus())
+	assert.Equal(t, http.StatusInternalServerError, Code("UNKNOWN").HTTPStatus())
+
+	// 每个错误码都有对应的状态码，通用错误码反查回自身
+	for code := range statuses {
+		assert.NotZero(t, code.HTTPStatus(), code)
+	}
+	for status, code := range defaults {
+		assert.Equal(t, status, code.HTTPStatus(), code)
+	}
+}
+
+func TestCodeForStatus(t *testing.T) {
+	assert.Equal(t, CodeNotFound, CodeForStatus(http.StatusNotFound))
+	assert.Equal(t, CodeInvalidArgument, CodeForStatus(http.StatusRequestEntityTooLarge))
+	assert.Equal(t, CodeInternal, CodeForStatus(http.StatusBadGateway))
+}
+
+func TestFrom(t *testing.T) {
+	assert.Nil(t, From(nil))
+
+	sentinel := New(CodeUsernameExists, "username already exists")
+	assert.Same(t, sentinel, From(sentinel))
+
+	// 被包装的 4xx 错误保留错误码，使用完整的错误信息
+	wrapped := fmt.Errorf("%w: file is empty", New(CodeInvalidImport, "invalid import"))
+	err := From(wrapped)
+	assert.Equal(t, CodeInvalidImport, err.Code)
+	assert.Equal(t, "invalid import: file is empty", err.Message)
+
+	err = From(fmt.Errorf("get user: %w", gorm.ErrRecordNotFound))
+	assert.Equal(t, CodeNotFound, err.Code)
+	assert.Equal(t, http.StatusNotFound, err.HTTPStatus())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = From(gorm.ErrDuplicatedKey)
+	assert.Equal(t, CodeConflict, err.Code)
+
+	// 未知错误不暴露原始错误信息
+	err = From(errors.New("Error 1045: Access denied for user 'root'"))
+	assert.Equal(t, CodeInternal, err.Code)
+	assert.Equal(t, "internal server error", err.Message)
+	assert.Contains(t, err.Error(), "Access denied")
+}
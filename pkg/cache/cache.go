@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// keyPrefix 缓存键前缀，与队列、调度器等其他用途的键区分
+const keyPrefix = "cache:"
+
+// notFoundMarker 记录不存在时写入的值，JSON 编码的值不会以 ! 开头
+const notFoundMarker = "!notfound"
+
+// Options 缓存参数
+type Options[V any] struct {
+	Name        string           // 缓存名称，用于键前缀和指标标签，例如 user:id
+	TTL         time.Duration    // 缓存有效期
+	NegativeTTL time.Duration    // 不存在的记录的缓存时间，0 不缓存
+	NotFound    error            // load 返回该错误时视为记录不存在，命中时返回该错误
+	Tags        func(V) []string // 缓存项所属的标签，按标签批量失效，同一条记录的不同查询键使用相同的标签
+	Logger      *slog.Logger
+}
+
+// Cache Redis 读穿透缓存，K 为查询键，V 为缓存的值，值使用 JSON 编码
+// 同一个键的并发未命中只加载一次，Redis 不可用时直接调用 load
+type Cache[K comparable, V any] struct {
+	client redis.Client
+	opts   Options[V]
+	group  singleflight.Group
+}
+
+// New 创建缓存
+func New[K comparable, V any](client redis.Client, opts Options[V]) *Cache[K, V] {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Cache[K, V]{client: client, opts: opts}
+}
+
+// Key 查询键对应的 Redis 键
+func (c *Cache[K, V]) Key(key K) string {
+	return fmt.Sprintf("%s%s:%v", keyPrefix, c.opts.Name, key)
+}
+
+// Get 读取缓存，未命中时通过 load 加载并写入缓存
+func (c *Cache[K, V]) Get(ctx context.Context, key K, load func(ctx context.Context) (V, error)) (V, error) {
+	redisKey := c.Key(key)
+	data, err := c.client.Get(ctx, redisKey).Result()
+	switch {
+	case err == nil:
+		if value, err, ok := c.decode(data); ok {
+			metrics.ObserveCache(c.opts.Name, metrics.CacheHit)
+			return value, err
+		}
+		// 无法解码（例如结构变化后的旧数据）时当作未命中，重新加载后覆盖
+		metrics.ObserveCache(c.opts.Name, metrics.CacheMiss)
+	case errors.Is(err, goredis.Nil):
+		metrics.ObserveCache(c.opts.Name, metrics.CacheMiss)
+	default:
+		// Redis 不可用时直接查询数据库，不写入缓存
+		metrics.ObserveCache(c.opts.Name, metrics.CacheError)
+		c.opts.Logger.WarnContext(ctx, "Cache unavailable, loading from database",
+			"cache", c.opts.Name, "error", err.Error())
+		return c.load(ctx, redisKey, load, false)
+	}
+	return c.load(ctx, redisKey, load, true)
+}
+
+// load 合并同一个键的并发加载，store 为 true 时写入缓存
+// 加载结果编码后由所有等待者共享，每个等待者解码出独立的值，调用方修改返回值不会影响其他请求
+func (c *Cache[K, V]) load(ctx context.Context, redisKey string, load func(ctx context.Context) (V, error), store bool) (V, error) {
+	result, err, _ := c.group.Do(redisKey, func() (any, error) {
+		// 加载结果由所有等待者共享，不能因为第一个请求取消而让其他请求失败
+		loadCtx := context.WithoutCancel(ctx)
+		value, err := load(loadCtx)
+		if err != nil {
+			if store {
+				c.storeNotFound(loadCtx, redisKey, err)
+			}
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if store {
+			if err := c.set(loadCtx, redisKey, value, data); err != nil {
+				c.opts.Logger.WarnContext(loadCtx, "Failed to write cache", "cache", c.opts.Name, "error", err.Error())
+			}
+		}
+		return data, nil
+	})
+
+	var value V
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(result.([]byte), &value)
+	return value, err
+}
+
+// storeNotFound 记录不存在时按 NegativeTTL 缓存，其他错误不缓存
+func (c *Cache[K, V]) storeNotFound(ctx context.Context, redisKey string, loadErr error) {
+	if c.opts.NotFound == nil || !errors.Is(loadErr, c.opts.NotFound) || c.opts.NegativeTTL <= 0 {
+		return
+	}
+	if err := c.client.Set(ctx, redisKey, notFoundMarker, c.opts.NegativeTTL).Err(); err != nil {
+		c.opts.Logger.WarnContext(ctx, "Failed to write cache", "cache", c.opts.Name, "error", err.Error())
+	}
+}
+
+// set 写入编码后的值并登记到所属标签，标签集合的有效期不短于其中的缓存项
+func (c *Cache[K, V]) set(ctx context.Context, redisKey string, value V, data []byte) error {
+	pipe := c.client.TxPipeline()
+	pipe.Set(ctx, redisKey, data, c.opts.TTL)
+	if c.opts.Tags != nil {
+		for _, tag := range c.opts.Tags(value) {
+			pipe.SAdd(ctx, tagKey(tag), redisKey)
+			pipe.Expire(ctx, tagKey(tag), c.opts.TTL)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// decode 解码缓存的值，ok 为 false 表示数据无法解码
+func (c *Cache[K, V]) decode(data string) (value V, err error, ok bool) {
+	if data == notFoundMarker {
+		return value, c.opts.NotFound, true
+	}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return value, nil, false
+	}
+	return value, nil, true
+}
+
+// Delete 删除查询键对应的缓存，包括不存在记录的缓存
+func (c *Cache[K, V]) Delete(ctx context.Context, keys ...K) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = c.Key(key)
+	}
+	return c.client.Del(ctx, redisKeys...).Err()
+}
+
+// InvalidateTags 删除标签下的所有缓存项，标签可以跨多个缓存
+func InvalidateTags(ctx context.Context, client redis.Client, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := client.SMembers(ctx, tagKey(tag)).Result()
+		if err != nil {
+			return err
+		}
+		if err := client.Del(ctx, append(keys, tagKey(tag))...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagKey 标签集合的 Redis 键
+func tagKey(tag string) string {
+	return keyPrefix + "tag:" + tag
+}
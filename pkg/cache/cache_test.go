@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = errors.New("record not found")
+
+type user struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestCache(t *testing.T, name string) (*miniredis.Miniredis, redis.Client, *Cache[uint, *user]) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+
+	c := New[uint, *user](client, Options[*user]{
+		Name:        name,
+		TTL:         time.Minute,
+		NegativeTTL: 10 * time.Second,
+		NotFound:    errNotFound,
+		Tags:        func(u *user) []string { return []string{"user:" + u.Name} },
+		Logger:      slog.New(slog.DiscardHandler),
+	})
+	return server, client, c
+}
+
+// loader 统计加载次数，id 为 0 时返回记录不存在
+func loader(calls *atomic.Int32, id uint) func(context.Context) (*user, error) {
+	return func(context.Context) (*user, error) {
+		calls.Add(1)
+		if id == 0 {
+			return nil, errNotFound
+		}
+		return &user{ID: id, Name: "alice"}, nil
+	}
+}
+
+// cacheCount 读取缓存查询次数指标
+func cacheCount(name, result string) float64 {
+	families, _ := metrics.Registry.Gather()
+	for _, family := range families {
+		if family.GetName() != "toge_cache_requests_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["cache"] == name && labels["result"] == result {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestGetLoadsOnceAndCaches(t *testing.T) {
+	server, _, c := newTestCache(t, "test:hit")
+	ctx := context.Background()
+	var calls atomic.Int32
+
+	for range 3 {
+		u, err := c.Get(ctx, 1, loader(&calls, 1))
+		require.NoError(t, err)
+		assert.Equal(t, &user{ID: 1, Name: "alice"}, u)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, float64(1), cacheCount("test:hit", metrics.CacheMiss))
+	assert.Equal(t, float64(2), cacheCount("test:hit", metrics.CacheHit))
+
+	assert.True(t, server.Exists("cache:test:hit:1"))
+	assert.Equal(t, time.Minute, server.TTL("cache:test:hit:1"))
+}
+
+func TestNegativeCaching(t *testing.T) {
+	server, _, c := newTestCache(t, "test:negative")
+	ctx := context.Background()
+	var calls atomic.Int32
+
+	for range 2 {
+		_, err := c.Get(ctx, 0, loader(&calls, 0))
+		assert.ErrorIs(t, err, errNotFound)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, 10*time.Second, server.TTL("cache:test:negative:0"))
+
+	// 其他错误不缓存
+	boom := errors.New("boom")
+	for range 2 {
+		_, err := c.Get(ctx, 2, func(context.Context) (*user, error) {
+			calls.Add(1)
+			return nil, boom
+		})
+		assert.ErrorIs(t, err, boom)
+	}
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestConcurrentMissesLoadOnce(t *testing.T) {
+	_, _, c := newTestCache(t, "test:singleflight")
+	ctx := context.Background()
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := c.Get(ctx, 1, func(context.Context) (*user, error) {
+				calls.Add(1)
+				<-release
+				return &user{ID: 1, Name: "alice"}, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, uint(1), u.ID)
+		}()
+	}
+	// 等待所有请求进入未命中，再放行唯一的一次加载
+	assert.Eventually(t, func() bool {
+		return cacheCount("test:singleflight", metrics.CacheMiss) == 10
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestConcurrentMissesReturnIndependentValues(t *testing.T) {
+	_, _, c := newTestCache(t, "test:singleflight_copy")
+	ctx := context.Background()
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]*user, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := c.Get(ctx, 1, func(context.Context) (*user, error) {
+				<-release
+				return &user{ID: 1, Name: "alice"}, nil
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			// 每个请求修改自己拿到的值，其他请求仍然读到加载的原值
+			assert.Equal(t, "alice", u.Name)
+			u.Name = ""
+			results[i] = u
+		}()
+	}
+	assert.Eventually(t, func() bool {
+		return cacheCount("test:singleflight_copy", metrics.CacheMiss) == 10
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i := 1; i < len(results); i++ {
+		assert.NotSame(t, results[0], results[i])
+	}
+}
+
+func TestDeleteAndInvalidateTags(t *testing.T) {
+	server, client, c := newTestCache(t, "test:invalidate")
+	ctx := context.Background()
+	var calls atomic.Int32
+
+	_, err := c.Get(ctx, 1, loader(&calls, 1))
+	require.NoError(t, err)
+	_, err = c.Get(ctx, 0, loader(&calls, 0))
+	require.ErrorIs(t, err, errNotFound)
+
+	require.NoError(t, c.Delete(ctx, 0))
+	assert.False(t, server.Exists("cache:test:invalidate:0"))
+	assert.True(t, server.Exists("cache:test:invalidate:1"))
+
+	require.NoError(t, InvalidateTags(ctx, client, "user:alice"))
+	assert.False(t, server.Exists("cache:test:invalidate:1"))
+	assert.False(t, server.Exists("cache:tag:user:alice"))
+
+	_, err = c.Get(ctx, 1, loader(&calls, 1))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestFallsBackToLoaderWhenRedisIsDown(t *testing.T) {
+	server, _, c := newTestCache(t, "test:down")
+	ctx := context.Background()
+	var calls atomic.Int32
+	server.Close()
+
+	for range 2 {
+		u, err := c.Get(ctx, 1, loader(&calls, 1))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", u.Name)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, float64(2), cacheCount("test:down", metrics.CacheError))
+}
+
+func TestUndecodableValueIsReloaded(t *testing.T) {
+	server, _, c := newTestCache(t, "test:decode")
+	ctx := context.Background()
+	var calls atomic.Int32
+	require.NoError(t, server.Set("cache:test:decode:1", "{not json"))
+
+	u, err := c.Get(ctx, 1, loader(&calls, 1))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.Name)
+	assert.Equal(t, int32(1), calls.Load())
+
+	data, err := server.Get("cache:test:decode:1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"name":"alice"}`, data)
+}
@@ -2,31 +2,59 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
 
-	"gopkg.in/yaml.v3"
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite" // 纯 Go 实现，不需要 CGO，database 为数据库文件路径或 :memory:
 )
 
 type Config struct {
-	App      AppConfig      `yaml:"app"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
-	Log      LogConfig      `yaml:"log"`
-	JWT      JWTConfig      `yaml:"jwt"`
-	CORS     CORSConfig     `yaml:"cors"`
-	Timezone TimezoneConfig `yaml:"timezone"`
+	Env          string             `yaml:"-"` // 加载的环境，例如 dev、production
+	App          AppConfig          `yaml:"app"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Redis        RedisConfig        `yaml:"redis"`
+	Cache        CacheConfig        `yaml:"cache"`
+	Log          LogConfig          `yaml:"log"`
+	JWT          JWTConfig          `yaml:"jwt"`
+	CORS         CORSConfig         `yaml:"cors"`
+	Timezone     TimezoneConfig     `yaml:"timezone"`
+	Notification NotificationConfig `yaml:"notification"`
+	Scheduler    SchedulerConfig    `yaml:"scheduler"`
+	Admin        AdminConfig        `yaml:"admin"`
+	Queue        QueueConfig        `yaml:"queue"`
+	Event        EventConfig        `yaml:"event"`
+	Search       SearchConfig       `yaml:"search"`
+	Transfer     TransferConfig     `yaml:"transfer"`
+	Webhook      WebhookConfig      `yaml:"webhook"`
+	Health       HealthConfig       `yaml:"health"`
+	Metrics      MetricsConfig      `yaml:"metrics"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	RateLimit    RateLimitConfig    `yaml:"rate_limit"`
+	Reload       ReloadConfig       `yaml:"reload"`
 }
 
 type AppConfig struct {
-	Name    string `yaml:"name"`
-	Version string `yaml:"version"`
-	Port    int    `yaml:"port"`
-	Mode    string `yaml:"mode"`
+	Name            string `yaml:"name"`
+	Version         string `yaml:"version"`
+	Port            int    `yaml:"port"`
+	Mode            string `yaml:"mode"`
+	ShutdownTimeout int    `yaml:"shutdown_timeout"` // 优雅停机等待时间（秒），超时后强制退出
+	DrainDelay      int    `yaml:"drain_delay"`      // 就绪状态置为 false 后等待负载均衡摘除实例的时间（秒）
+
+	// TrustedProxies 可信代理的 IP 或 CIDR，只有来自这些地址的请求才使用 X-Forwarded-For 和 X-Real-IP 确定客户端 IP
+	// 为空时不信任任何代理，客户端 IP 为连接的对端地址
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
-	Driver          string `yaml:"driver"`
+	Driver          string `yaml:"driver"` // mysql, postgres, sqlite
 	Host            string `yaml:"host"`
 	Port            int    `yaml:"port"`
 	Username        string `yaml:"username"`
@@ -35,9 +63,15 @@ type DatabaseConfig struct {
 	Charset         string `yaml:"charset"`
 	ParseTime       bool   `yaml:"parse_time"`
 	Loc             string `yaml:"loc"`
+	SSLMode         string `yaml:"ssl_mode"` // PostgreSQL sslmode，为空时使用 disable
 	MaxIdleConns    int    `yaml:"max_idle_conns"`
 	MaxOpenConns    int    `yaml:"max_open_conns"`
 	ConnMaxLifetime int    `yaml:"conn_max_lifetime"`
+	TxMaxRetries    int    `yaml:"tx_max_retries"`   // 死锁或锁等待超时时事务整体重试的次数，0 不重试
+	TxRetryBackoff  int    `yaml:"tx_retry_backoff"` // 毫秒，第 n 次重试前等待 n 倍该时间
+
+	Replicas             []string `yaml:"replicas"`               // 只读从库地址 host:port，与主库使用相同的账号和库名，省略端口时使用 port
+	ReplicaCheckInterval int      `yaml:"replica_check_interval"` // 从库健康检查间隔，毫秒
 }
 
 type RedisConfig struct {
@@ -48,6 +82,12 @@ type RedisConfig struct {
 	PoolSize int    `yaml:"pool_size"`
 }
 
+type CacheConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	TTL         int  `yaml:"ttl"`          // 缓存有效期，秒
+	NegativeTTL int  `yaml:"negative_ttl"` // 不存在的记录的缓存时间，秒，0 不缓存
+}
+
 type LogConfig struct {
 	Level  string     `yaml:"level"`
 	Format string     `yaml:"format"`
@@ -78,6 +118,7 @@ type CORSConfig struct {
 	AllowedOrigins   []string `yaml:"allowed_origins"`
 	AllowedMethods   []string `yaml:"allowed_methods"`
 	AllowedHeaders   []string `yaml:"allowed_headers"`
+	ExposedHeaders   []string `yaml:"exposed_headers"` // 允许浏览器读取的响应头，默认为 ETag
 	AllowCredentials bool     `yaml:"allow_credentials"`
 }
 
@@ -85,39 +126,203 @@ type TimezoneConfig struct {
 	Timezone string `yaml:"timezone"`
 }
 
-var GlobalConfig *Config
+type NotificationConfig struct {
+	Email             EmailConfig `yaml:"email"`
+	Push              PushConfig  `yaml:"push"`
+	ReadRetentionDays int         `yaml:"read_retention_days"` // 已读通知保留天数
+}
 
-// LoadConfig 加载配置文件
-func LoadConfig(env string) error {
-	configPath := filepath.Join("config", fmt.Sprintf("%s.yaml", env))
+type EmailConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
 
-	// 检查配置文件是否存在
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return fmt.Errorf("config file not found: %s", configPath)
-	}
+type PushConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Endpoint string `yaml:"endpoint"` // 推送网关地址
+	APIKey   string `yaml:"api_key"`
+	Timeout  int    `yaml:"timeout"` // 秒
+}
 
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to read config file: %v", err)
-	}
+type SchedulerConfig struct {
+	Enabled     bool                 `yaml:"enabled"`
+	Timezone    string               `yaml:"timezone"`     // 为空时使用 timezone.timezone
+	HistorySize int                  `yaml:"history_size"` // 每个任务保留的运行记录数
+	Jobs        map[string]JobConfig `yaml:"jobs"`
+}
 
-	// 解析 YAML
-	GlobalConfig = &Config{}
-	if err := yaml.Unmarshal(data, GlobalConfig); err != nil {
-		return fmt.Errorf("failed to parse config file: %v", err)
-	}
+type JobConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Spec    string `yaml:"spec"`    // cron 表达式
+	Jitter  int    `yaml:"jitter"`  // 秒
+	Timeout int    `yaml:"timeout"` // 秒
+}
+
+type AdminConfig struct {
+	UserIDs           []uint   `yaml:"user_ids"`           // 管理员的用户 ID，为空时没有管理员
+	ReservedUsernames []string `yaml:"reserved_usernames"` // 公开注册时不允许使用的用户名，不区分大小写
+}
+
+type QueueConfig struct {
+	Queues            map[string]int `yaml:"queues"`             // 队列名 → worker 并发数
+	MaxRetries        int            `yaml:"max_retries"`        // 默认最大重试次数
+	BackoffBase       int            `yaml:"backoff_base"`       // 秒
+	BackoffMax        int            `yaml:"backoff_max"`        // 秒
+	PollInterval      int            `yaml:"poll_interval"`      // 毫秒
+	Timeout           int            `yaml:"timeout"`            // 秒
+	DeadSize          int            `yaml:"dead_size"`          // 每个队列保留的死信任务数
+	VisibilityTimeout int            `yaml:"visibility_timeout"` // 秒，取出后超过该时间未确认的任务会被重新投递
+}
+
+type EventConfig struct {
+	RelayEnabled  bool `yaml:"relay_enabled"`
+	PollInterval  int  `yaml:"poll_interval"`  // 毫秒
+	BatchSize     int  `yaml:"batch_size"`     // 每次最多投递的事件数
+	MaxAttempts   int  `yaml:"max_attempts"`   // 超过后标记为 failed
+	BackoffBase   int  `yaml:"backoff_base"`   // 秒
+	BackoffMax    int  `yaml:"backoff_max"`    // 秒
+	RetentionDays int  `yaml:"retention_days"` // 已发布事件保留天数
+}
 
-	return nil
+type SearchConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	SyncInterval int  `yaml:"sync_interval"` // 读取变更事件的间隔，毫秒
+	BatchSize    int  `yaml:"batch_size"`    // 每次读取的事件数
+	Lookback     int  `yaml:"lookback"`      // 回看窗口，秒，覆盖事务提交晚于事件发生时间的情况
 }
 
-// GetDSN 获取数据库连接字符串
+type TransferConfig struct {
+	ExportBatchSize      int `yaml:"export_batch_size"`      // 导出时每批读取的行数，0 使用默认值 500
+	ImportMaxFileSize    int `yaml:"import_max_file_size"`   // 导入文件大小上限，MB，0 使用默认值 10
+	ImportMaxRows        int `yaml:"import_max_rows"`        // 导入行数上限，0 使用默认值 10000
+	ImportAsyncThreshold int `yaml:"import_async_threshold"` // 行数超过时转为后台任务，0 表示始终使用后台任务
+	ImportJobTimeout     int `yaml:"import_job_timeout"`     // 后台导入任务超时，秒，0 使用默认值 3600
+}
+
+type WebhookConfig struct {
+	Timeout              int  `yaml:"timeout"`                // 单次请求超时，秒
+	MaxAttempts          int  `yaml:"max_attempts"`           // 每个事件的最大投递次数
+	DisableAfter         int  `yaml:"disable_after"`          // 连续失败多少次后自动停用
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"` // 是否允许投递到内网地址
+}
+
+type HealthConfig struct {
+	Timeout       int `yaml:"timeout"`          // 单项检查超时，毫秒
+	CacheTTL      int `yaml:"cache_ttl"`        // 检查结果缓存时间，毫秒
+	DiskMinFreeMB int `yaml:"disk_min_free_mb"` // 日志目录所在磁盘的最小剩余空间，MB
+}
+
+type MetricsConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Port       int    `yaml:"port"`        // API 进程的指标端口，与业务端口分开
+	WorkerPort int    `yaml:"worker_port"` // worker 进程的指标端口
+	Path       string `yaml:"path"`
+}
+
+type TracingConfig struct {
+	Exporter    string            `yaml:"exporter"`     // 导出方式: otlp, stdout, none
+	Endpoint    string            `yaml:"endpoint"`     // OTLP/HTTP 地址，例如 otel-collector:4318
+	Insecure    bool              `yaml:"insecure"`     // 是否使用 http 连接 collector
+	SampleRatio float64           `yaml:"sample_ratio"` // 根 span 采样率，0~1
+	Headers     map[string]string `yaml:"headers"`      // OTLP 请求头
+}
+
+type RateLimitConfig struct {
+	Enabled           bool    `yaml:"enabled"`
+	RequestsPerSecond float64 `yaml:"requests_per_second"` // 每个客户端 IP 每秒允许的请求数
+	Burst             int     `yaml:"burst"`               // 允许的突发请求数
+}
+
+type ReloadConfig struct {
+	Watch    bool `yaml:"watch"`    // 是否监听配置文件变化，SIGHUP 始终会触发重新加载
+	Interval int  `yaml:"interval"` // 检查配置文件变化的间隔，毫秒
+}
+
+// GetDSN 按驱动获取数据库连接字符串
 func (c *DatabaseConfig) GetDSN() string {
+	switch c.Driver {
+	case DriverPostgres:
+		return c.PostgresDSN()
+	case DriverSQLite:
+		return c.SQLiteDSN()
+	default:
+		return c.MySQLDSN()
+	}
+}
+
+// MySQLDSN 获取 MySQL 连接字符串
+func (c *DatabaseConfig) MySQLDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s",
 		c.Username, c.Password, c.Host, c.Port, c.Database, c.Charset, c.ParseTime, c.Loc)
 }
 
+// PostgresDSN 获取 PostgreSQL 连接字符串，loc 为 Local 时使用服务端时区
+func (c *DatabaseConfig) PostgresDSN() string {
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	params := []string{
+		"host=" + quoteDSNValue(c.Host),
+		fmt.Sprintf("port=%d", c.Port),
+		"user=" + quoteDSNValue(c.Username),
+		"password=" + quoteDSNValue(c.Password),
+		"dbname=" + quoteDSNValue(c.Database),
+		"sslmode=" + quoteDSNValue(sslMode),
+	}
+	if c.Loc != "" && c.Loc != "Local" {
+		params = append(params, "TimeZone="+quoteDSNValue(c.Loc))
+	}
+	return strings.Join(params, " ")
+}
+
+// SQLiteDSN 获取 SQLite 连接字符串，开启外键约束，写冲突时等待而不是立即返回 busy
+func (c *DatabaseConfig) SQLiteDSN() string {
+	return c.Database + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// ForReplica 获取从库的连接配置，addr 为 host:port 或 host
+func (c *DatabaseConfig) ForReplica(addr string) DatabaseConfig {
+	replica := *c
+	replica.Replicas = nil
+	replica.Host = addr
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		replica.Host = host
+		replica.Port, _ = strconv.Atoi(port)
+	}
+	return replica
+}
+
+// quoteDSNValue 按 libpq 键值格式转义，值为空或包含空格、引号时加单引号
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// IsAdmin 用户是否为管理员
+func (c *AdminConfig) IsAdmin(userID uint) bool {
+	return userID != 0 && slices.Contains(c.UserIDs, userID)
+}
+
+// IsReservedUsername 用户名是否为保留的用户名
+func (c *AdminConfig) IsReservedUsername(username string) bool {
+	return slices.ContainsFunc(c.ReservedUsernames, func(reserved string) bool {
+		return strings.EqualFold(reserved, strings.TrimSpace(username))
+	})
+}
+
 // GetRedisAddr 获取 Redis 地址
 func (c *RedisConfig) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
+
+// GetSMTPAddr 获取 SMTP 地址
+func (c *EmailConfig) GetSMTPAddr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBase = `
+app:
+  name: "toge"
+  port: 8080
+  mode: "debug"
+database:
+  driver: "mysql"
+  host: "localhost"
+  port: 3306
+  password: "base-password"
+  database: "toge"
+redis:
+  host: "localhost"
+  port: 6379
+jwt:
+  secret: "base-secret"
+  expire_hours: 24
+log:
+  level: "info"
+  format: "json"
+  output: "stdout"
+cors:
+  allowed_origins:
+    - "*"
+`
+
+// writeConfig 在临时目录中写入配置文件
+func writeConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestLoadMergesBaseAndEnvFile(t *testing.T) {
+	dir := writeConfig(t, map[string]string{
+		"base.yaml": testBase,
+		"dev.yaml": `
+app:
+  port: 8081
+database:
+  host: "dev-db"
+`,
+	})
+
+	cfg, err := Load(dir, "dev", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "toge", cfg.App.Name)
+	assert.Equal(t, 8081, cfg.App.Port)
+	assert.Equal(t, "dev-db", cfg.Database.Host)
+	assert.Equal(t, 3306, cfg.Database.Port)
+	assert.Equal(t, []string{"*"}, cfg.CORS.AllowedOrigins)
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	dir := writeConfig(t, map[string]string{
+		"base.yaml": testBase,
+		"dev.yaml": `
+database:
+  pasword: "typo"
+`,
+	})
+
+	_, err := Load(dir, "dev", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pasword")
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(t.TempDir(), "dev", nil)
+	assert.ErrorContains(t, err, "config file not found")
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+
+	cfg, err := Load(dir, "dev", []string{
+		"TOGE_APP_PORT=9000",
+		"TOGE_DATABASE_PASSWORD=from-env",
+		"TOGE_CORS_ALLOWED_ORIGINS=https://a.example.com, https://b.example.com",
+		"TOGE_METRICS_ENABLED=false",
+		"TOGE_ADMIN_USER_IDS=1, 2",
+		"PATH=/usr/bin",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 9000, cfg.App.Port)
+	assert.Equal(t, []uint{1, 2}, cfg.Admin.UserIDs)
+	assert.Equal(t, "from-env", cfg.Database.Password)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORS.AllowedOrigins)
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+
+	_, err := Load(dir, "dev", []string{"TOGE_APP_PORT=http"})
+	assert.ErrorContains(t, err, "TOGE_APP_PORT")
+
+	_, err = Load(dir, "dev", []string{"TOGE_ADMIN_USER_IDS=1,-2"})
+	assert.ErrorContains(t, err, "TOGE_ADMIN_USER_IDS")
+
+	_, err = Load(dir, "dev", []string{"TOGE_DATABASE_PASWORD=typo"})
+	assert.ErrorContains(t, err, "unknown config environment variables: TOGE_DATABASE_PASWORD")
+}
+
+func TestLoadSecretFileOverridesEnv(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+	secret := filepath.Join(t.TempDir(), "db_password")
+	require.NoError(t, os.WriteFile(secret, []byte("from-file\n"), 0o600))
+
+	cfg, err := Load(dir, "dev", []string{
+		"TOGE_DATABASE_PASSWORD=from-env",
+		"TOGE_DATABASE_PASSWORD_FILE=" + secret,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Database.Password)
+
+	_, err = Load(dir, "dev", []string{"TOGE_JWT_SECRET_FILE=" + filepath.Join(dir, "missing")})
+	assert.ErrorContains(t, err, "TOGE_JWT_SECRET_FILE")
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+
+	_, err := Load(dir, "dev", []string{
+		"TOGE_APP_PORT=70000",
+		"TOGE_APP_MODE=prod",
+		"TOGE_TRACING_SAMPLE_RATIO=2",
+		"TOGE_TIMEZONE_TIMEZONE=Mars/Olympus",
+		"TOGE_CACHE_ENABLED=true",
+		"TOGE_CACHE_TTL=0",
+		"TOGE_APP_TRUSTED_PROXIES=10.0.0.0/8,load-balancer",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app.port")
+	assert.Contains(t, err.Error(), "app.mode")
+	assert.Contains(t, err.Error(), "tracing.sample_ratio")
+	assert.Contains(t, err.Error(), "timezone.timezone")
+	assert.Contains(t, err.Error(), "cache.ttl")
+	assert.Contains(t, err.Error(), `app.trusted_proxies: "load-balancer"`)
+	assert.NotContains(t, err.Error(), "10.0.0.0/8")
+}
+
+func TestValidateDatabaseDriver(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+
+	_, err := Load(dir, "dev", []string{"TOGE_DATABASE_DRIVER=oracle"})
+	assert.ErrorContains(t, err, "database.driver")
+
+	_, err = Load(dir, "dev", []string{"TOGE_DATABASE_DRIVER=postgres", "TOGE_DATABASE_SSL_MODE=on"})
+	assert.ErrorContains(t, err, "database.ssl_mode")
+
+	// SQLite 不需要地址，生产模式下也不要求数据库密码
+	cfg, err := Load(dir, "dev", []string{
+		"TOGE_APP_MODE=release",
+		"TOGE_JWT_SECRET=2f7c1e9a4b8d6f3a0c5e7b9d1f3a5c7e",
+		"TOGE_DATABASE_DRIVER=sqlite",
+		"TOGE_DATABASE_HOST=",
+		"TOGE_DATABASE_PORT=0",
+		"TOGE_DATABASE_PASSWORD=",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, DriverSQLite, cfg.Database.Driver)
+}
+
+func TestDatabaseDSN(t *testing.T) {
+	c := DatabaseConfig{
+		Driver: DriverMySQL, Host: "db", Port: 3306, Username: "toge", Password: "it's secret",
+		Database: "toge", Charset: "utf8mb4", ParseTime: true, Loc: "Local",
+	}
+	assert.Equal(t, "toge:it's secret@tcp(db:3306)/toge?charset=utf8mb4&parseTime=true&loc=Local", c.GetDSN())
+
+	c.Driver = DriverPostgres
+	c.Port = 5432
+	assert.Equal(t, `host=db port=5432 user=toge password='it\'s secret' dbname=toge sslmode=disable`, c.GetDSN())
+
+	c.SSLMode = "require"
+	c.Loc = "Asia/Shanghai"
+	assert.Equal(t, `host=db port=5432 user=toge password='it\'s secret' dbname=toge sslmode=require TimeZone=Asia/Shanghai`, c.GetDSN())
+
+	c.Driver = DriverSQLite
+	c.Database = ":memory:"
+	assert.Equal(t, ":memory:?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", c.GetDSN())
+}
+
+func TestDatabaseReplicas(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+
+	cfg, err := Load(dir, "dev", []string{"TOGE_DATABASE_REPLICAS=replica-1:3307, replica-2"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"replica-1:3307", "replica-2"}, cfg.Database.Replicas)
+
+	replica := cfg.Database.ForReplica(cfg.Database.Replicas[0])
+	assert.Equal(t, "replica-1", replica.Host)
+	assert.Equal(t, 3307, replica.Port)
+	assert.Equal(t, cfg.Database.Database, replica.Database)
+	assert.Nil(t, replica.Replicas)
+
+	// 省略端口时使用主库端口
+	replica = cfg.Database.ForReplica(cfg.Database.Replicas[1])
+	assert.Equal(t, "replica-2", replica.Host)
+	assert.Equal(t, 3306, replica.Port)
+
+	_, err = Load(dir, "dev", []string{"TOGE_DATABASE_REPLICAS=replica-1:http"})
+	assert.ErrorContains(t, err, "database.replicas[0]")
+
+	_, err = Load(dir, "dev", []string{"TOGE_DATABASE_DRIVER=sqlite", "TOGE_DATABASE_REPLICAS=replica-1"})
+	assert.ErrorContains(t, err, "database.replicas: not supported by the sqlite driver")
+}
+
+func TestValidateRejectsWeakSecretsInRelease(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"production.yaml": testBase})
+	release := "TOGE_APP_MODE=release"
+
+	_, err := Load(dir, "production", []string{release})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jwt.secret: must be at least 32 characters")
+
+	_, err = Load(dir, "production", []string{
+		release,
+		"TOGE_JWT_SECRET=your-secret-key",
+		"TOGE_DATABASE_PASSWORD=password",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jwt.secret: refusing")
+	assert.Contains(t, err.Error(), "database.password: refusing")
+
+	cfg, err := Load(dir, "production", []string{
+		release,
+		"TOGE_JWT_SECRET=2f7c1e9a4b8d6f3a0c5e7b9d1f3a5c7e",
+		"TOGE_DATABASE_PASSWORD=Xk9#mQ2vL7pR",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ModeRelease, cfg.App.Mode)
+}
+
+func TestRepositoryConfigs(t *testing.T) {
+	for _, env := range []string{"dev", "test", "local"} {
+		cfg, err := Load("../../config", env, nil)
+		require.NoError(t, err, env)
+		// 共用配置来自 base.yaml，仓库中的配置文件不包含密码
+		assert.Equal(t, 360, cfg.Queue.VisibilityTimeout, env)
+		assert.Empty(t, cfg.Database.Password, env)
+		assert.Empty(t, cfg.Redis.Password, env)
+		// 默认没有管理员，admin 只能由管理员创建
+		assert.Empty(t, cfg.Admin.UserIDs, env)
+		assert.True(t, cfg.Admin.IsReservedUsername("Admin"), env)
+	}
+
+	// 生产配置不包含密钥，必须由环境变量或 secret 文件提供
+	_, err := Load("../../config", "production", nil)
+	assert.ErrorContains(t, err, "TOGE_JWT_SECRET")
+
+	_, err = Load("../../config", "production", []string{
+		"TOGE_JWT_SECRET=2f7c1e9a4b8d6f3a0c5e7b9d1f3a5c7e",
+		"TOGE_DATABASE_PASSWORD=Xk9#mQ2vL7pR",
+	})
+	assert.NoError(t, err)
+}
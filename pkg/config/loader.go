@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 覆盖配置项的环境变量前缀，例如 TOGE_DATABASE_PASSWORD 覆盖 database.password
+const EnvPrefix = "TOGE_"
+
+// fileSuffix 从文件读取配置值的环境变量后缀，例如 TOGE_DATABASE_PASSWORD_FILE=/run/secrets/db_password
+const fileSuffix = "_FILE"
+
+// baseFile 所有环境共用的基础配置文件
+const baseFile = "base.yaml"
+
+// configDir 配置文件目录
+const configDir = "config"
+
+// LoadConfig 加载配置文件
+// 按顺序合并 config/base.yaml、config/<env>.yaml、TOGE_* 环境变量和 TOGE_*_FILE 文件，后者覆盖前者
+func LoadConfig(env string) (*Config, error) {
+	return Load(configDir, env, os.Environ())
+}
+
+// Load 从 dir 目录加载并校验配置，environ 为 KEY=VALUE 形式的环境变量
+func Load(dir, env string, environ []string) (*Config, error) {
+	configPath := filepath.Join(dir, fmt.Sprintf("%s.yaml", env))
+
+	// 检查配置文件是否存在
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file not found: %s", configPath)
+	}
+
+	// 基础配置文件可选
+	merged := map[string]any{}
+	for _, path := range []string{filepath.Join(dir, baseFile), configPath} {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) && path != configPath {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+
+		var layer map[string]any
+		if err := yaml.Unmarshal(data, &layer); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+		mergeMaps(merged, layer)
+	}
+
+	// 合并后严格解析，拼写错误或已废弃的配置项直接报错
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config files: %v", err)
+	}
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+
+	cfg.Env = env
+	if err := applyEnv(cfg, environ); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// mergeMaps 深度合并，src 中的值覆盖 dst，嵌套对象逐项合并，数组整体替换
+func mergeMaps(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// applyEnv 应用 TOGE_* 环境变量，再应用 TOGE_*_FILE 文件引用
+// 环境变量名由 yaml 路径转为大写并以下划线连接，未知的 TOGE_* 变量视为配置错误
+func applyEnv(cfg *Config, environ []string) error {
+	fields := map[string]reflect.Value{}
+	collectFields(reflect.ValueOf(cfg).Elem(), EnvPrefix, fields)
+
+	values := map[string]string{}
+	files := map[string]string{}
+	var unknown []string
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, EnvPrefix) {
+			continue
+		}
+		if _, known := fields[key]; known {
+			values[key] = value
+			continue
+		}
+		if name := strings.TrimSuffix(key, fileSuffix); name != key {
+			if _, known := fields[name]; known {
+				files[name] = value
+				continue
+			}
+		}
+		unknown = append(unknown, key)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown config environment variables: %s", strings.Join(unknown, ", "))
+	}
+
+	for name, value := range values {
+		if err := setField(fields[name], value); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+	}
+	for name, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s%s: %v", name, fileSuffix, err)
+		}
+		// 去掉文件末尾的换行，secret 文件通常以换行结尾
+		if err := setField(fields[name], strings.TrimRight(string(data), "\r\n")); err != nil {
+			return fmt.Errorf("invalid value for %s%s: %v", name, fileSuffix, err)
+		}
+	}
+	return nil
+}
+
+// collectFields 收集可以通过环境变量覆盖的字段，map 类型的配置项只能在文件中配置
+func collectFields(v reflect.Value, prefix string, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := yamlName(t.Field(i))
+		if tag == "" {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.Struct:
+			collectFields(field, name+"_", fields)
+		case reflect.Map:
+			continue
+		default:
+			fields[name] = field
+		}
+	}
+}
+
+// setField 按字段类型解析字符串，切片使用逗号分隔
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected a non-negative integer, got %q", value)
+		}
+		field.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("expected a boolean, got %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		items := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Struct {
+				return fmt.Errorf("unsupported slice type %s", field.Type())
+			}
+			if err := setField(elem, item); err != nil {
+				return err
+			}
+			items = reflect.Append(items, elem)
+		}
+		field.Set(items)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// reloadable 可以在运行时修改的配置路径，其余配置修改后需要重启
+var reloadable = []string{"log.level", "cors", "rate_limit"}
+
+// defaultReloadInterval 未配置时检查配置文件变化的间隔
+const defaultReloadInterval = 2 * time.Second
+
+// Reloader 监听配置文件变化和 SIGHUP，重新加载、校验后原子替换当前配置并通知订阅者
+type Reloader struct {
+	dir      string
+	env      string
+	environ  func() []string
+	interval time.Duration
+	watch    bool
+	log      *slog.Logger
+	current  atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []subscriber
+	stamps      map[string]fileStamp
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type subscriber struct {
+	name string
+	fn   func(*Config)
+}
+
+// notify 调用订阅者，单个订阅者 panic 不影响其他订阅者和后续的重新加载
+func (s subscriber) notify(cfg *Config, log *slog.Logger) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Error("Config subscriber panicked", "subscriber", s.name, "panic", p)
+		}
+	}()
+	s.fn(cfg)
+}
+
+// fileStamp 配置文件的修改时间和大小，用于轮询判断文件是否变化
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader 创建配置热更新器，cfg 为启动时加载的配置，从同一环境的配置文件重新加载
+func NewReloader(cfg *Config, log *slog.Logger) *Reloader {
+	return newReloader(configDir, cfg, os.Environ, log)
+}
+
+func newReloader(dir string, cfg *Config, environ func() []string, log *slog.Logger) *Reloader {
+	interval := defaultReloadInterval
+	if cfg.Reload.Interval > 0 {
+		interval = time.Duration(cfg.Reload.Interval) * time.Millisecond
+	}
+	r := &Reloader{dir: dir, env: cfg.Env, environ: environ, interval: interval, watch: cfg.Reload.Watch, log: log}
+	r.current.Store(cfg)
+	r.stamps = r.statFiles()
+	return r
+}
+
+// Current 返回当前生效的配置，包含热更新后的日志级别、CORS 和限流设置
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Subscribe 注册配置变更回调，只在可热更新的配置发生变化时调用
+func (r *Reloader) Subscribe(name string, fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, subscriber{name: name, fn: fn})
+}
+
+// Reload 重新加载配置，返回已生效的配置路径
+// 校验失败时保留当前配置；不可热更新的配置项发生变化时记录警告并忽略
+func (r *Reloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := Load(r.dir, r.env, r.environ())
+	if err != nil {
+		return nil, err
+	}
+
+	old := r.Current()
+	next := *old
+	var applied, rejected []string
+	for _, path := range diffPaths("", reflect.ValueOf(*old), reflect.ValueOf(*loaded)) {
+		if !isReloadable(path) {
+			rejected = append(rejected, path)
+			continue
+		}
+		fieldByPath(reflect.ValueOf(&next).Elem(), path).Set(fieldByPath(reflect.ValueOf(loaded).Elem(), path))
+		applied = append(applied, path)
+	}
+
+	if len(rejected) > 0 {
+		r.log.Warn("Config changes require a restart and were ignored", "paths", rejected)
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+
+	r.current.Store(&next)
+	for _, sub := range r.subscribers {
+		sub.notify(&next, r.log)
+	}
+	r.log.Info("Config reloaded", "paths", applied)
+	return applied, nil
+}
+
+// Start 开始监听 SIGHUP，reload.watch 开启时同时轮询配置文件变化
+func (r *Reloader) Start() {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if r.watch {
+		ticker = time.NewTicker(r.interval)
+		tick = ticker.C
+	}
+
+	go func() {
+		defer close(r.done)
+		defer signal.Stop(hup)
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-hup:
+				r.log.Info("Received SIGHUP, reloading config")
+				r.reloadAndLog()
+			case <-tick:
+				if r.filesChanged() {
+					r.log.Info("Config file changed, reloading config")
+					r.reloadAndLog()
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止监听
+func (r *Reloader) Stop() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+	r.stop = nil
+}
+
+func (r *Reloader) reloadAndLog() {
+	if _, err := r.Reload(); err != nil {
+		r.log.Error("Config reload rejected, keeping current config", "error", err)
+	}
+}
+
+// filesChanged 比较配置文件的修改时间和大小，ConfigMap 通过替换符号链接更新时同样有效
+func (r *Reloader) filesChanged() bool {
+	stamps := r.statFiles()
+	changed := !reflect.DeepEqual(stamps, r.stamps)
+	r.stamps = stamps
+	return changed
+}
+
+func (r *Reloader) statFiles() map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+	for _, name := range []string{baseFile, fmt.Sprintf("%s.yaml", r.env)} {
+		info, err := os.Stat(filepath.Join(r.dir, name))
+		if err != nil {
+			continue
+		}
+		stamps[name] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps
+}
+
+func isReloadable(path string) bool {
+	for _, prefix := range reloadable {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// diffPaths 逐字段比较两份配置，返回发生变化的 yaml 路径，例如 database.host
+func diffPaths(prefix string, a, b reflect.Value) []string {
+	if a.Kind() != reflect.Struct {
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var paths []string
+	for i := 0; i < a.NumField(); i++ {
+		tag := yamlName(a.Type().Field(i))
+		if tag == "" {
+			continue
+		}
+		path := tag
+		if prefix != "" {
+			path = prefix + "." + tag
+		}
+		paths = append(paths, diffPaths(path, a.Field(i), b.Field(i))...)
+	}
+	return paths
+}
+
+// fieldByPath 按 yaml 路径查找字段，路径来自 diffPaths，一定存在
+func fieldByPath(v reflect.Value, path string) reflect.Value {
+	for _, name := range strings.Split(path, ".") {
+		for i := 0; i < v.NumField(); i++ {
+			if yamlName(v.Type().Field(i)) == name {
+				v = v.Field(i)
+				break
+			}
+		}
+	}
+	return v
+}
+
+func yamlName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupReloader 加载 dir 中的 dev.yaml 作为当前配置
+func setupReloader(t *testing.T, dir string) *Reloader {
+	t.Helper()
+	cfg, err := Load(dir, "dev", nil)
+	require.NoError(t, err)
+	return newReloader(dir, cfg, func() []string { return nil }, slog.New(slog.DiscardHandler))
+}
+
+func rewrite(t *testing.T, dir, old, new string) {
+	t.Helper()
+	path := filepath.Join(dir, "dev.yaml")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(data), old, new, 1)), 0o600))
+}
+
+func TestReloadAppliesReloadableSettings(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+	reloader := setupReloader(t, dir)
+
+	var notified *Config
+	reloader.Subscribe("test", func(cfg *Config) { notified = cfg })
+
+	rewrite(t, dir, `level: "info"`, `level: "debug"`)
+	rewrite(t, dir, `- "*"`, `- "https://app.example.com"`)
+	rewrite(t, dir, "port: 8080", "port: 9000")
+
+	applied, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"log.level", "cors.allowed_origins"}, applied)
+
+	cfg := reloader.Current()
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, []string{"https://app.example.com"}, cfg.CORS.AllowedOrigins)
+	// 端口不能热更新，保留原值
+	assert.Equal(t, 8080, cfg.App.Port)
+	assert.Same(t, cfg, notified)
+}
+
+func TestReloadIgnoresNonReloadableChanges(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+	reloader := setupReloader(t, dir)
+	before := reloader.Current()
+
+	called := false
+	reloader.Subscribe("test", func(*Config) { called = true })
+
+	rewrite(t, dir, `host: "localhost"`, `host: "other-db"`)
+	applied, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Same(t, before, reloader.Current())
+	assert.False(t, called)
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+	reloader := setupReloader(t, dir)
+	before := reloader.Current()
+
+	rewrite(t, dir, `level: "info"`, `level: "verbose"`)
+	_, err := reloader.Reload()
+	assert.ErrorContains(t, err, "log.level")
+	assert.Same(t, before, reloader.Current())
+}
+
+func TestReloadFilesChanged(t *testing.T) {
+	dir := writeConfig(t, map[string]string{"dev.yaml": testBase})
+	reloader := setupReloader(t, dir)
+
+	assert.False(t, reloader.filesChanged())
+	rewrite(t, dir, `level: "info"`, `level: "error"`)
+	assert.True(t, reloader.filesChanged())
+	assert.False(t, reloader.filesChanged())
+}
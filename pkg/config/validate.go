@@ -0,0 +1,261 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ModeRelease 生产模式，与 gin.ReleaseMode 一致
+const ModeRelease = "release"
+
+// minSecretLength 生产环境 JWT 密钥的最小长度
+const minSecretLength = 32
+
+// weakSecrets 示例配置和常见的弱密码，生产环境禁止使用
+var weakSecrets = []string{
+	"password", "123456", "12345678", "admin", "root", "secret", "changeme", "change-me",
+	"your-secret-key", "test-secret-key", "production-secret-key-change-this",
+	"production_password", "redis_password",
+}
+
+// Validate 校验端口、时长、枚举值，生产模式下拒绝默认或弱密钥
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("app.mode", c.App.Mode, "debug", "release", "test")
+	v.port("app.port", c.App.Port)
+	v.nonNegative("app.shutdown_timeout", c.App.ShutdownTimeout)
+	v.nonNegative("app.drain_delay", c.App.DrainDelay)
+	for _, proxy := range c.App.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				v.add("app.trusted_proxies: %q is not a valid IP or CIDR", proxy)
+			}
+		}
+	}
+
+	v.oneOf("database.driver", c.Database.Driver, DriverMySQL, DriverPostgres, DriverSQLite)
+	v.required("database.database", c.Database.Database)
+	// SQLite 是本地文件，不需要地址
+	if c.Database.Driver != DriverSQLite {
+		v.port("database.port", c.Database.Port)
+		v.required("database.host", c.Database.Host)
+	}
+	if c.Database.Driver == DriverPostgres {
+		v.oneOf("database.ssl_mode", c.Database.SSLMode, "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+	v.nonNegative("database.max_idle_conns", c.Database.MaxIdleConns)
+	v.nonNegative("database.max_open_conns", c.Database.MaxOpenConns)
+	v.nonNegative("database.conn_max_lifetime", c.Database.ConnMaxLifetime)
+	v.nonNegative("database.tx_max_retries", c.Database.TxMaxRetries)
+	v.nonNegative("database.tx_retry_backoff", c.Database.TxRetryBackoff)
+	if c.Database.Driver == DriverSQLite && len(c.Database.Replicas) > 0 {
+		v.add("database.replicas: not supported by the sqlite driver")
+	}
+	for i, addr := range c.Database.Replicas {
+		replica := c.Database.ForReplica(addr)
+		v.required(fmt.Sprintf("database.replicas[%d]", i), replica.Host)
+		v.port(fmt.Sprintf("database.replicas[%d]", i), replica.Port)
+	}
+	v.nonNegative("database.replica_check_interval", c.Database.ReplicaCheckInterval)
+
+	v.port("redis.port", c.Redis.Port)
+	v.nonNegative("redis.database", c.Redis.Database)
+	v.nonNegative("redis.pool_size", c.Redis.PoolSize)
+
+	if c.Cache.Enabled {
+		v.positive("cache.ttl", c.Cache.TTL)
+	}
+	v.nonNegative("cache.negative_ttl", c.Cache.NegativeTTL)
+
+	v.positive("jwt.expire_hours", c.JWT.ExpireHours)
+	v.required("jwt.secret", c.JWT.Secret)
+
+	if c.Timezone.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone.Timezone); err != nil {
+			v.add("timezone.timezone: unknown timezone %q", c.Timezone.Timezone)
+		}
+	}
+
+	if c.Notification.Email.Enabled {
+		v.port("notification.email.port", c.Notification.Email.Port)
+		v.required("notification.email.host", c.Notification.Email.Host)
+	}
+	if c.Notification.Push.Enabled {
+		v.required("notification.push.endpoint", c.Notification.Push.Endpoint)
+		v.positive("notification.push.timeout", c.Notification.Push.Timeout)
+	}
+
+	for name, concurrency := range c.Queue.Queues {
+		v.positive("queue.queues."+name, concurrency)
+	}
+	v.nonNegative("queue.max_retries", c.Queue.MaxRetries)
+	v.nonNegative("queue.backoff_base", c.Queue.BackoffBase)
+	v.nonNegative("queue.backoff_max", c.Queue.BackoffMax)
+	v.nonNegative("queue.poll_interval", c.Queue.PollInterval)
+	v.nonNegative("queue.timeout", c.Queue.Timeout)
+
+	v.nonNegative("event.poll_interval", c.Event.PollInterval)
+	v.nonNegative("event.batch_size", c.Event.BatchSize)
+	v.nonNegative("event.max_attempts", c.Event.MaxAttempts)
+	v.nonNegative("event.retention_days", c.Event.RetentionDays)
+
+	if c.Search.Enabled {
+		v.positive("search.sync_interval", c.Search.SyncInterval)
+		v.positive("search.batch_size", c.Search.BatchSize)
+		v.positive("search.lookback", c.Search.Lookback)
+	}
+
+	v.nonNegative("transfer.export_batch_size", c.Transfer.ExportBatchSize)
+	v.nonNegative("transfer.import_max_file_size", c.Transfer.ImportMaxFileSize)
+	v.nonNegative("transfer.import_max_rows", c.Transfer.ImportMaxRows)
+	v.nonNegative("transfer.import_async_threshold", c.Transfer.ImportAsyncThreshold)
+	v.nonNegative("transfer.import_job_timeout", c.Transfer.ImportJobTimeout)
+
+	v.nonNegative("webhook.timeout", c.Webhook.Timeout)
+	v.nonNegative("webhook.max_attempts", c.Webhook.MaxAttempts)
+	v.nonNegative("webhook.disable_after", c.Webhook.DisableAfter)
+
+	v.nonNegative("health.timeout", c.Health.Timeout)
+	v.nonNegative("health.cache_ttl", c.Health.CacheTTL)
+	v.nonNegative("health.disk_min_free_mb", c.Health.DiskMinFreeMB)
+
+	if c.Metrics.Enabled {
+		v.port("metrics.port", c.Metrics.Port)
+		v.port("metrics.worker_port", c.Metrics.WorkerPort)
+		if c.Metrics.Port == c.App.Port {
+			v.add("metrics.port: must differ from app.port (%d)", c.App.Port)
+		}
+		if c.Metrics.Path != "" && !strings.HasPrefix(c.Metrics.Path, "/") {
+			v.add("metrics.path: must start with /")
+		}
+	}
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "", "otlp", "stdout", "none")
+	if c.Tracing.Exporter == "otlp" {
+		v.required("tracing.endpoint", c.Tracing.Endpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.add("tracing.sample_ratio: must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	// 日志级别可以热更新，重新加载时也需要校验
+	v.oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+	v.oneOf("log.format", c.Log.Format, "json", "text")
+	v.oneOf("log.output", c.Log.Output, "stdout", "stderr", "file", "both", "all")
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.RequestsPerSecond <= 0 {
+			v.add("rate_limit.requests_per_second: must be greater than 0, got %v", c.RateLimit.RequestsPerSecond)
+		}
+		v.positive("rate_limit.burst", c.RateLimit.Burst)
+	}
+	v.nonNegative("reload.interval", c.Reload.Interval)
+
+	if c.App.Mode == ModeRelease {
+		c.validateSecrets(v)
+	}
+	return v.err()
+}
+
+// validateSecrets 生产模式下的密钥检查
+func (c *Config) validateSecrets(v *validator) {
+	if len(c.JWT.Secret) < minSecretLength {
+		v.add("jwt.secret: must be at least %d characters in release mode", minSecretLength)
+	}
+	v.strongSecret("jwt.secret", c.JWT.Secret)
+	if c.Database.Driver != DriverSQLite {
+		v.strongSecret("database.password", c.Database.Password)
+	}
+	if c.Redis.Password != "" {
+		v.strongSecret("redis.password", c.Redis.Password)
+	}
+	if c.Notification.Email.Enabled {
+		v.strongSecret("notification.email.password", c.Notification.Email.Password)
+	}
+	if c.Notification.Push.Enabled {
+		v.strongSecret("notification.push.api_key", c.Notification.Push.APIKey)
+	}
+}
+
+// validator 收集所有校验错误，一次性报告
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config: %w", errors.Join(v.errs...))
+}
+
+func (v *validator) port(name string, port int) {
+	if port < 1 || port > 65535 {
+		v.add("%s: must be between 1 and 65535, got %d", name, port)
+	}
+}
+
+func (v *validator) positive(name string, n int) {
+	if n <= 0 {
+		v.add("%s: must be greater than 0, got %d", name, n)
+	}
+}
+
+func (v *validator) nonNegative(name string, n int) {
+	if n < 0 {
+		v.add("%s: must not be negative, got %d", name, n)
+	}
+}
+
+func (v *validator) required(name, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add("%s: is required", name)
+	}
+}
+
+func (v *validator) oneOf(name, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add("%s: must be one of [%s], got %q", name, strings.Join(nonEmpty(allowed), ", "), value)
+}
+
+// strongSecret 拒绝空值、示例值和常见弱密码
+func (v *validator) strongSecret(name, value string) {
+	if value == "" {
+		v.add("%s: must be set in release mode (use %s or %s_FILE)", name, envName(name), envName(name))
+		return
+	}
+	lower := strings.ToLower(value)
+	for _, weak := range weakSecrets {
+		if lower == weak {
+			v.add("%s: refusing to start with a default or weak secret in release mode", name)
+			return
+		}
+	}
+}
+
+// envName 配置路径对应的环境变量名
+func envName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -1,37 +1,46 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/chenyl99x/toge-api/pkg/config"
-	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+	"github.com/chenyl99x/toge-api/pkg/tracing"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-func InitDB() error {
-	var err error
+// New 创建数据库连接池，不会立即连接，由 Ping 在启动阶段检查连通性
+func New(dbConfig config.DatabaseConfig, logConfig config.LogConfig, log *slog.Logger) (*gorm.DB, error) {
+	sqlLogger := NewSQLLogger(log)
+	gormConfig := &gorm.Config{
+		Logger: sqlLogger,
+		// 连接在生命周期的启动阶段检查
+		DisableAutomaticPing: true,
+		// 把各数据库的唯一索引冲突等错误转换为 gorm.ErrDuplicatedKey 等通用错误，由 apperror 映射为错误码
+		TranslateError: true,
+	}
 
 	// 检查是否启用 SQL 日志
-	if !config.GlobalConfig.Log.SQL.Enabled {
-		// 如果不启用 SQL 日志，使用静默日志器
-		DB, err = gorm.Open(mysql.Open(config.GlobalConfig.Database.GetDSN()), &gorm.Config{})
-		if err != nil {
-			logger.Error("Failed to connect to database", "error", err.Error())
-			return err
-		}
+	if !logConfig.SQL.Enabled {
+		// 如果不启用 SQL 日志，使用静默日志器，查询指标由 metricsPlugin 记录
+		gormConfig.Logger = sqlLogger.LogMode(gormlogger.Silent)
 	} else {
-		// 创建 SQL 日志器
-		sqlLogger := NewSQLLogger()
-
 		// 设置慢查询阈值
-		sqlLogger.SlowThreshold = time.Duration(config.GlobalConfig.Log.SQL.SlowThreshold) * time.Millisecond
+		sqlLogger.SlowThreshold = time.Duration(logConfig.SQL.SlowThreshold) * time.Millisecond
 
 		// 根据配置设置日志级别
-		switch config.GlobalConfig.Log.SQL.LogLevel {
+		switch logConfig.SQL.LogLevel {
 		case "debug":
 			sqlLogger.LogLevel = "info"
 		case "info":
@@ -43,38 +52,124 @@ func InitDB() error {
 		default:
 			sqlLogger.LogLevel = "info"
 		}
+		// 启用预编译语句缓存
+		gormConfig.PrepareStmt = true
+	}
 
-		// 使用配置文件中的数据库连接信息
-		dsn := config.GlobalConfig.Database.GetDSN()
-		DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: sqlLogger,
-			// 启用 context 支持
-			PrepareStmt: true,
-		})
-		if err != nil {
-			logger.Error("Failed to connect to database", "error", err.Error())
-			return err
-		}
+	dialector, err := Dialector(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		log.Error("Failed to open database", "error", err.Error())
+		return nil, err
 	}
 
 	// 配置连接池
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		logger.Error("Failed to get database instance", "error", err.Error())
-		return err
+		log.Error("Failed to get database instance", "error", err.Error())
+		return nil, err
+	}
+
+	configurePool(sqlDB, dbConfig)
+
+	// 为每条 SQL 创建子 span
+	if err := db.Use(tracing.NewGormPlugin(dbConfig.Driver)); err != nil {
+		log.Error("Failed to register tracing plugin", "error", err.Error())
+		return nil, err
+	}
+
+	// 查询指标
+	if err := db.Use(metricsPlugin{}); err != nil {
+		log.Error("Failed to register metrics plugin", "error", err.Error())
+		return nil, err
 	}
 
-	sqlDB.SetMaxIdleConns(config.GlobalConfig.Database.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(config.GlobalConfig.Database.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(time.Duration(config.GlobalConfig.Database.ConnMaxLifetime) * time.Second)
+	// 连接池指标
+	metrics.RegisterDBStats(sqlDB, dbConfig.Database)
+
+	return db, nil
+}
 
-	logger.Info("Database connected successfully",
-		"host", config.GlobalConfig.Database.Host,
-		"port", config.GlobalConfig.Database.Port,
-		"database", config.GlobalConfig.Database.Database,
-		"max_idle_conns", config.GlobalConfig.Database.MaxIdleConns,
-		"max_open_conns", config.GlobalConfig.Database.MaxOpenConns,
-	)
+// configurePool 按配置设置连接池
+func configurePool(sqlDB *sql.DB, dbConfig config.DatabaseConfig) {
+	if dbConfig.Driver == config.DriverSQLite {
+		// SQLite 同一时刻只允许一个写入者，单连接避免 database is locked，
+		// 内存数据库随连接关闭而丢失，连接不能过期
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		return
+	}
+	sqlDB.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(dbConfig.ConnMaxLifetime) * time.Second)
+}
 
-	return nil
+// Dialector 根据 database.driver 选择 gorm 驱动，未配置时使用 MySQL
+func Dialector(dbConfig config.DatabaseConfig) (gorm.Dialector, error) {
+	switch dbConfig.Driver {
+	case config.DriverMySQL, "":
+		return mysql.Open(dbConfig.MySQLDSN()), nil
+	case config.DriverPostgres:
+		return postgres.Open(dbConfig.PostgresDSN()), nil
+	case config.DriverSQLite:
+		// 数据库文件所在目录不存在时 SQLite 无法创建文件
+		if dbConfig.Database != ":memory:" {
+			if err := os.MkdirAll(filepath.Dir(dbConfig.Database), 0o755); err != nil {
+				return nil, fmt.Errorf("failed to create sqlite directory: %w", err)
+			}
+		}
+		return sqlite.Open(dbConfig.SQLiteDSN()), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", dbConfig.Driver)
+	}
+}
+
+// LikeOperator 返回不区分大小写的模糊匹配运算符
+// MySQL 默认排序规则和 SQLite 的 LIKE 不区分大小写，PostgreSQL 的 LIKE 区分大小写，需要使用 ILIKE
+func LikeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == config.DriverPostgres {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
+
+// Ping 检查数据库连通性
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// PoolStats 连接池统计信息
+func PoolStats(sqlDB *sql.DB) map[string]any {
+	stats := sqlDB.Stats()
+	return map[string]any{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration":        stats.WaitDuration.String(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}
+}
+
+// Close 关闭数据库连接池
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
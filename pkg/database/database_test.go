@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newTestDB 创建 SQLite 内存数据库，测试不依赖外部数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = Close(db) })
+	return db
+}
+
+func TestNewSQLite(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, Ping(context.Background(), db))
+	assert.Equal(t, config.DriverSQLite, db.Dialector.Name())
+
+	// 外键约束通过 DSN 开启
+	var foreignKeys int
+	require.NoError(t, db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error)
+	assert.Equal(t, 1, foreignKeys)
+}
+
+func TestNewSQLiteCreatesDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "toge.db")
+	db, err := New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: path}, config.LogConfig{}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	defer Close(db)
+
+	assert.NoError(t, Ping(context.Background(), db))
+	assert.FileExists(t, path)
+}
+
+func TestDialector(t *testing.T) {
+	for _, driver := range []string{"", config.DriverMySQL, config.DriverPostgres, config.DriverSQLite} {
+		dialector, err := Dialector(config.DatabaseConfig{Driver: driver, Database: ":memory:"})
+		require.NoError(t, err, driver)
+		if driver != "" {
+			assert.Equal(t, driver, dialector.Name())
+		}
+	}
+
+	_, err := Dialector(config.DatabaseConfig{Driver: "oracle"})
+	assert.ErrorContains(t, err, "unsupported database driver: oracle")
+}
+
+func TestLikeOperator(t *testing.T) {
+	assert.Equal(t, "LIKE", LikeOperator(newTestDB(t)))
+	assert.Equal(t, "ILIKE", LikeOperator(&gorm.DB{Config: &gorm.Config{Dialector: postgres.Open("")}}))
+}
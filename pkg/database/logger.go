@@ -2,10 +2,9 @@ package database
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
-	"github.com/chenyl99x/toge-api/pkg/logger"
-
 	gormlogger "gorm.io/gorm/logger"
 )
 
@@ -13,13 +12,15 @@ import (
 type SQLLogger struct {
 	SlowThreshold time.Duration
 	LogLevel      string
+	log           *slog.Logger
 }
 
 // NewSQLLogger 创建新的 SQL 日志器
-func NewSQLLogger() *SQLLogger {
+func NewSQLLogger(log *slog.Logger) *SQLLogger {
 	return &SQLLogger{
 		SlowThreshold: 200 * time.Millisecond, // 慢查询阈值
 		LogLevel:      "info",
+		log:           log,
 	}
 }
 
@@ -44,7 +45,7 @@ func (l *SQLLogger) Info(ctx context.Context, msg string, data ...interface{}) {
 	if l.LogLevel == "silent" {
 		return
 	}
-	logger.InfoWithTrace(ctx, "SQL Info", "message", msg, "data", data)
+	l.log.InfoContext(ctx, "SQL Info", "message", msg, "data", data)
 }
 
 // Warn 记录警告日志
@@ -52,16 +53,17 @@ func (l *SQLLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
 	if l.LogLevel == "silent" {
 		return
 	}
-	logger.WarnWithTrace(ctx, "SQL Warn", "message", msg, "data", data)
+	l.log.WarnContext(ctx, "SQL Warn", "message", msg, "data", data)
 }
 
 // Error 记录错误日志
 func (l *SQLLogger) Error(ctx context.Context, msg string, data ...interface{}) {
-	logger.ErrorWithTrace(ctx, "SQL Error", "message", msg, "data", data)
+	l.log.ErrorContext(ctx, "SQL Error", "message", msg, "data", data)
 }
 
 // Trace 记录 SQL 查询跟踪
 func (l *SQLLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	// 关闭日志时不调用 fc，避免拼接参数生成完整 SQL，查询指标由 metricsPlugin 记录
 	if l.LogLevel == "silent" {
 		return
 	}
@@ -80,14 +82,14 @@ func (l *SQLLogger) Trace(ctx context.Context, begin time.Time, fc func() (strin
 	// 如果有错误，记录错误信息
 	if err != nil {
 		fields = append(fields, "error", err.Error())
-		logger.ErrorWithTrace(ctx, "SQL Query Error", fields...)
+		l.log.ErrorContext(ctx, "SQL Query Error", fields...)
 		return
 	}
 
 	// 根据查询时间选择日志级别
 	if elapsed > l.SlowThreshold {
-		logger.WarnWithTrace(ctx, "Slow SQL Query", fields...)
+		l.log.WarnContext(ctx, "Slow SQL Query", fields...)
 	} else {
-		logger.InfoWithTrace(ctx, "SQL Query", fields...)
+		l.log.InfoContext(ctx, "SQL Query", fields...)
 	}
 }
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -9,7 +11,7 @@ import (
 )
 
 func TestNewSQLLogger(t *testing.T) {
-	logger := NewSQLLogger()
+	logger := NewSQLLogger(slog.New(slog.DiscardHandler))
 
 	assert.NotNil(t, logger)
 	assert.Equal(t, 200*time.Millisecond, logger.SlowThreshold)
@@ -17,7 +19,7 @@ func TestNewSQLLogger(t *testing.T) {
 }
 
 func TestSQLLogger_LogMode(t *testing.T) {
-	logger := NewSQLLogger()
+	logger := NewSQLLogger(slog.New(slog.DiscardHandler))
 
 	// 测试不同日志级别
 	testCases := []struct {
@@ -45,7 +47,7 @@ func TestSQLLogger_LogMode(t *testing.T) {
 }
 
 func TestSQLLogger_Trace(t *testing.T) {
-	logger := NewSQLLogger()
+	logger := NewSQLLogger(slog.New(slog.DiscardHandler))
 
 	// 测试慢查询阈值设置
 	logger.SlowThreshold = 1 * time.Millisecond
@@ -55,3 +57,13 @@ func TestSQLLogger_Trace(t *testing.T) {
 	logger.LogLevel = "warn"
 	assert.Equal(t, "warn", logger.LogLevel)
 }
+
+func TestSQLLogger_TraceSilentSkipsSQL(t *testing.T) {
+	logger := NewSQLLogger(slog.New(slog.DiscardHandler)).LogMode(gormlogger.Silent)
+
+	// 关闭日志时不生成完整 SQL
+	logger.Trace(context.Background(), time.Now(), func() (string, int64) {
+		t.Fatal("fc called with logging disabled")
+		return "", 0
+	}, nil)
+}
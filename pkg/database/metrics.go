@@ -0,0 +1,55 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+
+	"gorm.io/gorm"
+)
+
+// queryStartKey 在 gorm.Statement 中保存开始时间的键
+const queryStartKey = "metrics:start"
+
+// metricsPlugin 记录每条 SQL 的耗时和错误，不受日志级别影响
+// 操作类型取自带占位符的 SQL，不需要日志器拼接参数后的完整 SQL
+type metricsPlugin struct{}
+
+func (metricsPlugin) Name() string {
+	return "metrics"
+}
+
+func (p metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", p.after),
+	}
+	return errors.Join(errs...)
+}
+
+func (metricsPlugin) before(db *gorm.DB) {
+	db.Statement.Settings.Store(queryStartKey, time.Now())
+}
+
+func (metricsPlugin) after(db *gorm.DB) {
+	value, ok := db.Statement.Settings.LoadAndDelete(queryStartKey)
+	// 没有生成 SQL 时（例如钩子返回错误）不计数，和 gorm 调用日志器的条件一致
+	if !ok || db.Statement.SQL.Len() == 0 {
+		return
+	}
+	// 记录不存在不计为错误
+	failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+	metrics.ObserveQuery(db.Statement.SQL.String(), time.Since(value.(time.Time)), failed)
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryMetric 读取指定操作的查询次数和错误次数
+func queryMetric(t *testing.T, operation string) (count uint64, failed float64) {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if len(m.GetLabel()) != 1 || m.GetLabel()[0].GetValue() != operation {
+				continue
+			}
+			switch family.GetName() {
+			case "toge_db_query_duration_seconds":
+				count = m.GetHistogram().GetSampleCount()
+			case "toge_db_query_errors_total":
+				failed = m.GetCounter().GetValue()
+			}
+		}
+	}
+	return count, failed
+}
+
+func TestMetricsPlugin(t *testing.T) {
+	// 默认关闭 SQL 日志，指标仍然记录
+	db := newTestDB(t)
+	type item struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&item{}))
+
+	inserts, _ := queryMetric(t, "insert")
+	selects, selectErrors := queryMetric(t, "select")
+
+	require.NoError(t, db.Create(&item{Name: "a"}).Error)
+	var got item
+	require.NoError(t, db.First(&got).Error)
+	// 记录不存在不计为错误
+	require.Error(t, db.First(&got, 100).Error)
+	require.Error(t, db.Table("missing").Select("id").Scan(&got).Error)
+
+	count, _ := queryMetric(t, "insert")
+	assert.Equal(t, inserts+1, count)
+	count, errs := queryMetric(t, "select")
+	assert.Equal(t, selects+3, count)
+	assert.Equal(t, selectErrors+1, errs)
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// preloadKey 用于在 context 中保存读取资源时需要预加载的关联
+type preloadKey struct{}
+
+// WithPreload 返回要求预加载关联的 context，关联为 gorm 的关联路径，例如 Owner、Owner.Spaces
+func WithPreload(ctx context.Context, associations ...string) context.Context {
+	if len(associations) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, preloadKey{}, slices.Concat(Preloads(ctx), associations))
+}
+
+// Preloads context 中要求预加载的关联
+func Preloads(ctx context.Context) []string {
+	associations, _ := ctx.Value(preloadKey{}).([]string)
+	return associations
+}
+
+// Preload 按 context 在查询上预加载关联，仓储只在读取资源本身的查询上调用，计数和更新不受影响
+func Preload(ctx context.Context, query *gorm.DB) *gorm.DB {
+	for _, association := range Preloads(ctx) {
+		query = query.Preload(association)
+	}
+	return query
+}
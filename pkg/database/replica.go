@@ -0,0 +1,270 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+// defaultReplicaCheckInterval 未配置时的从库健康检查间隔
+const defaultReplicaCheckInterval = 5 * time.Second
+
+// replicaPingTimeout 单个从库健康检查的超时时间
+const replicaPingTimeout = 2 * time.Second
+
+// forcePrimaryKey 用于在 context 中标记强制读主库
+type forcePrimaryKey struct{}
+
+// ForcePrimary 返回强制读主库的 context，用于写后立即读取或读取后修改再写回，避免读到从库复制延迟前的数据
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+// isForcePrimary 判断 context 是否要求读主库
+func isForcePrimary(ctx context.Context) bool {
+	force, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return force
+}
+
+// Replicas 只读从库，读请求轮询路由到健康的从库，全部不可用时回退到主库
+// 写操作、事务内的读、加锁读和 ForcePrimary 的请求始终使用主库
+type Replicas struct {
+	replicas []*replica
+	interval time.Duration
+	log      *slog.Logger
+	next     atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// replica 单个从库的连接池和健康状态
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// ReplicaStatus 从库状态和连接池统计，用于健康检查输出
+type ReplicaStatus struct {
+	Name    string         `json:"name" example:"db-replica-1:3306"`
+	Healthy bool           `json:"healthy" example:"true"`
+	Error   string         `json:"error,omitempty"`
+	Pool    map[string]any `json:"pool"`
+}
+
+// NewReplicas 打开从库连接池并在 db 上注册读写分离，未配置从库时不做任何处理
+// 与主库一样不会立即连接，Start 检查通过之前读请求使用主库
+func NewReplicas(db *gorm.DB, dbConfig config.DatabaseConfig, log *slog.Logger) (*Replicas, error) {
+	interval := time.Duration(dbConfig.ReplicaCheckInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultReplicaCheckInterval
+	}
+	rs := &Replicas{interval: interval, log: log}
+	if len(dbConfig.Replicas) == 0 {
+		return rs, nil
+	}
+
+	for _, addr := range dbConfig.Replicas {
+		replicaConfig := dbConfig.ForReplica(addr)
+		sqlDB, err := openReplica(replicaConfig)
+		if err != nil {
+			rs.Close()
+			return nil, fmt.Errorf("failed to open replica %s: %w", addr, err)
+		}
+		configurePool(sqlDB, replicaConfig)
+		metrics.RegisterDBStats(sqlDB, replicaConfig.Database+"@"+addr)
+
+		rs.replicas = append(rs.replicas, &replica{name: addr, db: sqlDB})
+	}
+
+	if err := rs.register(db, dbConfig.Driver); err != nil {
+		rs.Close()
+		return nil, fmt.Errorf("failed to register replicas: %w", err)
+	}
+	return rs, nil
+}
+
+// register 在 db 上注册 dbresolver，由 Resolve 选择从库
+func (rs *Replicas) register(db *gorm.DB, driver string) error {
+	primary, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	dialectors := make([]gorm.Dialector, 0, len(rs.replicas)+1)
+	for _, r := range rs.replicas {
+		dialectors = append(dialectors, connDialector(driver, r.db))
+	}
+	// 主库作为最后一个候选用于回退，dbresolver 只有一个候选时不会调用 Resolve
+	dialectors = append(dialectors, connDialector(driver, primary))
+
+	return db.Use(dbresolver.Register(dbresolver.Config{Replicas: dialectors, Policy: rs}))
+}
+
+// openReplica 打开从库连接池，不会立即连接
+func openReplica(dbConfig config.DatabaseConfig) (*sql.DB, error) {
+	var dialector gorm.Dialector
+	switch dbConfig.Driver {
+	case config.DriverMySQL, "":
+		// 跳过版本查询，从库不可用时也能正常启动
+		dialector = mysql.New(mysql.Config{DSN: dbConfig.MySQLDSN(), SkipInitializeWithVersion: true})
+	case config.DriverPostgres:
+		dialector = postgres.Open(dbConfig.PostgresDSN())
+	default:
+		return nil, fmt.Errorf("replicas are not supported by database driver: %s", dbConfig.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{DisableAutomaticPing: true, Logger: gormlogger.Discard})
+	if err != nil {
+		return nil, err
+	}
+	return db.DB()
+}
+
+// connDialector 使用已打开的连接池创建 gorm 驱动，供 dbresolver 路由
+func connDialector(driver string, conn *sql.DB) gorm.Dialector {
+	switch driver {
+	case config.DriverPostgres:
+		return postgres.New(postgres.Config{Conn: conn})
+	case config.DriverSQLite:
+		return &sqlite.Dialector{Conn: conn}
+	default:
+		return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true})
+	}
+}
+
+// Resolve 实现 dbresolver.Policy，connPools 与从库顺序一致，最后一个为主库
+func (rs *Replicas) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := range n {
+		idx := (start + i) % n
+		if rs.replicas[idx].healthy.Load() {
+			return connPools[idx]
+		}
+	}
+	return connPools[len(connPools)-1]
+}
+
+// Start 立即检查一次从库，之后在后台定期检查
+func (rs *Replicas) Start(ctx context.Context) {
+	if len(rs.replicas) == 0 {
+		return
+	}
+	rs.check(ctx)
+
+	rs.stop = make(chan struct{})
+	rs.done = make(chan struct{})
+	go rs.run()
+}
+
+func (rs *Replicas) run() {
+	defer close(rs.done)
+
+	ticker := time.NewTicker(rs.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			rs.check(context.Background())
+		}
+	}
+}
+
+// check 检查所有从库的连通性，状态变化时记录日志
+func (rs *Replicas) check(ctx context.Context) {
+	for _, r := range rs.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := r.db.PingContext(pingCtx)
+		cancel()
+
+		r.mu.Lock()
+		r.lastErr = err
+		r.mu.Unlock()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			rs.log.InfoContext(ctx, "Database replica available", "replica", r.name)
+		} else {
+			rs.log.WarnContext(ctx, "Database replica unavailable, reads fall back to other replicas or primary",
+				"replica", r.name, "error", err.Error())
+		}
+	}
+}
+
+// Close 停止健康检查并关闭从库连接池
+func (rs *Replicas) Close() error {
+	if rs.stop != nil {
+		close(rs.stop)
+		<-rs.done
+		rs.stop = nil
+	}
+
+	var errs []error
+	for _, r := range rs.replicas {
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Len 配置的从库数量
+func (rs *Replicas) Len() int {
+	return len(rs.replicas)
+}
+
+// Err 返回不可用的从库，全部可用时返回 nil
+func (rs *Replicas) Err() error {
+	var errs []error
+	for _, r := range rs.replicas {
+		if r.healthy.Load() {
+			continue
+		}
+		r.mu.Lock()
+		err := r.lastErr
+		r.mu.Unlock()
+		if err == nil {
+			err = errors.New("not checked yet")
+		}
+		errs = append(errs, fmt.Errorf("replica %s: %w", r.name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// Status 每个从库的健康状态和连接池统计
+func (rs *Replicas) Status() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(rs.replicas))
+	for _, r := range rs.replicas {
+		status := ReplicaStatus{Name: r.name, Healthy: r.healthy.Load(), Pool: PoolStats(r.db)}
+		r.mu.Lock()
+		if r.lastErr != nil {
+			status.Error = r.lastErr.Error()
+		}
+		r.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
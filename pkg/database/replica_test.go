@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestReplicas 两个 SQLite 内存数据库分别作为主库和从库，通过数据区分请求落在哪个库
+func newTestReplicas(t *testing.T) (*gorm.DB, *Replicas) {
+	t.Helper()
+	primary := newTestDB(t)
+	replicaDB := newTestDB(t)
+	for name, db := range map[string]*gorm.DB{"primary": primary, "replica": replicaDB} {
+		require.NoError(t, db.AutoMigrate(&txTestRecord{}))
+		require.NoError(t, db.Create(&txTestRecord{Name: name}).Error)
+	}
+
+	sqlDB, err := replicaDB.DB()
+	require.NoError(t, err)
+	rs := &Replicas{
+		replicas: []*replica{{name: "replica", db: sqlDB}},
+		interval: time.Hour,
+		log:      slog.New(slog.DiscardHandler),
+	}
+	require.NoError(t, rs.register(primary, config.DriverSQLite))
+	return primary, rs
+}
+
+// readName 读取唯一一条记录的名称，即请求所在的库
+func readName(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var record txTestRecord
+	require.NoError(t, db.First(&record).Error)
+	return record.Name
+}
+
+func TestReplicasRouting(t *testing.T) {
+	db, rs := newTestReplicas(t)
+	ctx := context.Background()
+
+	// 检查通过之前读主库
+	assert.Equal(t, "primary", readName(t, Conn(ctx, db)))
+	assert.Error(t, rs.Err())
+
+	rs.Start(ctx)
+	defer rs.Close()
+	require.NoError(t, rs.Err())
+	assert.Equal(t, "replica", readName(t, Conn(ctx, db)))
+	assert.Equal(t, "primary", readName(t, Conn(ForcePrimary(ctx), db)))
+
+	// 写主库
+	require.NoError(t, Conn(ctx, db).Model(&txTestRecord{}).Where("1 = 1").Update("name", "updated").Error)
+	assert.Equal(t, "updated", readName(t, Conn(ForcePrimary(ctx), db)))
+	assert.Equal(t, "replica", readName(t, Conn(ctx, db)))
+
+	// 事务内的读使用主库
+	m := NewTxManager(db, TxOptions{Logger: slog.New(slog.DiscardHandler)})
+	err := m.WithinTransaction(ctx, func(ctx context.Context) error {
+		assert.Equal(t, "updated", readName(t, Conn(ctx, db)))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestReplicasFallBackToPrimary(t *testing.T) {
+	db, rs := newTestReplicas(t)
+	ctx := context.Background()
+
+	rs.Start(ctx)
+	assert.Equal(t, "replica", readName(t, Conn(ctx, db)))
+
+	// 从库不可用时回退到主库
+	require.NoError(t, rs.replicas[0].db.Close())
+	rs.check(ctx)
+	assert.Equal(t, "primary", readName(t, Conn(ctx, db)))
+	assert.ErrorContains(t, rs.Err(), "replica replica")
+
+	statuses := rs.Status()
+	require.Len(t, statuses, 1)
+	assert.False(t, statuses[0].Healthy)
+	assert.NotEmpty(t, statuses[0].Error)
+	assert.Contains(t, statuses[0].Pool, "open_connections")
+}
+
+func TestNewReplicasWithoutReplicas(t *testing.T) {
+	db := newTestDB(t)
+	rs, err := NewReplicas(db, config.DatabaseConfig{Driver: config.DriverSQLite}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	rs.Start(context.Background())
+	assert.Zero(t, rs.Len())
+	assert.NoError(t, rs.Err())
+	assert.Empty(t, rs.Status())
+	assert.NoError(t, rs.Close())
+}
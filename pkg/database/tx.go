@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// defaultTxRetryBackoff 未配置时死锁重试的等待基数
+const defaultTxRetryBackoff = 50 * time.Millisecond
+
+// MySQL 错误码，出现时整个事务已被回滚，可以安全地整体重试
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+)
+
+// Postgres SQLSTATE，出现时事务已中止，回滚后可以整体重试
+const (
+	pgErrSerializationFailure = "40001"
+	pgErrDeadlockDetected     = "40P01"
+)
+
+// txKey 用于在 context 中保存当前事务
+type txKey struct{}
+
+// afterCommitKey 用于在 context 中保存最外层事务提交后执行的回调
+type afterCommitKey struct{}
+
+// TxOptions 事务管理器参数
+type TxOptions struct {
+	MaxRetries   int           // 死锁或锁等待超时时整体重试的次数，0 不重试
+	RetryBackoff time.Duration // 第 n 次重试前等待 n 倍该时间
+	Logger       *slog.Logger
+}
+
+// TxManager 工作单元，将事务放入 context，仓储通过 Conn 获取连接时自动加入事务
+type TxManager struct {
+	db         *gorm.DB
+	maxRetries int
+	backoff    time.Duration
+	log        *slog.Logger
+}
+
+// NewTxManager 创建事务管理器
+func NewTxManager(db *gorm.DB, opts TxOptions) *TxManager {
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultTxRetryBackoff
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &TxManager{db: db, maxRetries: opts.MaxRetries, backoff: opts.RetryBackoff, log: opts.Logger}
+}
+
+// WithinTransaction 在事务中执行 fn，fn 内所有仓储调用属于同一事务，fn 返回错误时回滚
+// ctx 中已有事务时创建保存点，fn 返回错误只回滚到保存点，由调用方决定外层事务是否继续
+// 最外层事务遇到死锁或锁等待超时时整体重试，fn 可能被执行多次，不能包含事务外的副作用
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		// gorm 在已开启的事务上调用 Transaction 时使用 SAVEPOINT
+		return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+	}
+
+	var callbacks []func()
+	err := m.retry(ctx, func() error {
+		// 重试时丢弃上一次执行注册的回调
+		callbacks = callbacks[:0]
+		return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txCtx := context.WithValue(ctx, txKey{}, tx)
+			return fn(context.WithValue(txCtx, afterCommitKey{}, &callbacks))
+		})
+	})
+	if err != nil {
+		return err
+	}
+	for _, callback := range callbacks {
+		callback()
+	}
+	return nil
+}
+
+// AfterCommit 在最外层事务提交后执行 fn，事务回滚时不执行，不在事务中时立即执行
+// 用于缓存失效等不能回滚的副作用，避免其他请求在提交前读到旧数据并重新写入缓存
+func AfterCommit(ctx context.Context, fn func()) {
+	if callbacks, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		*callbacks = append(*callbacks, fn)
+		return
+	}
+	fn()
+}
+
+// ReadsPrimary 判断当前 context 的读请求是否必须读主库，事务中或 ForcePrimary 时缓存也应跳过
+func ReadsPrimary(ctx context.Context) bool {
+	_, inTx := ctx.Value(txKey{}).(*gorm.DB)
+	return inTx || isForcePrimary(ctx)
+}
+
+// retry 执行 run，遇到可重试的错误时按线性退避重试
+func (m *TxManager) retry(ctx context.Context, run func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt > m.maxRetries || !IsRetryable(err) {
+			return err
+		}
+
+		wait := time.Duration(attempt) * m.backoff
+		m.log.WarnContext(ctx, "Transaction aborted by lock conflict, retrying",
+			"attempt", attempt, "wait", wait.String(), "error", err.Error())
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// IsRetryable 判断错误是否为死锁、锁等待超时或 Postgres 序列化失败，此时事务已回滚，可以整体重试
+func IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgErrDeadlockDetected || pgErr.Code == pgErrSerializationFailure
+	}
+	return false
+}
+
+// Conn 获取当前 context 对应的连接，处于事务中时返回事务连接，否则使用 db
+// 配置了从库时，非事务的读请求路由到从库，ForcePrimary 的 context 读主库
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	if isForcePrimary(ctx) {
+		return db.WithContext(ctx).Clauses(dbresolver.Write)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var deadlock = &mysql.MySQLError{Number: mysqlErrDeadlock, Message: "Deadlock found when trying to get lock"}
+
+func newTestTxManager(maxRetries int) *TxManager {
+	return NewTxManager(nil, TxOptions{
+		MaxRetries:   maxRetries,
+		RetryBackoff: time.Millisecond,
+		Logger:       slog.New(slog.DiscardHandler),
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(deadlock))
+	assert.True(t, IsRetryable(fmt.Errorf("create user: %w", deadlock)))
+	assert.True(t, IsRetryable(&mysql.MySQLError{Number: mysqlErrLockWaitTimeout}))
+	assert.False(t, IsRetryable(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+	assert.True(t, IsRetryable(&pgconn.PgError{Code: pgErrDeadlockDetected}))
+	assert.True(t, IsRetryable(fmt.Errorf("update space: %w", &pgconn.PgError{Code: pgErrSerializationFailure})))
+	assert.False(t, IsRetryable(&pgconn.PgError{Code: "23505", Message: "duplicate key value"}))
+	assert.False(t, IsRetryable(errors.New("deadlock")))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestTxManagerRetriesDeadlock(t *testing.T) {
+	m := newTestTxManager(2)
+
+	calls := 0
+	err := m.retry(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return deadlock
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestTxManagerGivesUpAfterMaxRetries(t *testing.T) {
+	m := newTestTxManager(2)
+
+	calls := 0
+	err := m.retry(context.Background(), func() error {
+		calls++
+		return deadlock
+	})
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 3, calls)
+}
+
+func TestTxManagerDoesNotRetryOtherErrors(t *testing.T) {
+	m := newTestTxManager(3)
+	boom := errors.New("boom")
+
+	calls := 0
+	err := m.retry(context.Background(), func() error {
+		calls++
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, calls)
+}
+
+func TestTxManagerStopsRetryingWhenContextDone(t *testing.T) {
+	m := NewTxManager(nil, TxOptions{MaxRetries: 5, RetryBackoff: time.Hour, Logger: slog.New(slog.DiscardHandler)})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := m.retry(ctx, func() error {
+		calls++
+		cancel()
+		return deadlock
+	})
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 1, calls)
+}
+
+type txTestRecord struct {
+	ID   uint
+	Name string
+}
+
+func newTestTxStore(t *testing.T) (*gorm.DB, *TxManager) {
+	t.Helper()
+	db := newTestDB(t)
+	require.NoError(t, db.AutoMigrate(&txTestRecord{}))
+	return db, NewTxManager(db, TxOptions{Logger: slog.New(slog.DiscardHandler)})
+}
+
+func countRecords(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	require.NoError(t, db.Model(&txTestRecord{}).Count(&n).Error)
+	return n
+}
+
+func TestWithinTransactionCommitsAndRollsBack(t *testing.T) {
+	db, m := newTestTxStore(t)
+	ctx := context.Background()
+
+	err := m.WithinTransaction(ctx, func(ctx context.Context) error {
+		return Conn(ctx, db).Create(&txTestRecord{Name: "committed"}).Error
+	})
+	require.NoError(t, err)
+
+	boom := errors.New("boom")
+	err = m.WithinTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, Conn(ctx, db).Create(&txTestRecord{Name: "rolled back"}).Error)
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, int64(1), countRecords(t, db))
+}
+
+func TestWithinTransactionNestedSavepoint(t *testing.T) {
+	db, m := newTestTxStore(t)
+
+	err := m.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := Conn(ctx, db).Create(&txTestRecord{Name: "outer"}).Error; err != nil {
+			return err
+		}
+
+		// 内层失败只回滚到保存点，外层继续提交
+		inner := m.WithinTransaction(ctx, func(ctx context.Context) error {
+			require.NoError(t, Conn(ctx, db).Create(&txTestRecord{Name: "inner"}).Error)
+			return errors.New("inner failed")
+		})
+		assert.Error(t, inner)
+		return nil
+	})
+	require.NoError(t, err)
+
+	var names []string
+	require.NoError(t, db.Model(&txTestRecord{}).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"outer"}, names)
+}
+
+func TestAfterCommit(t *testing.T) {
+	db, m := newTestTxStore(t)
+	ctx := context.Background()
+
+	// 不在事务中时立即执行
+	calls := 0
+	AfterCommit(ctx, func() { calls++ })
+	assert.Equal(t, 1, calls)
+
+	err := m.WithinTransaction(ctx, func(ctx context.Context) error {
+		assert.True(t, ReadsPrimary(ctx))
+		AfterCommit(ctx, func() { calls++ })
+		assert.Equal(t, 1, calls, "callback must wait for commit")
+		return m.WithinTransaction(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { calls++ })
+			return Conn(ctx, db).Create(&txTestRecord{Name: "committed"}).Error
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// 回滚时不执行
+	err = m.WithinTransaction(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { calls++ })
+		return errors.New("boom")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+	assert.False(t, ReadsPrimary(ctx))
+	assert.True(t, ReadsPrimary(ForcePrimary(ctx)))
+}
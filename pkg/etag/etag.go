@@ -0,0 +1,48 @@
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format 版本号对应的强 ETag，例如 "3"
+func Format(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// Condition If-Match 请求头解析后的条件
+type Condition struct {
+	any  bool
+	tags []string
+}
+
+// ParseIfMatch 解析 If-Match 请求头，请求头为空时 ok 为 false
+func ParseIfMatch(header string) (cond Condition, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return Condition{}, false
+	}
+	if header == "*" {
+		return Condition{any: true}, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cond.tags = append(cond.tags, tag)
+		}
+	}
+	return cond, true
+}
+
+// Matches 当前版本是否满足条件，If-Match 使用强比较，弱 ETag（W/ 前缀）不会匹配
+func (c Condition) Matches(version uint) bool {
+	if c.any {
+		return true
+	}
+	current := Format(version)
+	for _, tag := range c.tags {
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package etag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, `"1"`, Format(1))
+	assert.Equal(t, `"42"`, Format(42))
+}
+
+func TestParseIfMatch(t *testing.T) {
+	_, ok := ParseIfMatch("  ")
+	assert.False(t, ok)
+
+	cond, ok := ParseIfMatch("*")
+	assert.True(t, ok)
+	assert.True(t, cond.Matches(1))
+	assert.True(t, cond.Matches(7))
+
+	cond, ok = ParseIfMatch(`"2", "3"`)
+	assert.True(t, ok)
+	assert.False(t, cond.Matches(1))
+	assert.True(t, cond.Matches(2))
+	assert.True(t, cond.Matches(3))
+
+	// 弱 ETag 和不带引号的值不匹配
+	cond, _ = ParseIfMatch(`W/"2", 3`)
+	assert.False(t, cond.Matches(2))
+	assert.False(t, cond.Matches(3))
+}
@@ -0,0 +1,77 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// Wildcard 订阅所有事件类型
+const Wildcard = "*"
+
+// Handler 事件处理函数
+// 事件按至少一次语义投递，同一事件可能被处理多次，处理函数需要保证幂等
+type Handler func(ctx context.Context, e *Event) error
+
+// Bus 进程内事件总线
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]namedHandler
+	log      *slog.Logger
+}
+
+type namedHandler struct {
+	name    string
+	handler Handler
+}
+
+// NewBus 创建事件总线
+func NewBus(log *slog.Logger) *Bus {
+	return &Bus{handlers: map[string][]namedHandler{}, log: log}
+}
+
+// Subscribe 订阅事件，name 用于日志和错误信息，eventType 为 Wildcard 时订阅所有事件
+func (b *Bus) Subscribe(eventType string, name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], namedHandler{name: name, handler: h})
+}
+
+// HasSubscribers 是否有订阅者
+func (b *Bus) HasSubscribers(eventType string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.handlers[eventType]) > 0 || len(b.handlers[Wildcard]) > 0
+}
+
+// Dispatch 将事件投递给所有订阅者，任一订阅者失败都会返回错误
+// 单个订阅者失败不影响其他订阅者执行
+func (b *Bus) Dispatch(ctx context.Context, e *Event) error {
+	b.mu.RLock()
+	handlers := make([]namedHandler, 0, len(b.handlers[e.Type])+len(b.handlers[Wildcard]))
+	handlers = append(handlers, b.handlers[e.Type]...)
+	handlers = append(handlers, b.handlers[Wildcard]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := b.call(ctx, h, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// call 调用单个订阅者，处理 panic
+func (b *Bus) call(ctx context.Context, h namedHandler, e *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.log.ErrorContext(ctx, "Event handler panicked", "handler", h.name, "event_id", e.ID, "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h.handler(ctx, e)
+}
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+)
+
+// 发件箱中事件的状态
+const (
+	StatusPending   = "pending"   // 等待投递或等待重试
+	StatusPublished = "published" // 已投递到所有订阅者
+	StatusFailed    = "failed"    // 超过最大尝试次数，需要人工重放
+)
+
+// Event 领域事件
+type Event struct {
+	ID            string          `json:"id" example:"9b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"`
+	Type          string          `json:"type" example:"user.registered"`
+	AggregateType string          `json:"aggregate_type" example:"user"`
+	AggregateID   string          `json:"aggregate_id" example:"1"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	TraceID       string          `json:"trace_id,omitempty" example:"a1b2c3d4e5f60718"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+
+	Status      string     `json:"status" example:"pending"`
+	Attempts    int        `json:"attempts" example:"0"`
+	LastError   string     `json:"last_error,omitempty"`
+	AvailableAt time.Time  `json:"available_at"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+// New 创建领域事件，payload 会被编码为 JSON，ctx 中的 trace_id 随事件保存
+func New(ctx context.Context, eventType string, aggregateType string, aggregateID uint, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload of %s: %w", eventType, err)
+	}
+
+	now := time.Now()
+	return &Event{
+		ID:            newEventID(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatUint(uint64(aggregateID), 10),
+		Payload:       data,
+		TraceID:       string(logger.GetTraceID(ctx)),
+		OccurredAt:    now,
+		Status:        StatusPending,
+		AvailableAt:   now,
+	}, nil
+}
+
+// Unmarshal 将载荷解析到 v
+func (e *Event) Unmarshal(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// newEventID 生成事件 ID
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("%x", b)
+}
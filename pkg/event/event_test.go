@@ -0,0 +1,226 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger 测试中丢弃日志
+var testLogger = slog.New(slog.DiscardHandler)
+
+type userPayload struct {
+	UserID uint `json:"user_id"`
+}
+
+func newTestEvent(t *testing.T, eventType string) *Event {
+	ctx := logger.WithTraceID(context.Background(), "trace-1")
+	e, err := New(ctx, eventType, "user", 7, userPayload{UserID: 7})
+	require.NoError(t, err)
+	return e
+}
+
+func TestNewEvent(t *testing.T) {
+	e := newTestEvent(t, "user.registered")
+
+	assert.Len(t, e.ID, 32)
+	assert.Equal(t, "7", e.AggregateID)
+	assert.Equal(t, "trace-1", e.TraceID)
+	assert.Equal(t, StatusPending, e.Status)
+
+	var p userPayload
+	require.NoError(t, e.Unmarshal(&p))
+	assert.Equal(t, uint(7), p.UserID)
+}
+
+func TestBusDispatchesToTypeAndWildcard(t *testing.T) {
+	bus := NewBus(testLogger)
+	var calls []string
+	bus.Subscribe("user.registered", "typed", func(ctx context.Context, e *Event) error {
+		calls = append(calls, "typed")
+		return nil
+	})
+	bus.Subscribe(Wildcard, "audit", func(ctx context.Context, e *Event) error {
+		calls = append(calls, "audit")
+		return nil
+	})
+	bus.Subscribe("space.created", "other", func(ctx context.Context, e *Event) error {
+		calls = append(calls, "other")
+		return nil
+	})
+
+	require.NoError(t, bus.Dispatch(context.Background(), newTestEvent(t, "user.registered")))
+	assert.Equal(t, []string{"typed", "audit"}, calls)
+}
+
+func TestBusCollectsErrorsAndRecoversPanic(t *testing.T) {
+	bus := NewBus(testLogger)
+	ran := false
+	bus.Subscribe("user.registered", "fails", func(ctx context.Context, e *Event) error {
+		return errors.New("boom")
+	})
+	bus.Subscribe("user.registered", "panics", func(ctx context.Context, e *Event) error {
+		panic("unexpected")
+	})
+	bus.Subscribe("user.registered", "ok", func(ctx context.Context, e *Event) error {
+		ran = true
+		return nil
+	})
+
+	err := bus.Dispatch(context.Background(), newTestEvent(t, "user.registered"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fails: boom")
+	assert.Contains(t, err.Error(), "panics: panic: unexpected")
+	assert.True(t, ran)
+}
+
+func TestRelayPublishesAndForwardsToQueue(t *testing.T) {
+	store := NewMemoryStore()
+	bus := NewBus(testLogger)
+	broker := queue.NewMemoryBroker()
+	relay := NewRelay(store, bus, queue.NewClient(broker, 0, testLogger), RelayOptions{Locker: scheduler.NewMemoryLocker(), Logger: testLogger})
+	relay.Forward("user.registered")
+
+	var seenTrace string
+	bus.Subscribe("user.registered", "trace", func(ctx context.Context, e *Event) error {
+		seenTrace = string(logger.GetTraceID(ctx))
+		return nil
+	})
+
+	e := newTestEvent(t, "user.registered")
+	require.NoError(t, store.Append(context.Background(), e))
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "trace-1", seenTrace)
+
+	saved, _ := store.Get(e.ID)
+	assert.Equal(t, StatusPublished, saved.Status)
+	assert.Equal(t, 1, saved.Attempts)
+	assert.NotNil(t, saved.PublishedAt)
+
+	task, err := broker.Pop(context.Background(), queue.DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, TaskType("user.registered"), task.Type)
+	assert.Equal(t, "trace-1", task.TraceID)
+
+	var forwarded Event
+	require.NoError(t, task.Unmarshal(&forwarded))
+	assert.Equal(t, e.ID, forwarded.ID)
+
+	// 已发布的事件不会再次投递
+	n, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRelayRetriesWithBackoffThenFails(t *testing.T) {
+	store := NewMemoryStore()
+	bus := NewBus(testLogger)
+	relay := NewRelay(store, bus, nil, RelayOptions{MaxAttempts: 2, BackoffBase: time.Minute, BackoffMax: time.Hour, Logger: testLogger})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	relay.now = func() time.Time { return now }
+
+	bus.Subscribe("user.registered", "fails", func(ctx context.Context, e *Event) error {
+		return errors.New("boom")
+	})
+
+	e := newTestEvent(t, "user.registered")
+	e.AvailableAt = now
+	require.NoError(t, store.Append(context.Background(), e))
+
+	_, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	saved, _ := store.Get(e.ID)
+	assert.Equal(t, StatusPending, saved.Status)
+	assert.Equal(t, now.Add(time.Minute), saved.AvailableAt)
+	assert.Contains(t, saved.LastError, "boom")
+
+	// 未到重试时间不投递
+	n, _ := relay.RelayOnce(context.Background())
+	assert.Equal(t, 0, n)
+	saved, _ = store.Get(e.ID)
+	assert.Equal(t, 1, saved.Attempts)
+
+	now = now.Add(time.Minute)
+	_, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	saved, _ = store.Get(e.ID)
+	assert.Equal(t, StatusFailed, saved.Status)
+	assert.Equal(t, 2, saved.Attempts)
+}
+
+func TestRelayRetryDoesNotReenqueueCompletedTask(t *testing.T) {
+	store := NewMemoryStore()
+	bus := NewBus(testLogger)
+	broker := queue.NewMemoryBroker()
+	relay := NewRelay(store, bus, queue.NewClient(broker, 0, testLogger), RelayOptions{BackoffBase: time.Minute, Logger: testLogger})
+	relay.Forward("user.registered")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	relay.now = func() time.Time { return now }
+
+	bus.Subscribe("user.registered", "fails", func(ctx context.Context, e *Event) error {
+		return errors.New("boom")
+	})
+
+	var handled atomic.Int32
+	worker := queue.NewWorker(broker, queue.WorkerOptions{PollInterval: 10 * time.Millisecond, Logger: testLogger})
+	worker.Register(TaskType("user.registered"), func(ctx context.Context, task *queue.Task) error {
+		handled.Add(1)
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	e := newTestEvent(t, "user.registered")
+	e.AvailableAt = now
+	require.NoError(t, store.Append(context.Background(), e))
+
+	_, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	saved, _ := store.Get(e.ID)
+	require.Equal(t, StatusPending, saved.Status)
+
+	// 转发的任务已经完成
+	require.Eventually(t, func() bool { return handled.Load() == 1 }, 3*time.Second, 5*time.Millisecond)
+
+	// 订阅者失败导致事件重试时，不会再次入队已完成的任务
+	now = now.Add(time.Minute)
+	_, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	saved, _ = store.Get(e.ID)
+	assert.Equal(t, 2, saved.Attempts)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), handled.Load())
+	task, err := broker.Pop(context.Background(), queue.DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+}
+
+func TestRelaySkipsWhenLockHeld(t *testing.T) {
+	store := NewMemoryStore()
+	locker := scheduler.NewMemoryLocker()
+	relay := NewRelay(store, NewBus(testLogger), nil, RelayOptions{Locker: locker, Logger: testLogger})
+	require.NoError(t, store.Append(context.Background(), newTestEvent(t, "user.registered")))
+
+	_, ok, err := locker.Acquire(relayLockKey, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
@@ -0,0 +1,230 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/queue"
+	"github.com/chenyl99x/toge-api/pkg/scheduler"
+)
+
+// relayLockKey 多实例部署时保证同一时刻只有一个 Relay 在投递
+const relayLockKey = "event:relay"
+
+// TaskType 事件转发到任务队列时使用的任务类型
+func TaskType(eventType string) string {
+	return "event:" + eventType
+}
+
+// RelayOptions Relay 配置
+type RelayOptions struct {
+	PollInterval time.Duration    // 发件箱轮询间隔
+	BatchSize    int              // 每次最多投递的事件数
+	MaxAttempts  int              // 最大尝试次数，超过后标记为 failed
+	BackoffBase  time.Duration    // 第一次重试的等待时间
+	BackoffMax   time.Duration    // 重试等待时间上限
+	Locker       scheduler.Locker // 为空时不加锁，仅适用于单实例
+	Logger       *slog.Logger     // 为空时使用 slog.Default()
+}
+
+// Relay 从发件箱读取事件，投递给进程内订阅者并转发到任务队列
+// 所有目标都成功后事件才会标记为 published，否则按退避策略整体重试（至少一次）
+type Relay struct {
+	store    Store
+	bus      *Bus
+	client   *queue.Client
+	opts     RelayOptions
+	forwards map[string][]queue.Option
+	log      *slog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	now    func() time.Time
+}
+
+// NewRelay 创建 Relay，client 为空时不转发到任务队列
+func NewRelay(store Store, bus *Bus, client *queue.Client, opts RelayOptions) *Relay {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = 5 * time.Second
+	}
+	if opts.BackoffMax <= 0 {
+		opts.BackoffMax = time.Hour
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	return &Relay{
+		log:      opts.Logger,
+		store:    store,
+		bus:      bus,
+		client:   client,
+		opts:     opts,
+		forwards: map[string][]queue.Option{},
+		now:      time.Now,
+	}
+}
+
+// Forward 将指定类型的事件转发到任务队列，任务类型为 TaskType(eventType)，载荷为事件本身
+func (r *Relay) Forward(eventType string, opts ...queue.Option) {
+	r.forwards[eventType] = opts
+}
+
+// Start 启动后台轮询
+func (r *Relay) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.opts.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.RelayOnce(ctx); err != nil && ctx.Err() == nil {
+				r.log.Error("Failed to relay events", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	r.log.Info("Event relay started", "poll_interval", r.opts.PollInterval.String(), "forwards", len(r.forwards))
+}
+
+// Stop 停止轮询并等待当前批次结束
+func (r *Relay) Stop() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	r.log.Info("Event relay stopped")
+}
+
+// RelayOnce 投递一批到期事件，返回成功投递的数量
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	if r.opts.Locker != nil {
+		token, ok, err := r.opts.Locker.Acquire(relayLockKey, r.lockTTL())
+		if err != nil || !ok {
+			return 0, err
+		}
+		defer func() {
+			if err := r.opts.Locker.Release(relayLockKey, token); err != nil {
+				r.log.Warn("Failed to release event relay lock", "error", err)
+			}
+		}()
+	}
+
+	events, err := r.store.Pending(ctx, r.now(), r.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, e := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if r.deliver(e) {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// deliver 投递单个事件并保存结果，返回是否成功
+func (r *Relay) deliver(e *Event) bool {
+	// 投递不随 Stop 取消，避免事件投递到一半
+	ctx := logger.WithTraceID(context.Background(), logger.TraceID(e.TraceID))
+
+	err := r.dispatch(ctx, e)
+	e.Attempts++
+
+	if err == nil {
+		now := r.now()
+		e.Status = StatusPublished
+		e.PublishedAt = &now
+		e.LastError = ""
+	} else {
+		e.LastError = err.Error()
+		if e.Attempts >= r.opts.MaxAttempts {
+			e.Status = StatusFailed
+		} else {
+			e.AvailableAt = r.now().Add(queue.Backoff(r.opts.BackoffBase, r.opts.BackoffMax, e.Attempts))
+		}
+	}
+
+	if updateErr := r.store.Update(ctx, e); updateErr != nil {
+		r.log.ErrorContext(ctx, "Failed to save event status", "event_id", e.ID, "error", updateErr)
+		return false
+	}
+
+	switch e.Status {
+	case StatusPublished:
+		r.log.InfoContext(ctx, "Event published", "event_id", e.ID, "type", e.Type, "attempts", e.Attempts)
+		return true
+	case StatusFailed:
+		r.log.ErrorContext(ctx, "Event delivery failed permanently", "event_id", e.ID, "type", e.Type, "attempts", e.Attempts, "error", err)
+	default:
+		r.log.WarnContext(ctx, "Event delivery failed, retry scheduled", "event_id", e.ID, "type", e.Type, "attempts", e.Attempts, "retry_at", e.AvailableAt, "error", err)
+	}
+	return false
+}
+
+// dispatch 投递到进程内订阅者和任务队列
+func (r *Relay) dispatch(ctx context.Context, e *Event) error {
+	var errs []error
+	if err := r.bus.Dispatch(ctx, e); err != nil {
+		errs = append(errs, err)
+	}
+
+	if opts, ok := r.forwards[e.Type]; ok && r.client != nil {
+		taskType := TaskType(e.Type)
+		// 唯一键在任务完成后保留到过期，其他目标失败导致事件重试时不会再次入队已执行的任务
+		opts = append([]queue.Option{queue.Unique(e.ID+":"+taskType, 24*time.Hour), queue.KeepUnique()}, opts...)
+		if _, err := r.client.Enqueue(ctx, taskType, e, opts...); err != nil && !errors.Is(err, queue.ErrDuplicateTask) {
+			errs = append(errs, fmt.Errorf("enqueue %s: %w", taskType, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lockTTL 锁的有效期，需覆盖一个批次的投递时间
+func (r *Relay) lockTTL() time.Duration {
+	ttl := 30 * r.opts.PollInterval
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	return ttl
+}
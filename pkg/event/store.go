@@ -0,0 +1,80 @@
+package event
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store 事件发件箱存储
+type Store interface {
+	// Append 写入事件，应当与业务数据在同一事务中调用
+	Append(ctx context.Context, events ...*Event) error
+	// Pending 获取 available_at 不晚于 now 的待投递事件，按发生时间排序
+	Pending(ctx context.Context, now time.Time, limit int) ([]*Event, error)
+	// Update 保存事件的投递状态
+	Update(ctx context.Context, e *Event) error
+}
+
+// MemoryStore 进程内发件箱，用于测试
+type MemoryStore struct {
+	mu     sync.Mutex
+	events map[string]*Event
+}
+
+// NewMemoryStore 创建进程内发件箱
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: map[string]*Event{}}
+}
+
+// Append 写入事件
+func (s *MemoryStore) Append(ctx context.Context, events ...*Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		clone := *e
+		s.events[e.ID] = &clone
+	}
+	return nil
+}
+
+// Pending 获取待投递事件
+func (s *MemoryStore) Pending(ctx context.Context, now time.Time, limit int) ([]*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []*Event
+	for _, e := range s.events {
+		if e.Status == StatusPending && !e.AvailableAt.After(now) {
+			clone := *e
+			pending = append(pending, &clone)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].OccurredAt.Before(pending[j].OccurredAt) })
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+// Update 保存投递状态
+func (s *MemoryStore) Update(ctx context.Context, e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clone := *e
+	s.events[e.ID] = &clone
+	return nil
+}
+
+// Get 获取事件
+func (s *MemoryStore) Get(id string) (*Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.events[id]
+	if !ok {
+		return nil, false
+	}
+	clone := *e
+	return &clone, true
+}
@@ -0,0 +1,195 @@
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidSelection fields 或 expand 参数中的字段、关联不在白名单中，或展开层数超过限制
+var ErrInvalidSelection = errors.New("invalid field selection")
+
+const (
+	// FieldsParam 稀疏字段集的查询参数名，逗号分隔，展开的关联的字段用点号，例如 id,name,owner.nickname
+	FieldsParam = "fields"
+	// ExpandParam 关联展开的查询参数名，逗号分隔，嵌套的关联用点号，例如 owner,owner.spaces
+	ExpandParam = "expand"
+	// MaxDepth 关联展开的最大层数
+	MaxDepth = 2
+)
+
+// Relation 可展开的关联
+type Relation struct {
+	Name    string  // expand 参数和响应中的 JSON 字段名
+	Preload string  // gorm 关联名
+	Schema  *Schema // 关联资源的字段白名单
+}
+
+// Schema 资源的字段和关联白名单
+type Schema struct {
+	fields    []string
+	byName    map[string]bool
+	relations map[string]Relation
+}
+
+// NewSchema 创建字段白名单，字段名为响应中的 JSON 字段名
+func NewSchema(fields ...string) *Schema {
+	s := &Schema{byName: make(map[string]bool, len(fields)), relations: make(map[string]Relation)}
+	for _, field := range fields {
+		if s.byName[field] || field == "" || strings.ContainsAny(field, ".,") {
+			panic(fmt.Sprintf("fieldset field %q: empty, duplicate or invalid name", field))
+		}
+		s.fields = append(s.fields, field)
+		s.byName[field] = true
+	}
+	return s
+}
+
+// Relate 声明可展开的关联，资源之间互相关联时先创建白名单再声明关联，名称与字段重复时 panic
+func (s *Schema) Relate(relations ...Relation) *Schema {
+	for _, rel := range relations {
+		if _, ok := s.relations[rel.Name]; ok || s.byName[rel.Name] || rel.Schema == nil || rel.Preload == "" {
+			panic(fmt.Sprintf("fieldset relation %q: duplicate name or missing schema", rel.Name))
+		}
+		s.relations[rel.Name] = rel
+	}
+	return s
+}
+
+// Fields 白名单中的字段，按声明顺序
+func (s *Schema) Fields() []string {
+	return s.fields
+}
+
+// Selection 解析后的字段选择和关联展开
+type Selection struct {
+	schema    *Schema
+	preload   string
+	fields    map[string]bool       // 为 nil 时返回白名单中的全部字段
+	relations map[string]*Selection // 展开的关联
+}
+
+// Parse 解析查询参数中的 fields 和 expand
+func (s *Schema) Parse(values url.Values) (*Selection, error) {
+	root := s.selection("")
+	for _, path := range splitList(values.Get(ExpandParam)) {
+		parts := strings.Split(path, ".")
+		if len(parts) > MaxDepth {
+			return nil, fmt.Errorf("%w: expand %q exceeds max depth %d", ErrInvalidSelection, path, MaxDepth)
+		}
+		sel := root
+		for i, name := range parts {
+			rel, ok := sel.schema.relations[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: cannot expand %q", ErrInvalidSelection, strings.Join(parts[:i+1], "."))
+			}
+			child, ok := sel.relations[name]
+			if !ok {
+				child = rel.Schema.selection(rel.Preload)
+				if sel.preload != "" {
+					child.preload = sel.preload + "." + rel.Preload
+				}
+				sel.relations[name] = child
+			}
+			sel = child
+		}
+	}
+
+	for _, path := range splitList(values.Get(FieldsParam)) {
+		parts := strings.Split(path, ".")
+		sel := root
+		for _, name := range parts[:len(parts)-1] {
+			child, ok := sel.relations[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: field %q requires expand=%s", ErrInvalidSelection, path, strings.Join(parts[:len(parts)-1], "."))
+			}
+			sel = child
+		}
+		field := parts[len(parts)-1]
+		if !sel.schema.byName[field] {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSelection, path)
+		}
+		if sel.fields == nil {
+			sel.fields = make(map[string]bool)
+		}
+		sel.fields[field] = true
+	}
+	return root, nil
+}
+
+func (s *Schema) selection(preload string) *Selection {
+	return &Selection{schema: s, preload: preload, relations: make(map[string]*Selection)}
+}
+
+// splitList 拆分逗号分隔的参数，忽略空白和空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Empty 请求是否没有指定 fields 和 expand
+func (sel *Selection) Empty() bool {
+	return sel == nil || (sel.fields == nil && len(sel.relations) == 0)
+}
+
+// Preloads 需要预加载的 gorm 关联路径，例如 Owner、Owner.Spaces
+func (sel *Selection) Preloads() []string {
+	if sel == nil {
+		return nil
+	}
+	var preloads []string
+	for _, child := range sel.relations {
+		preloads = append(preloads, child.preload)
+		preloads = append(preloads, child.Preloads()...)
+	}
+	return preloads
+}
+
+// Project 按选择裁剪数据，data 按 JSON 序列化，对象只保留选择的白名单字段和展开的关联，数组逐项裁剪
+// 展开的关联同样只输出其白名单中的字段，预加载的关联不会带出密码等未列入白名单的字段
+func (sel *Selection) Project(data any) (any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return sel.project(v), nil
+}
+
+func (sel *Selection) project(v any) any {
+	switch t := v.(type) {
+	case []any:
+		for i := range t {
+			t[i] = sel.project(t[i])
+		}
+		return t
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for _, field := range sel.schema.fields {
+			if sel.fields != nil && !sel.fields[field] {
+				continue
+			}
+			if value, ok := t[field]; ok {
+				out[field] = value
+			}
+		}
+		for name, child := range sel.relations {
+			out[name] = child.project(t[name])
+		}
+		return out
+	}
+	return v
+}
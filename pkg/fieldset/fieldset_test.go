@@ -0,0 +1,95 @@
+package fieldset
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	ID       uint        `json:"id"`
+	Nickname string      `json:"nickname"`
+	Avatar   string      `json:"avatar"`
+	Password string      `json:"password,omitempty"`
+	Spaces   []testSpace `json:"spaces,omitempty"`
+}
+
+type testSpace struct {
+	ID    uint      `json:"id"`
+	Name  string    `json:"name"`
+	Owner *testUser `json:"owner,omitempty"`
+}
+
+var testUserSchema, testSpaceSchema = func() (*Schema, *Schema) {
+	user := NewSchema("id", "nickname", "avatar")
+	space := NewSchema("id", "name")
+	user.Relate(Relation{Name: "spaces", Preload: "Spaces", Schema: space})
+	space.Relate(Relation{Name: "owner", Preload: "Owner", Schema: user})
+	return user, space
+}()
+
+func parse(t *testing.T, schema *Schema, query string) (*Selection, error) {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	return schema.Parse(values)
+}
+
+func TestParse(t *testing.T) {
+	sel, err := parse(t, testSpaceSchema, "")
+	require.NoError(t, err)
+	assert.True(t, sel.Empty())
+	assert.Empty(t, sel.Preloads())
+
+	sel, err = parse(t, testSpaceSchema, "expand=owner.spaces,owner&fields=name,owner.nickname")
+	require.NoError(t, err)
+	assert.False(t, sel.Empty())
+	preloads := sel.Preloads()
+	sort.Strings(preloads)
+	assert.Equal(t, []string{"Owner", "Owner.Spaces"}, preloads)
+
+	for query, msg := range map[string]string{
+		"fields=password":                  `invalid field selection: unknown field "password"`,
+		"fields=owner.nickname":            `invalid field selection: field "owner.nickname" requires expand=owner`,
+		"expand=members":                   `invalid field selection: cannot expand "members"`,
+		"expand=owner.members":             `invalid field selection: cannot expand "owner.members"`,
+		"expand=owner.spaces.owner":        `invalid field selection: expand "owner.spaces.owner" exceeds max depth 2`,
+		"expand=owner&fields=owner.secret": `invalid field selection: unknown field "owner.secret"`,
+	} {
+		_, err := parse(t, testSpaceSchema, query)
+		assert.EqualError(t, err, msg, query)
+	}
+}
+
+func TestProject(t *testing.T) {
+	owner := &testUser{ID: 1, Nickname: "alice", Avatar: "a.png", Password: "hash", Spaces: []testSpace{{ID: 2, Name: "home"}}}
+	space := testSpace{ID: 2, Name: "home", Owner: owner}
+
+	// 只展开关联时返回全部白名单字段，关联同样不包含白名单以外的字段
+	sel, err := parse(t, testSpaceSchema, "expand=owner")
+	require.NoError(t, err)
+	got, err := sel.Project(space)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":    json.Number("2"),
+		"name":  "home",
+		"owner": map[string]any{"id": json.Number("1"), "nickname": "alice", "avatar": "a.png"},
+	}, got)
+
+	sel, err = parse(t, testSpaceSchema, "fields=name,owner.avatar&expand=owner.spaces")
+	require.NoError(t, err)
+	got, err = sel.Project([]testSpace{space, {ID: 3, Name: "empty"}})
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"name": "home", "owner": map[string]any{
+			"avatar": "a.png",
+			"spaces": []any{map[string]any{"id": json.Number("2"), "name": "home"}},
+		}},
+		// 关联为空时输出 null
+		map[string]any{"name": "empty", "owner": nil},
+	}, got)
+}
@@ -0,0 +1,406 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidFilter 过滤参数格式错误、字段或操作符不允许
+var ErrInvalidFilter = errors.New("invalid filter")
+
+const (
+	// paramPrefix 过滤参数的查询参数名前缀
+	paramPrefix = "filter"
+	// maxDepth 逻辑组合的最大嵌套层数
+	maxDepth = 3
+	// maxConditions 单个请求的最大条件数
+	maxConditions = 20
+	// maxValues in 和 nin 的最大取值个数
+	maxValues = 100
+)
+
+// Op 操作符
+type Op string
+
+const (
+	OpEq   Op = "eq"   // 等于，省略操作符时使用
+	OpNe   Op = "ne"   // 不等于
+	OpGt   Op = "gt"   // 大于
+	OpGte  Op = "gte"  // 大于等于
+	OpLt   Op = "lt"   // 小于
+	OpLte  Op = "lte"  // 小于等于
+	OpIn   Op = "in"   // 在逗号分隔的取值中
+	OpNin  Op = "nin"  // 不在逗号分隔的取值中
+	OpLike Op = "like" // 包含，不区分大小写
+	OpNull Op = "null" // 为 true 时为空，为 false 时不为空
+)
+
+// allOps 所有操作符
+var allOps = []Op{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin, OpLike, OpNull}
+
+// Type 字段类型，决定取值的解析方式和默认操作符
+type Type string
+
+const (
+	TypeString Type = "string"
+	TypeInt    Type = "int"
+	TypeFloat  Type = "float"
+	TypeBool   Type = "bool"
+	TypeTime   Type = "time" // RFC3339 或 2006-01-02，日期按 UTC 零点解析
+)
+
+// defaultOps 各类型未声明 ops 时允许的操作符
+var defaultOps = map[Type][]Op{
+	TypeString: {OpEq, OpNe, OpIn, OpNin, OpLike},
+	TypeInt:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin},
+	TypeFloat:  {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+	TypeBool:   {OpEq, OpNe},
+	TypeTime:   {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+}
+
+// Field 允许过滤的字段
+type Field struct {
+	Name        string // 查询参数中的字段名
+	Column      string // 数据库列名，为空时与 Name 相同
+	Type        Type
+	Ops         []Op   // 允许的操作符，为空时按类型使用默认操作符
+	Description string // Swagger 参数说明
+}
+
+// Schema 资源的过滤字段白名单
+type Schema struct {
+	fields []Field
+	byName map[string]Field
+}
+
+// NewSchema 创建过滤字段白名单，资源在包级变量中声明，字段声明错误时 panic
+func NewSchema(fields ...Field) *Schema {
+	s := &Schema{byName: make(map[string]Field, len(fields))}
+	for _, field := range fields {
+		if field.Column == "" {
+			field.Column = field.Name
+		}
+		ops, ok := defaultOps[field.Type]
+		if !ok {
+			panic(fmt.Sprintf("filter field %q: unknown type %q", field.Name, field.Type))
+		}
+		if len(field.Ops) == 0 {
+			field.Ops = ops
+		}
+		for _, op := range field.Ops {
+			if !slices.Contains(allOps, op) {
+				panic(fmt.Sprintf("filter field %q: unknown operator %q", field.Name, op))
+			}
+		}
+		if !isIdentifier(field.Name) || !isIdentifier(field.Column) {
+			panic(fmt.Sprintf("filter field %q: invalid name or column %q", field.Name, field.Column))
+		}
+		if _, ok := s.byName[field.Name]; ok || isLogical(field.Name) {
+			panic(fmt.Sprintf("filter field %q: duplicate or reserved name", field.Name))
+		}
+		s.fields = append(s.fields, field)
+		s.byName[field.Name] = field
+	}
+	return s
+}
+
+// Fields 白名单中的字段，按声明顺序
+func (s *Schema) Fields() []Field {
+	return s.fields
+}
+
+// isIdentifier 字段名和列名只能包含字母、数字和下划线
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isLogical 是否为逻辑组合关键字
+func isLogical(name string) bool {
+	return name == "and" || name == "or" || name == "not"
+}
+
+// Filter 解析后的过滤条件
+type Filter struct {
+	root *node
+}
+
+// node 一组条件，conds 和 children 之间按 logic 组合
+type node struct {
+	logic    string // and, or, not；not 表示组内条件同时满足后取反
+	conds    []cond
+	children map[string]*node // 键为 and/or 组内的序号，或者 and/or/not 关键字
+}
+
+// cond 单个条件
+type cond struct {
+	field Field
+	op    Op
+	value any
+}
+
+// Parse 解析查询参数中的过滤条件，参数格式：
+//
+//	filter[字段]=值                          等于
+//	filter[字段][操作符]=值                  in 和 nin 的值以逗号分隔
+//	filter[or][0][字段]=值&filter[or][1][字段]=值   序号相同的条件同时满足，不同序号之间满足其一
+//	filter[not][字段]=值                     取反
+//
+// 顶层条件同时满足，逻辑组合最多嵌套 3 层
+func (s *Schema) Parse(values url.Values) (*Filter, error) {
+	root := &node{logic: "and"}
+	count := 0
+
+	// 按参数名排序，生成的 SQL 稳定
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, paramPrefix+"[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path, err := splitPath(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values[key] {
+			if count++; count > maxConditions {
+				return nil, fmt.Errorf("%w: too many conditions, at most %d", ErrInvalidFilter, maxConditions)
+			}
+			if err := s.add(root, path, value, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Filter{root: root}, nil
+}
+
+// splitPath 拆分 filter[a][b][c] 为 [a b c]
+func splitPath(key string) ([]string, error) {
+	rest := strings.TrimPrefix(key, paramPrefix)
+	var path []string
+	for rest != "" {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidFilter, key)
+		}
+		end := strings.IndexByte(rest, ']')
+		if end < 2 {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidFilter, key)
+		}
+		path = append(path, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return path, nil
+}
+
+// add 把一个参数加入条件树
+func (s *Schema) add(n *node, path []string, value string, depth int) error {
+	head := path[0]
+	if isLogical(head) {
+		if depth++; depth > maxDepth {
+			return fmt.Errorf("%w: nesting deeper than %d", ErrInvalidFilter, maxDepth)
+		}
+		child := n.child(head, head)
+		rest := path[1:]
+		if head != "not" {
+			// and/or 的下一级为序号，同一序号内的条件组成一个 and 组
+			if len(rest) < 2 {
+				return fmt.Errorf("%w: %s requires an index, e.g. filter[%s][0][field]", ErrInvalidFilter, head, head)
+			}
+			if _, err := strconv.ParseUint(rest[0], 10, 8); err != nil {
+				return fmt.Errorf("%w: invalid %s index %q", ErrInvalidFilter, head, rest[0])
+			}
+			child = child.child(rest[0], "and")
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return fmt.Errorf("%w: empty %s group", ErrInvalidFilter, head)
+		}
+		return s.add(child, rest, value, depth)
+	}
+
+	field, ok := s.byName[head]
+	if !ok {
+		return fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, head)
+	}
+	op := OpEq
+	switch len(path) {
+	case 1:
+	case 2:
+		op = Op(path[1])
+	default:
+		return fmt.Errorf("%w: malformed condition on %q", ErrInvalidFilter, head)
+	}
+	if !slices.Contains(field.Ops, op) {
+		return fmt.Errorf("%w: operator %q is not allowed on %q", ErrInvalidFilter, op, head)
+	}
+
+	parsed, err := parseValue(field, op, value)
+	if err != nil {
+		return fmt.Errorf("%w: %s[%s]: %v", ErrInvalidFilter, head, op, err)
+	}
+	n.conds = append(n.conds, cond{field: field, op: op, value: parsed})
+	return nil
+}
+
+// child 返回或创建子组
+func (n *node) child(key, logic string) *node {
+	if n.children == nil {
+		n.children = make(map[string]*node)
+	}
+	if _, ok := n.children[key]; !ok {
+		n.children[key] = &node{logic: logic}
+	}
+	return n.children[key]
+}
+
+// parseValue 按字段类型解析取值
+func parseValue(field Field, op Op, value string) (any, error) {
+	switch op {
+	case OpNull:
+		return strconv.ParseBool(value)
+	case OpLike:
+		return escapeLike(value), nil
+	case OpIn, OpNin:
+		parts := strings.Split(value, ",")
+		if len(parts) > maxValues {
+			return nil, fmt.Errorf("at most %d values", maxValues)
+		}
+		values := make([]any, len(parts))
+		for i, part := range parts {
+			v, err := parseScalar(field.Type, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	return parseScalar(field.Type, value)
+}
+
+// likeEscaper 转义 LIKE 中的通配符，用户输入中的 % 和 _ 按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike 转义 LIKE 取值，和 ESCAPE '\' 一起使用
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// parseScalar 解析单个取值
+func parseScalar(t Type, value string) (any, error) {
+	switch t {
+	case TypeInt:
+		return strconv.ParseInt(value, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(value, 64)
+	case TypeBool:
+		return strconv.ParseBool(value)
+	case TypeTime:
+		if v, err := time.Parse(time.RFC3339, value); err == nil {
+			return v, nil
+		}
+		return time.Parse(time.DateOnly, value)
+	}
+	return value, nil
+}
+
+// Empty 是否没有任何条件
+func (f *Filter) Empty() bool {
+	return f.root.empty()
+}
+
+func (n *node) empty() bool {
+	return len(n.conds) == 0 && len(n.children) == 0
+}
+
+// Scope 把过滤条件编译为 GORM 条件，列名来自白名单并由 GORM 转义，取值全部作为参数传递
+func (f *Filter) Scope(db *gorm.DB) *gorm.DB {
+	if f.Empty() {
+		return db
+	}
+	return db.Where(f.root.expression(database.LikeOperator(db)))
+}
+
+// expression 编译一组条件
+func (n *node) expression(like string) clause.Expression {
+	exprs := make([]clause.Expression, 0, len(n.conds)+len(n.children))
+	for _, c := range n.conds {
+		exprs = append(exprs, c.expression(like))
+	}
+
+	// 子组按序号或关键字排序，生成的 SQL 稳定
+	keys := make([]string, 0, len(n.children))
+	for key := range n.children {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		ai, aErr := strconv.Atoi(a)
+		bi, bErr := strconv.Atoi(b)
+		if aErr == nil && bErr == nil {
+			return ai - bi
+		}
+		return strings.Compare(a, b)
+	})
+	for _, key := range keys {
+		exprs = append(exprs, n.children[key].expression(like))
+	}
+
+	switch n.logic {
+	case "or":
+		return clause.Or(exprs...)
+	case "not":
+		return clause.Not(clause.And(exprs...))
+	}
+	return clause.And(exprs...)
+}
+
+// expression 编译单个条件
+func (c cond) expression(like string) clause.Expression {
+	column := clause.Column{Table: clause.CurrentTable, Name: c.field.Column}
+	switch c.op {
+	case OpNe:
+		return clause.Neq{Column: column, Value: c.value}
+	case OpGt:
+		return clause.Gt{Column: column, Value: c.value}
+	case OpGte:
+		return clause.Gte{Column: column, Value: c.value}
+	case OpLt:
+		return clause.Lt{Column: column, Value: c.value}
+	case OpLte:
+		return clause.Lte{Column: column, Value: c.value}
+	case OpIn:
+		return clause.IN{Column: column, Values: c.value.([]any)}
+	case OpNin:
+		return clause.Not(clause.IN{Column: column, Values: c.value.([]any)})
+	case OpLike:
+		// 转义字符作为参数传入，MySQL 的字符串字面量中反斜杠本身需要转义
+		return clause.Expr{SQL: "? " + like + " ? ESCAPE ?", Vars: []any{column, "%" + c.value.(string) + "%", `\`}}
+	case OpNull:
+		if c.value.(bool) {
+			return clause.Eq{Column: column, Value: nil}
+		}
+		return clause.Neq{Column: column, Value: nil}
+	}
+	return clause.Eq{Column: column, Value: c.value}
+}
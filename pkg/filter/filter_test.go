@@ -0,0 +1,197 @@
+package filter
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type record struct {
+	ID        uint
+	Name      string
+	Status    int
+	Type      string
+	DeletedAt *time.Time
+	CreatedAt time.Time
+}
+
+var testSchema = NewSchema(
+	Field{Name: "name", Type: TypeString, Description: "名称"},
+	Field{Name: "status", Type: TypeInt, Ops: []Op{OpEq, OpIn}},
+	Field{Name: "type", Type: TypeString},
+	Field{Name: "removed", Column: "deleted_at", Type: TypeTime, Ops: []Op{OpNull}},
+	Field{Name: "created_at", Type: TypeTime},
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&record{}))
+
+	deleted := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	records := []record{
+		{Name: "alpha", Status: 1, Type: "a", CreatedAt: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "beta", Status: 0, Type: "b", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "gamma", Status: 1, Type: "c", CreatedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "delta", Status: 2, Type: "a", CreatedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), DeletedAt: &deleted},
+	}
+	require.NoError(t, db.Create(&records).Error)
+	return db
+}
+
+func TestFilter(t *testing.T) {
+	db := newTestDB(t)
+
+	tests := []struct {
+		name  string
+		query string
+		names []string
+	}{
+		{"no filter", "", []string{"alpha", "beta", "gamma", "delta"}},
+		{"equal", "filter[status]=1", []string{"alpha", "gamma"}},
+		{"explicit eq", "filter[status][eq]=0", []string{"beta"}},
+		{"in", "filter[type][in]=a,b", []string{"alpha", "beta", "delta"}},
+		{"not in", "filter[type][nin]=a,b", []string{"gamma"}},
+		{"date range", "filter[created_at][gte]=2025-01-01&filter[created_at][lt]=2025-03-01", []string{"beta", "gamma"}},
+		{"rfc3339", "filter[created_at][gt]=2025-02-01T00:00:00Z", []string{"delta"}},
+		{"like", "filter[name][like]=ET", []string{"beta"}},
+		{"null", "filter[removed][null]=true", []string{"alpha", "beta", "gamma"}},
+		{"not null", "filter[removed][null]=false", []string{"delta"}},
+		{"and", "filter[status]=1&filter[type]=c", []string{"gamma"}},
+		{"or", "filter[or][0][status]=0&filter[or][1][type]=c", []string{"beta", "gamma"}},
+		{"or of and groups", "filter[or][0][status]=1&filter[or][0][type]=a&filter[or][1][name]=delta", []string{"alpha", "delta"}},
+		{"or with top level and", "filter[or][0][type]=a&filter[or][1][type]=b&filter[status]=1", []string{"alpha"}},
+		{"not", "filter[not][type][in]=a,b", []string{"gamma"}},
+		{"nested", "filter[or][0][not][status]=1&filter[or][1][name]=alpha", []string{"alpha", "beta", "delta"}},
+		{"ignores other params", "page=2&keyword=x&filter[status]=2", []string{"delta"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			f, err := testSchema.Parse(values)
+			require.NoError(t, err)
+
+			var records []record
+			require.NoError(t, db.Scopes(f.Scope).Order("id").Find(&records).Error)
+			names := make([]string, len(records))
+			for i, r := range records {
+				names[i] = r.Name
+			}
+			assert.Equal(t, tt.names, names)
+		})
+	}
+}
+
+func TestFilterInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"unknown field", "filter[password]=x", `invalid filter: unknown field "password"`},
+		{"operator not allowed", "filter[status][gt]=1", `invalid filter: operator "gt" is not allowed on "status"`},
+		{"unknown operator", "filter[name][regex]=x", `invalid filter: operator "regex" is not allowed on "name"`},
+		{"bad int", "filter[status]=abc", "invalid filter: status[eq]"},
+		{"bad time", "filter[created_at][gte]=yesterday", "invalid filter: created_at[gte]"},
+		{"bad in value", "filter[status][in]=1,x", "invalid filter: status[in]"},
+		{"malformed", "filter[status=1", `invalid filter: malformed parameter "filter[status"`},
+		{"empty brackets", "filter[]=1", "invalid filter: malformed parameter"},
+		{"too many parts", "filter[status][eq][x]=1", `invalid filter: malformed condition on "status"`},
+		{"or without index", "filter[or][status]=1", "invalid filter: or requires an index"},
+		{"bad index", "filter[or][x][status]=1", `invalid filter: invalid or index "x"`},
+		{"too deep", "filter[not][not][not][not][status]=1", "invalid filter: nesting deeper than 3"},
+		{"column name", "filter[deleted_at][null]=true", `invalid filter: unknown field "deleted_at"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			_, err = testSchema.Parse(values)
+			require.ErrorIs(t, err, ErrInvalidFilter)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	values := url.Values{}
+	for i := range maxConditions + 1 {
+		values.Add("filter[status]", string(rune('0'+i%10)))
+	}
+	_, err := testSchema.Parse(values)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestFilterLikeMatchesWildcardsLiterally(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.Create(&[]record{{Name: "100% off"}, {Name: "snake_case"}, {Name: `back\slash`}}).Error)
+
+	tests := []struct {
+		value string
+		names []string
+	}{
+		{"%", []string{"100% off"}},
+		{"_", []string{"snake_case"}},
+		{`\`, []string{`back\slash`}},
+		{"0%", []string{"100% off"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			f, err := testSchema.Parse(url.Values{"filter[name][like]": {tt.value}})
+			require.NoError(t, err)
+
+			var names []string
+			require.NoError(t, db.Model(&record{}).Scopes(f.Scope).Order("id").Pluck("name", &names).Error)
+			assert.Equal(t, tt.names, names)
+		})
+	}
+}
+
+func TestFilterUsesBoundParameters(t *testing.T) {
+	db := newTestDB(t)
+	f, err := testSchema.Parse(url.Values{"filter[name]": {"x' OR '1'='1"}})
+	require.NoError(t, err)
+
+	stmt := db.Session(&gorm.Session{DryRun: true}).Scopes(f.Scope).Find(&[]record{}).Statement
+	assert.Equal(t, "SELECT * FROM `records` WHERE `records`.`name` = ?", stmt.SQL.String())
+	assert.Equal(t, []any{"x' OR '1'='1"}, stmt.Vars)
+}
+
+func TestNewSchemaPanicsOnInvalidField(t *testing.T) {
+	assert.Panics(t, func() { NewSchema(Field{Name: "a", Type: "uuid"}) })
+	assert.Panics(t, func() { NewSchema(Field{Name: "a", Type: TypeInt, Ops: []Op{"regex"}}) })
+	assert.Panics(t, func() { NewSchema(Field{Name: "a;drop", Type: TypeInt}) })
+	assert.Panics(t, func() { NewSchema(Field{Name: "or", Type: TypeInt}) })
+	assert.Panics(t, func() { NewSchema(Field{Name: "a", Type: TypeInt}, Field{Name: "a", Type: TypeString}) })
+}
+
+func TestAddParameters(t *testing.T) {
+	doc := `{"paths": {"/records/": {"get": {"parameters": [{"name": "page", "in": "query", "type": "integer"}]}}}}`
+	result, err := addParameters(doc, []Route{
+		{Method: "GET", Path: "/records/", Schema: testSchema},
+		{Method: "GET", Path: "/missing/", Schema: testSchema},
+	})
+	require.NoError(t, err)
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Parameters []map[string]any `json:"parameters"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result), &spec))
+	params := spec.Paths["/records/"]["get"].Parameters
+	require.Len(t, params, 6)
+	assert.Equal(t, "page", params[0]["name"])
+	assert.Equal(t, "filter[name]", params[1]["name"])
+	assert.Equal(t, "名称，操作符：eq, ne, in, nin, like，使用 filter[name][操作符] 指定，省略时为 eq", params[1]["description"])
+	assert.Equal(t, "integer", params[2]["type"])
+	assert.Equal(t, "filter[created_at]", params[5]["name"])
+}
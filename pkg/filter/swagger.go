@@ -0,0 +1,100 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/swaggo/swag"
+)
+
+// Route 使用过滤条件的接口
+type Route struct {
+	Method string // HTTP 方法，例如 GET
+	Path   string // Swagger 文档中的路径，例如 /users/
+	Schema *Schema
+}
+
+// swaggerDoc 在已注册的 Swagger 文档中为接口加入过滤参数
+// swag 根据注释生成文档，无法表达 filter[字段] 形式的参数名，因此在读取文档时按白名单生成
+type swaggerDoc struct {
+	base   string
+	routes []Route
+}
+
+// RegisterSwagger 以 name 注册 Swagger 文档，内容为名为 base 的文档加上各接口的过滤参数
+// 每个字段生成一个 filter[字段] 参数，说明中列出允许的操作符，文档与白名单始终一致
+func RegisterSwagger(name, base string, routes ...Route) {
+	if swag.GetSwagger(name) != nil {
+		return
+	}
+	swag.Register(name, &swaggerDoc{base: base, routes: routes})
+}
+
+// ReadDoc 实现 swag.Swagger，生成失败时返回原文档
+func (d *swaggerDoc) ReadDoc() string {
+	doc, err := swag.ReadDoc(d.base)
+	if err != nil {
+		return doc
+	}
+	result, err := addParameters(doc, d.routes)
+	if err != nil {
+		return doc
+	}
+	return result
+}
+
+// addParameters 为文档中的接口加入过滤参数，文档中不存在的接口忽略
+func addParameters(doc string, routes []Route) (string, error) {
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+		return "", err
+	}
+	paths, _ := spec["paths"].(map[string]any)
+	for _, route := range routes {
+		item, _ := paths[route.Path].(map[string]any)
+		operation, ok := item[strings.ToLower(route.Method)].(map[string]any)
+		if !ok {
+			continue
+		}
+		params, _ := operation["parameters"].([]any)
+		for _, field := range route.Schema.Fields() {
+			params = append(params, parameter(field))
+		}
+		operation["parameters"] = params
+	}
+	result, err := json.MarshalIndent(spec, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// parameter 字段对应的 Swagger 查询参数
+func parameter(field Field) map[string]any {
+	ops := make([]string, len(field.Ops))
+	for i, op := range field.Ops {
+		ops[i] = string(op)
+	}
+	description := fmt.Sprintf("操作符：%s，使用 filter[%s][操作符] 指定，省略时为 eq", strings.Join(ops, ", "), field.Name)
+	if field.Description != "" {
+		description = field.Description + "，" + description
+	}
+
+	paramType := "string"
+	switch field.Type {
+	case TypeInt:
+		paramType = "integer"
+	case TypeFloat:
+		paramType = "number"
+	case TypeBool:
+		paramType = "boolean"
+	}
+	return map[string]any{
+		"name":        "filter[" + field.Name + "]",
+		"in":          "query",
+		"required":    false,
+		"type":        paramType,
+		"description": description,
+	}
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"embed"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -11,10 +12,17 @@ import (
 	"text/template"
 )
 
+// DefaultImportPath 生成代码引用的 internal 包路径
+const DefaultImportPath = "github.com/chenyl99x/toge-api/internal"
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
 // ModelInfo 模型信息
 type ModelInfo struct {
 	Name       string   // 模型名称
 	Package    string   // 包名
+	ImportPath string   // domain、model 等包所在目录的导入路径
 	Fields     []Field  // 字段列表
 	TableName  string   // 表名
 	PrimaryKey string   // 主键字段
@@ -39,6 +47,9 @@ type Generator struct {
 
 // NewGenerator 创建生成器
 func NewGenerator(modelInfo *ModelInfo, outputDir string) *Generator {
+	if modelInfo.ImportPath == "" {
+		modelInfo.ImportPath = DefaultImportPath
+	}
 	return &Generator{
 		ModelInfo: modelInfo,
 		OutputDir: outputDir,
@@ -91,7 +102,7 @@ func (g *Generator) generateDomain() error {
 
 	// 创建模板并设置函数映射
 	tmpl := template.New("domain.go.tmpl").Funcs(funcMap)
-	tmpl, err := tmpl.ParseFiles("pkg/generator/templates/domain.go.tmpl")
+	tmpl, err := tmpl.ParseFS(templates, "templates/domain.go.tmpl")
 	if err != nil {
 		return fmt.Errorf("failed to parse domain template: %v", err)
 	}
@@ -121,7 +132,7 @@ func (g *Generator) generateService() error {
 
 	// 创建模板并设置函数映射
 	tmpl := template.New("service.go.tmpl").Funcs(funcMap)
-	tmpl, err := tmpl.ParseFiles("pkg/generator/templates/service.go.tmpl")
+	tmpl, err := tmpl.ParseFS(templates, "templates/service.go.tmpl")
 	if err != nil {
 		return fmt.Errorf("failed to parse service template: %v", err)
 	}
@@ -151,7 +162,7 @@ func (g *Generator) generateRepository() error {
 
 	// 创建模板并设置函数映射
 	tmpl := template.New("repository.go.tmpl").Funcs(funcMap)
-	tmpl, err := tmpl.ParseFiles("pkg/generator/templates/repository.go.tmpl")
+	tmpl, err := tmpl.ParseFS(templates, "templates/repository.go.tmpl")
 	if err != nil {
 		return fmt.Errorf("failed to parse repository template: %v", err)
 	}
@@ -181,7 +192,7 @@ func (g *Generator) generateHandler() error {
 
 	// 创建模板并设置函数映射
 	tmpl := template.New("handler.go.tmpl").Funcs(funcMap)
-	tmpl, err := tmpl.ParseFiles("pkg/generator/templates/handler.go.tmpl")
+	tmpl, err := tmpl.ParseFS(templates, "templates/handler.go.tmpl")
 	if err != nil {
 		return fmt.Errorf("failed to parse handler template: %v", err)
 	}
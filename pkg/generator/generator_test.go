@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = `package model
+
+import "gorm.io/gorm"
+
+// Widget 测试模型
+type Widget struct {
+	gorm.Model
+	Name        string ` + "`gorm:\"type:varchar(100);not null\" json:\"name\"`" + ` // 名称
+	Description string ` + "`gorm:\"type:text\" json:\"description\"`" + `             // 描述
+	Price       int    ` + "`gorm:\"not null\" json:\"price\"`" + `                    // 价格
+}
+`
+
+func TestGeneratedCodeCompiles(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	// 生成到模块内以 _ 开头的目录，./... 不会匹配到它
+	dir, err := os.MkdirTemp(".", "_generated")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	modelFile := filepath.Join(dir, "model", "widget.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(modelFile), 0755))
+	require.NoError(t, os.WriteFile(modelFile, []byte(testModel), 0644))
+
+	info, err := ParseModelFile(modelFile)
+	require.NoError(t, err)
+	info.ImportPath = "github.com/chenyl99x/toge-api/pkg/generator/" + filepath.Base(dir)
+	g := NewGenerator(info, dir)
+	require.NoError(t, g.generateDomain())
+	require.NoError(t, g.generateService())
+	require.NoError(t, g.generateRepository())
+	require.NoError(t, g.generateHandler())
+
+	// 通配符会跳过以 _ 开头的目录，逐个列出生成的包
+	args := []string{"vet"}
+	for _, pkg := range []string{"model", "domain", "service", "repository", "handler"} {
+		args = append(args, "./"+filepath.Join(dir, pkg))
+	}
+	cmd := exec.Command(goBin, args...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// 错误统一经过 AppError，不把底层错误信息返回给客户端
+	handler, err := os.ReadFile(filepath.Join(dir, "handler", "widget_handler.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(handler), "DatabaseError")
+	assert.NotContains(t, string(handler), "err.Error()")
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/chenyl99x/toge-api/pkg/database"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+
+	"gorm.io/gorm"
+)
+
+// Database 检查数据库连接
+func Database(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return database.Ping(ctx, db)
+	}
+}
+
+// DatabaseReplicas 检查只读从库，使用后台定期检查的结果，从库不可用时读请求已回退到主库
+func DatabaseReplicas(replicas *database.Replicas) CheckFunc {
+	return func(context.Context) error {
+		return replicas.Err()
+	}
+}
+
+// Redis 检查 Redis 连接
+func Redis(client redis.Client) CheckFunc {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// DiskSpace 检查目录所在磁盘的剩余空间不低于 minFreeBytes
+func DiskSpace(dir string, minFreeBytes uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeBytes(dir)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", dir, err)
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("low disk space in %s: %d MB free, %d MB required", dir, free>>20, minFreeBytes>>20)
+		}
+		return nil
+	}
+}
+
+// Migrations 检查数据库迁移是否已全部执行，pending 返回未执行的版本
+func Migrations(pending func(ctx context.Context) ([]string, error)) CheckFunc {
+	return func(ctx context.Context) error {
+		versions, err := pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(versions) > 0 {
+			return fmt.Errorf("pending migrations: %s", strings.Join(versions, ", "))
+		}
+		return nil
+	}
+}
+
+// LogDir 返回日志文件所在目录，未配置时使用当前目录
+func LogDir(logPath string) string {
+	if logPath == "" {
+		return "."
+	}
+	return filepath.Dir(logPath)
+}
+
+// parentDir 上级目录
+func parentDir(dir string) string {
+	return filepath.Dir(filepath.Clean(dir))
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package health
+
+import "math"
+
+// freeBytes 非 unix 平台不检查磁盘空间
+func freeBytes(string) (uint64, error) {
+	return math.MaxUint64, nil
+}
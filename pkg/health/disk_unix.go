@@ -0,0 +1,24 @@
+//go:build unix
+
+package health
+
+import (
+	"os"
+	"syscall"
+)
+
+// freeBytes 目录所在文件系统对非特权用户可用的字节数，目录不存在时检查其上级目录
+func freeBytes(dir string) (uint64, error) {
+	for {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(dir, &stat)
+		if err == nil {
+			return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+		}
+		parent := parentDir(dir)
+		if !os.IsNotExist(err) || parent == dir {
+			return 0, err
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,230 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 检查状态
+const (
+	StatusHealthy   = "healthy"   // 全部检查通过
+	StatusDegraded  = "degraded"  // 非关键检查失败，仍可接收流量
+	StatusUnhealthy = "unhealthy" // 关键检查失败，应当摘除流量
+)
+
+var ErrCheckExists = errors.New("health check already registered")
+
+// 未设置时的默认值
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// CheckFunc 检查函数，返回 nil 表示通过，应当响应 ctx 的取消
+type CheckFunc func(ctx context.Context) error
+
+// Check 依赖检查定义
+type Check struct {
+	Name     string
+	Critical bool          // 关键检查失败时整体为 unhealthy，否则为 degraded
+	Timeout  time.Duration // 单次检查超时，为空使用注册表默认值
+	CacheTTL time.Duration // 结果缓存时间，为空使用注册表默认值，小于 0 表示不缓存
+	Func     CheckFunc
+}
+
+// Result 单项检查结果
+type Result struct {
+	Status    string    `json:"status" example:"healthy"`
+	Critical  bool      `json:"critical" example:"true"`
+	LatencyMs float64   `json:"latency_ms" example:"1.25"`
+	Error     string    `json:"error,omitempty" example:"dial tcp 127.0.0.1:6379: connect: connection refused"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached" example:"false"`
+}
+
+// Report 汇总结果
+type Report struct {
+	Status    string            `json:"status" example:"healthy"`
+	Checks    map[string]Result `json:"checks"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// Healthy 是否可以接收流量，degraded 也视为可以接收流量
+func (r *Report) Healthy() bool {
+	return r.Status != StatusUnhealthy
+}
+
+// Options 注册表选项
+type Options struct {
+	Timeout  time.Duration // 默认单次检查超时
+	CacheTTL time.Duration // 默认缓存时间
+}
+
+type entry struct {
+	check Check
+
+	mu     sync.Mutex
+	result *Result
+}
+
+// Registry 依赖检查注册表，并发执行所有检查并缓存结果
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	opts    Options
+	now     func() time.Time
+}
+
+// NewRegistry 创建检查注册表
+func NewRegistry(opts Options) *Registry {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = defaultCacheTTL
+	}
+	return &Registry{
+		entries: make(map[string]*entry),
+		opts:    opts,
+		now:     time.Now,
+	}
+}
+
+// Register 注册检查
+func (r *Registry) Register(check Check) error {
+	if check.Name == "" || check.Func == nil {
+		return fmt.Errorf("health check requires a name and a func")
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = r.opts.Timeout
+	}
+	if check.CacheTTL == 0 {
+		check.CacheTTL = r.opts.CacheTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[check.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrCheckExists, check.Name)
+	}
+	r.entries[check.Name] = &entry{check: check}
+	return nil
+}
+
+// Names 已注册的检查名称，按名称排序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run 并发执行所有检查，缓存未过期的检查直接返回缓存结果
+func (r *Registry) Run(ctx context.Context) *Report {
+	r.mu.RLock()
+	entries := make([]*entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = r.run(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status:    StatusHealthy,
+		Checks:    make(map[string]Result, len(entries)),
+		Timestamp: r.now(),
+	}
+	for i, e := range entries {
+		result := results[i]
+		report.Checks[e.check.Name] = result
+		if result.Status == StatusHealthy {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusUnhealthy
+		} else if report.Status == StatusHealthy {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run 执行单项检查，同一检查同时只执行一次，并发请求等待并复用结果
+func (r *Registry) run(ctx context.Context, e *entry) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := r.now()
+	if e.result != nil && e.check.CacheTTL > 0 && now.Sub(e.result.CheckedAt) < e.check.CacheTTL {
+		cached := *e.result
+		cached.Cached = true
+		return cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, e.check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := safeCheck(checkCtx, e.check.Func)
+	latency := time.Since(start)
+
+	result := Result{
+		Status:    StatusHealthy,
+		Critical:  e.check.Critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		CheckedAt: now,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		if e.check.Critical {
+			result.Status = StatusUnhealthy
+		} else {
+			result.Status = StatusDegraded
+		}
+	}
+
+	// 调用方取消导致的失败不缓存，避免污染后续请求
+	if ctx.Err() == nil {
+		e.result = &result
+	}
+	return result
+}
+
+// safeCheck 执行检查函数，超时或 panic 都视为失败
+func safeCheck(ctx context.Context, fn CheckFunc) (err error) {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("health check panic: %v", rec)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("health check timed out: %w", ctx.Err())
+	}
+}
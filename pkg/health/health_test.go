@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func passing(calls *atomic.Int32) CheckFunc {
+	return func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}
+}
+
+func failing(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestRunStatuses(t *testing.T) {
+	var calls atomic.Int32
+
+	r := NewRegistry(Options{CacheTTL: -1})
+	require.NoError(t, r.Register(Check{Name: "database", Critical: true, Func: passing(&calls)}))
+	report := r.Run(context.Background())
+	assert.Equal(t, StatusHealthy, report.Status)
+	assert.True(t, report.Healthy())
+
+	// 非关键检查失败为 degraded
+	require.NoError(t, r.Register(Check{Name: "redis", Func: failing}))
+	report = r.Run(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.True(t, report.Healthy())
+	assert.Equal(t, StatusDegraded, report.Checks["redis"].Status)
+	assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+	assert.Equal(t, StatusHealthy, report.Checks["database"].Status)
+
+	// 关键检查失败为 unhealthy
+	require.NoError(t, r.Register(Check{Name: "migrations", Critical: true, Func: failing}))
+	report = r.Run(context.Background())
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.False(t, report.Healthy())
+	assert.Equal(t, []string{"database", "migrations", "redis"}, r.Names())
+
+	assert.ErrorIs(t, r.Register(Check{Name: "redis", Func: failing}), ErrCheckExists)
+}
+
+func TestRunCachesResults(t *testing.T) {
+	var calls atomic.Int32
+	now := time.Now()
+
+	r := NewRegistry(Options{CacheTTL: time.Minute})
+	r.now = func() time.Time { return now }
+	require.NoError(t, r.Register(Check{Name: "database", Func: passing(&calls)}))
+	require.NoError(t, r.Register(Check{Name: "redis", CacheTTL: -1, Func: passing(&calls)}))
+
+	first := r.Run(context.Background())
+	assert.False(t, first.Checks["database"].Cached)
+	assert.Equal(t, int32(2), calls.Load())
+
+	second := r.Run(context.Background())
+	assert.True(t, second.Checks["database"].Cached)
+	assert.False(t, second.Checks["redis"].Cached)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// 缓存过期后重新检查
+	now = now.Add(2 * time.Minute)
+	third := r.Run(context.Background())
+	assert.False(t, third.Checks["database"].Cached)
+	assert.Equal(t, int32(5), calls.Load())
+}
+
+func TestRunTimeoutAndPanic(t *testing.T) {
+	r := NewRegistry(Options{Timeout: 20 * time.Millisecond, CacheTTL: -1})
+	require.NoError(t, r.Register(Check{Name: "slow", Critical: true, Func: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}))
+	require.NoError(t, r.Register(Check{Name: "panic", Func: func(context.Context) error {
+		panic("boom")
+	}}))
+
+	start := time.Now()
+	report := r.Run(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.Contains(t, report.Checks["slow"].Error, "timed out")
+	assert.GreaterOrEqual(t, report.Checks["slow"].LatencyMs, float64(20))
+	assert.Contains(t, report.Checks["panic"].Error, "boom")
+}
+
+func TestBuiltinChecks(t *testing.T) {
+	ctx := context.Background()
+
+	assert.NoError(t, DiskSpace(t.TempDir(), 1)(ctx))
+	// 目录不存在时检查上级目录
+	assert.NoError(t, DiskSpace(t.TempDir()+"/missing/logs", 1)(ctx))
+	assert.ErrorContains(t, DiskSpace(t.TempDir(), 1<<62)(ctx), "low disk space")
+
+	assert.NoError(t, Migrations(func(context.Context) ([]string, error) { return nil, nil })(ctx))
+	assert.EqualError(t,
+		Migrations(func(context.Context) ([]string, error) { return []string{"015", "016"}, nil })(ctx),
+		"pending migrations: 015, 016")
+
+	assert.Equal(t, "logs", LogDir("logs/app.jsonl"))
+	assert.Equal(t, ".", LogDir(""))
+}
@@ -0,0 +1,18 @@
+package i18n
+
+import (
+	"github.com/go-playground/locales/en"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	"golang.org/x/text/language"
+)
+
+// enUS 英文，代码中的消息原文即英文，只需要自定义校验规则的提示
+var enUS = &catalog{
+	locale:     EnUS,
+	tag:        language.AmericanEnglish,
+	translator: en.New(),
+	validation: entranslations.RegisterDefaultTranslations,
+	rules: map[string]string{
+		RuleLocale: "{0} must be a supported language such as en-US or zh-CN",
+	},
+}
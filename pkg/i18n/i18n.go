@@ -0,0 +1,147 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+
+	"github.com/go-playground/locales"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/language"
+)
+
+// Locale 语言，BCP 47 语言标签，例如 zh-CN
+type Locale string
+
+const (
+	EnUS Locale = "en-US"
+	ZhCN Locale = "zh-CN"
+
+	// Default 默认语言，也是代码中消息原文的语言
+	Default = EnUS
+)
+
+// catalog 一种语言的消息目录
+type catalog struct {
+	locale Locale
+	// messages 英文原文到译文，原文为格式化字符串时译文中的占位符顺序保持一致，默认语言不需要
+	messages map[string]string
+	// codes 错误码的说明，错误信息没有译文时代替错误信息
+	codes map[apperror.Code]string
+	// rules 自定义校验规则的提示，{0} 为字段名
+	rules map[string]string
+
+	tag        language.Tag
+	translator locales.Translator
+	// validation 注册 validator 内置规则的翻译
+	validation func(v *validator.Validate, trans ut.Translator) error
+}
+
+// catalogs 支持的语言，第一个为默认语言，新增语言时在这里添加目录
+var catalogs = []*catalog{enUS, zhCN}
+
+var (
+	byLocale = make(map[Locale]*catalog, len(catalogs))
+	matcher  language.Matcher
+)
+
+func init() {
+	tags := make([]language.Tag, len(catalogs))
+	for i, c := range catalogs {
+		byLocale[c.locale] = c
+		tags[i] = c.tag
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// Supported 支持的语言，默认语言在前
+func Supported() []Locale {
+	supported := make([]Locale, len(catalogs))
+	for i, c := range catalogs {
+		supported[i] = c.locale
+	}
+	return supported
+}
+
+// Parse 解析用户设置的语言偏好，只接受支持的语言，不区分大小写
+func Parse(s string) (Locale, bool) {
+	for _, c := range catalogs {
+		if strings.EqualFold(s, string(c.locale)) {
+			return c.locale, true
+		}
+	}
+	return "", false
+}
+
+// Match 按 Accept-Language 请求头选择最接近的支持语言，例如 zh、zh-Hans-CN 为 zh-CN，没有可用的语言时为默认语言
+func Match(acceptLanguage string) Locale {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return catalogs[index].locale
+}
+
+// localeKey 用于在 context 中保存请求的语言
+type localeKey struct{}
+
+// WithLocale 返回指定语言的 context
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext context 中的语言，未设置时为默认语言
+func FromContext(ctx context.Context) Locale {
+	if locale, ok := ctx.Value(localeKey{}).(Locale); ok {
+		return locale
+	}
+	return Default
+}
+
+// catalogOf 语言的消息目录，不支持的语言使用默认语言
+func catalogOf(locale Locale) *catalog {
+	if c, ok := byLocale[locale]; ok {
+		return c
+	}
+	return byLocale[Default]
+}
+
+// translate 消息的译文，没有译文时 ok 为 false
+func translate(locale Locale, message string) (string, bool) {
+	if locale == Default {
+		return message, true
+	}
+	translated, ok := catalogOf(locale).messages[message]
+	return translated, ok
+}
+
+// T 翻译消息，没有译文时返回原文
+func T(locale Locale, message string) string {
+	if translated, ok := translate(locale, message); ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf 翻译格式化字符串后格式化，例如 Sprintf(ZhCN, "validation error: %s", detail)
+func Sprintf(locale Locale, format string, args ...any) string {
+	return fmt.Sprintf(T(locale, format), args...)
+}
+
+// ErrorMessage 本地化的错误信息：错误信息有译文时使用译文，否则使用错误码的说明，避免响应中混用两种语言
+func ErrorMessage(locale Locale, code apperror.Code, message string) string {
+	if translated, ok := translate(locale, message); ok {
+		return translated
+	}
+	if description, ok := catalogOf(locale).codes[code]; ok {
+		return description
+	}
+	return message
+}
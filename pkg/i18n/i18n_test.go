@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	cases := map[string]Locale{
+		"":                           Default,
+		"zh-CN,zh;q=0.9,en;q=0.8":    ZhCN,
+		"zh":                         ZhCN,
+		"zh-Hans-CN":                 ZhCN,
+		"en-GB,en;q=0.9":             EnUS,
+		"fr-FR":                      Default,
+		"fr-FR,zh;q=0.5":             ZhCN,
+		"en;q=0.5,zh-CN;q=0.8":       ZhCN,
+		"not a valid;;header===":     Default,
+		"ja-JP,fr;q=0.9,en-US;q=0.1": EnUS,
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, Match(header), header)
+	}
+}
+
+func TestParse(t *testing.T) {
+	locale, ok := Parse("zh-cn")
+	assert.True(t, ok)
+	assert.Equal(t, ZhCN, locale)
+
+	_, ok = Parse("zh")
+	assert.False(t, ok)
+	_, ok = Parse("")
+	assert.False(t, ok)
+	assert.Equal(t, []Locale{EnUS, ZhCN}, Supported())
+}
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, Default, FromContext(context.Background()))
+	assert.Equal(t, ZhCN, FromContext(WithLocale(context.Background(), ZhCN)))
+}
+
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "success", T(EnUS, "success"))
+	assert.Equal(t, "成功", T(ZhCN, "success"))
+	// 没有译文时返回原文，不支持的语言按默认语言处理
+	assert.Equal(t, "no translation", T(ZhCN, "no translation"))
+	assert.Equal(t, "success", T("fr-FR", "success"))
+
+	assert.Equal(t, "校验错误: x", Sprintf(ZhCN, "validation error: %s", "x"))
+	assert.Equal(t, "validation error: x", Sprintf(EnUS, "validation error: %s", "x"))
+}
+
+func TestErrorMessage(t *testing.T) {
+	assert.Equal(t, "user not found", ErrorMessage(EnUS, apperror.CodeNotFound, "user not found"))
+	assert.Equal(t, "用户不存在", ErrorMessage(ZhCN, apperror.CodeNotFound, "user not found"))
+	// 没有译文的错误信息使用错误码的说明
+	assert.Equal(t, "导入文件或列映射无效", ErrorMessage(ZhCN, apperror.CodeInvalidImport, "invalid import: file is empty"))
+}
+
+func TestCatalogsCoverCodes(t *testing.T) {
+	// 非默认语言的目录包含所有错误码的说明
+	codes := []apperror.Code{
+		apperror.CodeInvalidArgument, apperror.CodeValidationFailed, apperror.CodeUnauthenticated,
+		apperror.CodePermissionDenied, apperror.CodeNotFound, apperror.CodeMethodNotAllowed,
+		apperror.CodeConflict, apperror.CodeUsernameExists, apperror.CodeEmailExists,
+		apperror.CodeVersionConflict, apperror.CodePreconditionFailed, apperror.CodeUnsupportedMediaType,
+		apperror.CodeInvalidImport, apperror.CodePreconditionRequired, apperror.CodeRateLimited,
+		apperror.CodeInternal, apperror.CodeUnavailable,
+	}
+	for _, c := range catalogs[1:] {
+		for _, code := range codes {
+			assert.NotEmpty(t, c.codes[code], "%s %s", c.locale, code)
+		}
+	}
+}
+
+type testRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Locale  string `json:"locale" binding:"omitempty,locale"`
+	Profile struct {
+		Nickname string `json:"nickname" binding:"max=3"`
+	} `json:"profile"`
+	Page int `form:"page" binding:"min=1"`
+}
+
+func TestTranslateValidation(t *testing.T) {
+	v := validator.New()
+	v.SetTagName("binding")
+	require.NoError(t, RegisterValidator(v))
+
+	req := testRequest{Email: "x", Locale: "fr-FR"}
+	req.Profile.Nickname = "abcd"
+	err := v.Struct(req)
+	require.Error(t, err)
+
+	details, ok := TranslateValidation(EnUS, err)
+	require.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "locale", Rule: "locale", Message: "locale must be a supported language such as en-US or zh-CN"},
+		{Field: "profile.nickname", Rule: "max", Message: "nickname must be a maximum of 3 characters in length"},
+		{Field: "page", Rule: "min", Message: "page must be 1 or greater"},
+	}, details)
+
+	details, ok = TranslateValidation(ZhCN, err)
+	require.True(t, ok)
+	assert.Equal(t, "email必须是一个有效的邮箱", details[0].Message)
+	assert.Equal(t, "locale必须是支持的语言，例如 en-US、zh-CN", details[1].Message)
+
+	assert.NoError(t, v.Struct(testRequest{Email: "a@b.c", Locale: "zh-CN", Page: 1}))
+
+	_, ok = TranslateValidation(EnUS, assert.AnError)
+	assert.False(t, ok)
+}
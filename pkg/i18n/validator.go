@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// RuleLocale 语言偏好的校验规则，值为空或为支持的语言，例如 binding:"omitempty,locale"
+const RuleLocale = "locale"
+
+// FieldError 校验失败的字段
+type FieldError struct {
+	Field   string `json:"field" example:"email"`                                 // 字段的 JSON 路径，嵌套字段用点号
+	Rule    string `json:"rule" example:"email"`                                  // 校验规则
+	Message string `json:"message" example:"email must be a valid email address"` // 本地化的提示
+}
+
+// translators 各语言的校验错误翻译器，由 RegisterValidator 设置
+var translators map[Locale]ut.Translator
+
+// RegisterValidator 为 validator 注册各语言的校验错误翻译和 locale 规则，字段名使用 JSON 字段名，
+// 启动时在处理请求前调用一次，API 服务和 worker 共用 gin 的 validator
+func RegisterValidator(v *validator.Validate) error {
+	v.RegisterTagNameFunc(jsonFieldName)
+	if err := v.RegisterValidation(RuleLocale, func(fl validator.FieldLevel) bool {
+		_, ok := Parse(fl.Field().String())
+		return ok || fl.Field().String() == ""
+	}); err != nil {
+		return err
+	}
+
+	uni := ut.New(catalogs[0].translator, translatorsOf()...)
+	registered := make(map[Locale]ut.Translator, len(catalogs))
+	for _, c := range catalogs {
+		trans, _ := uni.GetTranslator(c.translator.Locale())
+		if err := c.validation(v, trans); err != nil {
+			return fmt.Errorf("register %s validation translations: %w", c.locale, err)
+		}
+		for rule, text := range c.rules {
+			if err := registerRule(v, trans, rule, text); err != nil {
+				return fmt.Errorf("register %s translation of rule %s: %w", c.locale, rule, err)
+			}
+		}
+		registered[c.locale] = trans
+	}
+	translators = registered
+	return nil
+}
+
+func translatorsOf() []locales.Translator {
+	list := make([]locales.Translator, len(catalogs))
+	for i, c := range catalogs {
+		list[i] = c.translator
+	}
+	return list
+}
+
+// registerRule 注册自定义规则的提示，{0} 为字段名
+func registerRule(v *validator.Validate, trans ut.Translator, rule, text string) error {
+	return v.RegisterTranslation(rule, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(rule, text, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			message, err := ut.T(rule, fe.Field())
+			if err != nil {
+				return fe.Error()
+			}
+			return message
+		})
+}
+
+// jsonFieldName 校验错误中的字段名使用 JSON 字段名，没有 json 标签时使用 form 标签
+func jsonFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// TranslateValidation 把 validator 的校验错误翻译为各字段的提示，err 不是校验错误时 ok 为 false
+func TranslateValidation(locale Locale, err error) (details []FieldError, ok bool) {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return nil, false
+	}
+	trans := translators[locale]
+	if trans == nil {
+		trans = translators[Default]
+	}
+
+	details = make([]FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		message := fe.Error()
+		if trans != nil {
+			message = fe.Translate(trans)
+		}
+		// 命名空间的第一段为结构体名称
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		details = append(details, FieldError{Field: field, Rule: fe.Tag(), Message: message})
+	}
+	return details, true
+}
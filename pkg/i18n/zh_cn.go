@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+
+	"github.com/go-playground/locales/zh"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+	"golang.org/x/text/language"
+)
+
+// zhCN 简体中文
+var zhCN = &catalog{
+	locale:     ZhCN,
+	tag:        language.SimplifiedChinese,
+	translator: zh.New(),
+	validation: zhtranslations.RegisterDefaultTranslations,
+	rules: map[string]string{
+		RuleLocale: "{0}必须是支持的语言，例如 en-US、zh-CN",
+	},
+	messages: map[string]string{
+		// 成功响应
+		"success":              "成功",
+		"created successfully": "创建成功",
+		"accepted":             "已接受",
+
+		// 格式化的错误信息
+		"validation error: %s": "校验错误: %s",
+		"database error: %s":   "数据库响应错误: %s",
+
+		// 通用错误
+		"Internal server error":                     "服务器内部错误",
+		"internal server error":                     "服务器内部错误",
+		"Resource not found":                        "资源不存在",
+		"resource not found":                        "资源不存在",
+		"resource already exists":                   "资源已存在",
+		"Method not allowed":                        "请求方法不支持",
+		"Request failed":                            "请求失败",
+		"Invalid ID":                                "无效的 ID",
+		"Too many requests, please try again later": "请求过于频繁，请稍后再试",
+
+		// 认证和权限
+		"Authorization header is required":    "缺少 Authorization 请求头",
+		"Invalid authorization header format": "Authorization 请求头格式错误",
+		"Invalid token":                       "无效的令牌",
+		"Invalid credentials":                 "用户名或密码错误",
+		"User not authenticated":              "用户未登录",
+		"Admin permission required":           "需要管理员权限",
+		"Failed to generate token":            "生成令牌失败",
+		"Failed to logout":                    "退出登录失败",
+
+		// 用户和岛屿
+		"user not found":                       "用户不存在",
+		"space not found":                      "岛屿不存在",
+		"already a member of the space":        "已经是岛屿的成员",
+		"only the space owner can add members": "只有岛屿拥有者可以添加成员",
+		"not a member of the space":            "不是岛屿的成员",
+		"username already exists":              "用户名已存在",
+		"email already exists":                 "邮箱已存在",
+		"username is reserved":                 "用户名已被保留",
+		"Failed to process password":           "密码处理失败",
+
+		// 并发控制
+		"If-Match header is required":             "缺少 If-Match 请求头",
+		"Resource has been modified":              "资源已被修改",
+		"Resource has been modified concurrently": "资源已被其他请求修改",
+		"version conflict":                        "资源已被其他请求修改",
+
+		// 导入导出
+		"File is required":                "缺少上传文件",
+		"Invalid format, use csv or xlsx": "文件格式错误，请使用 csv 或 xlsx",
+		"Import job not found":            "导入任务不存在",
+
+		// Webhook 和事件
+		"Webhook not found":                    "Webhook 不存在",
+		"Invalid webhook ID":                   "无效的 Webhook ID",
+		"Invalid delivery ID":                  "无效的投递记录 ID",
+		"no permission to manage this webhook": "没有权限管理该 Webhook",
+		"webhook is disabled":                  "Webhook 已停用",
+		"Event not found":                      "事件不存在",
+
+		// 通知
+		"Welcome to Together": "欢迎加入 Together",
+		"Hi %s, create or join a space to start recording your life together.": "你好 %s，创建或加入一个空间，开始记录你们的生活吧。",
+
+		// 健康检查
+		"Service is unhealthy":     "服务不健康",
+		"Service is not ready":     "服务未就绪",
+		"Service is shutting down": "服务正在关闭",
+	},
+	codes: map[apperror.Code]string{
+		apperror.CodeInvalidArgument:      "请求参数错误",
+		apperror.CodeValidationFailed:     "请求参数校验失败",
+		apperror.CodeUnauthenticated:      "未登录或令牌无效",
+		apperror.CodePermissionDenied:     "没有权限",
+		apperror.CodeNotFound:             "资源不存在",
+		apperror.CodeMethodNotAllowed:     "请求方法不支持",
+		apperror.CodeConflict:             "资源已存在或状态冲突",
+		apperror.CodeUsernameExists:       "用户名已存在",
+		apperror.CodeEmailExists:          "邮箱已存在",
+		apperror.CodeVersionConflict:      "资源已被其他请求修改",
+		apperror.CodePreconditionFailed:   "资源的版本与 If-Match 不一致",
+		apperror.CodeUnsupportedMediaType: "不支持的请求体格式",
+		apperror.CodeInvalidImport:        "导入文件或列映射无效",
+		apperror.CodePreconditionRequired: "缺少 If-Match 请求头",
+		apperror.CodeRateLimited:          "请求过于频繁，请稍后再试",
+		apperror.CodeInternal:             "服务器内部错误",
+		apperror.CodeUnavailable:          "服务暂不可用",
+	},
+}
@@ -0,0 +1,261 @@
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+)
+
+// 补丁的媒体类型
+const (
+	MediaTypeMergePatch = "application/merge-patch+json" // RFC 7396
+	MediaTypeJSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+	// ErrInvalidPatch 补丁格式错误、路径不存在或修改了不可写的字段
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed JSON Patch 的 test 操作不成立
+	ErrTestFailed = errors.New("patch test failed")
+	// ErrUnsupportedMediaType 请求的 Content-Type 不是支持的补丁格式
+	ErrUnsupportedMediaType = errors.New("unsupported patch media type")
+)
+
+// Field 允许通过补丁修改的字段
+type Field struct {
+	Name     string // JSON 字段名
+	Nullable bool   // 允许 null 或 remove，清空为与当前值同类型的零值
+}
+
+// Schema 资源的可写字段白名单
+type Schema struct {
+	fields []Field
+	byName map[string]Field
+}
+
+// NewSchema 创建可写字段白名单，资源在包级变量中声明，字段重复时 panic
+func NewSchema(fields ...Field) *Schema {
+	s := &Schema{byName: make(map[string]Field, len(fields))}
+	for _, field := range fields {
+		if _, ok := s.byName[field.Name]; ok || field.Name == "" {
+			panic(fmt.Sprintf("patch field %q: empty or duplicate name", field.Name))
+		}
+		s.fields = append(s.fields, field)
+		s.byName[field.Name] = field
+	}
+	return s
+}
+
+// Fields 白名单中的字段，按声明顺序
+func (s *Schema) Fields() []Field {
+	return s.fields
+}
+
+// Apply 按 Content-Type 把请求体中的补丁应用到资源的可写字段上，发生变化的字段解码到 v
+// resource 按 JSON 序列化后取白名单中的字段作为补丁的目标文档，缺少的字段（例如只写的密码）视为 null；
+// v 应为字段均为指针的结构体，未变化的字段保持 nil。application/json 按合并补丁处理
+func (s *Schema) Apply(contentType string, body []byte, resource any, v any) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+
+	current, err := s.document(resource)
+	if err != nil {
+		return err
+	}
+	patched, err := decode(body)
+	if err != nil {
+		return err
+	}
+
+	var result any
+	switch mediaType {
+	case MediaTypeMergePatch, "application/json":
+		result = MergePatch(deepCopy(current), patched)
+	case MediaTypeJSONPatch:
+		ops, err := parseOperations(patched)
+		if err != nil {
+			return err
+		}
+		if result, err = applyOperations(deepCopy(current), ops); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+
+	doc, ok := result.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%w: patched document is not an object", ErrInvalidPatch)
+	}
+	changes, err := s.changes(current, doc)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("%w: field %q must be %s", ErrInvalidPatch, typeErr.Field, typeErr.Type)
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return nil
+}
+
+// document 资源的可写字段组成的目标文档
+func (s *Schema) document(resource any) (map[string]any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any, len(s.fields))
+	for _, field := range s.fields {
+		doc[field.Name] = normalize(all[field.Name])
+	}
+	return doc, nil
+}
+
+// changes 比较补丁前后的文档，返回发生变化的字段，null 和删除的字段按白名单清空为零值
+func (s *Schema) changes(current, patched map[string]any) (map[string]any, error) {
+	changes := make(map[string]any)
+	for name, value := range patched {
+		if _, ok := s.byName[name]; !ok {
+			return nil, fmt.Errorf("%w: field %q is not writable", ErrInvalidPatch, name)
+		}
+		if value != nil && !equal(current[name], value) {
+			changes[name] = value
+		}
+	}
+	for name, old := range current {
+		if value, ok := patched[name]; (ok && value != nil) || old == nil {
+			continue
+		}
+		if !s.byName[name].Nullable {
+			return nil, fmt.Errorf("%w: field %q cannot be null", ErrInvalidPatch, name)
+		}
+		changes[name] = zero(old)
+	}
+	return changes, nil
+}
+
+// zero 与 v 同类型的零值
+func zero(v any) any {
+	switch v.(type) {
+	case string:
+		return ""
+	case json.Number:
+		return json.Number("0")
+	case bool:
+		return false
+	case []any:
+		return []any{}
+	case map[string]any:
+		return map[string]any{}
+	}
+	return nil
+}
+
+// decode 解析 JSON，数字保留为 json.Number
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after document", ErrInvalidPatch)
+	}
+	return v, nil
+}
+
+// normalize 把 float64 转为 json.Number，与 decode 的结果一致
+func normalize(v any) any {
+	switch t := v.(type) {
+	case float64:
+		return json.Number(strconv.FormatFloat(t, 'f', -1, 64))
+	case []any:
+		for i := range t {
+			t[i] = normalize(t[i])
+		}
+	case map[string]any:
+		for k := range t {
+			t[k] = normalize(t[k])
+		}
+	}
+	return v
+}
+
+// equal JSON 值是否相等，数字按数值比较
+func equal(a, b any) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, aerr := an.Float64()
+		bf, berr := bn.Float64()
+		if aerr == nil && berr == nil {
+			return af == bf
+		}
+		return an == bn
+	}
+
+	switch at := a.(type) {
+	case []any:
+		bt, ok := b.([]any)
+		if !ok || len(at) != len(bt) {
+			return false
+		}
+		for i := range at {
+			if !equal(at[i], bt[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		bt, ok := b.(map[string]any)
+		if !ok || len(at) != len(bt) {
+			return false
+		}
+		for k, av := range at {
+			bv, ok := bt[k]
+			if !ok || !equal(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// deepCopy 复制 JSON 值，补丁修改副本，不影响原文档
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case []any:
+		c := make([]any, len(t))
+		for i := range t {
+			c[i] = deepCopy(t[i])
+		}
+		return c
+	case map[string]any:
+		c := make(map[string]any, len(t))
+		for k, item := range t {
+			c[k] = deepCopy(item)
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,183 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecode(t *testing.T, s string) any {
+	t.Helper()
+	v, err := decode([]byte(s))
+	require.NoError(t, err)
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	// RFC 7396 附录 A 中的示例
+	cases := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		got := MergePatch(mustDecode(t, tc.target), mustDecode(t, tc.patch))
+		assert.True(t, equal(mustDecode(t, tc.want), got), "%s + %s = %v", tc.target, tc.patch, got)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	// RFC 6902 附录 A 中的示例
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"foo":1}`, `[{"op":"copy","from":"/foo","path":"/bar"}]`, `{"foo":1,"bar":1}`},
+		{`{"foo":1}`, `[{"op":"replace","path":"","value":{"bar":2}}]`, `{"bar":2}`},
+	}
+	for _, tc := range cases {
+		ops, err := parseOperations(mustDecode(t, tc.patch))
+		require.NoError(t, err)
+		got, err := applyOperations(mustDecode(t, tc.doc), ops)
+		require.NoError(t, err, tc.patch)
+		assert.True(t, equal(mustDecode(t, tc.want), got), "%s + %s = %v", tc.doc, tc.patch, got)
+	}
+
+	errorCases := []struct{ doc, patch string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"x"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/01"}]`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"x"}]`},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"inc","path":"/foo"}]`},
+		{`{"foo":"bar"}`, `{"op":"remove","path":"/foo"}`},
+	}
+	for _, tc := range errorCases {
+		ops, err := parseOperations(mustDecode(t, tc.patch))
+		if err == nil {
+			_, err = applyOperations(mustDecode(t, tc.doc), ops)
+		}
+		assert.ErrorIs(t, err, ErrInvalidPatch, tc.patch)
+	}
+}
+
+func TestPathError(t *testing.T) {
+	ops, err := parseOperations(mustDecode(t, `[{"op":"remove","path":"/a/b/c"}]`))
+	require.NoError(t, err)
+	_, err = applyOperations(mustDecode(t, `{"a":{"b":{}}}`), ops)
+	assert.EqualError(t, err, "invalid patch: path /a/b/c does not exist")
+}
+
+type testResource struct {
+	Name     string `json:"name"`
+	Nickname string `json:"nickname"`
+	Status   int    `json:"status"`
+	Secret   string `json:"secret,omitempty"`
+	Internal string `json:"internal"`
+}
+
+type testPatch struct {
+	Name     *string `json:"name"`
+	Nickname *string `json:"nickname"`
+	Status   *int    `json:"status"`
+	Secret   *string `json:"secret"`
+}
+
+var testSchema = NewSchema(
+	Field{Name: "name"},
+	Field{Name: "nickname", Nullable: true},
+	Field{Name: "status"},
+	Field{Name: "secret"},
+)
+
+func applyTest(t *testing.T, contentType, body string) (*testPatch, error) {
+	t.Helper()
+	resource := &testResource{Name: "alice", Nickname: "Al", Status: 1, Internal: "x"}
+	var patch testPatch
+	err := testSchema.Apply(contentType, []byte(body), resource, &patch)
+	// 资源本身不被修改
+	assert.Equal(t, "Al", resource.Nickname)
+	return &patch, err
+}
+
+func TestSchemaApply(t *testing.T) {
+	// 合并补丁：null 清空可为空的字段，未变化的字段不出现在结果中
+	patch, err := applyTest(t, MediaTypeMergePatch, `{"name":"alice","nickname":null,"status":0,"secret":"s"}`)
+	require.NoError(t, err)
+	assert.Nil(t, patch.Name)
+	require.NotNil(t, patch.Nickname)
+	assert.Empty(t, *patch.Nickname)
+	require.NotNil(t, patch.Status)
+	assert.Equal(t, 0, *patch.Status)
+	require.NotNil(t, patch.Secret)
+	assert.Equal(t, "s", *patch.Secret)
+
+	// application/json 按合并补丁处理，数字按数值比较
+	patch, err = applyTest(t, "application/json; charset=utf-8", `{"status":1.0}`)
+	require.NoError(t, err)
+	assert.Nil(t, patch.Status)
+
+	// JSON Patch：remove 清空，缺少的只写字段可以 replace
+	patch, err = applyTest(t, MediaTypeJSONPatch, `[{"op":"test","path":"/name","value":"alice"},{"op":"remove","path":"/nickname"},{"op":"replace","path":"/secret","value":"s"}]`)
+	require.NoError(t, err)
+	require.NotNil(t, patch.Nickname)
+	assert.Empty(t, *patch.Nickname)
+	require.NotNil(t, patch.Secret)
+
+	_, err = applyTest(t, MediaTypeJSONPatch, `[{"op":"test","path":"/name","value":"bob"}]`)
+	assert.ErrorIs(t, err, ErrTestFailed)
+
+	// 白名单以外的字段不可读也不可写
+	_, err = applyTest(t, MediaTypeMergePatch, `{"internal":"y"}`)
+	assert.EqualError(t, err, `invalid patch: field "internal" is not writable`)
+	_, err = applyTest(t, MediaTypeJSONPatch, `[{"op":"test","path":"/internal","value":"x"}]`)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = applyTest(t, MediaTypeMergePatch, `{"name":null}`)
+	assert.EqualError(t, err, `invalid patch: field "name" cannot be null`)
+	_, err = applyTest(t, MediaTypeJSONPatch, `[{"op":"replace","path":"/status","value":null}]`)
+	assert.EqualError(t, err, `invalid patch: field "status" cannot be null`)
+	_, err = applyTest(t, MediaTypeMergePatch, `{"status":"x"}`)
+	assert.EqualError(t, err, `invalid patch: field "status" must be int`)
+	_, err = applyTest(t, MediaTypeMergePatch, `["x"]`)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+	_, err = applyTest(t, MediaTypeMergePatch, `{"name":"x"} {}`)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = applyTest(t, "text/plain", `{}`)
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+	_, err = applyTest(t, "", `{}`)
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+}
+
+func TestNewSchemaPanicsOnDuplicate(t *testing.T) {
+	assert.Panics(t, func() { NewSchema(Field{Name: "a"}, Field{Name: "a"}) })
+}
+
+func TestNormalize(t *testing.T) {
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(`{"a":[1.5,2]}`), &v))
+	assert.True(t, equal(mustDecode(t, `{"a":[1.5,2.0]}`), normalize(v)))
+}
@@ -0,0 +1,22 @@
+package jsonpatch
+
+// MergePatch 按 RFC 7396 把合并补丁应用到 target 上，值为 null 的成员从结果中删除，target 会被修改
+func MergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		// 补丁不是对象时整体替换
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any, len(p))
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = MergePatch(t[name], value)
+	}
+	return t
+}
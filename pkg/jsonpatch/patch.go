@@ -0,0 +1,302 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxOperations 单个 JSON Patch 文档的最大操作数
+const maxOperations = 100
+
+// operation JSON Patch 中的一个操作
+type operation struct {
+	Op    string
+	Path  []string
+	From  []string
+	Value any
+}
+
+// parseOperations 解析 RFC 6902 JSON Patch 文档
+func parseOperations(doc any) ([]operation, error) {
+	items, ok := doc.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: JSON Patch must be an array of operations", ErrInvalidPatch)
+	}
+	if len(items) > maxOperations {
+		return nil, fmt.Errorf("%w: too many operations, at most %d", ErrInvalidPatch, maxOperations)
+	}
+
+	ops := make([]operation, 0, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: operation %d is not an object", ErrInvalidPatch, i)
+		}
+		op, _ := obj["op"].(string)
+		path, ok := obj["path"].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: operation %d: missing path", ErrInvalidPatch, i)
+		}
+		parsed := operation{Op: op}
+		var err error
+		if parsed.Path, err = parsePointer(path); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+
+		switch op {
+		case "add", "replace", "test":
+			value, ok := obj["value"]
+			if !ok {
+				return nil, fmt.Errorf("%w: operation %d: missing value", ErrInvalidPatch, i)
+			}
+			parsed.Value = value
+		case "move", "copy":
+			from, ok := obj["from"].(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: operation %d: missing from", ErrInvalidPatch, i)
+			}
+			if parsed.From, err = parsePointer(from); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d: unknown op %q", ErrInvalidPatch, i, op)
+		}
+		ops = append(ops, parsed)
+	}
+	return ops, nil
+}
+
+// parsePointer 解析 RFC 6901 JSON Pointer，空字符串表示整个文档
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid pointer %q", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// formatPointer 错误信息中的路径
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// applyOperations 依次执行操作，任何一个失败时整个补丁失败
+func applyOperations(doc any, ops []operation) (any, error) {
+	var err error
+	for _, op := range ops {
+		switch op.Op {
+		case "add":
+			doc, err = add(doc, op.Path, deepCopy(op.Value))
+		case "remove":
+			doc, _, err = remove(doc, op.Path)
+		case "replace":
+			if _, err = get(doc, op.Path); err == nil && len(op.Path) == 0 {
+				doc = deepCopy(op.Value)
+			} else if err == nil {
+				doc, _, err = remove(doc, op.Path)
+				if err == nil {
+					doc, err = add(doc, op.Path, deepCopy(op.Value))
+				}
+			}
+		case "move":
+			if isProperPrefix(op.From, op.Path) {
+				return nil, fmt.Errorf("%w: cannot move %s into itself", ErrInvalidPatch, formatPointer(op.From))
+			}
+			var value any
+			if doc, value, err = remove(doc, op.From); err == nil {
+				doc, err = add(doc, op.Path, value)
+			}
+		case "copy":
+			var value any
+			if value, err = get(doc, op.From); err == nil {
+				doc, err = add(doc, op.Path, deepCopy(value))
+			}
+		case "test":
+			var value any
+			if value, err = get(doc, op.Path); err == nil && !equal(value, op.Value) {
+				return nil, fmt.Errorf("%w: %s", ErrTestFailed, formatPointer(op.Path))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// isProperPrefix prefix 是否为 path 的真前缀
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// get 读取路径上的值
+func get(doc any, path []string) (any, error) {
+	for i, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, notFound(path[:i+1])
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1, path[:i+1])
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, notFound(path[:i+1])
+		}
+	}
+	return doc, nil
+}
+
+// add 在路径上添加值，对象成员已存在时替换，数组按下标插入，- 表示追加到末尾
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, notFound(path[:1])
+		}
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, prefixed(token, err)
+		}
+		node[token] = child
+		return node, nil
+	case []any:
+		if len(rest) == 0 {
+			index := len(node)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(node), path[:1]); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		index, err := arrayIndex(token, len(node)-1, path[:1])
+		if err != nil {
+			return nil, err
+		}
+		child, err := add(node[index], rest, value)
+		if err != nil {
+			return nil, prefixed(token, err)
+		}
+		node[index] = child
+		return node, nil
+	}
+	return nil, notFound(path[:1])
+}
+
+// remove 删除路径上的值，返回修改后的文档和被删除的值
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, notFound(path[:1])
+		}
+		if len(rest) == 0 {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, prefixed(token, err)
+		}
+		node[token] = child
+		return node, removed, nil
+	case []any:
+		index, err := arrayIndex(token, len(node)-1, path[:1])
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := node[index]
+			return append(node[:index], node[index+1:]...), removed, nil
+		}
+		child, removed, err := remove(node[index], rest)
+		if err != nil {
+			return nil, nil, prefixed(token, err)
+		}
+		node[index] = child
+		return node, removed, nil
+	}
+	return nil, nil, notFound(path[:1])
+}
+
+// arrayIndex 解析数组下标，不允许前导零和超过 max 的下标
+func arrayIndex(token string, max int, path []string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, notFound(path)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, notFound(path)
+	}
+	return index, nil
+}
+
+// pathError 路径不存在，path 为从出错的层级开始的相对路径，上层调用时补全前缀
+type pathError struct {
+	path []string
+}
+
+func (e *pathError) Error() string {
+	return fmt.Sprintf("%v: path %s does not exist", ErrInvalidPatch, formatPointer(e.path))
+}
+
+func (e *pathError) Unwrap() error {
+	return ErrInvalidPatch
+}
+
+func notFound(path []string) error {
+	return &pathError{path: append([]string(nil), path...)}
+}
+
+// prefixed 在路径错误前补上当前层级的 token
+func prefixed(token string, err error) error {
+	if e, ok := err.(*pathError); ok {
+		e.path = append([]string{token}, e.path...)
+	}
+	return err
+}
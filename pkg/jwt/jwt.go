@@ -16,26 +16,45 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// Manager 签发和校验 JWT token
+type Manager struct {
+	secret []byte
+	expire time.Duration
+}
+
+// NewManager 根据 JWT 配置创建 token 管理器
+func NewManager(jwtConfig config.JWTConfig) *Manager {
+	return &Manager{
+		secret: []byte(jwtConfig.Secret),
+		expire: time.Duration(jwtConfig.ExpireHours) * time.Hour,
+	}
+}
+
+// Expiration token 有效期
+func (m *Manager) Expiration() time.Duration {
+	return m.expire
+}
+
 // GenerateToken 生成 JWT token
-func GenerateToken(userID uint, username string) (string, error) {
+func (m *Manager) GenerateToken(userID uint, username string) (string, error) {
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(config.GlobalConfig.JWT.ExpireHours) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.expire)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.GlobalConfig.JWT.Secret))
+	return token.SignedString(m.secret)
 }
 
 // ParseToken 解析 JWT token
-func ParseToken(tokenString string) (*Claims, error) {
+func (m *Manager) ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GlobalConfig.JWT.Secret), nil
+		return m.secret, nil
 	})
 
 	if err != nil {
@@ -50,7 +69,7 @@ func ParseToken(tokenString string) (*Claims, error) {
 }
 
 // ValidateToken 验证 token 是否有效
-func ValidateToken(tokenString string) bool {
-	_, err := ParseToken(tokenString)
+func (m *Manager) ValidateToken(tokenString string) bool {
+	_, err := m.ParseToken(tokenString)
 	return err == nil
 }
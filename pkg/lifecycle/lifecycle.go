@@ -0,0 +1,218 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrHookExists     = errors.New("lifecycle hook already registered")
+	ErrUnknownDepends = errors.New("lifecycle hook depends on unknown hook")
+	ErrCycle          = errors.New("lifecycle hooks have a dependency cycle")
+	ErrAlreadyStarted = errors.New("lifecycle already started")
+)
+
+// Hook 组件的启动和停止钩子
+type Hook struct {
+	Name      string
+	DependsOn []string                        // 依赖的组件，启动时先于本组件启动，停止时晚于本组件停止
+	OnStart   func(ctx context.Context) error // 可为空
+	OnStop    func(ctx context.Context) error // 可为空，应当在 ctx 取消时尽快返回
+}
+
+// Manager 组件生命周期管理器，按依赖顺序启动，按相反顺序停止
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	names   map[string]bool
+	started []Hook // 已成功启动的组件，按启动顺序
+	running bool
+	log     *slog.Logger
+
+	ready atomic.Bool
+}
+
+// New 创建生命周期管理器
+func New(log *slog.Logger) *Manager {
+	return &Manager{names: make(map[string]bool), log: log}
+}
+
+// Append 注册组件钩子，依赖的组件可以晚于本组件注册
+func (m *Manager) Append(hook Hook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return ErrAlreadyStarted
+	}
+	if m.names[hook.Name] {
+		return fmt.Errorf("%w: %s", ErrHookExists, hook.Name)
+	}
+	m.names[hook.Name] = true
+	m.hooks = append(m.hooks, hook)
+	return nil
+}
+
+// Order 返回启动顺序，无依赖关系的组件保持注册顺序
+func (m *Manager) Order() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hooks, err := m.sorted()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(hooks))
+	for i, h := range hooks {
+		names[i] = h.Name
+	}
+	return names, nil
+}
+
+// Start 按依赖顺序启动所有组件，任一组件失败时停止已启动的组件并返回错误
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return ErrAlreadyStarted
+	}
+	hooks, err := m.sorted()
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	for _, h := range hooks {
+		if h.OnStart != nil {
+			start := time.Now()
+			if err := h.OnStart(ctx); err != nil {
+				m.log.Error("Failed to start component", "component", h.Name, "error", err)
+
+				// 回滚已启动的组件
+				if stopErr := m.Stop(context.WithoutCancel(ctx)); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return fmt.Errorf("start %s: %w", h.Name, err)
+			}
+			m.log.Info("Component started", "component", h.Name, "duration", time.Since(start).String())
+		}
+
+		m.mu.Lock()
+		m.started = append(m.started, h)
+		m.mu.Unlock()
+	}
+
+	m.ready.Store(true)
+	return nil
+}
+
+// Stop 按启动的相反顺序停止已启动的组件，单个组件失败不影响其他组件停止
+func (m *Manager) Stop(ctx context.Context) error {
+	m.ready.Store(false)
+
+	m.mu.Lock()
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		h := started[i]
+		if h.OnStop == nil {
+			continue
+		}
+
+		start := time.Now()
+		if err := h.OnStop(ctx); err != nil {
+			m.log.Error("Failed to stop component", "component", h.Name, "error", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+			continue
+		}
+		m.log.Info("Component stopped", "component", h.Name, "duration", time.Since(start).String())
+	}
+	return errors.Join(errs...)
+}
+
+// Ready 是否可以接收流量，所有组件启动后为 true，开始停止前置为 false
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// SetReady 设置就绪状态，优雅停机时先置为 false 让负载均衡摘除实例
+func (m *Manager) SetReady(ready bool) {
+	m.ready.Store(ready)
+}
+
+// sorted 拓扑排序，调用方需持有锁
+func (m *Manager) sorted() ([]Hook, error) {
+	index := make(map[string]int, len(m.hooks))
+	for i, h := range m.hooks {
+		index[h.Name] = i
+	}
+	for _, h := range m.hooks {
+		for _, dep := range h.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("%w: %s -> %s", ErrUnknownDepends, h.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(m.hooks))
+	result := make([]Hook, 0, len(m.hooks))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrCycle, m.hooks[i].Name)
+		}
+		state[i] = visiting
+		for _, dep := range m.hooks[i].DependsOn {
+			if err := visit(index[dep]); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		result = append(result, m.hooks[i])
+		return nil
+	}
+
+	for i := range m.hooks {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Wait 将阻塞的停止函数包装为 OnStop，ctx 超时后不再等待
+func Wait(fn func()) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			fn()
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
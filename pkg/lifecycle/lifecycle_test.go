@@ -0,0 +1,123 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger 测试中丢弃日志
+var testLogger = slog.New(slog.DiscardHandler)
+
+// recordHook 记录启动和停止顺序的钩子
+func recordHook(name string, calls *[]string, deps ...string) Hook {
+	return Hook{
+		Name:      name,
+		DependsOn: deps,
+		OnStart: func(context.Context) error {
+			*calls = append(*calls, "start:"+name)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			*calls = append(*calls, "stop:"+name)
+			return nil
+		},
+	}
+}
+
+func TestStartStopInDependencyOrder(t *testing.T) {
+	var calls []string
+	m := New(testLogger)
+	// 依赖的组件晚于本组件注册
+	require.NoError(t, m.Append(recordHook("http", &calls, "database", "redis")))
+	require.NoError(t, m.Append(recordHook("scheduler", &calls, "database")))
+	require.NoError(t, m.Append(recordHook("database", &calls)))
+	require.NoError(t, m.Append(recordHook("redis", &calls)))
+
+	order, err := m.Order()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"database", "redis", "http", "scheduler"}, order)
+
+	assert.False(t, m.Ready())
+	require.NoError(t, m.Start(context.Background()))
+	assert.True(t, m.Ready())
+
+	require.NoError(t, m.Stop(context.Background()))
+	assert.False(t, m.Ready())
+	assert.Equal(t, []string{
+		"start:database", "start:redis", "start:http", "start:scheduler",
+		"stop:scheduler", "stop:http", "stop:redis", "stop:database",
+	}, calls)
+}
+
+func TestStartFailureRollsBack(t *testing.T) {
+	var calls []string
+	m := New(testLogger)
+	require.NoError(t, m.Append(recordHook("database", &calls)))
+	require.NoError(t, m.Append(recordHook("redis", &calls, "database")))
+	require.NoError(t, m.Append(Hook{
+		Name:      "http",
+		DependsOn: []string{"redis"},
+		OnStart:   func(context.Context) error { return errors.New("address already in use") },
+		OnStop: func(context.Context) error {
+			calls = append(calls, "stop:http")
+			return nil
+		},
+	}))
+
+	err := m.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "start http")
+	assert.False(t, m.Ready())
+	// 失败的组件不会被停止，已启动的按相反顺序停止
+	assert.Equal(t, []string{"start:database", "start:redis", "stop:redis", "stop:database"}, calls)
+}
+
+func TestStopContinuesOnError(t *testing.T) {
+	var calls []string
+	m := New(testLogger)
+	require.NoError(t, m.Append(recordHook("database", &calls)))
+	require.NoError(t, m.Append(Hook{
+		Name:      "worker",
+		DependsOn: []string{"database"},
+		OnStop:    func(context.Context) error { return errors.New("boom") },
+	}))
+	require.NoError(t, m.Start(context.Background()))
+
+	err := m.Stop(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stop worker")
+	assert.Equal(t, []string{"start:database", "stop:database"}, calls)
+
+	// 重复停止不会再次调用钩子
+	require.NoError(t, m.Stop(context.Background()))
+}
+
+func TestDependencyErrors(t *testing.T) {
+	m := New(testLogger)
+	require.NoError(t, m.Append(Hook{Name: "a", DependsOn: []string{"b"}}))
+	assert.ErrorIs(t, m.Append(Hook{Name: "a"}), ErrHookExists)
+
+	_, err := m.Order()
+	assert.ErrorIs(t, err, ErrUnknownDepends)
+
+	require.NoError(t, m.Append(Hook{Name: "b", DependsOn: []string{"a"}}))
+	assert.ErrorIs(t, m.Start(context.Background()), ErrCycle)
+}
+
+func TestWaitHonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	stop := Wait(func() { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, stop(ctx), context.DeadlineExceeded)
+
+	assert.NoError(t, Wait(func() {})(context.Background()))
+}
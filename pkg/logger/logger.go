@@ -6,7 +6,6 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"runtime"
 
 	"github.com/chenyl99x/toge-api/pkg/config"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -33,45 +32,44 @@ func (mw *MultiWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-var Logger *slog.Logger
-
-// InitLogger 初始化日志
-func InitLogger() {
-	var level slog.Level
-	switch config.GlobalConfig.Log.Level {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
-	}
-
+// New 根据日志配置创建日志器，日志会自动带上 context 中的 trace_id、span_id 和调用位置
+// level 由 NewLevel 创建，配置热更新时修改即可生效
+func New(logConfig config.LogConfig, level *slog.LevelVar) *slog.Logger {
 	// 根据配置选择输出目标
-	writer := createLogWriter(config.GlobalConfig.Log)
+	writer := createLogWriter(logConfig)
+	options := &slog.HandlerOptions{Level: level}
 
 	var handler slog.Handler
-	switch config.GlobalConfig.Log.Format {
-	case "json":
-		handler = slog.NewJSONHandler(writer, &slog.HandlerOptions{
-			Level: level,
-		})
+	switch logConfig.Format {
 	case "text":
-		handler = slog.NewTextHandler(writer, &slog.HandlerOptions{
-			Level: level,
-		})
+		handler = slog.NewTextHandler(writer, options)
 	default:
-		handler = slog.NewJSONHandler(writer, &slog.HandlerOptions{
-			Level: level,
-		})
+		handler = slog.NewJSONHandler(writer, options)
 	}
+	return slog.New(NewTraceHandler(handler))
+}
+
+// NewLevel 创建可在运行时修改的日志级别
+func NewLevel(logConfig config.LogConfig) *slog.LevelVar {
+	level := new(slog.LevelVar)
+	level.Set(ParseLevel(logConfig.Level))
+	return level
+}
 
-	Logger = slog.New(handler)
-	slog.SetDefault(Logger)
+// ParseLevel 解析日志级别，未知级别使用 info
+func ParseLevel(name string) slog.Level {
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
 }
 
 // createLogWriter 创建日志写入器
@@ -159,99 +157,39 @@ func ensureLogDir(logPath string) error {
 	return os.MkdirAll(dir, 0755)
 }
 
-// getCallerInfo 获取调用者信息
-func getCallerInfo(skip int) (file string, line int, function string) {
-	// 获取调用栈信息，跳过指定数量的函数
-	pc, file, line, ok := runtime.Caller(skip)
-	if !ok {
-		return "unknown", 0, "unknown"
-	}
-
-	// 获取函数名
-	fn := runtime.FuncForPC(pc)
-	if fn == nil {
-		return filepath.Base(file), line, "unknown"
-	}
-
-	// 获取相对路径的文件名
-	fileName := filepath.Base(file)
-
-	// 获取函数名（去掉包路径）
-	funcName := fn.Name()
-	if idx := filepath.Ext(funcName); idx != "" {
-		funcName = funcName[:len(funcName)-len(idx)]
-	}
-
-	return fileName, line, funcName
-}
-
-// Debug 记录调试日志
-func Debug(msg string, args ...any) {
-	file, line, function := getCallerInfo(2)
-	Logger.With("file", file, "line", line, "function", function).Debug(msg, args...)
-}
-
-// Info 记录信息日志
-func Info(msg string, args ...any) {
-	file, line, function := getCallerInfo(2)
-	Logger.With("file", file, "line", line, "function", function).Info(msg, args...)
-}
-
-// Warn 记录警告日志
-func Warn(msg string, args ...any) {
-	file, line, function := getCallerInfo(2)
-	Logger.With("file", file, "line", line, "function", function).Warn(msg, args...)
-}
-
-// Error 记录错误日志
-func Error(msg string, args ...any) {
-	file, line, function := getCallerInfo(2)
-	Logger.With("file", file, "line", line, "function", function).Error(msg, args...)
-}
-
-// WithGroup 创建带分组的日志器
-func WithGroup(name string) *slog.Logger {
-	return Logger.WithGroup(name)
-}
-
-// With 创建带字段的日志器
-func With(args ...any) *slog.Logger {
-	return Logger.With(args...)
-}
-
 // GetLogStatus 获取日志状态信息
-func GetLogStatus() map[string]interface{} {
+func GetLogStatus(logConfig config.LogConfig) map[string]interface{} {
 	status := map[string]interface{}{
-		"level":  config.GlobalConfig.Log.Level,
-		"format": config.GlobalConfig.Log.Format,
-		"output": config.GlobalConfig.Log.Output,
+		"level":  logConfig.Level,
+		"format": logConfig.Format,
+		"output": logConfig.Output,
 	}
 
 	// 解析输出目标
-	outputs := parseOutputTargets(config.GlobalConfig.Log.Output)
+	outputs := parseOutputTargets(logConfig.Output)
 	status["targets"] = outputs
 
 	// 如果是文件输出或包含文件输出，添加文件信息
-	if config.GlobalConfig.Log.Output == "file" || config.GlobalConfig.Log.Output == "both" || config.GlobalConfig.Log.Output == "all" {
-		fileInfo, err := os.Stat(config.GlobalConfig.Log.File.Path)
+	if logConfig.Output == "file" || logConfig.Output == "both" || logConfig.Output == "all" {
+		fileInfo, err := os.Stat(logConfig.File.Path)
 		if err == nil {
 			status["file"] = map[string]interface{}{
-				"path":     config.GlobalConfig.Log.File.Path,
+				"path":     logConfig.File.Path,
 				"size":     fileInfo.Size(),
 				"mod_time": fileInfo.ModTime(),
 			}
 		} else {
 			status["file"] = map[string]interface{}{
-				"path":  config.GlobalConfig.Log.File.Path,
+				"path":  logConfig.File.Path,
 				"error": "file not found or not accessible",
 			}
 		}
 
 		// 添加轮转配置
 		status["rotation"] = map[string]interface{}{
-			"max_size":    config.GlobalConfig.Log.File.MaxSize,
-			"max_age":     config.GlobalConfig.Log.File.MaxAge,
-			"max_backups": config.GlobalConfig.Log.File.MaxBackups,
+			"max_size":    logConfig.File.MaxSize,
+			"max_age":     logConfig.File.MaxAge,
+			"max_backups": logConfig.File.MaxBackups,
 		}
 	}
 
@@ -277,9 +215,7 @@ func parseOutputTargets(output string) []string {
 }
 
 // ValidateLogConfig 验证日志配置
-func ValidateLogConfig() error {
-	logConfig := config.GlobalConfig.Log
-
+func ValidateLogConfig(logConfig config.LogConfig) error {
 	// 验证日志级别
 	validLevels := map[string]bool{
 		"debug": true, "info": true, "warn": true, "error": true,
@@ -5,6 +5,11 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TraceID 类型定义
@@ -26,65 +31,76 @@ func GenerateTraceID() TraceID {
 }
 
 // GetTraceID 从 context 中获取 traceId
+// 存在有效的 OpenTelemetry span 时返回其 trace ID，日志与链路追踪使用同一个 ID
 func GetTraceID(ctx context.Context) TraceID {
 	if ctx == nil {
 		return ""
 	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return TraceID(spanContext.TraceID().String())
+	}
 	if traceID, ok := ctx.Value(TraceIDKey).(TraceID); ok {
 		return traceID
 	}
 	return ""
 }
 
+// GetSpanID 从 context 中获取当前 OpenTelemetry span ID，没有 span 时为空
+func GetSpanID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasSpanID() {
+		return spanContext.SpanID().String()
+	}
+	return ""
+}
+
 // WithTraceID 为 context 添加 traceId
 func WithTraceID(ctx context.Context, traceID TraceID) context.Context {
 	return context.WithValue(ctx, TraceIDKey, traceID)
 }
 
-// WithTraceIDFromContext 从 context 创建带 traceId 的日志器
-func WithTraceIDFromContext(ctx context.Context) *slog.Logger {
-	traceID := GetTraceID(ctx)
-	if traceID == "" || Logger == nil {
-		return Logger
-	}
-
-	// 获取调用者信息
-	file, line, function := getCallerInfo(3)
-
-	return Logger.With(
-		"trace_id", string(traceID),
-		"file", file,
-		"line", line,
-		"function", function,
-	)
-}
-
-// DebugWithTrace 记录带 traceId 的调试日志
-func DebugWithTrace(ctx context.Context, msg string, args ...any) {
-	logger := WithTraceIDFromContext(ctx)
-	logger.Debug(msg, args...)
+// traceHandler 为每条日志添加 context 中的 trace_id、span_id 和调用位置
+// 使用 InfoContext 等带 context 的方法记录日志时才能取到 trace_id
+type traceHandler struct {
+	slog.Handler
 }
 
-// InfoWithTrace 记录带 traceId 的信息日志
-func InfoWithTrace(ctx context.Context, msg string, args ...any) {
-	logger := WithTraceIDFromContext(ctx)
-	logger.Info(msg, args...)
+// NewTraceHandler 包装 handler，自动添加链路信息
+func NewTraceHandler(handler slog.Handler) slog.Handler {
+	return traceHandler{Handler: handler}
 }
 
-// WarnWithTrace 记录带 traceId 的警告日志
-func WarnWithTrace(ctx context.Context, msg string, args ...any) {
-	logger := WithTraceIDFromContext(ctx)
-	logger.Warn(msg, args...)
+// Handle 实现 slog.Handler
+func (h traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if traceID := GetTraceID(ctx); traceID != "" {
+		record.AddAttrs(slog.String("trace_id", string(traceID)))
+	}
+	if spanID := GetSpanID(ctx); spanID != "" {
+		record.AddAttrs(slog.String("span_id", spanID))
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		function := frame.Function
+		if idx := strings.LastIndex(function, "/"); idx >= 0 {
+			function = function[idx+1:]
+		}
+		record.AddAttrs(
+			slog.String("file", filepath.Base(frame.File)),
+			slog.Int("line", frame.Line),
+			slog.String("function", function),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
 }
 
-// ErrorWithTrace 记录带 traceId 的错误日志
-func ErrorWithTrace(ctx context.Context, msg string, args ...any) {
-	logger := WithTraceIDFromContext(ctx)
-	logger.Error(msg, args...)
+// WithAttrs 实现 slog.Handler
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithAttrs(attrs)}
 }
 
-// WithTrace 创建带 traceId 的日志器
-func WithTrace(ctx context.Context, args ...any) *slog.Logger {
-	logger := WithTraceIDFromContext(ctx)
-	return logger.With(args...)
+// WithGroup 实现 slog.Handler
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithGroup(name)}
 }
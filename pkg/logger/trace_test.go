@@ -1,10 +1,15 @@
 package logger
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestGenerateTraceID(t *testing.T) {
@@ -48,18 +53,42 @@ func TestGetTraceID(t *testing.T) {
 	assert.Equal(t, traceID, retrievedTraceID)
 }
 
-func TestWithTraceIDFromContext(t *testing.T) {
-	ctx := context.Background()
+func TestTraceHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewTraceHandler(slog.NewJSONHandler(&buf, nil)))
 
-	// 测试空 context
-	logger := WithTraceIDFromContext(ctx)
-	// 由于 Logger 未初始化，这里可能返回 nil，所以不检查
-	_ = logger
+	// 没有 traceId 时不添加 trace_id
+	logger.InfoContext(context.Background(), "no trace")
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotContains(t, entry, "trace_id")
+	assert.Equal(t, "trace_test.go", entry["file"])
+	assert.Equal(t, "logger.TestTraceHandler", entry["function"])
 
-	// 测试有 traceId 的 context
+	// 有 traceId 的 context
+	buf.Reset()
 	traceID := GenerateTraceID()
-	newCtx := WithTraceID(ctx, traceID)
-	logger = WithTraceIDFromContext(newCtx)
-	// 由于 Logger 未初始化，这里可能返回 nil，所以不检查
-	_ = logger
+	logger.With("component", "test").InfoContext(WithTraceID(context.Background(), traceID), "with trace")
+	entry = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, string(traceID), entry["trace_id"])
+	assert.Equal(t, "test", entry["component"])
+}
+
+func TestGetTraceIDFromSpanContext(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	// OpenTelemetry 的 trace ID 优先于 context 中保存的 traceId
+	ctx := WithTraceID(context.Background(), "legacy-trace")
+	ctx = trace.ContextWithSpanContext(ctx, spanContext)
+
+	assert.Equal(t, TraceID("4bf92f3577b34da6a3ce929d0e0e4736"), GetTraceID(ctx))
+	assert.Equal(t, "00f067aa0ba902b7", GetSpanID(ctx))
+	assert.Empty(t, GetSpanID(context.Background()))
 }
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名前缀
+const namespace = "toge"
+
+// Registry 应用指标注册表，不使用 prometheus 默认注册表，避免第三方库注册的指标混入
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by route template, method and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "SQL query latency by operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	dbQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Total number of failed SQL queries by operation.",
+	}, []string{"operation"})
+
+	redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis command latency by command.",
+		Buckets:   []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	redisCommandErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_command_errors_total",
+		Help:      "Total number of failed Redis commands by command.",
+	}, []string{"command"})
+
+	cacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Total number of cache lookups by cache and result (hit, miss, error).",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		httpRequestsInFlight,
+		dbQueryDuration,
+		dbQueryErrors,
+		redisCommandDuration,
+		redisCommandErrors,
+		cacheRequestsTotal,
+	)
+}
+
+// Handler 返回 Prometheus 文本格式的指标处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// HTTPRequestStarted 记录请求开始
+func HTTPRequestStarted() {
+	httpRequestsInFlight.Inc()
+}
+
+// HTTPRequestFinished 记录请求结束，route 应为路由模板而不是实际路径，避免标签基数爆炸
+func HTTPRequestFinished(method, route string, status int, elapsed time.Duration) {
+	httpRequestsInFlight.Dec()
+	code := strconv.Itoa(status)
+	httpRequestsTotal.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery 记录一次 SQL 查询
+func ObserveQuery(sql string, elapsed time.Duration, failed bool) {
+	operation := SQLOperation(sql)
+	dbQueryDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+	if failed {
+		dbQueryErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// SQLOperation 取 SQL 的第一个关键字作为操作类型，限制标签的取值范围
+func SQLOperation(sql string) string {
+	sql = strings.TrimLeft(sql, " \t\r\n(")
+	if i := strings.IndexAny(sql, " \t\r\n("); i > 0 {
+		sql = sql[:i]
+	}
+	switch op := strings.ToLower(sql); op {
+	case "select", "insert", "update", "delete", "begin", "commit", "rollback", "savepoint", "release",
+		"create", "alter", "drop", "show", "set", "with":
+		return op
+	default:
+		return "other"
+	}
+}
+
+// 缓存查询结果
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error" // Redis 不可用，直接查询数据库
+)
+
+// ObserveCache 记录一次缓存查询
+func ObserveCache(cache, result string) {
+	cacheRequestsTotal.WithLabelValues(cache, result).Inc()
+}
+
+var (
+	collectorMu       sync.Mutex
+	dbStatsCollectors = map[string]prometheus.Collector{}
+)
+
+// RegisterDBStats 注册数据库连接池指标，主库和每个从库使用不同的 name，同名重复调用时替换之前的连接池
+func RegisterDBStats(db *sql.DB, name string) {
+	collectorMu.Lock()
+	defer collectorMu.Unlock()
+
+	if previous, ok := dbStatsCollectors[name]; ok {
+		Registry.Unregister(previous)
+	}
+	collector := collectors.NewDBStatsCollector(db, name)
+	Registry.MustRegister(collector)
+	dbStatsCollectors[name] = collector
+}
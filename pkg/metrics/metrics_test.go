@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLOperation(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM `user` WHERE id = 1":        "select",
+		"  insert INTO `space` (`name`) VALUES (?)": "insert",
+		"UPDATE `user` SET `name`=?":                "update",
+		"DELETE FROM `webhook` WHERE id = 1":        "delete",
+		"(SELECT 1) UNION (SELECT 2)":               "select",
+		"SAVEPOINT sp1":                             "savepoint",
+		"EXPLAIN SELECT 1":                          "other",
+		"":                                          "other",
+	}
+	for sql, expected := range cases {
+		assert.Equal(t, expected, SQLOperation(sql), sql)
+	}
+}
+
+func TestHTTPAndQueryMetrics(t *testing.T) {
+	HTTPRequestStarted()
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequestsInFlight))
+	HTTPRequestFinished(http.MethodGet, "/users/:id", http.StatusOK, 15*time.Millisecond)
+	assert.Equal(t, float64(0), testutil.ToFloat64(httpRequestsInFlight))
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "/users/:id", "200")))
+
+	before := testutil.ToFloat64(dbQueryErrors.WithLabelValues("update"))
+	ObserveQuery("UPDATE `user` SET name = ?", time.Millisecond, true)
+	ObserveQuery("UPDATE `user` SET name = ?", time.Millisecond, false)
+	assert.Equal(t, before+1, testutil.ToFloat64(dbQueryErrors.WithLabelValues("update")))
+
+	// 指标以 Prometheus 文本格式输出
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `toge_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="0.025"} 1`)
+	assert.Contains(t, body, `toge_db_query_duration_seconds_count{operation="update"}`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestRedisHook(t *testing.T) {
+	hook := RedisHook{}
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		return cmd.Err()
+	})
+
+	before := testutil.ToFloat64(redisCommandErrors.WithLabelValues("get"))
+
+	missing := redis.NewStringCmd(context.Background(), "GET", "missing")
+	missing.SetErr(redis.Nil)
+	assert.ErrorIs(t, process(context.Background(), missing), redis.Nil)
+
+	failed := redis.NewStringCmd(context.Background(), "GET", "key")
+	failed.SetErr(errors.New("connection reset"))
+	assert.Error(t, process(context.Background(), failed))
+
+	// key 不存在不计为错误
+	assert.Equal(t, before+1, testutil.ToFloat64(redisCommandErrors.WithLabelValues("get")))
+	assert.Equal(t, 1, testutil.CollectAndCount(redisCommandDuration, "toge_redis_command_duration_seconds"))
+}
+
+func TestRegisterRedisPool(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer client.Close()
+	RegisterRedisPool(client)
+	RegisterRedisPool(client)
+
+	families, err := Registry.Gather()
+	require.NoError(t, err)
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	assert.Contains(t, names, "toge_redis_pool_connections")
+}
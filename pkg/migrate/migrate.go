@@ -1,16 +1,18 @@
 package migrate
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/chenyl99x/toge-api/internal/model"
-	"github.com/chenyl99x/toge-api/pkg/database"
-	"github.com/chenyl99x/toge-api/pkg/logger"
+
+	"gorm.io/gorm"
 )
 
 // MigrationFunc 迁移函数类型
-type MigrationFunc func() error
+type MigrationFunc func(db *gorm.DB) error
 
 // Migration 迁移结构
 type Migration struct {
@@ -25,29 +27,189 @@ var migrations = []Migration{
 	{
 		Version:     "013",
 		Description: "Create initial tables",
-		Up: func() error {
-			return database.DB.AutoMigrate(
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(
 				&model.User{},
 				&model.Space{},
 			)
 		},
-		Down: func() error {
-			return database.DB.Migrator().DropTable(
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(
 				&model.User{},
+				&model.Space{},
+			)
+		},
+	},
+	{
+		Version:     "014",
+		Description: "Create notification tables",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(
+				&model.Notification{},
+				&model.NotificationPreference{},
+				&model.NotificationSetting{},
+			)
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(
+				&model.Notification{},
+				&model.NotificationPreference{},
+				&model.NotificationSetting{},
+			)
+		},
+	},
+	{
+		Version:     "015",
+		Description: "Create outbox event table",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(
+				&model.OutboxEvent{},
+			)
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(
+				&model.OutboxEvent{},
+			)
+		},
+	},
+	{
+		Version:     "016",
+		Description: "Create webhook tables",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(
+				&model.Webhook{},
+				&model.WebhookDelivery{},
+			)
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(
+				&model.Webhook{},
+				&model.WebhookDelivery{},
+			)
+		},
+	},
+	{
+		Version:     "017",
+		Description: "Add outbox event occurred_at index",
+		Up: func(db *gorm.DB) error {
+			// 新建的库在 015 中已按模型创建索引
+			if db.Migrator().HasIndex(&model.OutboxEvent{}, "idx_outbox_event_occurred_at") {
+				return nil
+			}
+			return db.Migrator().CreateIndex(&model.OutboxEvent{}, "idx_outbox_event_occurred_at")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropIndex(&model.OutboxEvent{}, "idx_outbox_event_occurred_at")
+		},
+	},
+	{
+		Version:     "018",
+		Description: "Create user import job table",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(
+				&model.UserImportJob{},
+			)
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(
+				&model.UserImportJob{},
 			)
 		},
 	},
+	{
+		Version:     "019",
+		Description: "Add version column to users and space",
+		Up: func(db *gorm.DB) error {
+			// 已有记录按默认值从版本 1 开始
+			for _, m := range []any{&model.User{}, &model.Space{}} {
+				if db.Migrator().HasColumn(m, "Version") {
+					continue
+				}
+				if err := db.Migrator().AddColumn(m, "Version"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(db *gorm.DB) error {
+			for _, m := range []any{&model.User{}, &model.Space{}} {
+				if err := db.Migrator().DropColumn(m, "Version"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     "020",
+		Description: "Add locale column to users",
+		Up: func(db *gorm.DB) error {
+			if db.Migrator().HasColumn(&model.User{}, "Locale") {
+				return nil
+			}
+			return db.Migrator().AddColumn(&model.User{}, "Locale")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropColumn(&model.User{}, "Locale")
+		},
+	},
+	{
+		Version:     "021",
+		Description: "Create space member table",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(
+				&model.SpaceMember{},
+			)
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(
+				&model.SpaceMember{},
+			)
+		},
+	},
+	{
+		Version:     "022",
+		Description: "Add dedup key column to notification",
+		Up: func(db *gorm.DB) error {
+			if !db.Migrator().HasColumn(&model.Notification{}, "DedupKey") {
+				if err := db.Migrator().AddColumn(&model.Notification{}, "DedupKey"); err != nil {
+					return err
+				}
+			}
+			if db.Migrator().HasIndex(&model.Notification{}, "DedupKey") {
+				return nil
+			}
+			return db.Migrator().CreateIndex(&model.Notification{}, "DedupKey")
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropIndex(&model.Notification{}, "DedupKey"); err != nil {
+				return err
+			}
+			return db.Migrator().DropColumn(&model.Notification{}, "DedupKey")
+		},
+	},
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+// New 创建迁移执行器
+func New(db *gorm.DB, log *slog.Logger) *Migrator {
+	return &Migrator{db: db, log: log}
 }
 
 // RunMigrations 执行所有未应用的迁移
-func RunMigrations() error {
+func (m *Migrator) RunMigrations() error {
 	// 首先确保 migrations 表存在
-	if err := database.DB.AutoMigrate(&model.Migration{}); err != nil {
+	if err := m.db.AutoMigrate(&model.Migration{}); err != nil {
 		return fmt.Errorf("failed to create migrations table: %v", err)
 	}
 
 	// 获取已应用的迁移
-	appliedMigrations, err := getAppliedMigrations()
+	appliedMigrations, err := m.getAppliedMigrations()
 	if err != nil {
 		return fmt.Errorf("failed to get applied migrations: %v", err)
 	}
@@ -55,20 +217,21 @@ func RunMigrations() error {
 	// 执行未应用的迁移
 	for _, migration := range migrations {
 		if isApplied(appliedMigrations, migration.Version) {
-			logger.Info("Migration already applied", "version", migration.Version)
+			m.log.Info("Migration already applied", "version", migration.Version)
 			continue
 		}
 
-		logger.Info("Applying migration", "version", migration.Version, "description", migration.Description)
+		m.log.Info("Applying migration", "version", migration.Version, "description", migration.Description)
 
 		// 开始事务
-		tx := database.DB.Begin()
+		tx := m.db.Begin()
 		if tx.Error != nil {
 			return fmt.Errorf("failed to start transaction: %v", tx.Error)
 		}
 
-		// 执行迁移
-		if err := migration.Up(); err != nil {
+		// 在同一事务中执行迁移，PostgreSQL 和 SQLite 的 DDL 可以随事务回滚，
+		// SQLite 只有一个连接，事务外执行会一直等待事务释放连接
+		if err := migration.Up(tx); err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to apply migration %s: %v", migration.Version, err)
 		}
@@ -90,19 +253,19 @@ func RunMigrations() error {
 			return fmt.Errorf("failed to commit migration %s: %v", migration.Version, err)
 		}
 
-		logger.Info("Migration applied successfully", "version", migration.Version)
+		m.log.Info("Migration applied successfully", "version", migration.Version)
 	}
 
 	return nil
 }
 
 // RollbackMigration 回滚指定版本的迁移
-func RollbackMigration(version string) error {
+func (m *Migrator) RollbackMigration(version string) error {
 	// 查找迁移
 	var migration *Migration
-	for _, m := range migrations {
-		if m.Version == version {
-			migration = &m
+	for i := range migrations {
+		if migrations[i].Version == version {
+			migration = &migrations[i]
 			break
 		}
 	}
@@ -112,7 +275,7 @@ func RollbackMigration(version string) error {
 	}
 
 	// 检查是否已应用
-	appliedMigrations, err := getAppliedMigrations()
+	appliedMigrations, err := m.getAppliedMigrations()
 	if err != nil {
 		return fmt.Errorf("failed to get applied migrations: %v", err)
 	}
@@ -121,16 +284,16 @@ func RollbackMigration(version string) error {
 		return fmt.Errorf("migration %s is not applied", version)
 	}
 
-	logger.Info("Rolling back migration", "version", version)
+	m.log.Info("Rolling back migration", "version", version)
 
 	// 开始事务
-	tx := database.DB.Begin()
+	tx := m.db.Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("failed to start transaction: %v", tx.Error)
 	}
 
 	// 执行回滚
-	if err := migration.Down(); err != nil {
+	if err := migration.Down(tx); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to rollback migration %s: %v", version, err)
 	}
@@ -146,23 +309,39 @@ func RollbackMigration(version string) error {
 		return fmt.Errorf("failed to commit rollback %s: %v", version, err)
 	}
 
-	logger.Info("Migration rolled back successfully", "version", version)
+	m.log.Info("Migration rolled back successfully", "version", version)
 	return nil
 }
 
 // GetMigrationStatus 获取迁移状态
-func GetMigrationStatus() ([]model.Migration, error) {
+func (m *Migrator) GetMigrationStatus() ([]model.Migration, error) {
 	var migrations []model.Migration
-	if err := database.DB.Order("applied_at").Find(&migrations).Error; err != nil {
+	if err := m.db.Order("applied_at").Find(&migrations).Error; err != nil {
 		return nil, err
 	}
 	return migrations, nil
 }
 
+// PendingMigrations 获取尚未执行的迁移版本
+func (m *Migrator) PendingMigrations(ctx context.Context) ([]string, error) {
+	var applied []string
+	if err := m.db.WithContext(ctx).Model(&model.Migration{}).Pluck("version", &applied).Error; err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, migration := range migrations {
+		if !isApplied(applied, migration.Version) {
+			pending = append(pending, migration.Version)
+		}
+	}
+	return pending, nil
+}
+
 // getAppliedMigrations 获取已应用的迁移版本
-func getAppliedMigrations() ([]string, error) {
+func (m *Migrator) getAppliedMigrations() ([]string, error) {
 	var versions []string
-	if err := database.DB.Model(&model.Migration{}).Pluck("version", &versions).Error; err != nil {
+	if err := m.db.Model(&model.Migration{}).Pluck("version", &versions).Error; err != nil {
 		return nil, err
 	}
 	return versions, nil
@@ -179,17 +358,24 @@ func isApplied(appliedMigrations []string, version string) bool {
 }
 
 // DropTables 删除所有表（谨慎使用）
-func DropTables() error {
-	return database.DB.Migrator().DropTable(
+func (m *Migrator) DropTables() error {
+	return m.db.Migrator().DropTable(
 		&model.User{},
+		&model.Space{},
+		&model.Notification{},
+		&model.NotificationPreference{},
+		&model.NotificationSetting{},
+		&model.OutboxEvent{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
 		&model.Migration{},
 	)
 }
 
 // ResetDatabase 重置数据库（删除并重新创建表）
-func ResetDatabase() error {
-	if err := DropTables(); err != nil {
+func (m *Migrator) ResetDatabase() error {
+	if err := m.DropTables(); err != nil {
 		return err
 	}
-	return RunMigrations()
+	return m.RunMigrations()
 }
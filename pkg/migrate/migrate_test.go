@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/chenyl99x/toge-api/internal/model"
+	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMigrator 使用 SQLite 内存数据库执行迁移
+func newTestMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	log := slog.New(slog.DiscardHandler)
+	db, err := database.New(config.DatabaseConfig{Driver: config.DriverSQLite, Database: ":memory:"}, config.LogConfig{}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close(db) })
+	return New(db, log)
+}
+
+func TestRunAndRollbackMigrations(t *testing.T) {
+	m := newTestMigrator(t)
+	ctx := context.Background()
+
+	require.NoError(t, m.RunMigrations())
+	pending, err := m.PendingMigrations(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// 重复执行不会重新应用
+	require.NoError(t, m.RunMigrations())
+
+	last := migrations[len(migrations)-1].Version
+	require.NoError(t, m.RollbackMigration(last))
+	pending, err = m.PendingMigrations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{last}, pending)
+
+	assert.ErrorContains(t, m.RollbackMigration(last), "is not applied")
+	assert.ErrorContains(t, m.RollbackMigration("999"), "not found")
+}
+
+func TestResetDatabase(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.RunMigrations())
+	require.NoError(t, m.db.Create(&model.User{Username: "alice", Email: "alice@example.com", Password: "hashed"}).Error)
+
+	require.NoError(t, m.ResetDatabase())
+
+	var count int64
+	require.NoError(t, m.db.Model(&model.User{}).Count(&count).Error)
+	assert.Zero(t, count)
+	status, err := m.GetMigrationStatus()
+	require.NoError(t, err)
+	assert.Len(t, status, len(migrations))
+}
@@ -0,0 +1,62 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+)
+
+// EmailChannel 通过 SMTP 发送邮件通知
+type EmailChannel struct {
+	cfg  config.EmailConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailChannel 创建邮件渠道
+func NewEmailChannel(cfg config.EmailConfig) *EmailChannel {
+	return &EmailChannel{cfg: cfg, send: smtp.SendMail}
+}
+
+// Name 渠道名称
+func (e *EmailChannel) Name() string {
+	return ChannelEmail
+}
+
+// Send 发送邮件
+func (e *EmailChannel) Send(ctx context.Context, msg *Message) error {
+	if msg.Recipient.Email == "" {
+		return errors.New("recipient email is empty")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+
+	body := msg.Content
+	if msg.Link != "" {
+		body += "\r\n\r\n" + msg.Link
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.Recipient.Email)
+	// 标题按 RFC 2047 编码，包含换行时不会被解析为额外的邮件头
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(body)
+
+	if err := e.send(e.cfg.GetSMTPAddr(), auth, e.cfg.From, []string{msg.Recipient.Email}, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeChannel 用于测试的投递渠道，记录所有收到的消息
+type FakeChannel struct {
+	name string
+
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+// NewFakeChannel 创建测试渠道
+func NewFakeChannel(name string) *FakeChannel {
+	return &FakeChannel{name: name}
+}
+
+// Name 渠道名称
+func (f *FakeChannel) Name() string {
+	return f.name
+}
+
+// Send 记录消息，如果设置了错误则返回该错误
+func (f *FakeChannel) Send(ctx context.Context, msg *Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.messages = append(f.messages, *msg)
+	return nil
+}
+
+// FailWith 让后续投递返回指定错误，传 nil 恢复正常
+func (f *FakeChannel) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Messages 返回已收到的消息副本
+func (f *FakeChannel) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := make([]Message, len(f.messages))
+	copy(messages, f.messages)
+	return messages
+}
+
+// Reset 清空已收到的消息
+func (f *FakeChannel) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = nil
+}
@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 渠道名称
+const (
+	ChannelInApp = "in_app" // 站内信
+	ChannelEmail = "email"  // 邮件
+	ChannelPush  = "push"   // 移动推送
+)
+
+// Recipient 通知接收人
+type Recipient struct {
+	UserID uint
+	Email  string
+}
+
+// Message 通知消息
+type Message struct {
+	Recipient Recipient
+	Category  string
+	Title     string
+	Content   string
+	Link      string
+	DedupKey  string // 去重键，相同键的消息重复投递时渠道应跳过已写入的记录，为空表示不去重
+}
+
+// Channel 通知投递渠道
+type Channel interface {
+	// Name 渠道名称，与 Preference 中的渠道开关对应
+	Name() string
+	// Send 投递消息
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Preference 单条消息的投递偏好
+type Preference struct {
+	Channels   map[string]bool // 渠道开关，未出现的渠道视为关闭
+	QuietHours *QuietHours     // 免打扰时段，为空表示不启用
+}
+
+// Enabled 检查渠道是否开启
+func (p *Preference) Enabled(channel string) bool {
+	if p == nil || p.Channels == nil {
+		return false
+	}
+	return p.Channels[channel]
+}
+
+// Result 投递结果
+type Result struct {
+	Delivered []string         // 投递成功的渠道
+	Skipped   []string         // 因偏好或免打扰被跳过的渠道
+	Failed    map[string]error // 投递失败的渠道
+}
+
+// Dispatcher 通知分发器，根据偏好和免打扰时段选择渠道
+type Dispatcher struct {
+	mu       sync.RWMutex
+	channels []Channel
+	now      func() time.Time
+}
+
+// NewDispatcher 创建通知分发器
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	return &Dispatcher{
+		channels: channels,
+		now:      time.Now,
+	}
+}
+
+// Register 注册投递渠道
+func (d *Dispatcher) Register(channel Channel) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels = append(d.channels, channel)
+}
+
+// Channels 返回已注册的渠道名称
+func (d *Dispatcher) Channels() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	names := make([]string, 0, len(d.channels))
+	for _, ch := range d.channels {
+		names = append(names, ch.Name())
+	}
+	return names
+}
+
+// Dispatch 按偏好投递消息
+// 免打扰时段内只投递站内信，邮件和推送会被跳过
+func (d *Dispatcher) Dispatch(ctx context.Context, msg *Message, pref *Preference) (*Result, error) {
+	if msg == nil {
+		return nil, errors.New("message is required")
+	}
+
+	quiet := false
+	if pref != nil && pref.QuietHours != nil {
+		in, err := pref.QuietHours.Contains(d.now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hours: %v", err)
+		}
+		quiet = in
+	}
+
+	d.mu.RLock()
+	channels := make([]Channel, len(d.channels))
+	copy(channels, d.channels)
+	d.mu.RUnlock()
+
+	result := &Result{Failed: map[string]error{}}
+	for _, ch := range channels {
+		name := ch.Name()
+		if !pref.Enabled(name) || (quiet && name != ChannelInApp) {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if err := ch.Send(ctx, msg); err != nil {
+			result.Failed[name] = err
+			continue
+		}
+		result.Delivered = append(result.Delivered, name)
+	}
+
+	if len(result.Failed) > 0 {
+		return result, fmt.Errorf("failed to deliver to %d channel(s)", len(result.Failed))
+	}
+	return result, nil
+}
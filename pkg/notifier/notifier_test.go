@@ -0,0 +1,131 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(now time.Time) (*Dispatcher, *FakeChannel, *FakeChannel, *FakeChannel) {
+	inApp := NewFakeChannel(ChannelInApp)
+	email := NewFakeChannel(ChannelEmail)
+	push := NewFakeChannel(ChannelPush)
+	d := NewDispatcher(inApp, email, push)
+	d.now = func() time.Time { return now }
+	return d, inApp, email, push
+}
+
+func TestDispatchRespectsPreference(t *testing.T) {
+	d, inApp, email, push := newTestDispatcher(time.Now())
+	msg := &Message{Recipient: Recipient{UserID: 1}, Category: "comment", Title: "新评论"}
+	pref := &Preference{Channels: map[string]bool{ChannelInApp: true, ChannelPush: true}}
+
+	result, err := d.Dispatch(context.Background(), msg, pref)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{ChannelInApp, ChannelPush}, result.Delivered)
+	assert.Equal(t, []string{ChannelEmail}, result.Skipped)
+
+	assert.Len(t, inApp.Messages(), 1)
+	assert.Len(t, push.Messages(), 1)
+	assert.Empty(t, email.Messages())
+	assert.Equal(t, "新评论", inApp.Messages()[0].Title)
+}
+
+func TestDispatchQuietHours(t *testing.T) {
+	// 2025-01-01 23:30 Asia/Shanghai == 15:30 UTC
+	now := time.Date(2025, 1, 1, 15, 30, 0, 0, time.UTC)
+	d, inApp, email, push := newTestDispatcher(now)
+	pref := &Preference{
+		Channels:   map[string]bool{ChannelInApp: true, ChannelEmail: true, ChannelPush: true},
+		QuietHours: &QuietHours{Start: "22:00", End: "07:00", Timezone: "Asia/Shanghai"},
+	}
+
+	result, err := d.Dispatch(context.Background(), &Message{Category: "reminder"}, pref)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ChannelInApp}, result.Delivered)
+	assert.Len(t, inApp.Messages(), 1)
+	assert.Empty(t, email.Messages())
+	assert.Empty(t, push.Messages())
+}
+
+func TestDispatchChannelFailure(t *testing.T) {
+	d, inApp, _, push := newTestDispatcher(time.Now())
+	push.FailWith(errors.New("gateway down"))
+	pref := &Preference{Channels: map[string]bool{ChannelInApp: true, ChannelPush: true}}
+
+	result, err := d.Dispatch(context.Background(), &Message{}, pref)
+	assert.Error(t, err)
+	assert.Contains(t, result.Failed, ChannelPush)
+	assert.Len(t, inApp.Messages(), 1)
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	tests := []struct {
+		name     string
+		quiet    QuietHours
+		at       time.Time
+		expected bool
+	}{
+		{"overnight inside before midnight", QuietHours{"22:00", "07:00", "Asia/Shanghai"}, time.Date(2025, 1, 1, 23, 0, 0, 0, shanghai), true},
+		{"overnight inside after midnight", QuietHours{"22:00", "07:00", "Asia/Shanghai"}, time.Date(2025, 1, 2, 6, 59, 0, 0, shanghai), true},
+		{"overnight outside", QuietHours{"22:00", "07:00", "Asia/Shanghai"}, time.Date(2025, 1, 2, 7, 0, 0, 0, shanghai), false},
+		{"same day inside", QuietHours{"12:00", "14:00", "Asia/Shanghai"}, time.Date(2025, 1, 1, 13, 0, 0, 0, shanghai), true},
+		{"same day outside", QuietHours{"12:00", "14:00", "Asia/Shanghai"}, time.Date(2025, 1, 1, 14, 30, 0, 0, shanghai), false},
+		{"converts timezone", QuietHours{"22:00", "07:00", "Asia/Shanghai"}, time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC), true},
+		{"empty range", QuietHours{"08:00", "08:00", "UTC"}, time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := tt.quiet.Contains(tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, in)
+		})
+	}
+}
+
+func TestQuietHoursValidate(t *testing.T) {
+	assert.NoError(t, (&QuietHours{"22:00", "07:00", "UTC"}).Validate())
+	assert.Error(t, (&QuietHours{"25:00", "07:00", "UTC"}).Validate())
+	assert.Error(t, (&QuietHours{"22:00", "7", "UTC"}).Validate())
+	assert.Error(t, (&QuietHours{"22:00", "07:00", "Invalid/Zone"}).Validate())
+}
+
+func TestEmailChannelEncodesSubject(t *testing.T) {
+	var sent string
+	e := NewEmailChannel(config.EmailConfig{Host: "smtp.example.com", Port: 25, From: "noreply@example.com"})
+	e.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = string(msg)
+		return nil
+	}
+
+	msg := &Message{
+		Recipient: Recipient{Email: "alice@example.com"},
+		Title:     "hello\r\nBcc: victim@example.com",
+		Content:   "body",
+	}
+	require.NoError(t, e.Send(context.Background(), msg))
+
+	header, body, ok := strings.Cut(sent, "\r\n\r\n")
+	require.True(t, ok)
+	assert.Equal(t, "body", body)
+	assert.NotContains(t, header, "\r\nBcc:")
+	assert.Contains(t, header, "Subject: =?utf-8?q?")
+
+	// 中文标题编码，纯 ASCII 标题保持原样
+	msg.Title = "新评论"
+	require.NoError(t, e.Send(context.Background(), msg))
+	assert.Contains(t, sent, "Subject: =?utf-8?q?")
+	msg.Title = "Weekly digest"
+	require.NoError(t, e.Send(context.Background(), msg))
+	assert.Contains(t, sent, "Subject: Weekly digest\r\n")
+}
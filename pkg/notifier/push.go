@@ -0,0 +1,79 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/config"
+)
+
+// PushChannel 通过推送网关发送移动推送
+// 网关负责根据 user_id 查找设备并下发
+type PushChannel struct {
+	cfg    config.PushConfig
+	client *http.Client
+}
+
+// NewPushChannel 创建推送渠道
+func NewPushChannel(cfg config.PushConfig) *PushChannel {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &PushChannel{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 渠道名称
+func (p *PushChannel) Name() string {
+	return ChannelPush
+}
+
+// pushPayload 推送网关请求体
+type pushPayload struct {
+	UserID   uint   `json:"user_id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	Link     string `json:"link,omitempty"`
+}
+
+// Send 调用推送网关
+func (p *PushChannel) Send(ctx context.Context, msg *Message) error {
+	payload, err := json.Marshal(pushPayload{
+		UserID:   msg.Recipient.UserID,
+		Category: msg.Category,
+		Title:    msg.Title,
+		Body:     msg.Content,
+		Link:     msg.Link,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call push gateway: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("push gateway returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours 免打扰时段，Start/End 为用户时区下的 "HH:MM"
+// 支持跨午夜的时段，例如 22:00 - 07:00
+type QuietHours struct {
+	Start    string
+	End      string
+	Timezone string
+}
+
+// Validate 验证免打扰时段配置
+func (q *QuietHours) Validate() error {
+	if _, err := parseClock(q.Start); err != nil {
+		return fmt.Errorf("invalid start: %v", err)
+	}
+	if _, err := parseClock(q.End); err != nil {
+		return fmt.Errorf("invalid end: %v", err)
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", q.Timezone)
+	}
+	return nil
+}
+
+// Contains 检查给定时刻是否处于免打扰时段
+func (q *QuietHours) Contains(t time.Time) (bool, error) {
+	if err := q.Validate(); err != nil {
+		return false, err
+	}
+
+	loc, _ := time.LoadLocation(q.Timezone)
+	start, _ := parseClock(q.Start)
+	end, _ := parseClock(q.End)
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	switch {
+	case start == end:
+		// 起止相同视为未设置时段
+		return false, nil
+	case start < end:
+		return minute >= start && minute < end, nil
+	default:
+		// 跨午夜
+		return minute >= start || minute < end, nil
+	}
+}
+
+// parseClock 将 "HH:MM" 解析为当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,283 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor 游标格式错误、签名不匹配或排序字段不允许
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// tieBreaker 排序值相同时按主键排序，保证顺序稳定且游标位置唯一
+const tieBreaker = "id"
+
+// cursorToken 游标内容，记录上一页边界记录的排序值和 ID
+type cursorToken struct {
+	SortBy    string `json:"s"`
+	SortOrder string `json:"o"`
+	Type      string `json:"t"` // 排序值类型：time, string, int, uint, float, bool
+	Value     string `json:"v"`
+	ID        uint64 `json:"i"`
+	Prev      bool   `json:"p,omitempty"` // 向前翻页
+}
+
+// Cursors 游标签名器，游标对客户端不透明，签名防止伪造或篡改
+type Cursors struct {
+	key []byte
+}
+
+// NewCursors 创建游标签名器，签名密钥由 secret 派生，与其他用途的签名互不通用
+func NewCursors(secret string) *Cursors {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("pagination cursor"))
+	return &Cursors{key: mac.Sum(nil)}
+}
+
+// encode 序列化并签名游标
+func (c *Cursors) encode(token cursorToken) (string, error) {
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// decode 校验签名并解析游标
+func (c *Cursors) decode(cursor string) (*cursorToken, error) {
+	payloadPart, sigPart, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(payloadPart)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+	var token cursorToken
+	if err := json.Unmarshal(payload, &token); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &token, nil
+}
+
+func (c *Cursors) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// CursorQuery 一次游标分页查询，由 Cursors.Query 创建
+type CursorQuery struct {
+	SortBy    string
+	SortOrder string
+	Limit     int
+	after     *cursorToken // 为空表示第一页
+	value     any          // 游标排序值，已还原为原始类型
+	cursors   *Cursors
+}
+
+// Query 校验排序字段和游标，创建游标分页查询
+// 带游标时使用游标中的排序字段和方向，忽略请求中的排序参数，翻页时无需重复传递
+// 未指定排序字段时按 defaultSortBy 排序
+func (c *Cursors) Query(page *PageRequest, defaultSortBy string, allowedSortFields []string) (*CursorQuery, error) {
+	q := &CursorQuery{
+		SortBy:    page.GetSortBy(),
+		SortOrder: page.GetSortOrder(),
+		Limit:     min(max(page.Limit, 1), 100),
+		cursors:   c,
+	}
+	if page.Cursor != "" {
+		token, err := c.decode(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeValue(token.Type, token.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		q.SortBy, q.SortOrder, q.after, q.value = token.SortBy, token.SortOrder, token, value
+	}
+	if q.SortBy == "" {
+		q.SortBy = defaultSortBy
+	}
+	if q.SortOrder != "asc" {
+		q.SortOrder = "desc"
+	}
+	if !slices.Contains(allowedSortFields, q.SortBy) && q.SortBy != tieBreaker {
+		if q.after != nil {
+			return nil, ErrInvalidCursor
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSortField, q.SortBy)
+	}
+	return q, nil
+}
+
+// backward 是否向前翻页
+func (q *CursorQuery) backward() bool {
+	return q.after != nil && q.after.Prev
+}
+
+// Scope 游标分页的 GORM scope，按排序字段和主键排序，从游标位置开始多取一条用于判断是否还有更多
+// 向前翻页时反向排序，结果由 FindCursorPage 还原顺序
+func (q *CursorQuery) Scope(db *gorm.DB) *gorm.DB {
+	desc := q.SortOrder == "desc"
+	if q.backward() {
+		desc = !desc
+	}
+	op, dir := ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+
+	if q.after != nil {
+		if q.SortBy == tieBreaker {
+			db = db.Where(fmt.Sprintf("%s %s ?", tieBreaker, op), q.after.ID)
+		} else {
+			// 排序字段已通过白名单校验，可以直接拼接
+			db = db.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", q.SortBy, op, tieBreaker),
+				q.value, q.value, q.after.ID)
+		}
+	}
+	if q.SortBy != tieBreaker {
+		db = db.Order(q.SortBy + " " + dir)
+	}
+	return db.Order(tieBreaker + " " + dir).Limit(q.Limit + 1)
+}
+
+// CursorPage 游标分页查询结果中的翻页信息
+type CursorPage struct {
+	Limit      int
+	NextCursor string
+	PrevCursor string
+	HasNext    bool
+	HasPrev    bool
+}
+
+// FindCursorPage 在 db 已有的过滤条件上按游标查询一页，返回按请求方向排列的记录和前后页游标
+// 不统计总数，翻页结果不受翻页期间新增记录的影响
+func FindCursorPage[T any](db *gorm.DB, q *CursorQuery) ([]T, *CursorPage, error) {
+	var items []T
+	tx := db.Scopes(q.Scope).Find(&items)
+	if tx.Error != nil {
+		return nil, nil, tx.Error
+	}
+
+	more := len(items) > q.Limit
+	if more {
+		items = items[:q.Limit]
+	}
+	page := &CursorPage{Limit: q.Limit}
+	switch {
+	case q.after == nil:
+		page.HasNext = more
+	case q.backward():
+		slices.Reverse(items)
+		page.HasNext, page.HasPrev = true, more
+	default:
+		page.HasNext, page.HasPrev = more, true
+	}
+	if len(items) == 0 {
+		page.HasNext, page.HasPrev = false, false
+		return items, page, nil
+	}
+
+	var err error
+	if page.HasNext {
+		if page.NextCursor, err = q.cursor(tx, &items[len(items)-1], false); err != nil {
+			return nil, nil, err
+		}
+	}
+	if page.HasPrev {
+		if page.PrevCursor, err = q.cursor(tx, &items[0], true); err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, page, nil
+}
+
+// cursor 为边界记录生成游标，排序值和主键通过模型的 schema 读取
+func (q *CursorQuery) cursor(tx *gorm.DB, item any, prev bool) (string, error) {
+	s := tx.Statement.Schema
+	sortField, idField := s.LookUpField(q.SortBy), s.LookUpField(tieBreaker)
+	if sortField == nil || idField == nil {
+		return "", fmt.Errorf("cursor pagination: %s has no column %s or %s", s.Name, q.SortBy, tieBreaker)
+	}
+	rv := reflect.ValueOf(item).Elem()
+
+	id, _ := idField.ValueOf(tx.Statement.Context, rv)
+	idValue := reflect.ValueOf(id)
+	token := cursorToken{SortBy: q.SortBy, SortOrder: q.SortOrder, Prev: prev}
+	switch idValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		token.ID = uint64(idValue.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		token.ID = idValue.Uint()
+	default:
+		return "", fmt.Errorf("cursor pagination: unsupported primary key type %T", id)
+	}
+
+	value, _ := sortField.ValueOf(tx.Statement.Context, rv)
+	var err error
+	if token.Type, token.Value, err = encodeValue(value, sortField); err != nil {
+		return "", err
+	}
+	return q.cursors.encode(token)
+}
+
+// encodeValue 把排序值编码为带类型的字符串，解码后按原始类型作为查询参数
+func encodeValue(value any, field *schema.Field) (string, string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		return "", "", fmt.Errorf("cursor pagination: column %s is NULL", field.DBName)
+	}
+	if t, ok := rv.Interface().(time.Time); ok {
+		return "time", t.Format(time.RFC3339Nano), nil
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return "string", rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int", strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint", strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return "float", strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	case reflect.Bool:
+		return "bool", strconv.FormatBool(rv.Bool()), nil
+	}
+	return "", "", fmt.Errorf("cursor pagination: unsupported type %s for column %s", rv.Type(), field.DBName)
+}
+
+// decodeValue 按类型还原排序值
+func decodeValue(typ, value string) (any, error) {
+	switch typ {
+	case "time":
+		return time.Parse(time.RFC3339Nano, value)
+	case "string":
+		return value, nil
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "uint":
+		return strconv.ParseUint(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	}
+	return nil, fmt.Errorf("unknown cursor value type %q", typ)
+}
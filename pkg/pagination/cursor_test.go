@@ -0,0 +1,145 @@
+package pagination
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type cursorTestRecord struct {
+	ID        uint
+	Name      string
+	Score     int
+	CreatedAt time.Time
+}
+
+// newCursorTestDB 创建 10 条记录，score 每两条相同，用于验证排序值相同时按 id 区分
+func newCursorTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&cursorTestRecord{}))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 10; i++ {
+		require.NoError(t, db.Create(&cursorTestRecord{
+			Name:      fmt.Sprintf("record-%02d", i),
+			Score:     i / 2,
+			CreatedAt: base.Add(time.Duration(i) * time.Millisecond),
+		}).Error)
+	}
+	return db
+}
+
+func ids(records []cursorTestRecord) []uint {
+	result := make([]uint, len(records))
+	for i, record := range records {
+		result[i] = record.ID
+	}
+	return result
+}
+
+// fetch 按游标查询一页
+func fetch(t *testing.T, db *gorm.DB, cursors *Cursors, page *PageRequest) ([]cursorTestRecord, *CursorPage) {
+	t.Helper()
+	q, err := cursors.Query(page, "created_at", []string{"name", "score", "created_at"})
+	require.NoError(t, err)
+	records, cursorPage, err := FindCursorPage[cursorTestRecord](db, q)
+	require.NoError(t, err)
+	return records, cursorPage
+}
+
+func TestCursorPagination(t *testing.T) {
+	db := newCursorTestDB(t)
+	cursors := NewCursors("secret")
+
+	tests := []struct {
+		name      string
+		sortBy    string
+		sortOrder string
+		pages     [][]uint
+	}{
+		{"default sort", "", "desc", [][]uint{{10, 9, 8, 7}, {6, 5, 4, 3}, {2, 1}}},
+		{"duplicate sort values", "score", "asc", [][]uint{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10}}},
+		{"string sort", "name", "desc", [][]uint{{10, 9, 8, 7}, {6, 5, 4, 3}, {2, 1}}},
+		{"id sort", "id", "asc", [][]uint{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := &PageRequest{SortBy: tt.sortBy, SortOrder: tt.sortOrder, Limit: 4}
+			var prevCursors []string
+
+			// 向后翻到最后一页
+			for i, want := range tt.pages {
+				records, cursorPage := fetch(t, db, cursors, page)
+				assert.Equal(t, want, ids(records), "page %d", i)
+				assert.Equal(t, i < len(tt.pages)-1, cursorPage.HasNext)
+				assert.Equal(t, i > 0, cursorPage.HasPrev)
+				prevCursors = append(prevCursors, cursorPage.PrevCursor)
+				page = &PageRequest{Cursor: cursorPage.NextCursor, Limit: 4}
+			}
+
+			// 从最后一页向前翻回第一页
+			for i := len(tt.pages) - 1; i > 0; i-- {
+				records, cursorPage := fetch(t, db, cursors, &PageRequest{Cursor: prevCursors[i], Limit: 4})
+				assert.Equal(t, tt.pages[i-1], ids(records), "page %d", i-1)
+				assert.True(t, cursorPage.HasNext)
+				assert.Equal(t, i-1 > 0, cursorPage.HasPrev)
+			}
+		})
+	}
+}
+
+func TestCursorPaginationIgnoresNewRecords(t *testing.T) {
+	db := newCursorTestDB(t)
+	cursors := NewCursors("secret")
+
+	_, cursorPage := fetch(t, db, cursors, &PageRequest{SortBy: "id", SortOrder: "asc", Limit: 4})
+
+	// 翻页期间在第一页前插入记录，下一页不重复也不遗漏
+	require.NoError(t, db.Create(&cursorTestRecord{ID: 100, Name: "new"}).Error)
+	require.NoError(t, db.Delete(&cursorTestRecord{}, 1).Error)
+
+	records, _ := fetch(t, db, cursors, &PageRequest{Cursor: cursorPage.NextCursor, Limit: 4})
+	assert.Equal(t, []uint{5, 6, 7, 8}, ids(records))
+}
+
+func TestCursorQueryValidation(t *testing.T) {
+	db := newCursorTestDB(t)
+	cursors := NewCursors("secret")
+	allowed := []string{"name", "created_at"}
+
+	// 排序字段白名单
+	_, err := cursors.Query(&PageRequest{SortBy: "password", Limit: 4}, "created_at", allowed)
+	assert.EqualError(t, err, "invalid sort field: password")
+	// 客户端传错排序字段返回 400，而不是 500
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+	assert.Equal(t, apperror.CodeInvalidArgument, apperror.From(err).Code)
+
+	_, cursorPage := fetch(t, db, cursors, &PageRequest{Limit: 4})
+	require.NotEmpty(t, cursorPage.NextCursor)
+
+	// 篡改内容或使用其他密钥签名的游标无效
+	tampered := "x" + cursorPage.NextCursor[1:]
+	_, err = cursors.Query(&PageRequest{Cursor: tampered, Limit: 4}, "created_at", allowed)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = NewCursors("other").Query(&PageRequest{Cursor: cursorPage.NextCursor, Limit: 4}, "created_at", allowed)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = cursors.Query(&PageRequest{Cursor: "not-a-cursor", Limit: 4}, "created_at", allowed)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	// 游标中的排序字段不在当前白名单中
+	_, cursorPage = fetch(t, db, cursors, &PageRequest{SortBy: "score", Limit: 4})
+	_, err = cursors.Query(&PageRequest{Cursor: cursorPage.NextCursor, Limit: 4}, "created_at", allowed)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -1,19 +1,32 @@
 package pagination
 
 import (
+	"net/url"
 	"strconv"
+	"strings"
+
+	"github.com/chenyl99x/toge-api/pkg/apperror"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 排序或搜索字段不在允许范围内，使用 fmt.Errorf("%w: %s", ...) 附带字段名，返回 400
+var (
+	ErrInvalidSortField   = apperror.New(apperror.CodeInvalidArgument, "invalid sort field")
+	ErrInvalidSearchField = apperror.New(apperror.CodeInvalidArgument, "invalid search field")
+)
+
 // PageRequest 分页请求参数
 type PageRequest struct {
-	Page      int    `json:"page" form:"page" binding:"min=1" example:"1"`                    // 页码，从1开始
-	PageSize  int    `json:"page_size" form:"page_size" binding:"min=1,max=100" example:"10"` // 每页大小，最大100
-	SortBy    string `json:"sort_by" form:"sort_by" example:"created_at"`                     // 排序字段
-	SortOrder string `json:"sort_order" form:"sort_order" example:"desc"`                     // 排序方向：asc, desc
-	Keyword   string `json:"keyword" form:"keyword" example:"john"`                           // 搜索关键词
-	SearchBy  string `json:"search_by" form:"search_by" example:"username"`                   // 搜索字段
+	Page      int        `json:"page" form:"page" binding:"min=1" example:"1"`                    // 页码，从1开始
+	PageSize  int        `json:"page_size" form:"page_size" binding:"min=1,max=100" example:"10"` // 每页大小，最大100
+	SortBy    string     `json:"sort_by" form:"sort_by" example:"created_at"`                     // 排序字段
+	SortOrder string     `json:"sort_order" form:"sort_order" example:"desc"`                     // 排序方向：asc, desc
+	Keyword   string     `json:"keyword" form:"keyword" example:"john"`                           // 搜索关键词
+	SearchBy  string     `json:"search_by" form:"search_by" example:"username"`                   // 搜索字段
+	Cursor    string     `json:"cursor" form:"cursor"`                                            // 游标，为空表示第一页
+	Limit     int        `json:"limit" form:"limit" example:"10"`                                 // 游标分页每页大小，最大100，为0表示使用页码分页
+	Filters   url.Values `json:"-" form:"-"`                                                      // 过滤参数 filter[...]，由仓储按白名单解析
 }
 
 // PageResponse 分页响应
@@ -27,7 +40,18 @@ type PageResponse struct {
 	HasPrev    bool        `json:"has_prev"`    // 是否有上一页
 }
 
+// CursorResponse 游标分页响应
+type CursorResponse struct {
+	Data       interface{} `json:"data"`        // 数据列表
+	Limit      int         `json:"limit"`       // 每页大小
+	NextCursor string      `json:"next_cursor"` // 下一页游标，没有下一页时为空
+	PrevCursor string      `json:"prev_cursor"` // 上一页游标，没有上一页时为空
+	HasNext    bool        `json:"has_next"`    // 是否有下一页
+	HasPrev    bool        `json:"has_prev"`    // 是否有上一页
+}
+
 // ParsePageRequest 从 gin.Context 解析分页请求
+// 请求包含 cursor 或 limit 参数时使用游标分页，否则使用页码分页
 func ParsePageRequest(c *gin.Context) *PageRequest {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
@@ -35,6 +59,8 @@ func ParsePageRequest(c *gin.Context) *PageRequest {
 	sortOrder := c.DefaultQuery("sort_order", "desc")
 	keyword := c.DefaultQuery("keyword", "")
 	searchBy := c.DefaultQuery("search_by", "")
+	cursor, hasCursor := c.GetQuery("cursor")
+	limitQuery, hasLimit := c.GetQuery("limit")
 
 	// 设置默认值和限制
 	if page < 1 {
@@ -47,6 +73,25 @@ func ParsePageRequest(c *gin.Context) *PageRequest {
 		pageSize = 100
 	}
 
+	// 过滤参数原样保留，由各资源按自己的白名单解析
+	filters := url.Values{}
+	for key, values := range c.Request.URL.Query() {
+		if strings.HasPrefix(key, "filter[") {
+			filters[key] = values
+		}
+	}
+
+	var limit int
+	if hasCursor || hasLimit {
+		limit, _ = strconv.Atoi(limitQuery)
+		if limit < 1 {
+			limit = 10
+		}
+		if limit > 100 {
+			limit = 100
+		}
+	}
+
 	// 验证排序方向
 	if sortOrder != "asc" && sortOrder != "desc" {
 		sortOrder = "desc"
@@ -59,6 +104,9 @@ func ParsePageRequest(c *gin.Context) *PageRequest {
 		SortOrder: sortOrder,
 		Keyword:   keyword,
 		SearchBy:  searchBy,
+		Cursor:    cursor,
+		Limit:     limit,
+		Filters:   filters,
 	}
 }
 
@@ -77,6 +125,23 @@ func NewPageResponse(data interface{}, total int64, page, pageSize int) *PageRes
 	}
 }
 
+// NewCursorResponse 创建游标分页响应
+func NewCursorResponse(data interface{}, page *CursorPage) *CursorResponse {
+	return &CursorResponse{
+		Data:       data,
+		Limit:      page.Limit,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		HasNext:    page.HasNext,
+		HasPrev:    page.HasPrev,
+	}
+}
+
+// IsCursor 检查是否使用游标分页
+func (p *PageRequest) IsCursor() bool {
+	return p.Limit > 0
+}
+
 // GetOffset 获取数据库查询的偏移量
 func (p *PageRequest) GetOffset() int {
 	return (p.Page - 1) * p.PageSize
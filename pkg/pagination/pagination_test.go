@@ -242,3 +242,44 @@ func TestPageRequest_ValidateSearchField(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePageRequest_Cursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedCursor string
+		expectedLimit  int
+		expectedIsCur  bool
+	}{
+		{"page mode", "?page=2", "", 0, false},
+		{"first cursor page", "?cursor=", "", 10, true},
+		{"limit only", "?limit=20", "", 20, true},
+		{"with cursor", "?cursor=abc.def&limit=5", "abc.def", 5, true},
+		{"limit too large", "?cursor=&limit=500", "", 100, true},
+		{"invalid limit", "?limit=abc", "", 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			req, _ := http.NewRequest("GET", "/test"+tt.query, nil)
+			c.Request = req
+
+			result := ParsePageRequest(c)
+
+			if result.Cursor != tt.expectedCursor {
+				t.Errorf("ParsePageRequest() cursor = %v, want %v", result.Cursor, tt.expectedCursor)
+			}
+			if result.Limit != tt.expectedLimit {
+				t.Errorf("ParsePageRequest() limit = %v, want %v", result.Limit, tt.expectedLimit)
+			}
+			if result.IsCursor() != tt.expectedIsCur {
+				t.Errorf("IsCursor() = %v, want %v", result.IsCursor(), tt.expectedIsCur)
+			}
+		})
+	}
+}
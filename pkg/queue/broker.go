@@ -0,0 +1,254 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Broker 任务存储
+type Broker interface {
+	// Push 将任务放入就绪队列
+	Push(ctx context.Context, task *Task) error
+	// Schedule 将任务放入延迟队列，到达 at 后才会被取出
+	Schedule(ctx context.Context, task *Task, at time.Time) error
+	// Pop 将一个任务从就绪队列移入处理中列表并返回，timeout 内没有任务时返回 nil, nil
+	Pop(ctx context.Context, queue string, timeout time.Duration) (*Task, error)
+	// Ack 确认任务已处理完（成功、已安排重试或已进入死信队列），从处理中列表移除
+	Ack(ctx context.Context, task *Task) error
+	// RecoverStale 将取出后超过 visibility 仍未确认的任务放回就绪队列，返回移动数量
+	// 用于 worker 崩溃或被杀死后恢复已取出的任务，任务自定义的超时会加到 visibility 上
+	RecoverStale(ctx context.Context, queue string, now time.Time, visibility time.Duration) (int, error)
+	// PromoteDue 将到期的延迟任务移入就绪队列，返回移动数量
+	PromoteDue(ctx context.Context, queue string, now time.Time) (int, error)
+	// Kill 将任务放入死信队列
+	Kill(ctx context.Context, task *Task) error
+	// Dead 获取死信队列中最近的任务
+	Dead(ctx context.Context, queue string, limit int) ([]*Task, error)
+	// Requeue 将死信任务重新放入就绪队列并清零重试次数
+	Requeue(ctx context.Context, queue string, id string) (*Task, error)
+	// AcquireUnique 占用唯一键，已被占用时返回 false
+	AcquireUnique(ctx context.Context, key string, taskID string, ttl time.Duration) (bool, error)
+	// ReleaseUnique 释放唯一键，只有占用者是 taskID 时才会删除
+	ReleaseUnique(ctx context.Context, key string, taskID string) error
+}
+
+// RedisBroker 基于 Redis 的任务存储
+//
+//	<prefix><queue>:ready      就绪队列（List，LPUSH/BLMOVE）
+//	<prefix><queue>:processing 处理中列表（List，取出后确认前的任务）
+//	<prefix><queue>:leases     处理中任务的租约（ZSet，score 为取出时间加任务自定义超时）
+//	<prefix><queue>:scheduled  延迟队列（ZSet，score 为执行时间）
+//	<prefix><queue>:dead       死信队列（List，保留最近 deadSize 个）
+//	<prefix>unique:<key>       唯一键
+type RedisBroker struct {
+	client   redis.Client
+	prefix   string
+	deadSize int64
+}
+
+// NewRedisBroker 创建 Redis 任务存储
+func NewRedisBroker(client redis.Client, prefix string, deadSize int) *RedisBroker {
+	if deadSize <= 0 {
+		deadSize = 1000
+	}
+	return &RedisBroker{client: client, prefix: prefix, deadSize: int64(deadSize)}
+}
+
+func (b *RedisBroker) key(queue, kind string) string {
+	return b.prefix + queue + ":" + kind
+}
+
+// Push 放入就绪队列
+func (b *RedisBroker) Push(ctx context.Context, task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	return b.client.LPush(ctx, b.key(task.Queue, "ready"), data).Err()
+}
+
+// Schedule 放入延迟队列
+func (b *RedisBroker) Schedule(ctx context.Context, task *Task, at time.Time) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	return b.client.ZAdd(ctx, b.key(task.Queue, "scheduled"), goredis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: data,
+	}).Err()
+}
+
+// Pop 阻塞取出任务，任务原子地移入处理中列表，确认前 worker 崩溃不会丢失
+func (b *RedisBroker) Pop(ctx context.Context, queue string, timeout time.Duration) (*Task, error) {
+	processing := b.key(queue, "processing")
+	raw, err := b.client.BLMove(ctx, b.key(queue, "ready"), processing, "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var task Task
+	if err := json.Unmarshal([]byte(raw), &task); err != nil {
+		// 无法解析的任务不会被确认，直接移除，避免被反复回收
+		b.client.LRem(ctx, processing, 1, raw)
+		return nil, err
+	}
+	task.raw = raw
+
+	// 记录租约失败时由 RecoverStale 从发现时开始计时，不影响执行
+	lease := time.Now().Add(task.Timeout).UnixMilli()
+	b.client.ZAdd(ctx, b.key(queue, "leases"), goredis.Z{Score: float64(lease), Member: raw})
+	return &task, nil
+}
+
+// Ack 从处理中列表移除任务和租约
+func (b *RedisBroker) Ack(ctx context.Context, task *Task) error {
+	if task.raw == "" {
+		return nil
+	}
+	pipe := b.client.TxPipeline()
+	pipe.LRem(ctx, b.key(task.Queue, "processing"), 1, task.raw)
+	pipe.ZRem(ctx, b.key(task.Queue, "leases"), task.raw)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// recoverScript 原子地将租约过期的任务从处理中列表放回就绪队列的出队端，优先重新执行
+// 没有租约的任务（取出后记录租约失败）从本次检查开始计时
+var recoverScript = goredis.NewScript(`
+local items = redis.call("LRANGE", KEYS[1], 0, -1)
+local moved = 0
+for _, item in ipairs(items) do
+	local lease = redis.call("ZSCORE", KEYS[2], item)
+	if not lease then
+		redis.call("ZADD", KEYS[2], ARGV[2], item)
+	elseif tonumber(lease) < tonumber(ARGV[1]) then
+		redis.call("LREM", KEYS[1], 1, item)
+		redis.call("ZREM", KEYS[2], item)
+		redis.call("RPUSH", KEYS[3], item)
+		moved = moved + 1
+	end
+end
+return moved
+`)
+
+// RecoverStale 回收超时未确认的任务
+func (b *RedisBroker) RecoverStale(ctx context.Context, queue string, now time.Time, visibility time.Duration) (int, error) {
+	keys := []string{b.key(queue, "processing"), b.key(queue, "leases"), b.key(queue, "ready")}
+	deadline := strconv.FormatInt(now.Add(-visibility).UnixMilli(), 10)
+	return recoverScript.Run(ctx, b.client, keys, deadline, strconv.FormatInt(now.UnixMilli(), 10)).Int()
+}
+
+// promoteScript 原子地将到期任务从延迟队列移入就绪队列
+var promoteScript = goredis.NewScript(`
+local items = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, item in ipairs(items) do
+	redis.call("ZREM", KEYS[1], item)
+	redis.call("LPUSH", KEYS[2], item)
+end
+return #items
+`)
+
+// PromoteDue 移动到期任务，每次最多 100 个
+func (b *RedisBroker) PromoteDue(ctx context.Context, queue string, now time.Time) (int, error) {
+	keys := []string{b.key(queue, "scheduled"), b.key(queue, "ready")}
+	return promoteScript.Run(ctx, b.client, keys, strconv.FormatInt(now.UnixMilli(), 10), 100).Int()
+}
+
+// Kill 放入死信队列
+func (b *RedisBroker) Kill(ctx context.Context, task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	key := b.key(task.Queue, "dead")
+	pipe := b.client.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, b.deadSize-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Dead 获取死信任务
+func (b *RedisBroker) Dead(ctx context.Context, queue string, limit int) ([]*Task, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	items, err := b.client.LRange(ctx, b.key(queue, "dead"), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*Task, 0, len(items))
+	for _, item := range items {
+		var task Task
+		if err := json.Unmarshal([]byte(item), &task); err != nil {
+			continue
+		}
+		tasks = append(tasks, &task)
+	}
+	return tasks, nil
+}
+
+// requeueScript 原子地从死信队列移除指定条目并放入就绪队列
+var requeueScript = goredis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("LPUSH", KEYS[2], ARGV[2])
+return 1
+`)
+
+// Requeue 重新投递死信任务
+func (b *RedisBroker) Requeue(ctx context.Context, queue string, id string) (*Task, error) {
+	items, err := b.client.LRange(ctx, b.key(queue, "dead"), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		var task Task
+		if err := json.Unmarshal([]byte(item), &task); err != nil || task.ID != id {
+			continue
+		}
+
+		task.Retried = 0
+		task.FailedAt = nil
+		data, err := json.Marshal(&task)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := []string{b.key(queue, "dead"), b.key(queue, "ready")}
+		moved, err := requeueScript.Run(ctx, b.client, keys, item, data).Int()
+		if err != nil {
+			return nil, err
+		}
+		if moved == 0 {
+			return nil, ErrTaskNotFound
+		}
+		return &task, nil
+	}
+	return nil, ErrTaskNotFound
+}
+
+// AcquireUnique 占用唯一键
+func (b *RedisBroker) AcquireUnique(ctx context.Context, key string, taskID string, ttl time.Duration) (bool, error) {
+	return b.client.SetNX(ctx, b.prefix+"unique:"+key, taskID, ttl).Result()
+}
+
+// ReleaseUnique 释放唯一键
+func (b *RedisBroker) ReleaseUnique(ctx context.Context, key string, taskID string) error {
+	_, err := redis.CompareAndDelete(ctx, b.client, b.prefix+"unique:"+key, taskID)
+	return err
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+)
+
+// defaultUniqueTTL 唯一键未指定有效期时的默认值
+const defaultUniqueTTL = 24 * time.Hour
+
+// Client 任务生产者
+type Client struct {
+	broker     Broker
+	maxRetries int
+	log        *slog.Logger
+	now        func() time.Time
+}
+
+// NewClient 创建任务生产者，maxRetries 为任务默认的最大重试次数
+func NewClient(broker Broker, maxRetries int, log *slog.Logger) *Client {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return &Client{broker: broker, maxRetries: maxRetries, log: log, now: time.Now}
+}
+
+// Enqueue 将任务入队，payload 会被编码为 JSON
+// ctx 中的 trace_id 会随任务一起保存，Worker 执行任务时沿用该 trace_id
+func (c *Client) Enqueue(ctx context.Context, taskType string, payload any, opts ...Option) (*Task, error) {
+	o := enqueueOptions{queue: DefaultQueue}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload of %s: %w", taskType, err)
+	}
+
+	now := c.now()
+	task := &Task{
+		ID:         newTaskID(),
+		Type:       taskType,
+		Queue:      o.queue,
+		Payload:    data,
+		TraceID:    string(logger.GetTraceID(ctx)),
+		UniqueKey:  o.uniqueKey,
+		KeepUnique: o.keepUnique,
+		MaxRetries: c.maxRetries,
+		Timeout:    o.timeout,
+		EnqueuedAt: now,
+	}
+	if o.maxRetries != nil {
+		task.MaxRetries = *o.maxRetries
+	}
+
+	if task.UniqueKey != "" {
+		ttl := o.uniqueTTL
+		if ttl <= 0 {
+			ttl = defaultUniqueTTL
+		}
+		ok, err := c.broker.AcquireUnique(ctx, task.UniqueKey, task.ID, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateTask, task.UniqueKey)
+		}
+	}
+
+	processAt := o.processAt
+	if o.delay > 0 {
+		processAt = now.Add(o.delay)
+	}
+
+	if processAt.After(now) {
+		err = c.broker.Schedule(ctx, task, processAt)
+	} else {
+		err = c.broker.Push(ctx, task)
+	}
+	if err != nil {
+		if task.UniqueKey != "" {
+			_ = c.broker.ReleaseUnique(ctx, task.UniqueKey, task.ID)
+		}
+		return nil, err
+	}
+
+	c.log.InfoContext(ctx, "Task enqueued", "task_id", task.ID, "type", task.Type, "queue", task.Queue, "process_at", processAt)
+	return task, nil
+}
+
+// Dead 获取死信队列中最近的任务
+func (c *Client) Dead(ctx context.Context, queue string, limit int) ([]*Task, error) {
+	return c.broker.Dead(ctx, queue, limit)
+}
+
+// Requeue 重新投递死信任务
+func (c *Client) Requeue(ctx context.Context, queue string, id string) (*Task, error) {
+	return c.broker.Requeue(ctx, queue, id)
+}
@@ -0,0 +1,225 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryBroker 进程内任务存储，用于单实例开发和测试，进程退出后任务丢失
+type MemoryBroker struct {
+	mu         sync.Mutex
+	ready      map[string][]*Task
+	processing map[string][]scheduledTask
+	scheduled  map[string][]scheduledTask
+	dead       map[string][]*Task
+	unique     map[string]uniqueEntry
+	wake       chan struct{}
+	now        func() time.Time
+}
+
+// scheduledTask 延迟任务和处理中的任务，处理中的任务 at 为租约的起始时间
+type scheduledTask struct {
+	task *Task
+	at   time.Time
+}
+
+type uniqueEntry struct {
+	taskID    string
+	expiresAt time.Time
+}
+
+// NewMemoryBroker 创建进程内任务存储
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		ready:      map[string][]*Task{},
+		processing: map[string][]scheduledTask{},
+		scheduled:  map[string][]scheduledTask{},
+		dead:       map[string][]*Task{},
+		unique:     map[string]uniqueEntry{},
+		wake:       make(chan struct{}),
+		now:        time.Now,
+	}
+}
+
+// notify 唤醒等待中的 Pop，调用方需持有锁
+func (b *MemoryBroker) notify() {
+	close(b.wake)
+	b.wake = make(chan struct{})
+}
+
+// Push 放入就绪队列
+func (b *MemoryBroker) Push(ctx context.Context, task *Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ready[task.Queue] = append(b.ready[task.Queue], cloneTask(task))
+	b.notify()
+	return nil
+}
+
+// Schedule 放入延迟队列
+func (b *MemoryBroker) Schedule(ctx context.Context, task *Task, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.scheduled[task.Queue] = append(b.scheduled[task.Queue], scheduledTask{task: cloneTask(task), at: at})
+	return nil
+}
+
+// Pop 取出任务，没有任务时最多等待 timeout
+func (b *MemoryBroker) Pop(ctx context.Context, queue string, timeout time.Duration) (*Task, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		b.mu.Lock()
+		if tasks := b.ready[queue]; len(tasks) > 0 {
+			task := tasks[0]
+			b.ready[queue] = tasks[1:]
+			b.processing[queue] = append(b.processing[queue], scheduledTask{task: cloneTask(task), at: b.now().Add(task.Timeout)})
+			b.mu.Unlock()
+			return task, nil
+		}
+		wake := b.wake
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, nil
+		case <-wake:
+		}
+	}
+}
+
+// Ack 从处理中列表移除任务
+func (b *MemoryBroker) Ack(ctx context.Context, task *Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, p := range b.processing[task.Queue] {
+		if p.task.ID == task.ID {
+			b.processing[task.Queue] = append(b.processing[task.Queue][:i:i], b.processing[task.Queue][i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// RecoverStale 回收超时未确认的任务
+func (b *MemoryBroker) RecoverStale(ctx context.Context, queue string, now time.Time, visibility time.Duration) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	deadline := now.Add(-visibility)
+	var remaining []scheduledTask
+	var recovered []*Task
+	for _, p := range b.processing[queue] {
+		if p.at.Before(deadline) {
+			recovered = append(recovered, p.task)
+		} else {
+			remaining = append(remaining, p)
+		}
+	}
+	if len(recovered) == 0 {
+		return 0, nil
+	}
+	b.processing[queue] = remaining
+	b.ready[queue] = append(recovered, b.ready[queue]...)
+	b.notify()
+	return len(recovered), nil
+}
+
+// PromoteDue 移动到期任务
+func (b *MemoryBroker) PromoteDue(ctx context.Context, queue string, now time.Time) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pending := b.scheduled[queue]
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].at.Before(pending[j].at) })
+
+	moved := 0
+	for moved < len(pending) && !pending[moved].at.After(now) {
+		b.ready[queue] = append(b.ready[queue], pending[moved].task)
+		moved++
+	}
+	b.scheduled[queue] = pending[moved:]
+	if moved > 0 {
+		b.notify()
+	}
+	return moved, nil
+}
+
+// Kill 放入死信队列
+func (b *MemoryBroker) Kill(ctx context.Context, task *Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dead[task.Queue] = append([]*Task{cloneTask(task)}, b.dead[task.Queue]...)
+	return nil
+}
+
+// Dead 获取死信任务
+func (b *MemoryBroker) Dead(ctx context.Context, queue string, limit int) ([]*Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tasks := b.dead[queue]
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	result := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, cloneTask(task))
+	}
+	return result, nil
+}
+
+// Requeue 重新投递死信任务
+func (b *MemoryBroker) Requeue(ctx context.Context, queue string, id string) (*Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, task := range b.dead[queue] {
+		if task.ID != id {
+			continue
+		}
+		b.dead[queue] = append(b.dead[queue][:i:i], b.dead[queue][i+1:]...)
+		task.Retried = 0
+		task.FailedAt = nil
+		b.ready[queue] = append(b.ready[queue], task)
+		b.notify()
+		return cloneTask(task), nil
+	}
+	return nil, ErrTaskNotFound
+}
+
+// AcquireUnique 占用唯一键
+func (b *MemoryBroker) AcquireUnique(ctx context.Context, key string, taskID string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if entry, ok := b.unique[key]; ok && now.Before(entry.expiresAt) {
+		return false, nil
+	}
+	b.unique[key] = uniqueEntry{taskID: taskID, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseUnique 释放唯一键
+func (b *MemoryBroker) ReleaseUnique(ctx context.Context, key string, taskID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if entry, ok := b.unique[key]; ok && entry.taskID == taskID {
+		delete(b.unique, key)
+	}
+	return nil
+}
+
+// cloneTask 复制任务，避免调用方修改存储中的数据
+func cloneTask(task *Task) *Task {
+	clone := *task
+	return &clone
+}
@@ -0,0 +1,369 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger 测试中丢弃日志
+var testLogger = slog.New(slog.DiscardHandler)
+
+type greetPayload struct {
+	Name string `json:"name"`
+}
+
+func newTestWorker(broker Broker, queues map[string]int) *Worker {
+	return NewWorker(broker, WorkerOptions{
+		Queues:       queues,
+		PollInterval: 10 * time.Millisecond,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+		Timeout:      time.Second,
+		Logger:       testLogger,
+	})
+}
+
+// eventually 等待条件成立
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met in time")
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := time.Second, 10*time.Second
+	assert.Equal(t, time.Duration(0), Backoff(base, max, 0))
+	assert.Equal(t, time.Second, Backoff(base, max, 1))
+	assert.Equal(t, 2*time.Second, Backoff(base, max, 2))
+	assert.Equal(t, 8*time.Second, Backoff(base, max, 4))
+	assert.Equal(t, max, Backoff(base, max, 5))
+	assert.Equal(t, max, Backoff(base, max, 100))
+}
+
+func TestTypedHandlerReceivesPayloadAndTraceID(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 3, testLogger)
+	worker := newTestWorker(broker, nil)
+
+	var got atomic.Value
+	var trace atomic.Value
+	Handle(worker, "greet", func(ctx context.Context, p greetPayload) error {
+		got.Store(p.Name)
+		trace.Store(string(logger.GetTraceID(ctx)))
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	ctx := logger.WithTraceID(context.Background(), "trace-from-request")
+	task, err := client.Enqueue(ctx, "greet", greetPayload{Name: "toge"})
+	require.NoError(t, err)
+	assert.Equal(t, "trace-from-request", task.TraceID)
+	assert.Equal(t, DefaultQueue, task.Queue)
+
+	eventually(t, func() bool { return got.Load() != nil })
+	assert.Equal(t, "toge", got.Load())
+	assert.Equal(t, "trace-from-request", trace.Load())
+}
+
+func TestDelayedTaskWaitsUntilDue(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 0, testLogger)
+
+	_, err := client.Enqueue(context.Background(), "greet", greetPayload{}, Delay(time.Hour))
+	require.NoError(t, err)
+
+	n, err := broker.PromoteDue(context.Background(), DefaultQueue, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	n, err = broker.PromoteDue(context.Background(), DefaultQueue, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	task, err := broker.Pop(context.Background(), DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "greet", task.Type)
+}
+
+func TestRetryThenDeadLetter(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 2, testLogger)
+	worker := newTestWorker(broker, nil)
+
+	var attempts atomic.Int32
+	worker.Register("flaky", func(ctx context.Context, task *Task) error {
+		attempts.Add(1)
+		return errors.New("boom")
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	task, err := client.Enqueue(context.Background(), "flaky", nil, Unique("flaky:1", time.Minute))
+	require.NoError(t, err)
+
+	var dead []*Task
+	eventually(t, func() bool {
+		dead, _ = client.Dead(context.Background(), DefaultQueue, 10)
+		return len(dead) == 1
+	})
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Equal(t, task.ID, dead[0].ID)
+	assert.Equal(t, 2, dead[0].Retried)
+	assert.Equal(t, "boom", dead[0].LastError)
+	assert.NotNil(t, dead[0].FailedAt)
+
+	// 进入死信队列后唯一键被释放
+	_, err = client.Enqueue(context.Background(), "flaky", nil, Unique("flaky:1", time.Minute), Delay(time.Hour))
+	assert.NoError(t, err)
+}
+
+func TestSkipRetryAndUnknownType(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 5, testLogger)
+	worker := newTestWorker(broker, nil)
+
+	var attempts atomic.Int32
+	Handle(worker, "typed", func(ctx context.Context, p greetPayload) error {
+		attempts.Add(1)
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	// 载荷无法解析和未注册的类型都不重试
+	_, err := client.Enqueue(context.Background(), "typed", "not an object")
+	require.NoError(t, err)
+	_, err = client.Enqueue(context.Background(), "unknown", nil)
+	require.NoError(t, err)
+
+	eventually(t, func() bool {
+		dead, _ := client.Dead(context.Background(), DefaultQueue, 10)
+		return len(dead) == 2
+	})
+	assert.Equal(t, int32(0), attempts.Load())
+}
+
+func TestUniqueKeyRejectsDuplicates(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 0, testLogger)
+
+	_, err := client.Enqueue(context.Background(), "greet", nil, Unique("greet:1", time.Minute))
+	require.NoError(t, err)
+
+	_, err = client.Enqueue(context.Background(), "greet", nil, Unique("greet:1", time.Minute))
+	assert.ErrorIs(t, err, ErrDuplicateTask)
+
+	_, err = client.Enqueue(context.Background(), "greet", nil, Unique("greet:2", time.Minute))
+	assert.NoError(t, err)
+}
+
+func TestKeepUniqueHoldsKeyAfterCompletion(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 0, testLogger)
+	worker := newTestWorker(broker, nil)
+
+	var done atomic.Int32
+	worker.Register("greet", func(ctx context.Context, task *Task) error {
+		done.Add(1)
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	_, err := client.Enqueue(context.Background(), "greet", nil, Unique("greet:kept", time.Minute), KeepUnique())
+	require.NoError(t, err)
+	_, err = client.Enqueue(context.Background(), "greet", nil, Unique("greet:released", time.Minute))
+	require.NoError(t, err)
+	eventually(t, func() bool { return done.Load() == 2 })
+
+	// 默认完成后释放唯一键，KeepUnique 的键保留到过期
+	eventually(t, func() bool {
+		_, err := client.Enqueue(context.Background(), "greet", nil, Unique("greet:released", time.Minute))
+		return err == nil
+	})
+	_, err = client.Enqueue(context.Background(), "greet", nil, Unique("greet:kept", time.Minute), KeepUnique())
+	assert.ErrorIs(t, err, ErrDuplicateTask)
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 0, testLogger)
+	worker := newTestWorker(broker, map[string]int{"limited": 2})
+
+	var running, peak, done atomic.Int32
+	var mu sync.Mutex
+	worker.Register("slow", func(ctx context.Context, task *Task) error {
+		n := running.Add(1)
+		mu.Lock()
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		done.Add(1)
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	for i := 0; i < 6; i++ {
+		_, err := client.Enqueue(context.Background(), "slow", nil, Queue("limited"))
+		require.NoError(t, err)
+	}
+
+	eventually(t, func() bool { return done.Load() == 6 })
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestPanicIsRecoveredAndRequeue(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 0, testLogger)
+	worker := newTestWorker(broker, nil)
+
+	var calls atomic.Int32
+	worker.Register("panics", func(ctx context.Context, task *Task) error {
+		if calls.Add(1) == 1 {
+			panic("unexpected")
+		}
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	task, err := client.Enqueue(context.Background(), "panics", nil)
+	require.NoError(t, err)
+
+	eventually(t, func() bool {
+		dead, _ := client.Dead(context.Background(), DefaultQueue, 10)
+		return len(dead) == 1
+	})
+
+	requeued, err := client.Requeue(context.Background(), DefaultQueue, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, requeued.Retried)
+
+	eventually(t, func() bool { return calls.Load() == 2 })
+	dead, _ := client.Dead(context.Background(), DefaultQueue, 10)
+	assert.Empty(t, dead)
+
+	_, err = client.Requeue(context.Background(), DefaultQueue, "missing")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestStaleTaskIsRecoveredAfterWorkerCrash(t *testing.T) {
+	broker := NewMemoryBroker()
+	client := NewClient(broker, 0, testLogger)
+
+	// 上一个 worker 取出任务两分钟后崩溃，没有确认
+	_, err := client.Enqueue(context.Background(), "greet", greetPayload{Name: "toge"})
+	require.NoError(t, err)
+	broker.now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+	crashed, err := broker.Pop(context.Background(), DefaultQueue, time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, crashed)
+	broker.now = time.Now
+
+	worker := newTestWorker(broker, nil)
+	var got atomic.Value
+	Handle(worker, "greet", func(ctx context.Context, p greetPayload) error {
+		got.Store(p.Name)
+		return nil
+	})
+	worker.Start()
+	defer worker.Stop()
+
+	eventually(t, func() bool { return got.Load() == "toge" })
+	// 完成后确认，处理中列表为空
+	eventually(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		return len(broker.processing[DefaultQueue]) == 0
+	})
+}
+
+func TestRedisBrokerAckAndRecoverStale(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisClient := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = redisClient.Close() })
+	broker := NewRedisBroker(redisClient, "queue:", 10)
+	client := NewClient(broker, 3, testLogger)
+	ctx := context.Background()
+
+	task, err := client.Enqueue(ctx, "greet", greetPayload{Name: "toge"})
+	require.NoError(t, err)
+
+	popped, err := broker.Pop(ctx, DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, popped)
+	assert.Equal(t, task.ID, popped.ID)
+	processing, _ := server.List("queue:default:processing")
+	assert.Len(t, processing, 1)
+
+	// 可见性超时内不回收
+	visibility := time.Minute
+	recovered, err := broker.RecoverStale(ctx, DefaultQueue, time.Now(), visibility)
+	require.NoError(t, err)
+	assert.Zero(t, recovered)
+
+	// 超时后放回就绪队列，可以再次取出
+	recovered, err = broker.RecoverStale(ctx, DefaultQueue, time.Now().Add(2*visibility), visibility)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+	assert.False(t, server.Exists("queue:default:processing"))
+
+	popped, err = broker.Pop(ctx, DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, popped)
+	assert.Equal(t, task.ID, popped.ID)
+
+	// 确认时按取出的原始数据移除，不受处理过程中修改任务的影响
+	popped.Retried++
+	popped.LastError = "boom"
+	require.NoError(t, broker.Ack(ctx, popped))
+	assert.False(t, server.Exists("queue:default:processing"))
+	assert.False(t, server.Exists("queue:default:leases"))
+}
+
+func TestRedisBrokerRecoversTaskWithoutLease(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisClient := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = redisClient.Close() })
+	broker := NewRedisBroker(redisClient, "queue:", 10)
+	ctx := context.Background()
+
+	// 取出后还没记录租约就崩溃，从第一次检查开始计时
+	_, err := server.Lpush("queue:default:processing", `{"id":"orphan","type":"greet","queue":"default"}`)
+	require.NoError(t, err)
+
+	now := time.Now()
+	recovered, err := broker.RecoverStale(ctx, DefaultQueue, now, time.Minute)
+	require.NoError(t, err)
+	assert.Zero(t, recovered)
+
+	recovered, err = broker.RecoverStale(ctx, DefaultQueue, now.Add(2*time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+	ready, _ := server.List("queue:default:ready")
+	assert.Len(t, ready, 1)
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrDuplicateTask = errors.New("duplicate task")
+	ErrTaskNotFound  = errors.New("task not found")
+	// ErrSkipRetry 处理函数返回包装了该错误的错误时，任务直接进入死信队列不再重试
+	ErrSkipRetry = errors.New("skip retry")
+)
+
+// DefaultQueue 未指定队列时使用的队列名
+const DefaultQueue = "default"
+
+// Task 队列中的任务
+type Task struct {
+	ID         string          `json:"id" example:"3f9a1c2b7d4e5f60"`
+	Type       string          `json:"type" example:"notification:send"`
+	Queue      string          `json:"queue" example:"default"`
+	Payload    json.RawMessage `json:"payload" swaggertype:"object"`
+	TraceID    string          `json:"trace_id,omitempty" example:"a1b2c3d4e5f60718"`
+	UniqueKey  string          `json:"unique_key,omitempty"`
+	KeepUnique bool            `json:"keep_unique,omitempty"` // 任务结束后保留唯一键，直到过期
+	MaxRetries int             `json:"max_retries" example:"3"`
+	Retried    int             `json:"retried" example:"0"`
+	Timeout    time.Duration   `json:"timeout,omitempty" swaggertype:"integer"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	LastError  string          `json:"last_error,omitempty"`
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`
+
+	raw string // 取出时在处理中列表里的原始数据，用于确认
+}
+
+// Unmarshal 将载荷解析到 v
+func (t *Task) Unmarshal(v any) error {
+	if err := json.Unmarshal(t.Payload, v); err != nil {
+		return fmt.Errorf("%w: decode payload of %s: %v", ErrSkipRetry, t.Type, err)
+	}
+	return nil
+}
+
+// Option 入队选项
+type Option func(*enqueueOptions)
+
+type enqueueOptions struct {
+	queue      string
+	processAt  time.Time
+	delay      time.Duration
+	maxRetries *int
+	uniqueKey  string
+	uniqueTTL  time.Duration
+	keepUnique bool
+	timeout    time.Duration
+}
+
+// Queue 指定任务所在队列
+func Queue(name string) Option {
+	return func(o *enqueueOptions) { o.queue = name }
+}
+
+// Delay 延迟 d 后执行
+func Delay(d time.Duration) Option {
+	return func(o *enqueueOptions) { o.delay = d }
+}
+
+// ProcessAt 在指定时间执行
+func ProcessAt(t time.Time) Option {
+	return func(o *enqueueOptions) { o.processAt = t }
+}
+
+// MaxRetries 最大重试次数，0 表示失败后直接进入死信队列
+func MaxRetries(n int) Option {
+	return func(o *enqueueOptions) { o.maxRetries = &n }
+}
+
+// Unique 唯一键，相同键的任务在完成前或 ttl 过期前不会重复入队
+func Unique(key string, ttl time.Duration) Option {
+	return func(o *enqueueOptions) {
+		o.uniqueKey = key
+		o.uniqueTTL = ttl
+	}
+}
+
+// KeepUnique 任务完成或进入死信队列后不释放唯一键，ttl 过期前相同键的任务都不会再次入队
+// 用于按事件 ID 等业务标识去重的任务，避免上游重试时重复执行已完成的任务
+func KeepUnique() Option {
+	return func(o *enqueueOptions) { o.keepUnique = true }
+}
+
+// Timeout 单次执行超时，覆盖 Worker 的默认值
+func Timeout(d time.Duration) Option {
+	return func(o *enqueueOptions) { o.timeout = d }
+}
+
+// newTaskID 生成任务 ID
+func newTaskID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("%x", b)
+}
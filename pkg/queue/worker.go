@@ -0,0 +1,311 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+)
+
+// Handler 任务处理函数，返回错误时任务会按退避策略重试
+type Handler func(ctx context.Context, task *Task) error
+
+// Handle 注册类型化的处理函数，载荷会被解析为 T
+func Handle[T any](w *Worker, taskType string, fn func(ctx context.Context, payload T) error) {
+	w.Register(taskType, func(ctx context.Context, task *Task) error {
+		var payload T
+		if err := task.Unmarshal(&payload); err != nil {
+			return err
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// WorkerOptions Worker 配置
+type WorkerOptions struct {
+	Queues            map[string]int // 队列名 → 并发数
+	PollInterval      time.Duration  // 延迟任务检查间隔，也是取任务的阻塞超时
+	BackoffBase       time.Duration  // 第一次重试的等待时间
+	BackoffMax        time.Duration  // 重试等待时间上限
+	Timeout           time.Duration  // 单个任务默认超时
+	VisibilityTimeout time.Duration  // 取出后超过该时间未确认视为 worker 已崩溃并重新投递，不大于 Timeout 时使用 Timeout 加一分钟
+	Logger            *slog.Logger   // 为空时使用 slog.Default()
+}
+
+// Worker 任务消费者
+type Worker struct {
+	broker   Broker
+	opts     WorkerOptions
+	handlers map[string]Handler
+	log      *slog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	now    func() time.Time
+}
+
+// NewWorker 创建任务消费者
+func NewWorker(broker Broker, opts WorkerOptions) *Worker {
+	if len(opts.Queues) == 0 {
+		opts.Queues = map[string]int{DefaultQueue: 1}
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = 10 * time.Second
+	}
+	if opts.BackoffMax <= 0 {
+		opts.BackoffMax = time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.VisibilityTimeout <= opts.Timeout {
+		opts.VisibilityTimeout = opts.Timeout + time.Minute
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	return &Worker{
+		broker:   broker,
+		opts:     opts,
+		handlers: map[string]Handler{},
+		log:      opts.Logger,
+		now:      time.Now,
+	}
+}
+
+// Register 注册任务处理函数，需在 Start 之前调用
+func (w *Worker) Register(taskType string, h Handler) {
+	w.handlers[taskType] = h
+}
+
+// Types 已注册的任务类型
+func (w *Worker) Types() []string {
+	types := make([]string, 0, len(w.handlers))
+	for t := range w.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Start 为每个队列启动配置数量的消费协程和一个延迟任务搬运协程
+func (w *Worker) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	for queue, concurrency := range w.opts.Queues {
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+
+		w.wg.Add(1)
+		go w.promote(ctx, queue)
+
+		for i := 0; i < concurrency; i++ {
+			w.wg.Add(1)
+			go w.consume(ctx, queue)
+		}
+		w.log.Info("Queue worker started", "queue", queue, "concurrency", concurrency)
+	}
+}
+
+// Stop 停止取新任务并等待执行中的任务结束
+func (w *Worker) Stop() {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.cancel = nil
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	w.wg.Wait()
+	w.log.Info("Queue worker stopped")
+}
+
+// promote 定期将到期的延迟任务移入就绪队列，并回收超时未确认的任务
+func (w *Worker) promote(ctx context.Context, queue string) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.broker.PromoteDue(ctx, queue, w.now()); err != nil && ctx.Err() == nil {
+			w.log.Error("Failed to promote scheduled tasks", "queue", queue, "error", err)
+		}
+		recovered, err := w.broker.RecoverStale(ctx, queue, w.now(), w.opts.VisibilityTimeout)
+		if err != nil && ctx.Err() == nil {
+			w.log.Error("Failed to recover stale tasks", "queue", queue, "error", err)
+		}
+		if recovered > 0 {
+			w.log.Warn("Stale tasks recovered", "queue", queue, "count", recovered)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// consume 循环取出并执行任务
+func (w *Worker) consume(ctx context.Context, queue string) {
+	defer w.wg.Done()
+
+	for ctx.Err() == nil {
+		task, err := w.broker.Pop(ctx, queue, w.opts.PollInterval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.log.Error("Failed to pop task", "queue", queue, "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.opts.PollInterval):
+			}
+			continue
+		}
+		if task == nil {
+			continue
+		}
+
+		// 执行中的任务不随 Stop 取消，保证已取出的任务能够完成
+		w.process(context.Background(), task)
+	}
+}
+
+// process 执行单个任务，失败时重试或放入死信队列
+func (w *Worker) process(ctx context.Context, task *Task) {
+	traceID := logger.TraceID(task.TraceID)
+	if traceID == "" {
+		traceID = logger.GenerateTraceID()
+	}
+	ctx = logger.WithTraceID(ctx, traceID)
+
+	start := w.now()
+	w.log.InfoContext(ctx, "Task started", "task_id", task.ID, "type", task.Type, "queue", task.Queue, "retried", task.Retried)
+
+	err := w.execute(ctx, task)
+	duration := w.now().Sub(start)
+
+	if err == nil {
+		w.releaseUnique(ctx, task)
+		w.ack(ctx, task)
+		w.log.InfoContext(ctx, "Task completed", "task_id", task.ID, "type", task.Type, "duration", duration.String())
+		return
+	}
+
+	task.LastError = err.Error()
+	if errors.Is(err, ErrSkipRetry) || task.Retried >= task.MaxRetries {
+		failedAt := w.now()
+		task.FailedAt = &failedAt
+		if killErr := w.broker.Kill(ctx, task); killErr != nil {
+			// 不确认，任务在可见性超时后重新投递
+			w.log.ErrorContext(ctx, "Failed to move task to dead queue", "task_id", task.ID, "error", killErr)
+			return
+		}
+		w.releaseUnique(ctx, task)
+		w.ack(ctx, task)
+		w.log.ErrorContext(ctx, "Task failed permanently", "task_id", task.ID, "type", task.Type, "retried", task.Retried, "error", err)
+		return
+	}
+
+	task.Retried++
+	retryAt := w.now().Add(w.backoff(task.Retried))
+	if scheduleErr := w.broker.Schedule(ctx, task, retryAt); scheduleErr != nil {
+		w.log.ErrorContext(ctx, "Failed to schedule task retry", "task_id", task.ID, "error", scheduleErr)
+		return
+	}
+	w.ack(ctx, task)
+	w.log.WarnContext(ctx, "Task failed, retry scheduled", "task_id", task.ID, "type", task.Type, "retried", task.Retried, "retry_at", retryAt, "error", err)
+}
+
+// execute 调用处理函数，处理超时和 panic
+func (w *Worker) execute(ctx context.Context, task *Task) (err error) {
+	handler, ok := w.handlers[task.Type]
+	if !ok {
+		return fmt.Errorf("%w: no handler registered for %s", ErrSkipRetry, task.Type)
+	}
+
+	timeout := task.Timeout
+	if timeout <= 0 {
+		timeout = w.opts.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			w.log.ErrorContext(ctx, "Task panicked", "task_id", task.ID, "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, task)
+}
+
+// backoff 第 retried 次重试前的等待时间，附加最多 10% 的随机抖动
+func (w *Worker) backoff(retried int) time.Duration {
+	d := Backoff(w.opts.BackoffBase, w.opts.BackoffMax, retried)
+	if jitter := int64(d / 10); jitter > 0 {
+		d += time.Duration(rand.Int63n(jitter))
+	}
+	return d
+}
+
+// ack 确认任务，失败时任务在可见性超时后会被重新执行
+func (w *Worker) ack(ctx context.Context, task *Task) {
+	if err := w.broker.Ack(ctx, task); err != nil {
+		w.log.WarnContext(ctx, "Failed to ack task", "task_id", task.ID, "error", err)
+	}
+}
+
+// releaseUnique 任务结束后释放唯一键，KeepUnique 的任务保留到唯一键过期
+func (w *Worker) releaseUnique(ctx context.Context, task *Task) {
+	if task.UniqueKey == "" || task.KeepUnique {
+		return
+	}
+	if err := w.broker.ReleaseUnique(ctx, task.UniqueKey, task.ID); err != nil {
+		w.log.WarnContext(ctx, "Failed to release unique key", "task_id", task.ID, "unique_key", task.UniqueKey, "error", err)
+	}
+}
+
+// Backoff 指数退避：base * 2^(retried-1)，不超过 max
+func Backoff(base, max time.Duration, retried int) time.Duration {
+	if retried <= 0 {
+		return 0
+	}
+	d := base
+	for i := 1; i < retried; i++ {
+		d *= 2
+		if d >= max || d <= 0 {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
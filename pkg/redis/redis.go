@@ -5,55 +5,76 @@ import (
 	"time"
 
 	"github.com/chenyl99x/toge-api/pkg/config"
+	"github.com/chenyl99x/toge-api/pkg/metrics"
+	"github.com/chenyl99x/toge-api/pkg/tracing"
 
 	"github.com/redis/go-redis/v9"
 )
 
-var Client *redis.Client
+// Client Redis 客户端接口，单机、哨兵和集群客户端都实现了该接口
+type Client = redis.UniversalClient
 
-// InitRedis 初始化 Redis 连接
-func InitRedis() error {
-	Client = redis.NewClient(&redis.Options{
-		Addr:     config.GlobalConfig.Redis.GetRedisAddr(),
-		Password: config.GlobalConfig.Redis.Password,
-		DB:       config.GlobalConfig.Redis.Database,
-		PoolSize: config.GlobalConfig.Redis.PoolSize,
+// pingTimeout 启动时检查连接的超时时间
+const pingTimeout = 5 * time.Second
+
+// New 创建 Redis 客户端，不会立即连接，由 Ping 在启动阶段检查连通性
+func New(redisConfig config.RedisConfig) Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisConfig.GetRedisAddr(),
+		Password: redisConfig.Password,
+		DB:       redisConfig.Database,
+		PoolSize: redisConfig.PoolSize,
 	})
 
-	// 测试连接
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// 命令耗时、连接池指标和链路追踪
+	client.AddHook(metrics.RedisHook{})
+	client.AddHook(tracing.RedisHook{})
+	metrics.RegisterRedisPool(client)
 
-	return Client.Ping(ctx).Err()
+	return client
 }
 
-// Set 设置键值对
-func Set(key string, value interface{}, expiration time.Duration) error {
-	ctx := context.Background()
-	return Client.Set(ctx, key, value, expiration).Err()
-}
+// Ping 检查 Redis 连通性
+func Ping(ctx context.Context, client Client) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
 
-// Get 获取值
-func Get(key string) (string, error) {
-	ctx := context.Background()
-	return Client.Get(ctx, key).Result()
+	return client.Ping(ctx).Err()
 }
 
-// Del 删除键
-func Del(key string) error {
-	ctx := context.Background()
-	return Client.Del(ctx, key).Err()
+// compareAndDeleteScript 仅当值匹配时删除键，避免误删他人持有的锁
+var compareAndDeleteScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// CompareAndDelete 仅当键的值等于 value 时删除，返回是否删除
+func CompareAndDelete(ctx context.Context, client Client, key string, value string) (bool, error) {
+	n, err := compareAndDeleteScript.Run(ctx, client, []string{key}, value).Int()
+	return n > 0, err
 }
 
-// Exists 检查键是否存在
-func Exists(key string) (bool, error) {
-	ctx := context.Background()
-	result, err := Client.Exists(ctx, key).Result()
-	return result > 0, err
+// compareAndExpireScript 仅当值匹配时重置过期时间，用于续期自己持有的锁
+var compareAndExpireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// CompareAndExpire 仅当键的值等于 value 时将过期时间设为 ttl，返回是否设置
+func CompareAndExpire(ctx context.Context, client Client, key string, value string, ttl time.Duration) (bool, error) {
+	n, err := compareAndExpireScript.Run(ctx, client, []string{key}, value, ttl.Milliseconds()).Int()
+	return n > 0, err
 }
 
-// Expire 设置过期时间
-func Expire(key string, expiration time.Duration) error {
-	ctx := context.Background()
-	return Client.Expire(ctx, key, expiration).Err()
+// LPushTrim 从左侧插入列表并保留最近 size 个元素
+func LPushTrim(ctx context.Context, client Client, key string, value interface{}, size int64) error {
+	pipe := client.TxPipeline()
+	pipe.LPush(ctx, key, value)
+	pipe.LTrim(ctx, key, 0, size-1)
+	_, err := pipe.Exec(ctx)
+	return err
 }
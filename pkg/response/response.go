@@ -1,46 +1,151 @@
 package response
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/fieldset"
+	"github.com/chenyl99x/toge-api/pkg/i18n"
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
 
 	"github.com/gin-gonic/gin"
 )
 
+// selectionKey 在 gin.Context 中保存字段选择
+const selectionKey = "response.selection"
+
 // Response 统一响应结构
 type Response struct {
-	Code    int         `json:"code"`            // 状态码
-	Message string      `json:"message"`         // 响应消息
-	Data    interface{} `json:"data,omitempty"`  // 响应数据
-	Error   string      `json:"error,omitempty"` // 错误信息
+	Code      int               `json:"code"`                 // 状态码
+	Message   string            `json:"message"`              // 响应消息，按请求的语言本地化
+	Data      interface{}       `json:"data,omitempty"`       // 响应数据
+	Error     string            `json:"error,omitempty"`      // 错误信息，英文原文，不随语言变化
+	ErrorCode apperror.Code     `json:"error_code,omitempty"` // 业务错误码，只在错误响应中返回
+	TraceID   string            `json:"trace_id,omitempty"`   // 链路 ID，与响应头 X-Trace-ID 相同，只在错误响应中返回
+	Details   []i18n.FieldError `json:"details,omitempty"`    // 校验失败的字段，只在校验错误响应中返回
+}
+
+// Select 设置字段选择，Success 和 Created 按选择裁剪数据，分页响应裁剪其中的列表
+func Select(c *gin.Context, sel *fieldset.Selection) {
+	c.Set(selectionKey, sel)
+}
+
+// project 按请求的字段选择裁剪数据，没有选择时原样返回
+func project(c *gin.Context, data interface{}) (interface{}, error) {
+	sel, _ := c.Value(selectionKey).(*fieldset.Selection)
+	if sel.Empty() || data == nil {
+		return data, nil
+	}
+
+	var err error
+	switch page := data.(type) {
+	case *pagination.PageResponse:
+		projected := *page
+		projected.Data, err = sel.Project(page.Data)
+		return &projected, err
+	case *pagination.CursorResponse:
+		projected := *page
+		projected.Data, err = sel.Project(page.Data)
+		return &projected, err
+	}
+	return sel.Project(data)
 }
 
 // Success 成功响应
 func Success(c *gin.Context, data interface{}) {
+	data, err := project(c, data)
+	if err != nil {
+		InternalServerError(c, err.Error())
+		return
+	}
 	c.JSON(http.StatusOK, Response{
 		Code:    200,
-		Message: "成功",
+		Message: i18n.T(locale(c), "success"),
 		Data:    data,
 	})
 }
 
 // Created 创建成功响应
 func Created(c *gin.Context, data interface{}) {
+	data, err := project(c, data)
+	if err != nil {
+		InternalServerError(c, err.Error())
+		return
+	}
 	c.JSON(http.StatusCreated, Response{
 		Code:    201,
-		Message: "创建成功",
+		Message: i18n.T(locale(c), "created successfully"),
 		Data:    data,
 	})
 }
 
-// Error 错误响应
-func Error(c *gin.Context, code int, message string) {
-	c.JSON(code, Response{
-		Code:    code,
-		Message: message,
-		Error:   message,
+// Accepted 已接受响应，请求转为后台任务处理
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Response{
+		Code:    202,
+		Message: i18n.T(locale(c), "accepted"),
+		Data:    data,
 	})
 }
 
+// Error 错误响应，错误码为 HTTP 状态码对应的通用错误码
+func Error(c *gin.Context, code int, message string) {
+	fail(c, code, apperror.CodeForStatus(code), message, nil)
+}
+
+// ErrorWithData 错误响应，附带资源的当前数据等
+func ErrorWithData(c *gin.Context, code int, message string, data interface{}) {
+	fail(c, code, apperror.CodeForStatus(code), message, data)
+}
+
+// AppError 按错误码返回错误响应，err 按 apperror.From 转换，资源不存在为 404，唯一索引冲突为 409，
+// 其他错误为 500 且不返回原始错误信息，原始错误记录到 gin.Context 中，由链路追踪中间件写入日志和 span
+func AppError(c *gin.Context, err error) {
+	AppErrorWithData(c, err, nil)
+}
+
+// AppErrorWithData 按错误码返回错误响应，附带资源的当前数据等
+func AppErrorWithData(c *gin.Context, err error, data interface{}) {
+	appErr := apperror.From(err)
+	status := appErr.HTTPStatus()
+	if status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+	fail(c, status, appErr.Code, appErr.Message, data)
+}
+
+// fail 写入错误响应，message 为英文原文，按请求的语言本地化
+func fail(c *gin.Context, status int, code apperror.Code, message string, data interface{}) {
+	resp := errorResponse(c, status, code, message, i18n.ErrorMessage(locale(c), code, message))
+	resp.Data = data
+	c.JSON(status, resp)
+}
+
+// errorResponse 带错误码和链路 ID 的错误响应，error 为英文原文，message 为本地化的 localized
+func errorResponse(c *gin.Context, status int, code apperror.Code, message, localized string) Response {
+	resp := Response{
+		Code:      status,
+		Message:   localized,
+		Error:     message,
+		ErrorCode: code,
+	}
+	if c.Request != nil {
+		resp.TraceID = string(logger.GetTraceID(c.Request.Context()))
+	}
+	return resp
+}
+
+// locale 请求的语言，由语言中间件按用户偏好和 Accept-Language 设置，未设置时为默认语言
+func locale(c *gin.Context) i18n.Locale {
+	if c.Request == nil {
+		return i18n.Default
+	}
+	return i18n.FromContext(c.Request.Context())
+}
+
 // BadRequest 400 错误响应
 func BadRequest(c *gin.Context, message string) {
 	Error(c, http.StatusBadRequest, message)
@@ -68,10 +173,40 @@ func InternalServerError(c *gin.Context, message string) {
 
 // ValidationError 验证错误响应
 func ValidationError(c *gin.Context, message string) {
-	Error(c, http.StatusBadRequest, "校验错误: "+message)
+	c.JSON(http.StatusBadRequest, errorResponse(c, http.StatusBadRequest, apperror.CodeValidationFailed,
+		fmt.Sprintf("validation error: %s", message), i18n.Sprintf(locale(c), "validation error: %s", message)))
+}
+
+// BindError 请求参数绑定或校验失败的响应，校验错误按请求的语言翻译，并在 details 中返回各字段的提示，
+// 其他错误（例如 JSON 格式错误）按 ValidationError 返回
+func BindError(c *gin.Context, err error) {
+	details, ok := i18n.TranslateValidation(locale(c), err)
+	if !ok {
+		ValidationError(c, err.Error())
+		return
+	}
+	source, _ := i18n.TranslateValidation(i18n.Default, err)
+	resp := errorResponse(c, http.StatusBadRequest, apperror.CodeValidationFailed,
+		fmt.Sprintf("validation error: %s", joinMessages(source)), i18n.Sprintf(locale(c), "validation error: %s", joinMessages(details)))
+	resp.Details = details
+	c.JSON(http.StatusBadRequest, resp)
+}
+
+func joinMessages(details []i18n.FieldError) string {
+	messages := make([]string, len(details))
+	for i, detail := range details {
+		messages[i] = detail.Message
+	}
+	return strings.Join(messages, "; ")
 }
 
 // DatabaseError 数据库错误响应
 func DatabaseError(c *gin.Context, message string) {
-	Error(c, http.StatusInternalServerError, "数据库响应错误: "+message)
+	c.JSON(http.StatusInternalServerError, errorResponse(c, http.StatusInternalServerError, apperror.CodeInternal,
+		fmt.Sprintf("database error: %s", message), i18n.Sprintf(locale(c), "database error: %s", message)))
+}
+
+// ServiceUnavailable 503 错误响应，附带检查结果等数据
+func ServiceUnavailable(c *gin.Context, message string, data interface{}) {
+	fail(c, http.StatusServiceUnavailable, apperror.CodeUnavailable, message, data)
 }
@@ -2,12 +2,22 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/chenyl99x/toge-api/pkg/apperror"
+	"github.com/chenyl99x/toge-api/pkg/fieldset"
+	"github.com/chenyl99x/toge-api/pkg/i18n"
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/pagination"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func setupTestRouter() *gin.Engine {
@@ -157,3 +167,157 @@ func TestDatabaseError(t *testing.T) {
 	assert.Equal(t, "database error: Connection failed", response.Message)
 	assert.Equal(t, "database error: Connection failed", response.Error)
 }
+
+func TestSuccessWithSelection(t *testing.T) {
+	schema := fieldset.NewSchema("id", "name", "secret")
+	r := setupTestRouter()
+	r.GET("/test", func(c *gin.Context) {
+		sel, err := schema.Parse(c.Request.URL.Query())
+		assert.NoError(t, err)
+		Select(c, sel)
+		Success(c, &pagination.PageResponse{
+			Data:  []map[string]string{{"id": "1", "name": "a", "secret": "s", "password": "p"}},
+			Total: 1,
+		})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?fields=id,name", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data struct {
+			Data  []map[string]interface{} `json:"data"`
+			Total int64                    `json:"total"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": "1", "name": "a"}}, response.Data.Data)
+	assert.Equal(t, int64(1), response.Data.Total)
+}
+
+func TestAppError(t *testing.T) {
+	cases := []struct {
+		err     error
+		status  int
+		code    apperror.Code
+		message string
+	}{
+		{apperror.New(apperror.CodeUsernameExists, "username already exists"), http.StatusConflict, apperror.CodeUsernameExists, "username already exists"},
+		{fmt.Errorf("get space: %w", gorm.ErrRecordNotFound), http.StatusNotFound, apperror.CodeNotFound, "resource not found"},
+		{gorm.ErrDuplicatedKey, http.StatusConflict, apperror.CodeConflict, "resource already exists"},
+		{errors.New("Error 2003: Can't connect to MySQL server"), http.StatusInternalServerError, apperror.CodeInternal, "internal server error"},
+	}
+	for _, tc := range cases {
+		r := setupTestRouter()
+		var recorded int
+		r.GET("/test", func(c *gin.Context) {
+			c.Request = c.Request.WithContext(logger.WithTraceID(c.Request.Context(), "abc123"))
+			AppError(c, tc.err)
+			recorded = len(c.Errors)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code)
+
+		var response Response
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.status, response.Code)
+		assert.Equal(t, tc.code, response.ErrorCode)
+		assert.Equal(t, tc.message, response.Message)
+		assert.Equal(t, "abc123", response.TraceID)
+		// 只有内部错误的原始错误记录到 gin.Context 中
+		assert.Equal(t, tc.status >= http.StatusInternalServerError, recorded == 1)
+	}
+}
+
+func TestErrorCodeForStatus(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/test", func(c *gin.Context) {
+		NotFound(c, "User not found")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(w, req)
+
+	var response Response
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, apperror.CodeNotFound, response.ErrorCode)
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	r := setupTestRouter()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), i18n.ZhCN))
+	})
+	r.GET("/success", func(c *gin.Context) {
+		Success(c, nil)
+	})
+	r.GET("/not-found", func(c *gin.Context) {
+		AppError(c, apperror.Wrap(apperror.CodeNotFound, gorm.ErrRecordNotFound, "user not found"))
+	})
+	r.GET("/untranslated", func(c *gin.Context) {
+		BadRequest(c, "invalid search type: x")
+	})
+
+	cases := []struct {
+		path    string
+		message string
+		error   string
+	}{
+		{"/success", "成功", ""},
+		{"/not-found", "用户不存在", "user not found"},
+		// 没有译文时使用错误码的说明，原文保留在 error 中
+		{"/untranslated", "请求参数错误", "invalid search type: x"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", tc.path, nil)
+		r.ServeHTTP(w, req)
+
+		var response Response
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.message, response.Message, tc.path)
+		assert.Equal(t, tc.error, response.Error, tc.path)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	v := validator.New()
+	v.SetTagName("binding")
+	assert.NoError(t, i18n.RegisterValidator(v))
+
+	type request struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	r := setupTestRouter()
+	r.GET("/test", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), i18n.Match(c.GetHeader("Accept-Language"))))
+		BindError(c, v.Struct(request{Email: "x"}))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response Response
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, apperror.CodeValidationFailed, response.ErrorCode)
+	assert.Equal(t, "校验错误: email必须是一个有效的邮箱", response.Message)
+	assert.Equal(t, "validation error: email must be a valid email address", response.Error)
+	assert.Equal(t, []i18n.FieldError{{Field: "email", Rule: "email", Message: "email必须是一个有效的邮箱"}}, response.Details)
+}
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/redis"
+)
+
+// 运行状态
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusTimeout = "timeout"
+	StatusPanic   = "panic"
+)
+
+// 触发方式
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Run 任务运行记录
+type Run struct {
+	Job         string    `json:"job" example:"notification_purge"`
+	TraceID     string    `json:"trace_id" example:"a1b2c3d4e5f60718"`
+	Trigger     string    `json:"trigger" example:"schedule"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	Duration    string    `json:"duration" example:"1.2s"`
+	Status      string    `json:"status" example:"success"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// HistoryStore 运行记录存储
+type HistoryStore interface {
+	Record(run *Run) error
+	List(job string, limit int) ([]Run, error)
+}
+
+// RedisHistory 基于 Redis 列表的运行记录，每个任务保留最近 size 条
+type RedisHistory struct {
+	client redis.Client
+	prefix string
+	size   int64
+}
+
+// NewRedisHistory 创建 Redis 运行记录存储
+func NewRedisHistory(client redis.Client, prefix string, size int) *RedisHistory {
+	if size <= 0 {
+		size = 50
+	}
+	return &RedisHistory{client: client, prefix: prefix, size: int64(size)}
+}
+
+// Record 记录一次运行
+func (h *RedisHistory) Record(run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return redis.LPushTrim(context.Background(), h.client, h.prefix+run.Job, data, h.size)
+}
+
+// List 按时间倒序返回最近的运行记录
+func (h *RedisHistory) List(job string, limit int) ([]Run, error) {
+	if limit <= 0 || int64(limit) > h.size {
+		limit = int(h.size)
+	}
+	items, err := h.client.LRange(context.Background(), h.prefix+job, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]Run, 0, len(items))
+	for _, item := range items {
+		var run Run
+		if err := json.Unmarshal([]byte(item), &run); err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// MemoryHistory 进程内运行记录，用于测试
+type MemoryHistory struct {
+	mu   sync.Mutex
+	size int
+	runs map[string][]Run
+}
+
+// NewMemoryHistory 创建进程内运行记录存储
+func NewMemoryHistory(size int) *MemoryHistory {
+	if size <= 0 {
+		size = 50
+	}
+	return &MemoryHistory{size: size, runs: map[string][]Run{}}
+}
+
+// Record 记录一次运行
+func (h *MemoryHistory) Record(run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := append([]Run{*run}, h.runs[run.Job]...)
+	if len(runs) > h.size {
+		runs = runs[:h.size]
+	}
+	h.runs[run.Job] = runs
+	return nil
+}
+
+// List 按时间倒序返回最近的运行记录
+func (h *MemoryHistory) List(job string, limit int) ([]Run, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := h.runs[job]
+	if limit > 0 && limit < len(runs) {
+		runs = runs[:limit]
+	}
+	result := make([]Run, len(runs))
+	copy(result, runs)
+	return result, nil
+}
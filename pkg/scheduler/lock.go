@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+	"github.com/chenyl99x/toge-api/pkg/redis"
+)
+
+// Locker 分布式锁，保证同一任务在多个实例中只执行一次
+type Locker interface {
+	// Acquire 尝试获取锁，成功时返回持有者令牌
+	Acquire(key string, ttl time.Duration) (token string, ok bool, err error)
+	// Release 释放锁，只有令牌匹配时才会删除
+	Release(key string, token string) error
+	// Extend 续期锁，只有令牌匹配时才会重置过期时间，返回 false 表示锁已过期或被他人持有
+	Extend(key string, token string, ttl time.Duration) (bool, error)
+}
+
+// RedisLocker 基于 Redis SET NX 的分布式锁
+type RedisLocker struct {
+	client redis.Client
+	prefix string
+}
+
+// NewRedisLocker 创建 Redis 锁
+func NewRedisLocker(client redis.Client, prefix string) *RedisLocker {
+	return &RedisLocker{client: client, prefix: prefix}
+}
+
+// Acquire 获取锁
+func (l *RedisLocker) Acquire(key string, ttl time.Duration) (string, bool, error) {
+	token := string(logger.GenerateTraceID())
+	ok, err := l.client.SetNX(context.Background(), l.prefix+key, token, ttl).Result()
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// Release 释放锁
+func (l *RedisLocker) Release(key string, token string) error {
+	_, err := redis.CompareAndDelete(context.Background(), l.client, l.prefix+key, token)
+	return err
+}
+
+// Extend 续期锁
+func (l *RedisLocker) Extend(key string, token string, ttl time.Duration) (bool, error) {
+	return redis.CompareAndExpire(context.Background(), l.client, l.prefix+key, token, ttl)
+}
+
+// MemoryLocker 进程内锁，用于单实例部署和测试
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+	now   func() time.Time
+}
+
+type memoryLock struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewMemoryLocker 创建进程内锁
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: map[string]memoryLock{}, now: time.Now}
+}
+
+// Acquire 获取锁
+func (l *MemoryLocker) Acquire(key string, ttl time.Duration) (string, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if lock, exists := l.locks[key]; exists && now.Before(lock.expiresAt) {
+		return "", false, nil
+	}
+
+	token := string(logger.GenerateTraceID())
+	l.locks[key] = memoryLock{token: token, expiresAt: now.Add(ttl)}
+	return token, true, nil
+}
+
+// Release 释放锁
+func (l *MemoryLocker) Release(key string, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lock, exists := l.locks[key]; exists && lock.token == token {
+		delete(l.locks, key)
+	}
+	return nil
+}
+
+// Extend 续期锁
+func (l *MemoryLocker) Extend(key string, token string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	lock, exists := l.locks[key]
+	if !exists || lock.token != token || !now.Before(lock.expiresAt) {
+		return false, nil
+	}
+	lock.expiresAt = now.Add(ttl)
+	l.locks[key] = lock
+	return true, nil
+}
@@ -0,0 +1,507 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is already running")
+	ErrJobExists   = errors.New("job already registered")
+	ErrStopped     = errors.New("scheduler is stopped")
+)
+
+// defaultTimeout 未设置超时时的默认值
+const defaultTimeout = 10 * time.Minute
+
+// defaultGracePeriod 超时或停止后等待任务退出的默认时间，小于运行锁在超时之外多保留的 1 分钟
+const defaultGracePeriod = 30 * time.Second
+
+// defaultLockMargin 运行锁在超时之外多保留的时间，任务运行期间每隔三分之一有效期续期一次
+const defaultLockMargin = time.Minute
+
+// JobFunc 任务函数，应当响应 ctx 的取消
+type JobFunc func(ctx context.Context) error
+
+// Job 定时任务定义
+type Job struct {
+	Name        string
+	Description string
+	Spec        string        // 标准 5 段 cron 表达式，例如 "0 3 * * *"
+	Timezone    string        // 解析 Spec 使用的时区，为空使用调度器默认时区
+	Jitter      time.Duration // 随机延迟上限，用于错开多实例同时触发
+	Timeout     time.Duration // 单次运行超时，默认 10 分钟
+	Func        JobFunc
+}
+
+// JobInfo 任务状态
+type JobInfo struct {
+	Name        string     `json:"name" example:"notification_purge"`
+	Description string     `json:"description" example:"清理已读通知"`
+	Spec        string     `json:"spec" example:"0 3 * * *"`
+	Timezone    string     `json:"timezone" example:"Asia/Shanghai"`
+	Timeout     string     `json:"timeout" example:"10m0s"`
+	Jitter      string     `json:"jitter" example:"30s"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	LastRun     *Run       `json:"last_run,omitempty"`
+	Running     bool       `json:"running"`
+}
+
+// Options 调度器选项
+type Options struct {
+	Timezone string       // 默认时区
+	Locker   Locker       // 分布式锁，默认进程内锁
+	History  HistoryStore // 运行记录存储，默认进程内存储
+	Logger   *slog.Logger // 为空时使用 slog.Default()
+	// GracePeriod 超时或停止后等待任务退出的时间，默认 30 秒，
+	// 超过后记录为放弃等待，运行锁保留到任务真正返回
+	GracePeriod time.Duration
+}
+
+type entry struct {
+	job      *Job
+	schedule cron.Schedule
+	loc      *time.Location
+
+	mu      sync.Mutex
+	next    time.Time
+	last    *Run
+	running bool
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	loc     *time.Location
+	locker  Locker
+	history HistoryStore
+	grace   time.Duration
+	margin  time.Duration
+	log     *slog.Logger
+	now     func() time.Time
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	stopped bool
+}
+
+// New 创建调度器
+func New(opts Options) (*Scheduler, error) {
+	loc := time.Local
+	if opts.Timezone != "" {
+		l, err := time.LoadLocation(opts.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %s", opts.Timezone)
+		}
+		loc = l
+	}
+	if opts.Locker == nil {
+		opts.Locker = NewMemoryLocker()
+	}
+	if opts.History == nil {
+		opts.History = NewMemoryHistory(0)
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = defaultGracePeriod
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		entries: map[string]*entry{},
+		loc:     loc,
+		locker:  opts.Locker,
+		history: opts.History,
+		grace:   opts.GracePeriod,
+		margin:  defaultLockMargin,
+		log:     opts.Logger,
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// Register 注册任务，调度器启动后注册的任务会立即开始调度
+func (s *Scheduler) Register(job *Job) error {
+	if job.Name == "" || job.Func == nil {
+		return errors.New("job name and func are required")
+	}
+
+	schedule, err := cron.ParseStandard(job.Spec)
+	if err != nil {
+		return fmt.Errorf("invalid cron spec for job %s: %v", job.Name, err)
+	}
+
+	loc := s.loc
+	if job.Timezone != "" {
+		loc, err = time.LoadLocation(job.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone for job %s: %s", job.Name, job.Timezone)
+		}
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("%w: %s", ErrJobExists, job.Name)
+	}
+
+	e := &entry{job: job, schedule: schedule, loc: loc}
+	e.next = schedule.Next(s.now().In(loc))
+	s.entries[job.Name] = e
+
+	if s.started && !s.stopped {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+	return nil
+}
+
+// Start 启动调度
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+	s.log.Info("Scheduler started", "jobs", len(s.entries), "timezone", s.loc.String())
+}
+
+// Stop 停止调度，取消正在运行的任务并等待任务函数返回，包括超时后放弃等待的任务，
+// 调用方通过 lifecycle.Wait 限制等待时间
+func (s *Scheduler) Stop() {
+	// 标记停止后不再接受手动触发，避免 wg.Add 与 wg.Wait 并发
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+	s.log.Info("Scheduler stopped")
+}
+
+// Jobs 返回所有任务的状态，按名称排序
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.RLock()
+	entries := make([]*entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].job.Name < entries[j].job.Name })
+
+	infos := make([]JobInfo, 0, len(entries))
+	for _, e := range entries {
+		e.mu.Lock()
+		info := JobInfo{
+			Name:        e.job.Name,
+			Description: e.job.Description,
+			Spec:        e.job.Spec,
+			Timezone:    e.loc.String(),
+			Timeout:     e.job.Timeout.String(),
+			Jitter:      e.job.Jitter.String(),
+			LastRun:     e.last,
+			Running:     e.running,
+		}
+		if !e.next.IsZero() {
+			next := e.next
+			info.NextRun = &next
+		}
+		e.mu.Unlock()
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// History 返回任务的运行记录
+func (s *Scheduler) History(name string, limit int) ([]Run, error) {
+	if _, err := s.lookup(name); err != nil {
+		return nil, err
+	}
+	return s.history.List(name, limit)
+}
+
+// Trigger 手动触发任务，任务在后台运行，返回本次运行的 traceId
+// 调度器停止后返回 ErrStopped
+func (s *Scheduler) Trigger(name string) (string, error) {
+	e, err := s.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	token, ok, err := s.locker.Acquire(runningKey(name), s.lockTTL(e))
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire job lock: %v", err)
+	}
+	if !ok {
+		return "", ErrJobRunning
+	}
+
+	// 持有读锁直到 wg.Add 完成，Stop 标记停止后才会开始等待
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		s.release(runningKey(name), token)
+		return "", ErrStopped
+	}
+
+	traceID := logger.GenerateTraceID()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(e, TriggerManual, s.now(), traceID, token)
+	}()
+	return string(traceID), nil
+}
+
+func (s *Scheduler) lookup(name string) (*entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	return e, nil
+}
+
+// loop 单个任务的调度循环
+func (s *Scheduler) loop(e *entry) {
+	defer s.wg.Done()
+
+	for {
+		next := e.schedule.Next(s.now().In(e.loc))
+		e.mu.Lock()
+		e.next = next
+		e.mu.Unlock()
+
+		timer := time.NewTimer(next.Sub(s.now()) + jitter(e.job.Jitter))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runScheduled(e, next)
+	}
+}
+
+// runScheduled 执行一次计划触发
+// 每个触发时刻使用独立的锁且不主动释放，保证多实例中只有一个实例执行该次触发
+func (s *Scheduler) runScheduled(e *entry, scheduledAt time.Time) {
+	name := e.job.Name
+	occurrenceKey := fmt.Sprintf("job:%s:%d", name, scheduledAt.Unix())
+	if _, ok, err := s.locker.Acquire(occurrenceKey, e.job.Timeout+e.job.Jitter+time.Minute); err != nil {
+		s.log.Error("Failed to acquire job lock", "job", name, "error", err.Error())
+		return
+	} else if !ok {
+		s.log.Debug("Job claimed by another instance", "job", name, "scheduled_at", scheduledAt)
+		return
+	}
+
+	token, ok, err := s.locker.Acquire(runningKey(name), s.lockTTL(e))
+	if err != nil {
+		s.log.Error("Failed to acquire job lock", "job", name, "error", err.Error())
+		return
+	}
+	if !ok {
+		s.log.Warn("Job skipped because previous run is still in progress", "job", name)
+		return
+	}
+
+	s.execute(e, TriggerSchedule, scheduledAt, logger.GenerateTraceID(), token)
+}
+
+// execute 在超时控制和 panic 恢复下运行任务并记录结果，任务函数返回后释放运行锁
+// 超时或停止时先等待 GracePeriod，仍未返回的任务记录结果后在后台等待，返回前一直续期运行锁，避免下一次运行与其重叠
+func (s *Scheduler) execute(e *entry, trigger string, scheduledAt time.Time, traceID logger.TraceID, token string) {
+	ctx := logger.WithTraceID(s.ctx, traceID)
+	ctx, cancel := context.WithTimeout(ctx, e.job.Timeout)
+	defer cancel()
+
+	stopKeepAlive := s.keepAlive(runningKey(e.job.Name), token, s.lockTTL(e))
+
+	e.mu.Lock()
+	e.running = true
+	e.mu.Unlock()
+
+	run := &Run{
+		Job:         e.job.Name,
+		TraceID:     string(traceID),
+		Trigger:     trigger,
+		ScheduledAt: scheduledAt,
+		StartedAt:   s.now(),
+	}
+	s.log.InfoContext(ctx, "Job started", "job", run.Job, "trigger", trigger)
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.log.ErrorContext(ctx, "Job panic recovered", "job", run.Job, "panic", r, "stack", string(debug.Stack()))
+				done <- &panicError{value: r}
+			}
+		}()
+		done <- e.job.Func(ctx)
+	}()
+
+	var err error
+	abandoned := false
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		grace := time.NewTimer(s.grace)
+		select {
+		case <-done:
+		case <-grace.C:
+			abandoned = true
+		}
+		grace.Stop()
+	}
+
+	run.FinishedAt = s.now()
+	run.Duration = run.FinishedAt.Sub(run.StartedAt).String()
+
+	var pe *panicError
+	switch {
+	case err == nil:
+		run.Status = StatusSuccess
+	case errors.As(err, &pe):
+		run.Status = StatusPanic
+		run.Error = pe.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		run.Status = StatusTimeout
+		run.Error = err.Error()
+	default:
+		run.Status = StatusFailed
+		run.Error = err.Error()
+	}
+
+	if run.Status == StatusSuccess {
+		s.log.InfoContext(ctx, "Job finished", "job", run.Job, "duration", run.Duration)
+	} else {
+		s.log.ErrorContext(ctx, "Job failed", "job", run.Job, "status", run.Status, "error", run.Error, "duration", run.Duration)
+	}
+
+	if err := s.history.Record(run); err != nil {
+		s.log.ErrorContext(ctx, "Failed to record job run", "job", run.Job, "error", err.Error())
+	}
+
+	e.mu.Lock()
+	e.last = run
+	e.mu.Unlock()
+
+	finish := func() {
+		e.mu.Lock()
+		e.running = false
+		e.mu.Unlock()
+		stopKeepAlive()
+		s.release(runningKey(run.Job), token)
+	}
+	if !abandoned {
+		finish()
+		return
+	}
+
+	s.log.ErrorContext(ctx, "Job abandoned after not responding to cancellation, lock is held until it returns",
+		"job", run.Job, "grace_period", s.grace.String())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		<-done
+		s.log.WarnContext(ctx, "Abandoned job returned", "job", run.Job, "duration", s.now().Sub(run.StartedAt).String())
+		finish()
+	}()
+}
+
+// lockTTL 运行锁的有效期
+func (s *Scheduler) lockTTL(e *entry) time.Duration {
+	return e.job.Timeout + s.margin
+}
+
+// keepAlive 每隔三分之一有效期续期运行锁，直到返回的 stop 被调用
+// 不随调度器停止而结束，超时后放弃等待的任务仍在运行时其他实例也无法获取运行锁
+func (s *Scheduler) keepAlive(key, token string, ttl time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+			}
+			ok, err := s.locker.Extend(key, token, ttl)
+			if err != nil {
+				s.log.Error("Failed to extend job lock", "key", key, "error", err.Error())
+				continue
+			}
+			if !ok {
+				s.log.Warn("Job lock lost before the run returned", "key", key)
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+func (s *Scheduler) release(key, token string) {
+	if err := s.locker.Release(key, token); err != nil {
+		s.log.Error("Failed to release job lock", "key", key, "error", err.Error())
+	}
+}
+
+func runningKey(name string) string {
+	return "running:" + name
+}
+
+// jitter 返回 [0, max) 之间的随机延迟
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// panicError 任务 panic 时的错误
+type panicError struct {
+	value any
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
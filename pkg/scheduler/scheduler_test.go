@@ -0,0 +1,291 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger 测试中丢弃日志
+var testLogger = slog.New(slog.DiscardHandler)
+
+func newTestScheduler(t *testing.T, locker Locker) *Scheduler {
+	s, err := New(Options{Timezone: "Asia/Shanghai", Locker: locker, Logger: testLogger})
+	require.NoError(t, err)
+	return s
+}
+
+// waitForRun 等待任务产生指定数量的运行记录
+func waitForRun(t *testing.T, s *Scheduler, name string, count int) []Run {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		runs, err := s.History(name, 0)
+		require.NoError(t, err)
+		if len(runs) >= count {
+			return runs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d run(s) of %s", count, name)
+	return nil
+}
+
+func TestRegisterValidation(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	noop := func(ctx context.Context) error { return nil }
+
+	assert.Error(t, s.Register(&Job{Name: "bad_spec", Spec: "not a cron", Func: noop}))
+	assert.Error(t, s.Register(&Job{Name: "bad_tz", Spec: "* * * * *", Timezone: "Invalid/Zone", Func: noop}))
+	assert.NoError(t, s.Register(&Job{Name: "ok", Spec: "0 3 * * *", Func: noop}))
+	assert.ErrorIs(t, s.Register(&Job{Name: "ok", Spec: "0 3 * * *", Func: noop}), ErrJobExists)
+}
+
+func TestJobsNextRunUsesTimezone(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	s.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	require.NoError(t, s.Register(&Job{Name: "daily", Spec: "0 3 * * *", Func: func(ctx context.Context) error { return nil }}))
+
+	jobs := s.Jobs()
+	require.Len(t, jobs, 1)
+	require.NotNil(t, jobs[0].NextRun)
+	// 03:00 Asia/Shanghai == 19:00 UTC on the previous day
+	assert.Equal(t, time.Date(2025, 1, 1, 19, 0, 0, 0, time.UTC), jobs[0].NextRun.UTC())
+	assert.Equal(t, "Asia/Shanghai", jobs[0].Timezone)
+}
+
+func TestTriggerRecordsRunWithTraceID(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	var seenTrace atomic.Value
+	require.NoError(t, s.Register(&Job{Name: "manual", Spec: "0 3 * * *", Func: func(ctx context.Context) error {
+		seenTrace.Store(string(logger.GetTraceID(ctx)))
+		return nil
+	}}))
+
+	traceID, err := s.Trigger("manual")
+	require.NoError(t, err)
+
+	runs := waitForRun(t, s, "manual", 1)
+	assert.Equal(t, StatusSuccess, runs[0].Status)
+	assert.Equal(t, TriggerManual, runs[0].Trigger)
+	assert.Equal(t, traceID, runs[0].TraceID)
+	assert.Equal(t, traceID, seenTrace.Load())
+
+	_, err = s.Trigger("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestTriggerRecoversPanic(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	require.NoError(t, s.Register(&Job{Name: "boom", Spec: "0 3 * * *", Func: func(ctx context.Context) error {
+		panic("something went wrong")
+	}}))
+
+	_, err := s.Trigger("boom")
+	require.NoError(t, err)
+
+	runs := waitForRun(t, s, "boom", 1)
+	assert.Equal(t, StatusPanic, runs[0].Status)
+	assert.Contains(t, runs[0].Error, "something went wrong")
+}
+
+func TestTriggerTimeout(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	require.NoError(t, s.Register(&Job{Name: "slow", Spec: "0 3 * * *", Timeout: 20 * time.Millisecond, Func: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+
+	_, err := s.Trigger("slow")
+	require.NoError(t, err)
+
+	runs := waitForRun(t, s, "slow", 1)
+	assert.Equal(t, StatusTimeout, runs[0].Status)
+}
+
+func TestTimeoutWaitsForJobToReturn(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	var returned atomic.Bool
+	require.NoError(t, s.Register(&Job{Name: "slow", Spec: "0 3 * * *", Timeout: 20 * time.Millisecond, Func: func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		returned.Store(true)
+		return ctx.Err()
+	}}))
+
+	_, err := s.Trigger("slow")
+	require.NoError(t, err)
+
+	// 任务在宽限期内返回后才记录结果并释放运行锁
+	runs := waitForRun(t, s, "slow", 1)
+	assert.Equal(t, StatusTimeout, runs[0].Status)
+	assert.True(t, returned.Load())
+	assert.Eventually(t, func() bool { return !s.Jobs()[0].Running }, time.Second, 5*time.Millisecond)
+	_, err = s.Trigger("slow")
+	assert.NoError(t, err)
+	s.Stop()
+}
+
+func TestAbandonedJobHoldsLockUntilItReturns(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	s.grace = 20 * time.Millisecond
+	release := make(chan struct{})
+	require.NoError(t, s.Register(&Job{Name: "stuck", Spec: "0 3 * * *", Timeout: 20 * time.Millisecond, Func: func(ctx context.Context) error {
+		<-release
+		return nil
+	}}))
+
+	_, err := s.Trigger("stuck")
+	require.NoError(t, err)
+
+	// 超过宽限期后记录为超时，但任务仍在运行时不能再次触发
+	runs := waitForRun(t, s, "stuck", 1)
+	assert.Equal(t, StatusTimeout, runs[0].Status)
+	assert.True(t, s.Jobs()[0].Running)
+	_, err = s.Trigger("stuck")
+	assert.ErrorIs(t, err, ErrJobRunning)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, err := s.Trigger("stuck")
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	s.Stop()
+}
+
+func TestAbandonedJobLockIsExtendedAcrossInstances(t *testing.T) {
+	locker := NewMemoryLocker()
+	a := newTestScheduler(t, locker)
+	b := newTestScheduler(t, locker)
+	a.grace = 10 * time.Millisecond
+	a.margin = 30 * time.Millisecond
+	release := make(chan struct{})
+	job := func(fn JobFunc) *Job {
+		return &Job{Name: "stuck", Spec: "0 3 * * *", Timeout: 20 * time.Millisecond, Func: fn}
+	}
+	require.NoError(t, a.Register(job(func(ctx context.Context) error {
+		<-release
+		return nil
+	})))
+	require.NoError(t, b.Register(job(func(ctx context.Context) error { return nil })))
+
+	_, err := a.Trigger("stuck")
+	require.NoError(t, err)
+	waitForRun(t, a, "stuck", 1)
+
+	// 超过锁的初始有效期后任务仍在运行，另一个实例不能开始重叠的运行
+	time.Sleep(150 * time.Millisecond)
+	_, err = b.Trigger("stuck")
+	assert.ErrorIs(t, err, ErrJobRunning)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, err := b.Trigger("stuck")
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	a.Stop()
+	b.Stop()
+}
+
+func TestTriggerAfterStop(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	require.NoError(t, s.Register(&Job{Name: "noop", Spec: "0 3 * * *", Func: func(ctx context.Context) error { return nil }}))
+	s.Stop()
+
+	_, err := s.Trigger("noop")
+	assert.ErrorIs(t, err, ErrStopped)
+	assert.Nil(t, s.Jobs()[0].LastRun)
+}
+
+func TestStopWaitsForRunningJobs(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	started := make(chan struct{})
+	var returned atomic.Bool
+	require.NoError(t, s.Register(&Job{Name: "slow", Spec: "0 3 * * *", Func: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		returned.Store(true)
+		return ctx.Err()
+	}}))
+
+	_, err := s.Trigger("slow")
+	require.NoError(t, err)
+	<-started
+	s.Stop()
+	assert.True(t, returned.Load())
+}
+
+func TestTriggerRejectsConcurrentRun(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	release := make(chan struct{})
+	require.NoError(t, s.Register(&Job{Name: "long", Spec: "0 3 * * *", Func: func(ctx context.Context) error {
+		<-release
+		return errors.New("done")
+	}}))
+
+	_, err := s.Trigger("long")
+	require.NoError(t, err)
+	_, err = s.Trigger("long")
+	assert.ErrorIs(t, err, ErrJobRunning)
+
+	close(release)
+	runs := waitForRun(t, s, "long", 1)
+	assert.Equal(t, StatusFailed, runs[0].Status)
+}
+
+func TestScheduledRunOnlyOnceAcrossInstances(t *testing.T) {
+	locker := NewMemoryLocker()
+	var count atomic.Int32
+	job := func() *Job {
+		return &Job{Name: "tick", Spec: "* * * * *", Func: func(ctx context.Context) error {
+			count.Add(1)
+			return nil
+		}}
+	}
+
+	a := newTestScheduler(t, locker)
+	b := newTestScheduler(t, locker)
+	require.NoError(t, a.Register(job()))
+	require.NoError(t, b.Register(job()))
+
+	scheduledAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ea, _ := a.lookup("tick")
+	eb, _ := b.lookup("tick")
+	a.runScheduled(ea, scheduledAt)
+	b.runScheduled(eb, scheduledAt)
+
+	assert.Equal(t, int32(1), count.Load())
+
+	// 下一个触发时刻可以再次执行
+	b.runScheduled(eb, scheduledAt.Add(time.Minute))
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestMemoryLockerExpiry(t *testing.T) {
+	l := NewMemoryLocker()
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	_, ok, err := l.Acquire("k", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, ok, _ = l.Acquire("k", time.Second)
+	assert.False(t, ok)
+
+	assert.NoError(t, l.Release("k", "wrong-token"))
+	_, ok, _ = l.Acquire("k", time.Second)
+	assert.False(t, ok)
+
+	now = now.Add(2 * time.Second)
+	_, ok, _ = l.Acquire("k", time.Second)
+	assert.True(t, ok)
+}
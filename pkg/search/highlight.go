@@ -0,0 +1,66 @@
+package search
+
+import (
+	"html"
+	"slices"
+	"strings"
+)
+
+const (
+	// snippetLength 正文片段的最大字符数
+	snippetLength = 120
+	// snippetLead 片段中第一个命中词之前保留的字符数
+	snippetLead = 30
+)
+
+// highlight 用 <mark> 包裹 text 中命中的词，其余内容转义，没有命中时返回空字符串
+// text 超过 maxLength 个字符时截取第一个命中词附近的片段，maxLength 为 0 时不截取
+func highlight(text string, queryTerms map[string]bool, maxLength int) string {
+	runes := []rune(text)
+
+	// 命中区间，相邻或重叠的二元词合并为一段
+	tokens := tokenize(text, true)
+	slices.SortStableFunc(tokens, func(a, b token) int { return a.start - b.start })
+	var spans [][2]int
+	for _, t := range tokens {
+		if !queryTerms[t.term] {
+			continue
+		}
+		if n := len(spans); n > 0 && t.start <= spans[n-1][1] {
+			spans[n-1][1] = max(spans[n-1][1], t.end)
+			continue
+		}
+		spans = append(spans, [2]int{t.start, t.end})
+	}
+	if len(spans) == 0 {
+		return ""
+	}
+
+	from, to := 0, len(runes)
+	if maxLength > 0 && len(runes) > maxLength {
+		from = max(spans[0][0]-snippetLead, 0)
+		to = min(from+maxLength, len(runes))
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, span := range spans {
+		start, end := max(span[0], from), min(span[1], to)
+		if start >= end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[start:end])))
+		b.WriteString("</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package search
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+)
+
+const (
+	// titleBoost 标题中的词频权重
+	titleBoost = 2
+	// BM25 参数
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// MemoryIndex 进程内倒排索引，按 BM25 排序
+// 索引不持久化，进程启动时需要全量重建，适合中小规模数据；数据量大或需要多实例共享索引时可以实现 Indexer 接入外部搜索引擎
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*entry
+	postings map[string]map[string]float64 // 词 -> 文档键 -> 加权词频
+	totalLen int
+}
+
+// entry 已索引的文档
+type entry struct {
+	doc    Document
+	length int      // 加权后的词数
+	terms  []string // 文档包含的词，删除时清理倒排表
+}
+
+// NewMemoryIndex 创建进程内索引
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     map[string]*entry{},
+		postings: map[string]map[string]float64{},
+	}
+}
+
+// Len 已索引的文档数
+func (m *MemoryIndex) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.docs)
+}
+
+// Index 写入或覆盖文档
+func (m *MemoryIndex) Index(ctx context.Context, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, doc := range docs {
+		key := doc.key()
+		m.remove(key)
+
+		freqs := map[string]float64{}
+		for _, t := range tokenize(doc.Title, true) {
+			freqs[t.term] += titleBoost
+		}
+		for _, t := range tokenize(doc.Body, true) {
+			freqs[t.term]++
+		}
+
+		e := &entry{doc: doc, terms: make([]string, 0, len(freqs))}
+		for term, freq := range freqs {
+			if m.postings[term] == nil {
+				m.postings[term] = map[string]float64{}
+			}
+			m.postings[term][key] = freq
+			e.terms = append(e.terms, term)
+			e.length += int(freq)
+		}
+		m.docs[key] = e
+		m.totalLen += e.length
+	}
+	return nil
+}
+
+// Delete 删除文档
+func (m *MemoryIndex) Delete(ctx context.Context, docType string, ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		m.remove((&Document{Type: docType, ID: id}).key())
+	}
+	return nil
+}
+
+// remove 删除文档及其倒排记录，调用方持有写锁
+func (m *MemoryIndex) remove(key string) {
+	e, ok := m.docs[key]
+	if !ok {
+		return
+	}
+	for _, term := range e.terms {
+		delete(m.postings[term], key)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	m.totalLen -= e.length
+	delete(m.docs, key)
+}
+
+// Search 查询可见范围内同时包含所有查询词的文档
+func (m *MemoryIndex) Search(ctx context.Context, q Query) (*Result, error) {
+	queryTerms := terms(q.Text)
+	if len(queryTerms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := &Result{Hits: []Hit{}, Facets: map[string]int{}}
+
+	// 从文档最少的词开始求交集
+	slices.SortFunc(queryTerms, func(a, b string) int { return len(m.postings[a]) - len(m.postings[b]) })
+	avgLen := float64(m.totalLen) / float64(max(len(m.docs), 1))
+	var hits []Hit
+	for key := range m.postings[queryTerms[0]] {
+		e := m.docs[key]
+		if !matchesAll(m.postings, queryTerms[1:], key) || !visible(e.doc.Scopes, q.Scopes) {
+			continue
+		}
+		result.Facets[e.doc.Type]++
+		if len(q.Types) > 0 && !slices.Contains(q.Types, e.doc.Type) {
+			continue
+		}
+
+		var score float64
+		for _, term := range queryTerms {
+			freq := m.postings[term][key]
+			idf := math.Log(1 + (float64(len(m.docs))-float64(len(m.postings[term]))+0.5)/(float64(len(m.postings[term]))+0.5))
+			score += idf * freq * (bm25K1 + 1) / (freq + bm25K1*(1-bm25B+bm25B*float64(e.length)/avgLen))
+		}
+		hits = append(hits, Hit{Type: e.doc.Type, ID: e.doc.ID, Title: e.doc.Title, Score: score})
+	}
+
+	// 相关度相同时按类型和 ID 排序，翻页结果稳定
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Type != hits[j].Type {
+			return hits[i].Type < hits[j].Type
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	result.Total = len(hits)
+
+	// 只为当前页生成高亮片段
+	from := min(max(q.Offset, 0), len(hits))
+	to := len(hits)
+	if q.Limit > 0 {
+		to = min(from+q.Limit, len(hits))
+	}
+	termSet := make(map[string]bool, len(queryTerms))
+	for _, term := range queryTerms {
+		termSet[term] = true
+	}
+	for _, hit := range hits[from:to] {
+		doc := m.docs[(&Document{Type: hit.Type, ID: hit.ID}).key()].doc
+		hit.Highlights = map[string]string{}
+		if title := highlight(doc.Title, termSet, 0); title != "" {
+			hit.Highlights["title"] = title
+		}
+		if body := highlight(doc.Body, termSet, snippetLength); body != "" {
+			hit.Highlights["body"] = body
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
+}
+
+// matchesAll 文档是否包含所有词
+func matchesAll(postings map[string]map[string]float64, terms []string, key string) bool {
+	for _, term := range terms {
+		if _, ok := postings[term][key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// visible 文档的可见范围与查询方的可见范围是否有交集
+func visible(docScopes, scopes []string) bool {
+	for _, scope := range docScopes {
+		if slices.Contains(scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package search
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrEmptyQuery 查询文本切分后没有可检索的词
+var ErrEmptyQuery = errors.New("empty search query")
+
+// Document 被索引的内容
+type Document struct {
+	Type   string   // 内容类型，例如 user、space，用于过滤和分面统计
+	ID     string   // 类型内唯一的 ID
+	Title  string   // 标题，命中时权重更高
+	Body   string   // 正文
+	Scopes []string // 可见范围，查询方拥有其中任意一个时可见
+}
+
+// key 索引内唯一的键
+func (d *Document) key() string {
+	return d.Type + ":" + d.ID
+}
+
+// Query 查询条件
+type Query struct {
+	Text   string   // 查询文本，切分后的所有词都需要命中
+	Types  []string // 只返回这些类型，为空时不限制，不影响分面统计
+	Scopes []string // 查询方的可见范围
+	Offset int
+	Limit  int
+}
+
+// Hit 命中的内容
+type Hit struct {
+	Type       string            `json:"type" example:"space"`
+	ID         string            `json:"id" example:"1"`
+	Title      string            `json:"title" example:"我的岛屿"`
+	Score      float64           `json:"score" example:"1.5"`
+	Highlights map[string]string `json:"highlights"` // 命中片段，键为 title 或 body，命中词用 <mark> 包裹，其余内容已转义
+}
+
+// Result 查询结果
+type Result struct {
+	Total  int            `json:"total" example:"1"`           // 按类型过滤后的命中总数
+	Hits   []Hit          `json:"hits"`                        // 按相关度排序的当前页
+	Facets map[string]int `json:"facets" swaggertype:"object"` // 各内容类型的命中数，不受类型过滤影响
+}
+
+// Indexer 全文索引
+type Indexer interface {
+	// Index 写入或覆盖文档
+	Index(ctx context.Context, docs ...Document) error
+	// Delete 删除文档，不存在时忽略
+	Delete(ctx context.Context, docType string, ids ...string) error
+	// Search 查询可见范围内的文档
+	Search(ctx context.Context, q Query) (*Result, error)
+}
@@ -0,0 +1,273 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/event"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenTerms(tokens []token) []string {
+	result := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		result = append(result, t.term)
+	}
+	return result
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "world", "42"}, tokenTerms(tokenize("Hello, World-42", false)))
+
+	// 查询时汉字按二元切分，索引时额外输出单字
+	assert.Equal(t, []string{"家庭", "庭空", "空间"}, tokenTerms(tokenize("家庭空间", false)))
+	assert.ElementsMatch(t, []string{"家", "庭", "空", "间", "家庭", "庭空", "空间"}, tokenTerms(tokenize("家庭空间", true)))
+	assert.Equal(t, []string{"岛"}, tokenTerms(tokenize("岛", false)))
+
+	// 混合文本的位置按字符计算
+	tokens := tokenize("我的island", false)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, token{term: "我的", start: 0, end: 2}, tokens[0])
+	assert.Equal(t, token{term: "island", start: 2, end: 8}, tokens[1])
+
+	assert.Equal(t, []string{"空间"}, terms("空间 空间"))
+	assert.Empty(t, terms("  ,，。 "))
+}
+
+func TestHighlight(t *testing.T) {
+	queryTerms := map[string]bool{"空间": true, "美好": true}
+
+	assert.Equal(t, "这是一个<mark>美好</mark>的<mark>空间</mark>", highlight("这是一个美好的空间", queryTerms, 0))
+	assert.Equal(t, "", highlight("没有命中", queryTerms, 0))
+
+	// 相邻的二元词合并为一段，其余内容转义
+	assert.Equal(t, "&lt;b&gt;<mark>空间站</mark>", highlight("<b>空间站", map[string]bool{"空间": true, "间站": true}, 0))
+
+	// 超长文本截取第一个命中词附近的片段
+	long := strings.Repeat("啊", 100) + "美好" + strings.Repeat("啊", 100)
+	snippet := highlight(long, queryTerms, 40)
+	assert.Equal(t, "…"+strings.Repeat("啊", snippetLead)+"<mark>美好</mark>"+strings.Repeat("啊", 40-snippetLead-2)+"…", snippet)
+}
+
+func newTestIndex(t *testing.T) *MemoryIndex {
+	index := NewMemoryIndex()
+	require.NoError(t, index.Index(context.Background(),
+		Document{Type: "user", ID: "1", Title: "alice", Body: "爱丽丝\nalice@example.com", Scopes: []string{"public"}},
+		Document{Type: "user", ID: "2", Title: "bob", Body: "鲍勃 喜欢海边的岛屿", Scopes: []string{"public"}},
+		Document{Type: "space", ID: "1", Title: "我的岛屿", Body: "情侣空间\n海边的小岛屿", Scopes: []string{"space:1"}},
+		Document{Type: "space", ID: "2", Title: "家庭空间", Body: "家庭空间\n别人的岛屿", Scopes: []string{"space:2"}},
+	))
+	return index
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	index := newTestIndex(t)
+	assert.Equal(t, 4, index.Len())
+
+	// 只能看到可见范围内的文档，标题命中的排在前面
+	result, err := index.Search(ctx, Query{Text: "岛屿", Scopes: []string{"public", "space:1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	require.Len(t, result.Hits, 2)
+	assert.Equal(t, "space", result.Hits[0].Type)
+	assert.Equal(t, "1", result.Hits[0].ID)
+	assert.Equal(t, "我的<mark>岛屿</mark>", result.Hits[0].Highlights["title"])
+	assert.Equal(t, "情侣空间\n海边的小<mark>岛屿</mark>", result.Hits[0].Highlights["body"])
+	assert.Equal(t, "user", result.Hits[1].Type)
+	assert.Equal(t, map[string]int{"user": 1, "space": 1}, result.Facets)
+
+	// 类型过滤不影响分面统计
+	result, err = index.Search(ctx, Query{Text: "岛屿", Types: []string{"user"}, Scopes: []string{"public", "space:1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "2", result.Hits[0].ID)
+	assert.Equal(t, map[string]int{"user": 1, "space": 1}, result.Facets)
+
+	// 所有词都需要命中，英文不区分大小写
+	result, err = index.Search(ctx, Query{Text: "ALICE example", Scopes: []string{"public"}})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "1", result.Hits[0].ID)
+	result, err = index.Search(ctx, Query{Text: "alice 岛屿", Scopes: []string{"public"}})
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+	assert.Empty(t, result.Hits)
+
+	// 单字查询
+	result, err = index.Search(ctx, Query{Text: "岛", Scopes: []string{"public", "space:1", "space:2"}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+
+	_, err = index.Search(ctx, Query{Text: " ，", Scopes: []string{"public"}})
+	assert.ErrorIs(t, err, ErrEmptyQuery)
+}
+
+func TestMemoryIndexPaging(t *testing.T) {
+	ctx := context.Background()
+	index := newTestIndex(t)
+	scopes := []string{"public", "space:1", "space:2"}
+
+	all, err := index.Search(ctx, Query{Text: "岛屿", Scopes: scopes})
+	require.NoError(t, err)
+	require.Len(t, all.Hits, 3)
+
+	var paged []Hit
+	for offset := 0; offset < 3; offset += 2 {
+		result, err := index.Search(ctx, Query{Text: "岛屿", Scopes: scopes, Offset: offset, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Total)
+		paged = append(paged, result.Hits...)
+	}
+	assert.Equal(t, all.Hits, paged)
+
+	result, err := index.Search(ctx, Query{Text: "岛屿", Scopes: scopes, Offset: 10, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Empty(t, result.Hits)
+}
+
+func TestMemoryIndexUpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	index := newTestIndex(t)
+	scopes := []string{"public", "space:1"}
+
+	// 覆盖文档后旧内容不再命中
+	require.NoError(t, index.Index(ctx, Document{Type: "space", ID: "1", Title: "我的花园", Scopes: []string{"space:1"}}))
+	assert.Equal(t, 4, index.Len())
+	result, err := index.Search(ctx, Query{Text: "岛屿", Types: []string{"space"}, Scopes: scopes})
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+	result, err = index.Search(ctx, Query{Text: "花园", Scopes: scopes})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+
+	require.NoError(t, index.Delete(ctx, "space", "1", "404"))
+	assert.Equal(t, 3, index.Len())
+	result, err = index.Search(ctx, Query{Text: "花园", Scopes: scopes})
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+	assert.NotContains(t, index.postings, "花园")
+}
+
+// fakeSource 内存中的数据来源，事件按发生时间排序，Apply 将事件类型作为文档标题写入索引
+type fakeSource struct {
+	mu       sync.Mutex
+	events   []*event.Event
+	applied  []string
+	failOn   string
+	rebuilds int
+}
+
+func (s *fakeSource) Rebuild(ctx context.Context, index Indexer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rebuilds++
+	return index.Index(ctx, Document{Type: "user", ID: "1", Title: "initial", Scopes: []string{"public"}})
+}
+
+func (s *fakeSource) Events(ctx context.Context, after time.Time, afterID string, limit int) ([]*event.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*event.Event
+	for _, e := range s.events {
+		if e.OccurredAt.After(after) || (e.OccurredAt.Equal(after) && e.ID > afterID) {
+			result = append(result, e)
+		}
+		if len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeSource) Apply(ctx context.Context, index Indexer, e *event.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.ID == s.failOn {
+		return errors.New("apply failed")
+	}
+	s.applied = append(s.applied, e.ID)
+	return index.Index(ctx, Document{Type: "user", ID: e.AggregateID, Title: e.Type, Scopes: []string{"public"}})
+}
+
+func (s *fakeSource) add(id string, occurredAt time.Time, aggregateID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, &event.Event{ID: id, Type: "updated" + id, AggregateID: aggregateID, OccurredAt: occurredAt})
+	slices.SortFunc(s.events, func(a, b *event.Event) int {
+		if c := a.OccurredAt.Compare(b.OccurredAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+func TestSyncerSyncOnce(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	source := &fakeSource{}
+	s := NewSyncer(index, source, SyncOptions{BatchSize: 2, Lookback: time.Minute, Logger: slog.New(slog.DiscardHandler)})
+
+	require.NoError(t, s.step(ctx))
+	assert.Equal(t, 1, source.rebuilds)
+	assert.Equal(t, 1, index.Len())
+
+	now := time.Now()
+	source.add("a", now.Add(time.Second), "2")
+	source.add("b", now.Add(2*time.Second), "3")
+	source.add("c", now.Add(3*time.Second), "4")
+
+	// 超过一批时继续读取
+	applied, err := s.SyncOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, applied)
+	assert.Equal(t, 4, index.Len())
+
+	// 晚提交的事件发生时间早于游标，仍在回看窗口内，只应用一次
+	source.add("d", now.Add(time.Second/2), "5")
+	applied, err = s.SyncOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, source.applied)
+
+	// 应用失败时停止，下次同步重试
+	source.add("e", now.Add(4*time.Second), "6")
+	source.add("f", now.Add(5*time.Second), "7")
+	source.failOn = "e"
+	applied, err = s.SyncOnce(ctx)
+	require.Error(t, err)
+	assert.Zero(t, applied)
+	source.failOn = ""
+	applied, err = s.SyncOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, applied)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, source.applied)
+
+	result, err := index.Search(ctx, Query{Text: "updatedf", Scopes: []string{"public"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+}
+
+func TestSyncerStartStop(t *testing.T) {
+	index := NewMemoryIndex()
+	source := &fakeSource{}
+	s := NewSyncer(index, source, SyncOptions{Interval: 10 * time.Millisecond, Logger: slog.New(slog.DiscardHandler)})
+
+	s.Start()
+	s.Start()
+	source.add("a", time.Now().Add(time.Second), "2")
+	assert.Eventually(t, func() bool { return index.Len() == 2 }, time.Second, 10*time.Millisecond)
+	s.Stop()
+	s.Stop()
+	assert.Equal(t, 1, source.rebuilds)
+}
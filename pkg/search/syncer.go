@@ -0,0 +1,166 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chenyl99x/toge-api/pkg/event"
+)
+
+// Source 索引的数据来源
+type Source interface {
+	// Rebuild 读取全部数据写入索引
+	Rebuild(ctx context.Context, index Indexer) error
+	// Events 按发生时间和 ID 升序读取 (after, afterID) 之后的变更事件
+	Events(ctx context.Context, after time.Time, afterID string, limit int) ([]*event.Event, error)
+	// Apply 根据变更事件重新读取数据并更新索引，与索引无关的事件忽略
+	Apply(ctx context.Context, index Indexer, e *event.Event) error
+}
+
+// SyncOptions 同步参数
+type SyncOptions struct {
+	Interval  time.Duration // 读取变更事件的间隔
+	BatchSize int           // 每次读取的事件数
+	Lookback  time.Duration // 回看窗口，事务提交顺序与事件发生时间不一致时，晚提交的事件仍能读到
+	Logger    *slog.Logger  // 为空时使用 slog.Default()
+}
+
+// Syncer 启动时全量重建索引，之后定期读取发件箱中的变更事件增量更新
+// 每个实例独立读取事件，不依赖 Relay 的投递，多实例部署时各自的进程内索引都能保持最新
+type Syncer struct {
+	index  Indexer
+	source Source
+	opts   SyncOptions
+	log    *slog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// 以下字段只在同步协程中访问
+	built  bool
+	cursor time.Time            // 已应用事件的最大发生时间
+	seen   map[string]time.Time // 回看窗口内已应用的事件，避免重复应用
+}
+
+// NewSyncer 创建同步器
+func NewSyncer(index Indexer, source Source, opts SyncOptions) *Syncer {
+	if opts.Interval <= 0 {
+		opts.Interval = 2 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.Lookback <= 0 {
+		opts.Lookback = time.Minute
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Syncer{index: index, source: source, opts: opts, log: opts.Logger, seen: map[string]time.Time{}}
+}
+
+// Start 启动后台同步，重建失败时在下一个间隔重试
+func (s *Syncer) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.step(ctx); err != nil && ctx.Err() == nil {
+				s.log.Error("Failed to sync search index", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	s.log.Info("Search index syncer started", "interval", s.opts.Interval.String())
+}
+
+// Stop 停止同步并等待当前批次结束
+func (s *Syncer) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	s.log.Info("Search index syncer stopped")
+}
+
+// step 未重建时全量重建，否则增量同步
+func (s *Syncer) step(ctx context.Context) error {
+	if s.built {
+		_, err := s.SyncOnce(ctx)
+		return err
+	}
+
+	// 重建前记录时间，重建期间产生的事件在之后的同步中应用
+	start := time.Now()
+	if err := s.source.Rebuild(ctx, s.index); err != nil {
+		return err
+	}
+	s.built = true
+	s.cursor = start
+	s.log.Info("Search index rebuilt", "duration", time.Since(start).String())
+	return nil
+}
+
+// SyncOnce 应用回看窗口起的所有新事件，返回应用的事件数，只能在同步协程中调用
+// 应用失败时停止，游标不越过失败的事件，下次同步重试
+func (s *Syncer) SyncOnce(ctx context.Context) (int, error) {
+	after, afterID := s.cursor.Add(-s.opts.Lookback), ""
+	applied := 0
+	for {
+		events, err := s.source.Events(ctx, after, afterID, s.opts.BatchSize)
+		if err != nil {
+			return applied, err
+		}
+		for _, e := range events {
+			after, afterID = e.OccurredAt, e.ID
+			if _, ok := s.seen[e.ID]; ok {
+				continue
+			}
+			if err := s.source.Apply(ctx, s.index, e); err != nil {
+				return applied, err
+			}
+			s.seen[e.ID] = e.OccurredAt
+			if e.OccurredAt.After(s.cursor) {
+				s.cursor = e.OccurredAt
+			}
+			applied++
+		}
+		if len(events) < s.opts.BatchSize {
+			break
+		}
+	}
+
+	// 清理回看窗口之外的记录
+	for id, occurredAt := range s.seen {
+		if occurredAt.Before(s.cursor.Add(-s.opts.Lookback)) {
+			delete(s.seen, id)
+		}
+	}
+	return applied, nil
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"unicode"
+)
+
+// token 切分出的词，start 和 end 为在原文中的字符（rune）位置
+type token struct {
+	term       string
+	start, end int
+}
+
+// isHan 是否为汉字
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+// isWord 是否为字母或数字，汉字单独处理
+func isWord(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isHan(r)
+}
+
+// tokenize 切分文本，字母和数字按连续片段切分并转为小写，汉字按二元切分（相邻两个字为一个词）
+// 不依赖词典，任意中文词语都能命中；索引时额外输出单字，使单字查询也能命中
+func tokenize(text string, index bool) []token {
+	runes := []rune(text)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		switch {
+		case isHan(runes[i]):
+			j := i
+			for j < len(runes) && isHan(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, hanTokens(runes, i, j, index)...)
+			i = j
+		case isWord(runes[i]):
+			j := i
+			for j < len(runes) && isWord(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{term: lower(runes[i:j]), start: i, end: j})
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// hanTokens 切分 runes[start:end] 的连续汉字
+// 查询时只有一个字才使用单字，多个字使用二元词，结果更精确
+func hanTokens(runes []rune, start, end int, index bool) []token {
+	var tokens []token
+	if index || end-start == 1 {
+		for i := start; i < end; i++ {
+			tokens = append(tokens, token{term: string(runes[i]), start: i, end: i + 1})
+		}
+	}
+	for i := start; i+1 < end; i++ {
+		tokens = append(tokens, token{term: string(runes[i : i+2]), start: i, end: i + 2})
+	}
+	return tokens
+}
+
+// lower 转为小写，逐字转换保证字符数不变，命中位置可以直接用于原文
+func lower(runes []rune) string {
+	out := make([]rune, len(runes))
+	for i, r := range runes {
+		out[i] = unicode.ToLower(r)
+	}
+	return string(out)
+}
+
+// terms 查询文本切分后去重的词
+func terms(text string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, t := range tokenize(text, false) {
+		if !seen[t.term] {
+			seen[t.term] = true
+			result = append(result, t.term)
+		}
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrInvalidSealed 密文格式错误、被篡改或使用其他密钥加密
+var ErrInvalidSealed = errors.New("invalid sealed value")
+
+// Box 使用 AES-256-GCM 加密需要暂存但不能明文保存的短文本，例如后台任务中的明文密码
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New 创建加密器，密钥由 secret 和 purpose 派生，不同用途的密文互不通用
+// secret 更换后，之前加密的内容无法解密
+func New(secret, purpose string) *Box {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		panic(err) // 派生的密钥固定为 32 字节，不会出错
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &Box{aead: aead}
+}
+
+// Seal 加密文本，每次使用随机 nonce，返回 base64 编码的 nonce 和密文
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b.aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// Open 解密 Seal 返回的密文
+func (b *Box) Open(sealed string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrInvalidSealed
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrInvalidSealed
+	}
+	return string(plaintext), nil
+}
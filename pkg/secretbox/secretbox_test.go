@@ -0,0 +1,36 @@
+package secretbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	box := New("secret", "user import password")
+
+	sealed, err := box.Seal("Sup3r-Secret")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "Sup3r-Secret")
+	plaintext, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "Sup3r-Secret", plaintext)
+
+	// 相同内容每次加密结果不同
+	again, err := box.Seal("Sup3r-Secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	// 其他密钥或用途、被篡改的密文无法解密
+	_, err = New("other", "user import password").Open(sealed)
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+	_, err = New("secret", "other purpose").Open(sealed)
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+	tampered := []byte(sealed)
+	tampered[len(tampered)-1] ^= 1
+	_, err = box.Open(string(tampered))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+	_, err = box.Open("!")
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+}
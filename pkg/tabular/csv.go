@@ -0,0 +1,78 @@
+package tabular
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// utf8BOM Excel 打开没有 BOM 的 UTF-8 CSV 时中文会乱码
+const utf8BOM = "\uFEFF"
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (w *csvWriter) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, value := range record {
+		escaped[i] = escapeFormula(value)
+	}
+	return w.w.Write(escaped)
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+// escapeFormula 以公式字符开头的值加上单引号，避免在表格软件中作为公式执行
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type csvReader struct {
+	r     *csv.Reader
+	row   int
+	first bool
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.FieldsPerRecord = -1
+	return &csvReader{r: cr, first: true}
+}
+
+func (r *csvReader) Read() ([]string, error) {
+	record, err := r.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.row, _ = r.r.FieldPos(0)
+	if r.first {
+		r.first = false
+		if len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], utf8BOM)
+		}
+	}
+	return record, nil
+}
+
+func (r *csvReader) Row() int {
+	return r.row
+}
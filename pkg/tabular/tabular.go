@@ -0,0 +1,83 @@
+package tabular
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// 支持的文件格式
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat 不支持的文件格式
+var ErrUnsupportedFormat = errors.New("unsupported table format")
+
+// Formats 支持的文件格式
+var Formats = []string{FormatCSV, FormatXLSX}
+
+// FormatFromFilename 根据扩展名推断格式，不支持时返回空字符串
+func FormatFromFilename(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	for _, format := range Formats {
+		if ext == format {
+			return format
+		}
+	}
+	return ""
+}
+
+// ContentType 格式对应的 MIME 类型
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// Writer 按行写入表格
+type Writer interface {
+	// Write 写入一行
+	Write(record []string) error
+	// Flush 将缓冲的数据写入底层 io.Writer
+	Flush() error
+	// Close 写入结尾并刷新，不关闭底层 io.Writer
+	Close() error
+}
+
+// NewWriter 创建指定格式的 Writer
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// Reader 按行读取表格，XLSX 只读取第一个工作表
+type Reader interface {
+	// Read 读取下一行，没有更多行时返回 io.EOF，空行可能被跳过
+	Read() ([]string, error)
+	// Row 上一次 Read 返回的行在文件中的行号，从 1 开始
+	Row() int
+}
+
+// NewReader 创建指定格式的 Reader，XLSX 需要完整读入内存，调用方应限制文件大小
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r), nil
+	case FormatXLSX:
+		return newXLSXReader(r)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
@@ -0,0 +1,157 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecords = [][]string{
+	{"username", "nickname", "note"},
+	{"alice", "爱丽丝", "a,b \"quoted\"\nnew line"},
+	{"bob", "", "<tag> & 'x'"},
+}
+
+func writeAll(t *testing.T, format string, records [][]string) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format)
+	require.NoError(t, err)
+	for _, record := range records {
+		require.NoError(t, w.Write(record))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func readAll(t *testing.T, format string, data []byte) ([][]string, []int) {
+	r, err := NewReader(bytes.NewReader(data), format)
+	require.NoError(t, err)
+	var records [][]string
+	var rows []int
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, rows
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+		rows = append(rows, r.Row())
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	data := writeAll(t, FormatCSV, testRecords)
+	assert.True(t, bytes.HasPrefix(data, []byte(utf8BOM)))
+
+	records, rows := readAll(t, FormatCSV, data)
+	assert.Equal(t, testRecords, records)
+	// 第二行包含换行，第三行从第 4 行开始
+	assert.Equal(t, []int{1, 2, 4}, rows)
+}
+
+func TestCSVEscapesFormula(t *testing.T) {
+	data := writeAll(t, FormatCSV, [][]string{{"=SUM(A1)", "+1", "-1", "@x", "ok"}})
+	records, _ := readAll(t, FormatCSV, data)
+	assert.Equal(t, [][]string{{"'=SUM(A1)", "'+1", "'-1", "'@x", "ok"}}, records)
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	data := writeAll(t, FormatXLSX, testRecords)
+
+	// bob 的昵称为空，读取时按单元格引用补齐
+	records, rows := readAll(t, FormatXLSX, data)
+	assert.Equal(t, testRecords, records)
+	assert.Equal(t, []int{1, 2, 3}, rows)
+}
+
+// zipFiles 把文件打包为 xlsx 压缩包
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(f, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestXLSXSharedStrings(t *testing.T) {
+	// 模拟表格软件保存的文件：共享字符串、富文本、数字、布尔值、省略的单元格和空行
+	data := zipFiles(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="用户" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/users.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>用户名</t></si><si><t>状态</t></si>` +
+			`<si><r><t>car</t></r><r><t>ol</t></r><rPh><t>キャロル</t></rPh></si></sst>`,
+		"xl/worksheets/users.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+			`<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3"><v>1</v></c><c r="D3" t="b"><v>1</v></c></row>` +
+			`</sheetData></worksheet>`,
+	})
+
+	records, rows := readAll(t, FormatXLSX, data)
+	assert.Equal(t, [][]string{{"用户名", "状态"}, {"carol", "1", "", "TRUE"}}, records)
+	assert.Equal(t, []int{1, 3}, rows)
+}
+
+func TestXLSXRejectsOversizedInput(t *testing.T) {
+	sheet := func(cells string) string {
+		return `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1">` +
+			cells + `</row></sheetData></worksheet>`
+	}
+	read := func(files map[string]string) error {
+		r, err := NewReader(bytes.NewReader(zipFiles(t, files)), FormatXLSX)
+		if err != nil {
+			return err
+		}
+		_, err = r.Read()
+		return err
+	}
+
+	// 最后一列可以读取，超过 XFD 的列不会按引用扩展记录
+	require.NoError(t, read(map[string]string{"xl/worksheets/sheet1.xml": sheet(`<c r="XFD1"><v>1</v></c>`)}))
+	err := read(map[string]string{"xl/worksheets/sheet1.xml": sheet(`<c r="ZZZZZZZ1"><v>1</v></c>`)})
+	assert.ErrorContains(t, err, "beyond the last column XFD")
+
+	// 解压后的大小按实际读取的字节数限制
+	defer func(size int64) { maxPartSize = size }(maxPartSize)
+	maxPartSize = 1 << 10
+	err = read(map[string]string{
+		"xl/sharedStrings.xml":     `<sst>` + strings.Repeat("<si><t>a</t></si>", 100) + `</sst>`,
+		"xl/worksheets/sheet1.xml": sheet(`<c r="A1"><v>1</v></c>`),
+	})
+	assert.ErrorContains(t, err, "xl/sharedStrings.xml is larger than")
+	err = read(map[string]string{"xl/worksheets/sheet1.xml": sheet(strings.Repeat(`<c><v>1</v></c>`, 100))})
+	assert.ErrorContains(t, err, "xl/worksheets/sheet1.xml is larger than")
+}
+
+func TestColumnName(t *testing.T) {
+	for i, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA", 16383: "XFD"} {
+		assert.Equal(t, name, columnName(i))
+		assert.Equal(t, i, columnIndex(name+"12"))
+	}
+	assert.Equal(t, -1, columnIndex("12"))
+	assert.Equal(t, maxColumns, columnIndex("XFE1"))
+	assert.Equal(t, maxColumns, columnIndex(strings.Repeat("Z", 30)+"1"))
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, FormatXLSX, FormatFromFilename("Users.XLSX"))
+	assert.Equal(t, FormatCSV, FormatFromFilename("/tmp/users.csv"))
+	assert.Empty(t, FormatFromFilename("users.xls"))
+
+	_, err := NewWriter(io.Discard, "xls")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = NewReader(bytes.NewReader(nil), FormatXLSX)
+	assert.Error(t, err)
+}
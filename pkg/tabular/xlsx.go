@@ -0,0 +1,416 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxParts 除工作表外的固定部件，只包含一个工作表，单元格均为内联字符串，不需要样式和共享字符串表
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+const (
+	sheetHeader = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetFooter = `</sheetData></worksheet>`
+
+	// maxColumns 工作表的最大列数，最后一列为 XFD
+	maxColumns = 16384
+)
+
+// maxPartSize 压缩包中单个文件解压后的大小上限，上传大小只限制了压缩后的文件，防止压缩炸弹耗尽内存
+var maxPartSize int64 = 50 << 20
+
+// xlsxWriter 边写边压缩，工作表内容不在内存中缓存
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetHeader); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (w *xlsxWriter) Write(record []string) error {
+	w.row++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.row)
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+		fmt.Fprintf(w.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(i), w.row)
+		// 非法的 XML 字符会被替换为 U+FFFD
+		if err := xml.EscapeText(w.sheet, []byte(value)); err != nil {
+			return err
+		}
+		w.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (w *xlsxWriter) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Flush()
+}
+
+func (w *xlsxWriter) Close() error {
+	if _, err := w.sheet.WriteString(sheetFooter); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// columnName 列序号对应的列名，0 为 A，26 为 AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// columnIndex 单元格引用（例如 AB12）中的列序号，无法解析时返回 -1，超过 XFD 时返回 maxColumns
+func columnIndex(ref string) int {
+	index := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		if index > maxColumns {
+			return maxColumns
+		}
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return index - 1
+}
+
+// xlsxReader 流式解析第一个工作表，共享字符串表一次读入
+type xlsxReader struct {
+	dec     *xml.Decoder
+	sheet   io.Closer
+	strings []string
+	row     int
+}
+
+func newXLSXReader(r io.Reader) (*xlsxReader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sheetFile, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("open xlsx: missing worksheet %s", sheetPath)
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := openPart(sheetFile)
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxReader{dec: xml.NewDecoder(sheet), sheet: sheet, strings: shared}, nil
+}
+
+// firstSheetPath 从工作簿和关系文件中找到第一个工作表的路径
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeFile(files["xl/workbook.xml"], &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return fallback, nil
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return fallback, nil
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+// xlsxPart 压缩包中的文件，解压后超过 maxPartSize 时返回错误
+type xlsxPart struct {
+	io.Closer
+	name    string
+	limited *io.LimitedReader
+}
+
+// openPart 打开压缩包中的文件，声明的大小只用于提前拒绝，读取时按实际解压的字节数限制
+func openPart(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > uint64(maxPartSize) {
+		return nil, fmt.Errorf("open xlsx: %s is larger than %d MB after decompression", f.Name, maxPartSize>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxPart{Closer: rc, name: f.Name, limited: &io.LimitedReader{R: rc, N: maxPartSize + 1}}, nil
+}
+
+func (p *xlsxPart) Read(b []byte) (int, error) {
+	n, err := p.limited.Read(b)
+	if p.limited.N <= 0 {
+		return 0, fmt.Errorf("%s is larger than %d MB after decompression", p.name, maxPartSize>>20)
+	}
+	return n, err
+}
+
+// decodeFile 解析压缩包中的 XML 文件
+func decodeFile(f *zip.File, v any) error {
+	if f == nil {
+		return errors.New("file not found")
+	}
+	rc, err := openPart(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// readSharedStrings 读取共享字符串表，富文本的多段文字拼接，忽略注音
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := openPart(f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var result []string
+	var b strings.Builder
+	inPhonetic := false
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read shared strings: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				b.Reset()
+			case "rPh":
+				inPhonetic = true
+			case "t":
+				if inPhonetic {
+					continue
+				}
+				var text string
+				if err := dec.DecodeElement(&text, &t); err != nil {
+					return nil, fmt.Errorf("read shared strings: %w", err)
+				}
+				b.WriteString(text)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				result = append(result, b.String())
+			case "rPh":
+				inPhonetic = false
+			}
+		}
+	}
+}
+
+// xlsxCell 工作表中的单元格
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+func (r *xlsxReader) Read() ([]string, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err == io.EOF {
+			r.sheet.Close()
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read worksheet: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		r.row++
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "r" {
+				if n, err := strconv.Atoi(attr.Value); err == nil {
+					r.row = n
+				}
+			}
+		}
+		return r.readRow()
+	}
+}
+
+// readRow 读取 <row> 中的单元格，按单元格引用定位列，省略的单元格为空字符串
+func (r *xlsxReader) readRow() ([]string, error) {
+	var record []string
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("read worksheet: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "c" {
+				continue
+			}
+			var cell xlsxCell
+			if err := r.dec.DecodeElement(&cell, &t); err != nil {
+				return nil, fmt.Errorf("read worksheet: %w", err)
+			}
+			col := columnIndex(cell.Ref)
+			if col < 0 {
+				col = len(record)
+			}
+			if col >= maxColumns {
+				return nil, fmt.Errorf("read worksheet: cell %q is beyond the last column XFD", cell.Ref)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			record[col] = r.cellValue(&cell)
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				return record, nil
+			}
+		}
+	}
+}
+
+// cellValue 单元格的文本，数字和日期返回原始值
+func (r *xlsxReader) cellValue(cell *xlsxCell) string {
+	switch cell.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || i < 0 || i >= len(r.strings) {
+			return ""
+		}
+		return r.strings[i]
+	case "inlineStr":
+		if len(cell.Inline.Runs) == 0 {
+			return cell.Inline.Text
+		}
+		var b strings.Builder
+		for _, run := range cell.Inline.Runs {
+			b.WriteString(run.Text)
+		}
+		return b.String()
+	case "b":
+		if cell.Value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return cell.Value
+}
+
+func (r *xlsxReader) Row() int {
+	return r.row
+}
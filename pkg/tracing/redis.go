@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 为每个 Redis 命令创建子 span 的 go-redis 钩子
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span, ok := startRedisSpan(ctx, strings.ToLower(cmd.Name()))
+		if !ok {
+			return next(ctx, cmd)
+		}
+		defer span.End()
+
+		err := next(ctx, cmd)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span, ok := startRedisSpan(ctx, "pipeline")
+		if !ok {
+			return next(ctx, cmds)
+		}
+		defer span.End()
+
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = strings.ToLower(cmd.Name())
+		}
+		span.SetAttributes(attribute.StringSlice("db.redis.commands", names))
+
+		err := next(ctx, cmds)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+// startRedisSpan 没有上游 span 时不创建孤立的根 span，例如队列轮询
+func startRedisSpan(ctx context.Context, command string) (context.Context, trace.Span, bool) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return ctx, nil, false
+	}
+	// 只记录命令名，不记录 key 和参数
+	ctx, span := Tracer().Start(ctx, "redis."+command, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", command),
+		))
+	return ctx, span, true
+}
+
+// endRedisSpan key 不存在不视为错误
+func endRedisSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
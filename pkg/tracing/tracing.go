@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本项目创建的 span 使用的 tracer 名称
+const instrumentationName = "github.com/chenyl99x/toge-api"
+
+// 导出方式
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// Options 链路追踪配置
+type Options struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	Exporter       string            // otlp, stdout, none
+	Endpoint       string            // OTLP/HTTP 地址，例如 otel-collector:4318
+	Insecure       bool              // 是否使用 http 而不是 https
+	Headers        map[string]string // OTLP 请求头，例如鉴权信息
+	SampleRatio    float64           // 根 span 采样率，0~1，上游已采样的请求始终跟随上游
+}
+
+// Init 设置全局 TracerProvider 和 W3C traceparent/tracestate 传播器，返回用于刷新和关闭的函数
+// exporter 为 none 时只设置传播器，span 不会被记录，但仍然透传上游的 trace 上下文
+func Init(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(Propagator())
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case ExporterOTLP:
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opts.Endpoint)}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		if len(opts.Headers) > 0 {
+			clientOpts = append(clientOpts, otlptracehttp.WithHeaders(opts.Headers))
+		}
+		otlpExporter, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		exporter = otlpExporter
+	case ExporterStdout:
+		stdoutExporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+		exporter = stdoutExporter
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter: %s", opts.Exporter)
+	}
+
+	provider := NewProvider(opts, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider 创建 TracerProvider，extra 用于指定导出方式
+// 测试中可以使用 sdktrace.WithSyncer(tracetest.NewInMemoryExporter()) 同步导出到内存
+func NewProvider(opts Options, extra ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	ratio := opts.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", opts.ServiceName),
+		attribute.String("service.version", opts.ServiceVersion),
+	}
+	if opts.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment", opts.Environment))
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}
+	return sdktrace.NewTracerProvider(append(providerOpts, extra...)...)
+}
+
+// Propagator W3C traceparent/tracestate 和 baggage 传播器
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// Tracer 返回本项目使用的 tracer，未初始化时为 noop
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
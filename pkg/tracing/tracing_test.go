@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupInMemory 使用内存导出器替换全局 TracerProvider
+func setupInMemory(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(Options{ServiceName: "toge-test"}, sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+func TestPropagatorContinuesUpstreamTrace(t *testing.T) {
+	setupInMemory(t)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set("tracestate", "vendor=value")
+
+	ctx := Propagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+	ctx, span := Tracer().Start(ctx, "GET /users/:id", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
+	spanContext := span.SpanContext()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanContext.TraceID().String())
+	assert.NotEqual(t, "00f067aa0ba902b7", spanContext.SpanID().String())
+	assert.Equal(t, "vendor=value", spanContext.TraceState().String())
+
+	// 向下游传播时携带当前 span
+	out := http.Header{}
+	Propagator().Inject(ctx, propagation.HeaderCarrier(out))
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+spanContext.SpanID().String()+"-01", out.Get("traceparent"))
+}
+
+func TestRedisHookCreatesChildSpans(t *testing.T) {
+	exporter := setupInMemory(t)
+
+	process := RedisHook{}.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		return cmd.Err()
+	})
+
+	// 没有上游 span 时不创建 span
+	require.NoError(t, process(context.Background(), redis.NewStatusCmd(context.Background(), "PING")))
+	assert.Empty(t, exporter.GetSpans())
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	missing := redis.NewStringCmd(ctx, "GET", "missing")
+	missing.SetErr(redis.Nil)
+	_ = process(ctx, missing)
+
+	failed := redis.NewStringCmd(ctx, "SET", "key", "secret-value")
+	failed.SetErr(errors.New("connection reset"))
+	_ = process(ctx, failed)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	get, set := spans[0], spans[1]
+	assert.Equal(t, "redis.get", get.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), get.Parent.SpanID())
+	assert.Equal(t, codes.Unset, get.Status.Code)
+
+	assert.Equal(t, "redis.set", set.Name)
+	assert.Equal(t, codes.Error, set.Status.Code)
+	for _, attr := range set.Attributes {
+		assert.NotContains(t, attr.Value.Emit(), "secret-value")
+	}
+}
+
+func TestInit(t *testing.T) {
+	shutdown, err := Init(context.Background(), Options{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), Options{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
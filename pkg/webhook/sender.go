@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress 目标地址位于内网，未允许时拒绝连接，防止 SSRF
+var ErrPrivateAddress = errors.New("webhook target resolves to a private address")
+
+// defaultSnapshotSize 响应快照保留的最大字节数
+const defaultSnapshotSize = 4096
+
+// Options Sender 配置
+type Options struct {
+	Timeout              time.Duration // 单次请求超时
+	AllowPrivateNetworks bool          // 是否允许投递到内网地址，仅开发和测试环境开启
+	SnapshotSize         int           // 响应快照保留的最大字节数
+	UserAgent            string
+}
+
+// Request 一次投递请求
+type Request struct {
+	URL        string
+	Secret     string
+	EventType  string
+	EventID    string
+	DeliveryID string
+	Body       []byte
+}
+
+// Result 投递结果快照
+type Result struct {
+	RequestHeaders  http.Header
+	RequestBody     string
+	StatusCode      int
+	ResponseHeaders http.Header
+	ResponseBody    string
+	Duration        time.Duration
+	Err             error
+}
+
+// OK 是否投递成功（2xx）
+func (r *Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Error 投递失败原因，成功时为空
+func (r *Result) Error() string {
+	switch {
+	case r.Err != nil:
+		return r.Err.Error()
+	case !r.OK():
+		return fmt.Sprintf("unexpected status code %d", r.StatusCode)
+	default:
+		return ""
+	}
+}
+
+// Sender 签名并发送 webhook
+type Sender struct {
+	client *http.Client
+	opts   Options
+	now    func() time.Time
+}
+
+// NewSender 创建 Sender
+func NewSender(opts Options) *Sender {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.SnapshotSize <= 0 {
+		opts.SnapshotSize = defaultSnapshotSize
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "toge-webhook/1.0"
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		// 在建立连接时检查解析后的 IP，避免 DNS 重绑定绕过
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip, err := netip.ParseAddr(host); err != nil || isPrivateIP(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &Sender{
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: transport,
+			// 不跟随重定向，避免被重定向到内网地址
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		opts: opts,
+		now:  time.Now,
+	}
+}
+
+// Send 发送一次 webhook，网络错误和非 2xx 响应都记录在 Result 中
+func (s *Sender) Send(ctx context.Context, req *Request) *Result {
+	// 请求体完整保存，手动重新投递时需要原样发送
+	result := &Result{RequestBody: string(req.Body)}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	timestamp := s.now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", s.opts.UserAgent)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+	httpReq.Header.Set(HeaderEventID, req.EventID)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	result.RequestHeaders = httpReq.Header.Clone()
+
+	start := time.Now()
+	resp, err := s.client.Do(httpReq)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(s.opts.SnapshotSize)+1))
+	result.StatusCode = resp.StatusCode
+	result.ResponseHeaders = resp.Header.Clone()
+	result.ResponseBody = s.snapshot(body)
+	return result
+}
+
+// snapshot 截断过长的内容
+func (s *Sender) snapshot(body []byte) string {
+	if len(body) > s.opts.SnapshotSize {
+		return string(body[:s.opts.SnapshotSize]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// ValidateURL 校验 webhook 地址，只允许 http 和 https
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("invalid webhook url: scheme must be http or https")
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("invalid webhook url: missing host")
+	}
+	if u.User != nil {
+		return fmt.Errorf("invalid webhook url: credentials are not allowed")
+	}
+	return nil
+}
+
+// blockedPrefixes 不允许投递的地址段：回环、内网、运营商级 NAT、链路本地、基准测试、文档示例、组播和保留地址
+// 云厂商的元数据和内部服务可能使用 100.64.0.0/10 等不在 net.IP.IsPrivate 中的地址段
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // 本网络
+	netip.MustParsePrefix("10.0.0.0/8"),      // 私有网络
+	netip.MustParsePrefix("100.64.0.0/10"),   // 运营商级 NAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // 回环
+	netip.MustParsePrefix("169.254.0.0/16"),  // 链路本地，包括云厂商的元数据服务
+	netip.MustParsePrefix("172.16.0.0/12"),   // 私有网络
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF 协议分配
+	netip.MustParsePrefix("192.0.2.0/24"),    // 文档示例
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 中继
+	netip.MustParsePrefix("192.168.0.0/16"),  // 私有网络
+	netip.MustParsePrefix("198.18.0.0/15"),   // 基准测试
+	netip.MustParsePrefix("198.51.100.0/24"), // 文档示例
+	netip.MustParsePrefix("203.0.113.0/24"),  // 文档示例
+	netip.MustParsePrefix("224.0.0.0/4"),     // 组播
+	netip.MustParsePrefix("240.0.0.0/4"),     // 保留，包括广播地址
+	netip.MustParsePrefix("::/128"),          // 未指定
+	netip.MustParsePrefix("::1/128"),         // 回环
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // 本地使用的 NAT64
+	netip.MustParsePrefix("100::/64"),        // 丢弃
+	netip.MustParsePrefix("2001:db8::/32"),   // 文档示例
+	netip.MustParsePrefix("fc00::/7"),        // 唯一本地地址
+	netip.MustParsePrefix("fe80::/10"),       // 链路本地
+	netip.MustParsePrefix("fec0::/10"),       // 站点本地，已废弃
+	netip.MustParsePrefix("ff00::/8"),        // 组播
+}
+
+// nat64Prefix NAT64 知名前缀，地址的后 32 位为转换后的 IPv4 地址
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// isPrivateIP 是否为不允许投递的地址，IPv4 映射地址和 NAT64 地址按其中的 IPv4 地址检查
+func isPrivateIP(ip netip.Addr) bool {
+	// 带区域的地址不会匹配任何地址段，先去掉区域
+	ip = ip.WithZone("").Unmap()
+	if nat64Prefix.Contains(ip) {
+		b := ip.As16()
+		ip = netip.AddrFrom4([4]byte(b[12:]))
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
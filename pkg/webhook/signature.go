@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 请求头
+const (
+	HeaderSignature = "X-Toge-Signature" // sha256=<hex>
+	HeaderTimestamp = "X-Toge-Timestamp" // Unix 秒
+	HeaderEvent     = "X-Toge-Event"     // 事件类型
+	HeaderEventID   = "X-Toge-Event-Id"  // 事件 ID，接收方可据此去重
+	HeaderDelivery  = "X-Toge-Delivery"  // 投递记录 ID
+)
+
+// signaturePrefix 签名值前缀，便于以后更换算法
+const signaturePrefix = "sha256="
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrTimestampExpired = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign 计算签名：HMAC-SHA256(secret, "<timestamp>.<body>")
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名和时间戳，tolerance 为允许的时钟偏差，用于防重放
+func Verify(secret string, timestampHeader string, signatureHeader string, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		diff := now.Sub(time.Unix(timestamp, 0))
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			return ErrTimestampExpired
+		}
+	}
+
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signatureHeader)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}